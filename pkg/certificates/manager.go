@@ -18,6 +18,7 @@ import (
 	"context"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -44,6 +45,8 @@ const (
 
 	podsWebhookSuffix = "-pods.gardener.cloud"
 	vpasWebhookSuffix = "-vpas.gardener.cloud"
+
+	workloadsWebhookSuffix = "-workloads.gardener.cloud"
 )
 
 type certManager struct {
@@ -170,7 +173,8 @@ func (c *certManager) Start(ctx context.Context) error {
 
 	defer cancel()
 
-	return c.cleanUpMutatingWebhookConfiguration(ctx) // Clean up the webhook CABundles
+	// Clean up the webhook CABundles
+	return errors.Join(c.cleanUpMutatingWebhookConfiguration(ctx), c.cleanUpValidatingWebhookConfiguration(ctx))
 }
 
 // setupWebhooksCABundles is invoked during runnable initialization and before the controller manager Start method is called.
@@ -182,7 +186,7 @@ func (c *certManager) setupWebhooksCABundles(ctx context.Context, config *rest.C
 		return fmt.Errorf("error creating k8s client: %w", err)
 	}
 
-	return retry.RetryOnConflict(webhookUpdateRetry, func() error {
+	if err = retry.RetryOnConflict(webhookUpdateRetry, func() error {
 		mutatingWebhook, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, c.webhookName, metav1.GetOptions{})
 		if err != nil {
 			return fmt.Errorf("error getting webhook: %w", err)
@@ -216,7 +220,11 @@ func (c *certManager) setupWebhooksCABundles(ctx context.Context, config *rest.C
 		}
 
 		return err
-	})
+	}); err != nil {
+		return err
+	}
+
+	return c.setupValidatingWebhookCABundles(ctx, clientset)
 }
 
 // updateWebhookConfiguration is invoked when runnable (certManager) is running.
@@ -317,6 +325,10 @@ OuterLoop:
 				_log.Error(err, "Error updating webhook CA bundle")
 			}
 
+			if err := c.updateValidatingWebhookConfiguration(ctx); err != nil {
+				_log.Error(err, "Error updating validating webhook CA bundle")
+			}
+
 			t, _ := generateTLSCert(c.certPath, ops, c.dnsNames, c.ca)
 			c.tls.key = t.key
 			c.tls.cert = t.cert
@@ -500,6 +512,12 @@ func (c *certManager) syncWebhookCaBundle(ctx context.Context, wg *sync.WaitGrou
 					_log.V(9).Info("Webhook CA bundle is in sync", "webhook", w.Name)
 				}
 			}
+
+			if !c.validatingWebhookCABundleInSync(ctx) {
+				if err := c.updateValidatingWebhookConfiguration(ctx); err != nil {
+					_log.Error(err, "Error updating validating webhook CA bundle")
+				}
+			}
 		case <-ctx.Done():
 			_log.Info("Shutting down the CA bundle checker")
 
@@ -0,0 +1,140 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificates
+
+import (
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// The validating webhook configuration shares the name of the mutating one. It is optional, hence a missing
+// configuration is not considered an error.
+
+func (c *certManager) setupValidatingWebhookCABundles(ctx context.Context, clientset kubernetes.Interface) error {
+	return retry.RetryOnConflict(webhookUpdateRetry, func() error {
+		validatingWebhook, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx,
+			c.webhookName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("error getting validating webhook: %w", err)
+		}
+
+		c.updateValidatingWebhookCABundles(validatingWebhook)
+
+		_, err = clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Update(ctx,
+			validatingWebhook, metav1.UpdateOptions{})
+
+		return err
+	})
+}
+
+// updateValidatingWebhookConfiguration adds the current CA to the validating webhook CABundle
+func (c *certManager) updateValidatingWebhookConfiguration(ctx context.Context) error {
+	webhook := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+
+	return retry.RetryOnConflict(webhookUpdateRetry, func() error {
+		if err := c.client.Get(ctx, types.NamespacedName{Name: c.webhookName}, webhook); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		c.updateValidatingWebhookCABundles(webhook)
+
+		_log.Info("Updating validating webhook CA bundle", "webhook", c.webhookName)
+
+		return c.client.Update(ctx, webhook)
+	})
+}
+
+func (c *certManager) cleanUpValidatingWebhookConfiguration(ctx context.Context) error {
+	webhook := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+
+	return retry.RetryOnConflict(webhookUpdateRetry, func() error {
+		if err := c.client.Get(ctx, types.NamespacedName{Name: c.webhookName}, webhook); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		for i, w := range webhook.Webhooks {
+			if w.Name != c.webhookName+workloadsWebhookSuffix {
+				continue
+			}
+
+			b, err := c.removeCABundle(w.Name, w.ClientConfig.CABundle)
+			if err != nil {
+				_log.Error(err, "Error updating validating webhook CA Bundle")
+
+				break
+			}
+
+			webhook.Webhooks[i].ClientConfig.CABundle = b
+		}
+
+		return c.client.Update(ctx, webhook)
+	})
+}
+
+// validatingWebhookCABundleInSync returns false if the current CA is missing in the validating webhook CABundle
+func (c *certManager) validatingWebhookCABundleInSync(ctx context.Context) bool {
+	webhook := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := c.client.Get(ctx, types.NamespacedName{Name: c.webhookName}, webhook); err != nil {
+		if !apierrors.IsNotFound(err) {
+			_log.Error(err, "Error fetching validating webhook")
+		}
+
+		return true
+	}
+
+	for _, w := range webhook.Webhooks {
+		if w.Name == c.webhookName+workloadsWebhookSuffix && !caBundleFound(w.ClientConfig.CABundle, c.ca.cert) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *certManager) updateValidatingWebhookCABundles(webhook *admissionregistrationv1.ValidatingWebhookConfiguration) {
+	for i, w := range webhook.Webhooks {
+		if w.Name != c.webhookName+workloadsWebhookSuffix {
+			continue
+		}
+
+		b, err := c.updateCABundles(w.Name, w.ClientConfig.CABundle)
+		if err != nil {
+			_log.Error(err, "Error updating validating webhook CA bundle")
+
+			break
+		}
+
+		webhook.Webhooks[i].ClientConfig.CABundle = b
+	}
+}
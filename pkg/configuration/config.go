@@ -17,16 +17,33 @@ package configuration
 import (
 	"context"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"net"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode"
 
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -54,26 +71,231 @@ type Configuration struct {
 	Annotations map[string]string `json:"annotations,omitempty"`
 	DomainName  string            `json:"domainName,omitempty"`
 
+	// ResourceLabels are added to all generated secrets, services and ingresses. The labels of the target
+	// configuration are merged onto the global ones, the labels managed by the controller take precedence.
+	ResourceLabels map[string]string `json:"resourceLabels,omitempty"`
+
+	// PropagatedAnnotations are the keys of the container scoped pod annotations, in the form <key>/<container>, which
+	// are propagated from the application container to the injected proxies, e.g.
+	// container.apparmor.security.beta.kubernetes.io
+	PropagatedAnnotations []string `json:"propagatedAnnotations,omitempty"`
+
 	OidcCABundle    string                  `json:"oidcCABundle,omitempty"`
 	OidcCASecretRef *corev1.SecretReference `json:"oidcCASecretRef,omitempty"`
+
+	// OidcPublicIssuer designates an OIDC issuer presenting a publicly trusted certificate, e.g. Google or Azure. The
+	// proxies then verify it against the system trust store of their images and the configured CA bundles are ignored.
+	OidcPublicIssuer *bool `json:"oidcPublicIssuer,omitempty"`
+
+	// NativeSidecars injects the proxies as native sidecar init containers (restartPolicy: Always) on clusters
+	// supporting them
+	NativeSidecars *bool `json:"nativeSidecars,omitempty"`
+
+	// DisableOwnerReferences omits the owner references on the generated resources, which are then cleaned up by
+	// their owner label. Used when the generated resources are also managed by a GitOps tool.
+	DisableOwnerReferences *bool `json:"disableOwnerReferences,omitempty"`
+
+	// DeletionPropagationPolicy is the propagation policy of the deletions cleaning up the generated resources,
+	// Foreground, Background or Orphan. Only the global configuration is taken into account.
+	DeletionPropagationPolicy metav1.DeletionPropagation `json:"deletionPropagationPolicy,omitempty"`
+
+	// RetryBackoff is the backoff of the retried updates of the generated resources on conflicts. Only the global
+	// configuration is taken into account.
+	RetryBackoff *RetryBackoffConfig `json:"retryBackoff,omitempty"`
+
+	// RecreateServicesOnImmutableChange deletes and recreates the generated services, which cannot be patched due to a
+	// change of an immutable field. Only the global configuration is taken into account.
+	RecreateServicesOnImmutableChange *bool `json:"recreateServicesOnImmutableChange,omitempty"`
+
+	// RestartOnConfigChange restarts the target workloads, whose oauth2-proxy configuration has changed, by stamping
+	// the restartedAt annotation on their pod templates. Only the global configuration is taken into account.
+	RestartOnConfigChange *bool `json:"restartOnConfigChange,omitempty"`
+
+	// MaxPerPodResources caps the number of StatefulSet pods, which get a dedicated ingress and service. The pods with
+	// an ordinal beyond the cap are not exposed. Zero means no cap. Only the global configuration is taken into
+	// account.
+	MaxPerPodResources int32 `json:"maxPerPodResources,omitempty"`
+
+	// GenerateNames suffixes the deterministic names of the generated ingresses and network policies with the hash of
+	// the workload UID, so that a recreated workload does not collide with the resources of its predecessor pending
+	// the garbage collection. Only the global configuration is taken into account.
+	GenerateNames *bool `json:"generateNames,omitempty"`
+}
+
+// RetryBackoffConfig overrides the retry.DefaultRetry backoff of the conflicting updates
+type RetryBackoffConfig struct {
+	// Steps is the maximal number of attempts
+	Steps int `json:"steps,omitempty"`
+	// Duration is the initial delay between the attempts, e.g. 10ms
+	Duration string `json:"duration,omitempty"`
+	// Factor multiplies the delay after each attempt
+	Factor float64 `json:"factor,omitempty"`
 }
 
 // Oauth2ProxyConfig OIDC Provider configuration
 type Oauth2ProxyConfig struct {
-	Scope                              string `json:"scope,omitempty"`
-	ClientID                           string `json:"clientId"`
-	ClientSecret                       string `json:"clientSecret,omitempty"`
-	RedirectURL                        string `json:"redirectUrl"`
-	OidcIssuerURL                      string `json:"oidcIssuerUrl"`
-	SSLInsecureSkipVerify              *bool  `json:"sslInsecureSkipVerify,omitempty"`
-	InsecureOidcSkipIssuerVerification *bool  `json:"insecureOidcSkipIssuerVerification,omitempty"`
-	InsecureOidcSkipNonce              *bool  `json:"insecureOidcSkipNonce,omitempty"`
+	Scope                              string            `json:"scope,omitempty"`
+	ClientID                           string            `json:"clientId"`
+	ClientSecret                       string            `json:"clientSecret,omitempty"`
+	RedirectURL                        string            `json:"redirectUrl"`
+	OidcIssuerURL                      string            `json:"oidcIssuerUrl"`
+	SSLInsecureSkipVerify              *bool             `json:"sslInsecureSkipVerify,omitempty"`
+	InsecureOidcSkipIssuerVerification *bool             `json:"insecureOidcSkipIssuerVerification,omitempty"`
+	InsecureOidcSkipNonce              *bool             `json:"insecureOidcSkipNonce,omitempty"`
+	MetricsPort                        int32             `json:"metricsPort,omitempty"`
+	StartupProbe                       *StartupProbeConf `json:"startupProbe,omitempty"`
+	// UpstreamTimeout is the maximum duration of an upstream request, e.g. "5m" for long-polling endpoints
+	UpstreamTimeout string `json:"upstreamTimeout,omitempty"`
+	// FlushInterval is the period of flushing the buffered upstream response to the client, e.g. "100ms"
+	// for streaming endpoints
+	FlushInterval string `json:"flushInterval,omitempty"`
+	// ShutdownDrain is the period, e.g. "15s", the proxies keep serving upon the termination of a pod, while it is
+	// removed from the service endpoints. The termination grace period of the pods is raised to cover it. Disabled when
+	// empty.
+	ShutdownDrain string `json:"shutdownDrain,omitempty"`
+	// CookieCSRFPerRequest enables a unique CSRF cookie per authentication request. Enabled by default for
+	// StatefulSet targets, where parallel logins against the per-pod hosts would overwrite a shared CSRF cookie.
+	CookieCSRFPerRequest *bool `json:"cookieCsrfPerRequest,omitempty"`
+	// CookieCSRFExpire is the lifetime of the CSRF cookie, e.g. "5m"
+	CookieCSRFExpire string `json:"cookieCsrfExpire,omitempty"`
+	// CookieRefresh is the period after which the session of an active user is refreshed, also extending the session
+	// cookie expiration, e.g. "15m". It must be shorter than the CookieExpire. Defaults to 1h.
+	CookieRefresh string `json:"cookieRefresh,omitempty"`
+	// CookieExpire is the lifetime of the session cookie without activity, e.g. "12h". Defaults to 168h.
+	CookieExpire string `json:"cookieExpire,omitempty"`
+	// CookieSecretMaxAge is the maximum age of the cookie secret, e.g. "720h", after which the cookie secret is
+	// regenerated. The sessions signed with the previous cookie secret are invalidated, as oauth2-proxy accepts a single
+	// cookie secret. Never rotated when empty.
+	CookieSecretMaxAge string `json:"cookieSecretMaxAge,omitempty"`
+	// CookieSameSite is the SameSite attribute of the cookies, lax, strict or none. The cookies of an app embedded
+	// cross-site require none, which enforces the Secure attribute. Defaults to the browser default.
+	CookieSameSite string `json:"cookieSameSite,omitempty"`
+	// SkipProviderButton skips the oauth2-proxy sign-in page and redirects straight to the OIDC provider. Defaults to
+	// true.
+	SkipProviderButton *bool `json:"skipProviderButton,omitempty"`
+	// SignInPage customizes the oauth2-proxy sign-in page
+	SignInPage *SignInPageConf `json:"signInPage,omitempty"`
+	// ReverseProxy makes oauth2-proxy trust the X-Forwarded-* headers of the ingress controller, so that the redirects
+	// use the original scheme and host. Defaults to true, as the proxy is always fronted by an ingress.
+	ReverseProxy *bool `json:"reverseProxy,omitempty"`
+	// UpstreamHealthPath is the health endpoint of the upstream, e.g. "/healthz", served without authentication so
+	// that the upstream health can be probed through the proxies
+	UpstreamHealthPath string `json:"upstreamHealthPath,omitempty"`
+	// ExtraAudiences are the token audiences accepted in addition to the client id
+	ExtraAudiences []string `json:"extraAudiences,omitempty"`
+	// AudienceClaims are the token claims holding the audience, oauth2-proxy defaults to "aud"
+	AudienceClaims []string `json:"audienceClaims,omitempty"`
+	// RealClientIPHeader is the header holding the client IP set by the ingress controller, used by oauth2-proxy for
+	// logging and rate limiting. It requires reverseProxy to be enabled.
+	RealClientIPHeader string `json:"realClientIpHeader,omitempty"`
+	// CookieName is the name of the session cookie. It defaults to a name derived from the target, so that the proxies
+	// on the subdomains of a shared parent domain do not overwrite each other's cookies.
+	CookieName string `json:"cookieName,omitempty"`
+	// CodeChallengeMethod is the PKCE code challenge method, S256 or plain. Defaults to S256.
+	CodeChallengeMethod string `json:"codeChallengeMethod,omitempty"`
+	// CustomTemplatesRef is a config map in the target namespace with the sign_in.html and error.html templates,
+	// replacing the built-in oauth2-proxy pages. A missing template falls back to the built-in one.
+	CustomTemplatesRef *corev1.LocalObjectReference `json:"customTemplatesRef,omitempty"`
+	// SkipJWTBearerTokens lets API clients presenting a bearer JWT through without a login redirect. The tokens are
+	// verified against the OIDC issuer and the ExtraJWTIssuers. Defaults to true.
+	SkipJWTBearerTokens *bool `json:"skipJwtBearerTokens,omitempty"`
+	// ExtraJWTIssuers are the additional issuers of the accepted bearer JWTs as <issuer URL>=<audience>
+	ExtraJWTIssuers []string `json:"extraJwtIssuers,omitempty"`
+	// Provider is the oauth2-proxy provider type, oidc, google, github or azure. Defaults to oidc.
+	Provider string `json:"provider,omitempty"`
+	// GitHubOrg restricts the logins of the github provider to the members of the organization
+	GitHubOrg string `json:"githubOrg,omitempty"`
+	// GitHubTeam restricts the logins of the github provider further to the members of the teams, comma separated
+	GitHubTeam string `json:"githubTeam,omitempty"`
+	// AzureTenant is the directory tenant of the azure provider
+	AzureTenant string `json:"azureTenant,omitempty"`
+	// SkipOidcDiscovery disables the OIDC discovery of IdPs without a proper discovery document, the OidcEndpoints
+	// are used instead
+	SkipOidcDiscovery *bool `json:"skipOidcDiscovery,omitempty"`
+	// OidcEndpoints are the static endpoints of the OIDC provider, required when the discovery is skipped
+	OidcEndpoints *OidcEndpointsConf `json:"oidcEndpoints,omitempty"`
+	// AllowQuerySemicolons keeps the semicolons of the query strings as separators, which are otherwise dropped
+	// together with the query parameters containing them. Required by legacy upstreams. Defaults to false.
+	AllowQuerySemicolons *bool `json:"allowQuerySemicolons,omitempty"`
+	// UpstreamReadiness makes the oauth2-proxy sidecar report not ready while the upstream is down, by probing the
+	// UpstreamHealthPath through both proxies. Requires the UpstreamHealthPath. Defaults to false.
+	UpstreamReadiness *bool `json:"upstreamReadiness,omitempty"`
+	// UpstreamPathRewrite rewrites the request paths before they are forwarded to the upstream, e.g. stripping the
+	// ingress base path. The proxy is then configured through an additional oauth2-proxy alpha configuration.
+	UpstreamPathRewrite *UpstreamPathRewriteConf `json:"upstreamPathRewrite,omitempty"`
+	// APIRoutes are the regular expressions of the request paths of API endpoints, e.g. ^/api, which respond with a 401
+	// to the unauthenticated requests instead of redirecting them to the login.
+	APIRoutes []string `json:"apiRoutes,omitempty"`
+	// SetXAuthRequest passes the user identity to the upstream in the X-Auth-Request-User, -Email, -Groups and
+	// -Preferred-Username headers, which are also set on the responses. The proxy is then configured through an
+	// additional oauth2-proxy alpha configuration. Defaults to false.
+	SetXAuthRequest *bool `json:"setXAuthRequest,omitempty"`
+	// XAuthRequestClaims are additional token claims passed to the upstream as X-Auth-Request-<Claim> headers, e.g.
+	// the tenant_id claim as X-Auth-Request-Tenant-Id. Taken into account only with SetXAuthRequest.
+	XAuthRequestClaims []string `json:"xAuthRequestClaims,omitempty"`
+	// PassUserHeaders passes the user, email, groups and preferred username of the authenticated user to the upstream
+	// in the X-Forwarded-* headers. Defaults to true.
+	PassUserHeaders *bool `json:"passUserHeaders,omitempty"`
+	// PassBasicAuth passes the user to legacy upstreams in a basic Authorization header with the BasicAuthPassword,
+	// instead of the ID token as a bearer token. It implies the user headers. Defaults to false.
+	PassBasicAuth *bool `json:"passBasicAuth,omitempty"`
+	// BasicAuthPassword is the password of the basic Authorization header, required with PassBasicAuth. It is rendered
+	// into the oauth2-proxy configuration secret.
+	BasicAuthPassword string `json:"basicAuthPassword,omitempty"`
+	// MetricsScrapers are the only peers, e.g. the namespace of the monitoring stack, allowed to access the metrics
+	// port. A network policy restricting the metrics port of the target pods is generated when set, as the metrics
+	// endpoint is not authenticated.
+	MetricsScrapers []networkingv1.NetworkPolicyPeer `json:"metricsScrapers,omitempty"`
+}
+
+// UpstreamPathRewriteConf holds the rewrite of the request paths forwarded to the upstream
+type UpstreamPathRewriteConf struct {
+	// Path is the regular expression matching the request paths, starting with ^. Its capture groups are referenced by
+	// the RewriteTarget. Defaults to the ingress base path and the rest of the path captured, e.g. ^/app(?:/(.*))?$
+	Path string `json:"path,omitempty"`
+	// RewriteTarget is the path forwarded to the upstream, e.g. /$1. Defaults to /$1
+	RewriteTarget string `json:"rewriteTarget,omitempty"`
+}
+
+// OidcEndpointsConf holds the static endpoints of an OIDC provider
+type OidcEndpointsConf struct {
+	// AuthorizationURL is the authorization endpoint the logins are redirected to, required
+	AuthorizationURL string `json:"authorizationUrl,omitempty"`
+	// TokenURL is the endpoint redeeming the authorization codes, required
+	TokenURL string `json:"tokenUrl,omitempty"`
+	// JWKSURL is the endpoint of the keys verifying the token signatures, required
+	JWKSURL string `json:"jwksUrl,omitempty"`
+	// UserInfoURL is the endpoint of the user profile, optional
+	UserInfoURL string `json:"userInfoUrl,omitempty"`
+}
+
+// SignInPageConf holds the customizations of the oauth2-proxy sign-in page
+type SignInPageConf struct {
+	// Banner is a text or HTML snippet shown above the sign-in button, "-" disables the default banner
+	Banner string `json:"banner,omitempty"`
+	// Footer is a text or HTML snippet shown in the page footer, "-" disables the default footer
+	Footer string `json:"footer,omitempty"`
+	// Logo is a URL or a path of the logo shown on the sign-in page, "-" disables the default logo
+	Logo string `json:"logo,omitempty"`
+	// ConfigMapRef is a config map in the target namespace with a banner and/or a footer key, shared by the platform
+	// teams. Its values take precedence over the Banner and the Footer, a missing config map or key is ignored.
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+}
+
+// StartupProbeConf holds the startup probe configuration of the oauth2-proxy sidecar
+type StartupProbeConf struct {
+	Enabled          bool  `json:"enabled,omitempty"`
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+	PeriodSeconds    int32 `json:"periodSeconds,omitempty"`
 }
 
 // KubeRbacProxyConfig kube-rbac-proxy configuration
 type KubeRbacProxyConfig struct {
 	KubeConfigStr string                  `json:"kubeConfigStr,omitempty"`
 	KubeSecretRef *corev1.SecretReference `json:"kubeSecretRef,omitempty"`
+	// APIServerCASecretRef is a secret in the target namespace with a ca.crt key holding the CA bundle of the API
+	// server, which the kube-rbac-proxy trusts instead of the service account CA. Ignored when a kubeconfig is used.
+	APIServerCASecretRef *corev1.SecretReference `json:"apiServerCASecretRef,omitempty"`
 }
 
 // Target workload selector configuration
@@ -84,7 +306,46 @@ type Target struct {
 	TargetPort        intstr.IntOrString    `json:"targetPort,omitempty"`
 	TargetProtocol    string                `json:"targetProtocol,omitempty"`
 	Ingress           *IngressConf          `json:"ingress,omitempty"`
+	Service           *ServiceConf          `json:"service,omitempty"`
 	Configuration     *Configuration        `json:"configuration,omitempty"`
+	// TargetServerName is the TLS server name of an https upstream, when its certificate is not issued for localhost.
+	// The upstream is then addressed by this name, which resolves to the pod itself.
+	TargetServerName string `json:"targetServerName,omitempty"`
+	// TargetContainer is the container declaring the named TargetPort, when the port name is not unique across the
+	// containers of the pods
+	TargetContainer string `json:"targetContainer,omitempty"`
+	// Upstreams are additional upstreams of the pods, e.g. the ports of further application containers, which the
+	// oauth2-proxy routes the requests to by their path. Each one is authorized by a dedicated kube-rbac-proxy sidecar.
+	Upstreams []UpstreamConf `json:"upstreams,omitempty"`
+}
+
+// UpstreamConf holds an additional upstream of the target pods
+type UpstreamConf struct {
+	// Path is the prefix of the request paths routed to the upstream, e.g. /api/
+	Path string `json:"path"`
+	// TargetPort is the port of the upstream, a container port number or name
+	TargetPort intstr.IntOrString `json:"targetPort"`
+	// TargetProtocol is the protocol of the upstream, http or https. Defaults to http.
+	TargetProtocol string `json:"targetProtocol,omitempty"`
+	// TargetContainer is the container declaring the named TargetPort, when the port name is not unique across the
+	// containers of the pods
+	TargetContainer string `json:"targetContainer,omitempty"`
+}
+
+// Target returns the protocol and port tuple of the upstream
+func (u UpstreamConf) Target() string {
+	return upstreamTarget(u.TargetProtocol, u.TargetPort)
+}
+
+// maxUpstreams caps the additional upstreams of a target, each one adding a kube-rbac-proxy sidecar to the pods
+const maxUpstreams = 10
+
+// ServiceConf holds configuration for the generated oauth2 service
+type ServiceConf struct {
+	// Type of the generated oauth2 service, ClusterIP, NodePort or LoadBalancer. Defaults to ClusterIP.
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// SkipIngress omits the ingress of a NodePort or LoadBalancer service, which exposes the oauth2-proxy directly
+	SkipIngress bool `json:"skipIngress,omitempty"`
 }
 
 // IngressConf holds configuration for the ingress entry-point
@@ -95,6 +356,33 @@ type IngressConf struct {
 	Annotations      map[string]string      `json:"annotations,omitempty"`
 	TLSSecretRef     corev1.SecretReference `json:"tlsSecretRef,omitempty"`
 	IngressClassName string                 `json:"ingressClassName,omitempty"`
+	Path             string                 `json:"path,omitempty"`
+	PathType         string                 `json:"pathType,omitempty"`
+	// ExternalDNS stamps the external-dns hostname annotation with the ingress host on the generated ingresses
+	ExternalDNS bool `json:"externalDNS,omitempty"`
+	// ServiceName is an existing service of the target, which the ingress routes to instead of a generated oauth2
+	// service. The oauth2-proxy port is added to the existing service, which has to carry the
+	// oidc-application-controller/component: oidc-apps label to be visible to the controller. Applies to deployments
+	// only.
+	ServiceName string `json:"serviceName,omitempty"`
+	// ServicePort is the port of the oauth2-proxy added to the existing ServiceName. Defaults to 8080.
+	ServicePort int32 `json:"servicePort,omitempty"`
+	// SessionAffinity stamps the ingress-nginx cookie affinity annotations on the generated ingresses, so that the
+	// requests of a session stick to the same oauth2-proxy replica
+	SessionAffinity bool `json:"sessionAffinity,omitempty"`
+	// ProxyBodySize is the maximum size of the request bodies accepted by the ingress, e.g. 100m for large uploads or 0
+	// for no limit. It sets the ingress-nginx proxy-body-size annotation, whose default of 1m rejects larger uploads.
+	ProxyBodySize string `json:"proxyBodySize,omitempty"`
+	// IngressClassNames spreads the per-pod ingresses of a statefulset across several ingress controllers. The pods
+	// are assigned to the classes according to IngressClassAssignment.
+	IngressClassNames []string `json:"ingressClassNames,omitempty"`
+	// IngressClassAssignment is the assignment of the per-pod ingresses to the IngressClassNames, RoundRobin by the
+	// pod ordinal or Hash of the pod name. Defaults to RoundRobin.
+	IngressClassAssignment string `json:"ingressClassAssignment,omitempty"`
+	// Shared registers the workloads of the target as path rules of a shared, controller-managed ingress of the
+	// configured Host, instead of a dedicated ingress per workload. The base path of a workload is its name, nested
+	// under the configured Path. Applies to deployments only.
+	Shared bool `json:"shared,omitempty"`
 }
 
 var config *OIDCAppsControllerConfig
@@ -149,334 +437,2099 @@ func CreateControllerConfigOrDie(path string, opts ...Options) *OIDCAppsControll
 			config.log.Error(err, "failed to unmarshal extension configuration")
 			panic("terminating")
 		}
+
+		if err = config.Validate(); err != nil {
+			if config.log.IsZero() {
+				log.SetLogger(zap.New(zap.UseDevMode(true)))
+				config.log = log.Log.WithName("oidcAppsExtensionConfig")
+			}
+
+			config.log.Error(err, "invalid extension configuration")
+			panic("terminating")
+		}
 	})
 
 	return config
 }
 
-// GetOIDCAppsControllerConfig returns the loaded configuration
-func GetOIDCAppsControllerConfig() *OIDCAppsControllerConfig {
-	return config
-}
+// Validate verifies the values of the loaded configuration which cannot be checked upon unmarshalling
+func (c *OIDCAppsControllerConfig) Validate() error {
+	if err := c.Configuration.Oauth2Proxy.validate(); err != nil {
+		return fmt.Errorf("invalid oauth2Proxy configuration: %w", err)
+	}
 
-// Match accepts a client.Object and verifies if is a target defined in the controller configuration
-func (c *OIDCAppsControllerConfig) Match(o client.Object) bool {
-	if c == nil || c.Targets == nil || len(c.Targets) == 0 {
-		return false
+	if p := c.Configuration.DeletionPropagationPolicy; p != "" && !slices.Contains(deletionPropagationPolicies, p) {
+		return fmt.Errorf("invalid deletionPropagationPolicy %q, expected one of %v", p, deletionPropagationPolicies)
+	}
+
+	if err := c.Configuration.RetryBackoff.validate(); err != nil {
+		return fmt.Errorf("invalid retryBackoff configuration: %w", err)
+	}
+
+	if err := validateLabels(c.Configuration.ResourceLabels); err != nil {
+		return fmt.Errorf("invalid resourceLabels: %w", err)
+	}
+
+	if err := validatePropagatedAnnotations(c.Configuration.PropagatedAnnotations); err != nil {
+		return fmt.Errorf("invalid propagatedAnnotations: %w", err)
+	}
+
+	if err := validateProvider(c.Configuration.Oauth2Proxy, nil); err != nil {
+		return fmt.Errorf("invalid oauth2Proxy configuration: %w", err)
+	}
+
+	if err := validateOidcDiscovery(c.Configuration.Oauth2Proxy, nil); err != nil {
+		return fmt.Errorf("invalid oauth2Proxy configuration: %w", err)
+	}
+
+	if err := validateUpstreamReadiness(c.Configuration.Oauth2Proxy, nil); err != nil {
+		return fmt.Errorf("invalid oauth2Proxy configuration: %w", err)
+	}
+
+	if err := validateCookieRefresh(c.Configuration.Oauth2Proxy, nil); err != nil {
+		return fmt.Errorf("invalid oauth2Proxy configuration: %w", err)
+	}
+
+	if c.Configuration.MaxPerPodResources < 0 {
+		return fmt.Errorf("invalid maxPerPodResources %d, expected a non-negative number",
+			c.Configuration.MaxPerPodResources)
 	}
 
 	for _, t := range c.Targets {
-		if c.targetMatchesLabels(t, o) {
-			return true
+		if err := t.Ingress.validate(); err != nil {
+			return fmt.Errorf("invalid ingress configuration of target %s: %w", t.Name, err)
+		}
+
+		if err := t.Service.validate(); err != nil {
+			return fmt.Errorf("invalid service configuration of target %s: %w", t.Name, err)
+		}
+
+		if err := t.validateServerName(); err != nil {
+			return fmt.Errorf("invalid targetServerName of target %s: %w", t.Name, err)
+		}
+
+		if err := t.validateUpstreams(); err != nil {
+			return fmt.Errorf("invalid upstreams of target %s: %w", t.Name, err)
+		}
+
+		if t.Configuration == nil {
+			continue
+		}
+
+		if err := t.Configuration.Oauth2Proxy.validate(); err != nil {
+			return fmt.Errorf("invalid oauth2Proxy configuration of target %s: %w", t.Name, err)
+		}
+
+		if err := validateProvider(c.Configuration.Oauth2Proxy, t.Configuration.Oauth2Proxy); err != nil {
+			return fmt.Errorf("invalid oauth2Proxy configuration of target %s: %w", t.Name, err)
+		}
+
+		if err := validateOidcDiscovery(c.Configuration.Oauth2Proxy, t.Configuration.Oauth2Proxy); err != nil {
+			return fmt.Errorf("invalid oauth2Proxy configuration of target %s: %w", t.Name, err)
+		}
+
+		if err := validateUpstreamReadiness(c.Configuration.Oauth2Proxy, t.Configuration.Oauth2Proxy); err != nil {
+			return fmt.Errorf("invalid oauth2Proxy configuration of target %s: %w", t.Name, err)
+		}
+
+		if err := validateCookieRefresh(c.Configuration.Oauth2Proxy, t.Configuration.Oauth2Proxy); err != nil {
+			return fmt.Errorf("invalid oauth2Proxy configuration of target %s: %w", t.Name, err)
+		}
+
+		if err := validateLabels(t.Configuration.ResourceLabels); err != nil {
+			return fmt.Errorf("invalid resourceLabels of target %s: %w", t.Name, err)
+		}
+
+		if err := validatePropagatedAnnotations(t.Configuration.PropagatedAnnotations); err != nil {
+			return fmt.Errorf("invalid propagatedAnnotations of target %s: %w", t.Name, err)
 		}
 	}
 
-	return false
+	return nil
 }
 
-// GetHost return the domain name for a given workload target
-func (c *OIDCAppsControllerConfig) GetHost(object client.Object) string {
-	t := c.fetchTarget(object)
-	domain := c.Configuration.DomainName
+// validateServerName verifies that the server name of the upstream is a DNS name and the upstream is served over https
+func (t Target) validateServerName() error {
+	if t.TargetServerName == "" {
+		return nil
+	}
 
-	if len(os.Getenv(constants.GardenSeedDomainName)) > 0 {
-		domain = os.Getenv(constants.GardenSeedDomainName)
+	if t.TargetProtocol != "https" {
+		return fmt.Errorf("server name %q requires the https target protocol", t.TargetServerName)
+	}
+
+	if errs := validation.IsDNS1123Subdomain(t.TargetServerName); len(errs) > 0 {
+		return fmt.Errorf("server name %q: %s", t.TargetServerName, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// validateUpstreams verifies the additional upstreams, which are routed to by distinct path prefixes other than the
+// root path
+func (t Target) validateUpstreams() error {
+	if len(t.Upstreams) > maxUpstreams {
+		return fmt.Errorf("%d upstreams exceed the maximum of %d", len(t.Upstreams), maxUpstreams)
+	}
+
+	if errs := validation.IsDNS1123Label(t.TargetContainer); t.TargetContainer != "" && len(errs) > 0 {
+		return fmt.Errorf("target container %q: %s", t.TargetContainer, strings.Join(errs, "; "))
+	}
+
+	paths := make(map[string]struct{}, len(t.Upstreams))
+
+	for _, u := range t.Upstreams {
+		if !strings.HasPrefix(u.Path, "/") || u.Path == "/" {
+			return fmt.Errorf("path %q must start with / and differ from the root path", u.Path)
+		}
+
+		if _, found := paths[u.Path]; found {
+			return fmt.Errorf("path %q is routed to several upstreams", u.Path)
+		}
+
+		paths[u.Path] = struct{}{}
+
+		if (u.TargetPort.Type == intstr.Int && (u.TargetPort.IntVal < 1 || u.TargetPort.IntVal > 65535)) ||
+			(u.TargetPort.Type == intstr.String && u.TargetPort.StrVal == "") {
+			return fmt.Errorf("invalid target port %q of path %s", u.TargetPort.String(), u.Path)
+		}
+
+		if u.TargetProtocol != "" && u.TargetProtocol != "http" && u.TargetProtocol != "https" {
+			return fmt.Errorf("target protocol %q of path %s, expected http or https", u.TargetProtocol, u.Path)
+		}
+
+		if errs := validation.IsDNS1123Label(u.TargetContainer); u.TargetContainer != "" && len(errs) > 0 {
+			return fmt.Errorf("target container %q of path %s: %s", u.TargetContainer, u.Path, strings.Join(errs, "; "))
+		}
+	}
+
+	return nil
+}
+
+func (i *IngressConf) validate() error {
+	if i == nil {
+		return nil
+	}
+
+	if a := i.IngressClassAssignment; a != "" && !slices.Contains(ingressClassAssignments, a) {
+		return fmt.Errorf("ingressClassAssignment %q, expected one of %v", a, ingressClassAssignments)
+	}
+
+	if slices.Contains(i.IngressClassNames, "") {
+		return errors.New("ingressClassNames: empty ingress class name")
+	}
+
+	if i.Shared && i.Host == "" {
+		return errors.New("shared: the host of the shared ingress is required")
+	}
+
+	if i.ServicePort < 0 || i.ServicePort > 65535 {
+		return fmt.Errorf("servicePort %d, expected a port between 1 and 65535", i.ServicePort)
+	}
+
+	if s := i.ProxyBodySize; s != "" && !proxyBodySizePattern.MatchString(s) {
+		return fmt.Errorf("proxyBodySize %q, expected a size like 100m or 0 for no limit", s)
+	}
+
+	return nil
+}
+
+func (s *ServiceConf) validate() error {
+	if s == nil {
+		return nil
+	}
+
+	if t := s.Type; t != "" && !slices.Contains(serviceTypes, t) {
+		return fmt.Errorf("type %q, expected one of %v", t, serviceTypes)
+	}
+
+	return nil
+}
+
+func validateLabels(l map[string]string) error {
+	for k, v := range l {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("label key %q: %s", k, strings.Join(errs, "; "))
+		}
+
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("label value %q of %s: %s", v, k, strings.Join(errs, "; "))
+		}
+	}
+
+	return nil
+}
+
+// validateNetworkPolicyPeer verifies that the peer selects either pods and namespaces or an IP block
+func validateNetworkPolicyPeer(peer networkingv1.NetworkPolicyPeer) error {
+	if peer.IPBlock != nil {
+		if peer.PodSelector != nil || peer.NamespaceSelector != nil {
+			return errors.New("ipBlock must not be combined with a pod or namespace selector")
+		}
+
+		for _, cidr := range append([]string{peer.IPBlock.CIDR}, peer.IPBlock.Except...) {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("ipBlock: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	if peer.PodSelector == nil && peer.NamespaceSelector == nil {
+		return errors.New("peer must set a pod selector, a namespace selector or an ipBlock")
+	}
+
+	for _, selector := range []*metav1.LabelSelector{peer.PodSelector, peer.NamespaceSelector} {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePropagatedAnnotations verifies that the keys form qualified annotation names together with the proxy
+// container names
+func validatePropagatedAnnotations(keys []string) error {
+	for _, k := range keys {
+		if errs := validation.IsQualifiedName(k + "/" + constants.ContainerNameKubeRbacProxy); len(errs) > 0 {
+			return fmt.Errorf("annotation key %q: %s", k, strings.Join(errs, "; "))
+		}
+	}
+
+	return nil
+}
+
+func (o *Oauth2ProxyConfig) validate() error {
+	if o == nil {
+		return nil
+	}
+
+	if err := validateDuration(o.UpstreamTimeout); err != nil {
+		return fmt.Errorf("upstreamTimeout: %w", err)
+	}
+
+	if err := validateDuration(o.FlushInterval); err != nil {
+		return fmt.Errorf("flushInterval: %w", err)
+	}
+
+	if err := validateDuration(o.ShutdownDrain); err != nil {
+		return fmt.Errorf("shutdownDrain: %w", err)
+	}
+
+	if err := validateDuration(o.CookieCSRFExpire); err != nil {
+		return fmt.Errorf("cookieCsrfExpire: %w", err)
+	}
+
+	if err := validateDuration(o.CookieRefresh); err != nil {
+		return fmt.Errorf("cookieRefresh: %w", err)
+	}
+
+	if err := validateDuration(o.CookieExpire); err != nil {
+		return fmt.Errorf("cookieExpire: %w", err)
+	}
+
+	if err := validateDuration(o.CookieSecretMaxAge); err != nil {
+		return fmt.Errorf("cookieSecretMaxAge: %w", err)
+	}
+
+	if o.UpstreamHealthPath != "" && !strings.HasPrefix(o.UpstreamHealthPath, "/") {
+		return fmt.Errorf("upstreamHealthPath: path %q must start with /", o.UpstreamHealthPath)
+	}
+
+	if o.RealClientIPHeader != "" && !slices.Contains(realClientIPHeaders, http.CanonicalHeaderKey(o.RealClientIPHeader)) {
+		return fmt.Errorf("realClientIpHeader: header %q is not one of %s", o.RealClientIPHeader,
+			strings.Join(realClientIPHeaders, ", "))
+	}
+
+	if o.CookieName != "" {
+		if err := (&http.Cookie{Name: o.CookieName}).Valid(); err != nil {
+			return fmt.Errorf("cookieName: %w", err)
+		}
+	}
+
+	if m := o.CookieSameSite; m != "" && !slices.Contains(cookieSameSiteModes, m) {
+		return fmt.Errorf("cookieSameSite: mode %q is not one of %s", m, strings.Join(cookieSameSiteModes, ", "))
+	}
+
+	if m := o.CodeChallengeMethod; m != "" && !slices.Contains(codeChallengeMethods, m) {
+		return fmt.Errorf("codeChallengeMethod: method %q is not one of %s", m, strings.Join(codeChallengeMethods, ", "))
+	}
+
+	for _, i := range o.ExtraJWTIssuers {
+		if issuer, audience, found := strings.Cut(i, "="); !found || issuer == "" || audience == "" {
+			return fmt.Errorf("extraJwtIssuers: issuer %q is not of the form <issuer URL>=<audience>", i)
+		}
+	}
+
+	for _, claim := range o.XAuthRequestClaims {
+		if errs := validation.IsHTTPHeaderName(XAuthRequestHeader(claim)); claim == "" || len(errs) > 0 {
+			return fmt.Errorf("xAuthRequestClaims: claim %q is not a valid header name suffix", claim)
+		}
+	}
+
+	if ptr.Deref(o.PassBasicAuth, false) && o.BasicAuthPassword == "" {
+		return errors.New("passBasicAuth: basicAuthPassword is required")
+	}
+
+	if strings.ContainsFunc(o.BasicAuthPassword, unicode.IsControl) {
+		return errors.New("basicAuthPassword: password must not contain control characters")
+	}
+
+	for _, route := range o.APIRoutes {
+		if route == "" {
+			return errors.New("apiRoutes: empty route")
+		}
+
+		if _, err := regexp.Compile(route); err != nil {
+			return fmt.Errorf("apiRoutes: %w", err)
+		}
+	}
+
+	for _, peer := range o.MetricsScrapers {
+		if err := validateNetworkPolicyPeer(peer); err != nil {
+			return fmt.Errorf("metricsScrapers: %w", err)
+		}
+	}
+
+	if r := o.UpstreamPathRewrite; r != nil && r.Path != "" {
+		if !strings.HasPrefix(r.Path, "^") {
+			return fmt.Errorf("upstreamPathRewrite: path %q must start with ^", r.Path)
+		}
+
+		if _, err := regexp.Compile(r.Path); err != nil {
+			return fmt.Errorf("upstreamPathRewrite: %w", err)
+		}
+	}
+
+	if r := o.UpstreamPathRewrite; r != nil && r.RewriteTarget != "" && !strings.HasPrefix(r.RewriteTarget, "/") {
+		return fmt.Errorf("upstreamPathRewrite: rewrite target %q must start with /", r.RewriteTarget)
+	}
+
+	return nil
+}
+
+// validateProvider verifies the explicitly configured provider type and its required fields, which are taken from the
+// target configuration or else from the global one
+func validateProvider(global, target *Oauth2ProxyConfig) error {
+	effective := func(value func(*Oauth2ProxyConfig) string) string {
+		if target != nil && value(target) != "" {
+			return value(target)
+		}
+
+		if global != nil {
+			return value(global)
+		}
+
+		return ""
+	}
+
+	provider := effective(func(o *Oauth2ProxyConfig) string { return o.Provider })
+	if provider == "" {
+		return nil
+	}
+
+	fields, supported := providerRequiredFields[provider]
+	if !supported {
+		return fmt.Errorf("provider: %q is not one of %s", provider, strings.Join(providers, ", "))
+	}
+
+	for _, f := range fields {
+		if effective(f.value) == "" {
+			return fmt.Errorf("provider: %s requires %s", provider, f.name)
+		}
+	}
+
+	return nil
+}
+
+// validateOidcDiscovery verifies that the required static endpoints are present when the OIDC discovery is skipped.
+// The settings are taken from the target configuration or else from the global one.
+func validateOidcDiscovery(global, target *Oauth2ProxyConfig) error {
+	skip, endpoints := false, (*OidcEndpointsConf)(nil)
+
+	for _, o := range []*Oauth2ProxyConfig{global, target} {
+		if o == nil {
+			continue
+		}
+
+		if o.SkipOidcDiscovery != nil {
+			skip = *o.SkipOidcDiscovery
+		}
+
+		if o.OidcEndpoints != nil {
+			endpoints = o.OidcEndpoints
+		}
+	}
+
+	if !skip {
+		return nil
+	}
+
+	if endpoints == nil {
+		return errors.New("skipOidcDiscovery: oidcEndpoints are required when the discovery is skipped")
+	}
+
+	for _, e := range []struct{ name, value string }{
+		{name: "authorizationUrl", value: endpoints.AuthorizationURL},
+		{name: "tokenUrl", value: endpoints.TokenURL},
+		{name: "jwksUrl", value: endpoints.JWKSURL},
+	} {
+		if e.value == "" {
+			return fmt.Errorf("skipOidcDiscovery: oidcEndpoints.%s is required when the discovery is skipped", e.name)
+		}
+	}
+
+	return nil
+}
+
+// validateUpstreamReadiness verifies that the upstream health path is present when the upstream readiness is enabled.
+// The settings are taken from the target configuration or else from the global one.
+func validateUpstreamReadiness(global, target *Oauth2ProxyConfig) error {
+	enabled, healthPath := false, ""
+
+	for _, o := range []*Oauth2ProxyConfig{global, target} {
+		if o == nil {
+			continue
+		}
+
+		if o.UpstreamReadiness != nil {
+			enabled = *o.UpstreamReadiness
+		}
+
+		if o.UpstreamHealthPath != "" {
+			healthPath = o.UpstreamHealthPath
+		}
+	}
+
+	if enabled && healthPath == "" {
+		return errors.New("upstreamReadiness: upstreamHealthPath is required")
+	}
+
+	return nil
+}
+
+// The oauth2-proxy session cookie refresh period and lifetime, unless configured
+const (
+	defaultCookieRefresh = time.Hour
+	defaultCookieExpire  = 168 * time.Hour
+)
+
+// validateCookieRefresh verifies that the sessions are refreshed before their cookie expires, as required by
+// oauth2-proxy. The settings are taken from the target configuration or else from the global one.
+func validateCookieRefresh(global, target *Oauth2ProxyConfig) error {
+	refresh, expire := defaultCookieRefresh, defaultCookieExpire
+
+	for _, o := range []*Oauth2ProxyConfig{global, target} {
+		if o == nil {
+			continue
+		}
+
+		// The durations are already validated
+		if d, err := time.ParseDuration(o.CookieRefresh); err == nil {
+			refresh = d
+		}
+
+		if d, err := time.ParseDuration(o.CookieExpire); err == nil {
+			expire = d
+		}
+	}
+
+	if refresh >= expire {
+		return fmt.Errorf("cookieRefresh: refresh period %s must be shorter than the cookie expiration %s", refresh, expire)
+	}
+
+	return nil
+}
+
+// The supported oauth2-proxy provider types
+const (
+	ProviderOIDC   = "oidc"
+	ProviderGoogle = "google"
+	ProviderGitHub = "github"
+	ProviderAzure  = "azure"
+)
+
+var providers = []string{ProviderOIDC, ProviderGoogle, ProviderGitHub, ProviderAzure}
+
+type providerField struct {
+	name  string
+	value func(*Oauth2ProxyConfig) string
+}
+
+// providerRequiredFields are the fields required by the provider types. The github logins are restricted to an
+// organization, as any GitHub account could log in otherwise.
+var providerRequiredFields = map[string][]providerField{
+	ProviderOIDC:   {{name: "oidcIssuerUrl", value: func(o *Oauth2ProxyConfig) string { return o.OidcIssuerURL }}},
+	ProviderGoogle: nil,
+	ProviderGitHub: {{name: "githubOrg", value: func(o *Oauth2ProxyConfig) string { return o.GitHubOrg }}},
+	ProviderAzure:  {{name: "azureTenant", value: func(o *Oauth2ProxyConfig) string { return o.AzureTenant }}},
+}
+
+// cookieSameSiteModes are the SameSite attributes of the cookies supported by oauth2-proxy
+var cookieSameSiteModes = []string{"lax", "strict", "none"}
+
+// proxyBodySizePattern matches the nginx sizes, a number of bytes optionally suffixed with k, m or g
+var proxyBodySizePattern = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+
+// codeChallengeMethods are the PKCE code challenge methods supported by oauth2-proxy
+var codeChallengeMethods = []string{"S256", "plain"}
+
+// realClientIPHeaders are the client IP headers supported by oauth2-proxy, in canonical form
+var realClientIPHeaders = []string{
+	"X-Forwarded-For", "X-Real-Ip", "X-Proxyuser-Ip", "X-Envoy-External-Address", "Cf-Connecting-Ip",
+}
+
+func (r *RetryBackoffConfig) validate() error {
+	if r == nil {
+		return nil
+	}
+
+	if r.Steps < 0 {
+		return fmt.Errorf("steps %d must not be negative", r.Steps)
+	}
+
+	if err := validateDuration(r.Duration); err != nil {
+		return fmt.Errorf("duration: %w", err)
+	}
+
+	if r.Factor < 0 {
+		return fmt.Errorf("factor %v must not be negative", r.Factor)
+	}
+
+	return nil
+}
+
+func validateDuration(d string) error {
+	if d == "" {
+		return nil
+	}
+
+	v, err := time.ParseDuration(d)
+	if err != nil {
+		return err
+	}
+
+	if v <= 0 {
+		return fmt.Errorf("duration %q must be positive", d)
+	}
+
+	return nil
+}
+
+// GetOIDCAppsControllerConfig returns the loaded configuration
+func GetOIDCAppsControllerConfig() *OIDCAppsControllerConfig {
+	return config
+}
+
+// Match accepts a client.Object and verifies if is a target defined in the controller configuration
+func (c *OIDCAppsControllerConfig) Match(o client.Object) bool {
+	if c == nil || c.Targets == nil || len(c.Targets) == 0 {
+		return false
+	}
+
+	for _, t := range c.Targets {
+		if c.targetMatchesLabels(t, o) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetHost return the domain name for a given workload target
+func (c *OIDCAppsControllerConfig) GetHost(object client.Object) string {
+	t := c.fetchTarget(object)
+	domain := c.Configuration.DomainName
+
+	if len(os.Getenv(constants.GardenSeedDomainName)) > 0 {
+		domain = os.Getenv(constants.GardenSeedDomainName)
+	}
+
+	prefix := object.GetName() + "-" + object.GetNamespace()
+	if t.Ingress != nil && t.Ingress.HostPrefix != "" {
+		prefix = t.Ingress.HostPrefix + "-" + rand.GenerateSha256(object.GetName()+"-"+object.GetNamespace())
+	}
+
+	if t.Ingress != nil && t.Ingress.Host != "" {
+		prefix, domain, _ = strings.Cut(t.Ingress.Host, ".")
+	}
+
+	if domain == "" {
+		return prefix
+	}
+
+	return strings.Join([]string{prefix, domain}, ".")
+}
+
+// GetUpstreamTarget returns the protocol and port tuple of the target workload
+func (c *OIDCAppsControllerConfig) GetUpstreamTarget(object client.Object) string {
+	t := c.fetchTarget(object)
+
+	return upstreamTarget(t.TargetProtocol, t.TargetPort)
+}
+
+// upstreamTarget returns the protocol and port tuple of an upstream, the protocol defaults to http
+func upstreamTarget(targetProtocol string, port intstr.IntOrString) string {
+	b := strings.Builder{}
+	protocol := "http"
+
+	if targetProtocol == "https" {
+		protocol = "https"
+	}
+
+	b.Grow(9)
+	_, _ = b.WriteString("protocol=")
+	b.Grow(len(protocol))
+	_, _ = b.WriteString(protocol)
+	b.Grow(7)
+	_, _ = b.WriteString(", port=")
+	b.Grow(len(port.String()))
+	_, _ = b.WriteString(port.String())
+
+	return b.String()
+}
+
+// GetTargetContainer returns the container declaring the named target port, empty when any container may declare it
+func (c *OIDCAppsControllerConfig) GetTargetContainer(object client.Object) string {
+	return c.fetchTarget(object).TargetContainer
+}
+
+// GetUpstreams returns the additional upstreams of the target pods, routed to by their path
+func (c *OIDCAppsControllerConfig) GetUpstreams(object client.Object) []UpstreamConf {
+	return c.fetchTarget(object).Upstreams
+}
+
+// GetUpstreamServerName returns the TLS server name of an https upstream, empty when the upstream is addressed as
+// localhost
+func (c *OIDCAppsControllerConfig) GetUpstreamServerName(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.TargetProtocol != "https" {
+		return ""
+	}
+
+	return t.TargetServerName
+}
+
+// GetTargetPort returns the upstream port of the given target, a container port number or name
+func (c *OIDCAppsControllerConfig) GetTargetPort(object client.Object) intstr.IntOrString {
+	return c.fetchTarget(object).TargetPort
+}
+
+// GetKubeSecretName returns the kubeconfig secret name of the target workload
+func (c *OIDCAppsControllerConfig) GetKubeSecretName(object client.Object) string {
+	secretName := ""
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil &&
+		t.Configuration.KubeRbacProxy != nil &&
+		t.Configuration.KubeRbacProxy.KubeSecretRef != nil &&
+		t.Configuration.KubeRbacProxy.KubeSecretRef.Name != "" {
+		return t.Configuration.KubeRbacProxy.KubeSecretRef.Name
+	}
+
+	if c.Configuration.KubeRbacProxy != nil &&
+		c.Configuration.KubeRbacProxy.KubeSecretRef != nil &&
+		c.Configuration.KubeRbacProxy.KubeSecretRef.Name != "" {
+		secretName = c.Configuration.KubeRbacProxy.KubeSecretRef.Name
+	}
+
+	return secretName
+}
+
+// GetKubeRbacProxyAPIServerCASecretName returns the name of the secret holding the API server CA bundle trusted by the
+// kube-rbac-proxy, empty for the service account CA
+func (c *OIDCAppsControllerConfig) GetKubeRbacProxyAPIServerCASecretName(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil &&
+		t.Configuration.KubeRbacProxy != nil &&
+		t.Configuration.KubeRbacProxy.APIServerCASecretRef != nil &&
+		t.Configuration.KubeRbacProxy.APIServerCASecretRef.Name != "" {
+		return t.Configuration.KubeRbacProxy.APIServerCASecretRef.Name
+	}
+
+	if c.Configuration.KubeRbacProxy != nil &&
+		c.Configuration.KubeRbacProxy.APIServerCASecretRef != nil {
+		return c.Configuration.KubeRbacProxy.APIServerCASecretRef.Name
+	}
+
+	return ""
+}
+
+// GetKubeConfigStr returns the kubeconfig string of the target workload
+func (c *OIDCAppsControllerConfig) GetKubeConfigStr(object client.Object) string {
+	kubeConfig := ""
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil &&
+		t.Configuration.KubeRbacProxy != nil &&
+		t.Configuration.KubeRbacProxy.KubeConfigStr != "" {
+		return t.Configuration.KubeRbacProxy.KubeConfigStr
+	}
+
+	if c.Configuration.KubeRbacProxy != nil &&
+		c.Configuration.KubeRbacProxy.KubeConfigStr != "" {
+		kubeConfig = c.Configuration.KubeRbacProxy.KubeConfigStr
+	}
+
+	return kubeConfig
+}
+
+// GetOidcCASecretName returns the secret name holding the trusts CA certificate of the OIDC Provider
+func (c *OIDCAppsControllerConfig) GetOidcCASecretName(object client.Object) string {
+	secretName := ""
+
+	if c.GetOidcPublicIssuer(object) {
+		return ""
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil &&
+		t.Configuration.OidcCASecretRef != nil &&
+		t.Configuration.OidcCASecretRef.Name != "" {
+		return t.Configuration.OidcCASecretRef.Name
+	}
+
+	if c.Configuration.OidcCASecretRef != nil &&
+		c.Configuration.OidcCASecretRef.Name != "" {
+		secretName = c.Configuration.OidcCASecretRef.Name
+	}
+
+	return secretName
+}
+
+// GetOidcCABundle returns the trusted CA bundle certificates of the OIDC Provider
+func (c *OIDCAppsControllerConfig) GetOidcCABundle(object client.Object) string {
+	var (
+		decodedBytes []byte
+		err          error
+		oidcCABundle string
+	)
+
+	if c.GetOidcPublicIssuer(object) {
+		return ""
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil &&
+		t.Configuration.OidcCABundle != "" {
+		if decodedBytes, err = base64.StdEncoding.DecodeString(t.Configuration.OidcCABundle); err != nil {
+			c.log.Error(err, "failed to decode oidc ca bundle")
+
+			return ""
+		}
+
+		return string(decodedBytes)
+	}
+
+	if c.Configuration.OidcCABundle != "" {
+		oidcCABundle = c.Configuration.OidcCABundle
+	}
+
+	if decodedBytes, err = base64.StdEncoding.DecodeString(oidcCABundle); err != nil {
+		c.log.Error(err, "failed to decode oidc ca bundle")
+
+		return ""
+	}
+
+	return string(decodedBytes)
+}
+
+// OidcIssuer is a configured OIDC issuer together with the CA bundle trusted for it
+type OidcIssuer struct {
+	URL      string
+	CABundle string
+}
+
+// GetOidcIssuers returns the distinct OIDC issuers of the global and the target configurations, which publish a
+// discovery document, together with their decoded CA bundles
+func (c *OIDCAppsControllerConfig) GetOidcIssuers() []OidcIssuer {
+	var issuers []OidcIssuer
+
+	add := func(target *Configuration) {
+		issuer, caBundle, skipDiscovery := "", c.Configuration.OidcCABundle, false
+		public := ptr.Deref(c.Configuration.OidcPublicIssuer, false)
+		if p := c.Configuration.Oauth2Proxy; p != nil {
+			issuer, skipDiscovery = p.OidcIssuerURL, ptr.Deref(p.SkipOidcDiscovery, false)
+		}
+
+		if target != nil {
+			if target.OidcCABundle != "" {
+				caBundle = target.OidcCABundle
+			}
+
+			if p := target.Oauth2Proxy; p != nil && p.OidcIssuerURL != "" {
+				issuer = p.OidcIssuerURL
+			}
+
+			if p := target.Oauth2Proxy; p != nil && p.SkipOidcDiscovery != nil {
+				skipDiscovery = *p.SkipOidcDiscovery
+			}
+
+			if target.OidcPublicIssuer != nil {
+				public = *target.OidcPublicIssuer
+			}
+		}
+
+		if issuer == "" || skipDiscovery {
+			return
+		}
+
+		// The certificate of a public issuer is verified against the system trust store
+		if public {
+			caBundle = ""
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(caBundle)
+		if err != nil {
+			c.log.Error(err, "failed to decode oidc ca bundle")
+		}
+
+		if i := (OidcIssuer{URL: issuer, CABundle: string(decoded)}); !slices.Contains(issuers, i) {
+			issuers = append(issuers, i)
+		}
+	}
+
+	add(nil)
+
+	for _, t := range c.Targets {
+		add(t.Configuration)
+	}
+
+	return issuers
+}
+
+// GetOidcCASecretKeyRef returns the secret key holding the trusted CA bundle of the OIDC Provider of the workload,
+// referenced by the oidc-ca-secret annotation. It returns nil when the configured CA bundle applies.
+func (c *OIDCAppsControllerConfig) GetOidcCASecretKeyRef(object client.Object) *corev1.SecretKeySelector {
+	v := object.GetAnnotations()[constants.AnnotationOidcCASecretKey]
+	if v == "" {
+		return nil
+	}
+
+	name, key, _ := strings.Cut(v, "/")
+	if key == "" {
+		key = "ca.crt"
+	}
+
+	return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+}
+
+// GetClientID returns the OIDC Provider client_id for the given workload target
+func (c *OIDCAppsControllerConfig) GetClientID(object client.Object) string {
+	if len(os.Getenv(constants.GardenSeedOauth2ProxyClientID)) > 0 {
+		return os.Getenv(constants.GardenSeedOauth2ProxyClientID)
+	}
+
+	t := c.fetchTarget(object)
+
+	if t.Configuration != nil &&
+		t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.ClientID != "" {
+		return t.Configuration.Oauth2Proxy.ClientID
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.ClientID != "" {
+		return c.Configuration.Oauth2Proxy.ClientID
+	}
+
+	return ""
+}
+
+// GetClientSecret returns the OIDC Provider secret for the given target workload
+func (c *OIDCAppsControllerConfig) GetClientSecret(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil &&
+		t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.ClientSecret != "" {
+		return t.Configuration.Oauth2Proxy.ClientSecret
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.ClientSecret != "" {
+		return c.Configuration.Oauth2Proxy.ClientSecret
+	}
+
+	return ""
+}
+
+// GetScope returns the OIDC Provider scope for the given target workload
+func (c *OIDCAppsControllerConfig) GetScope(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.Scope != "" {
+		return t.Configuration.Oauth2Proxy.Scope
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.Scope != "" {
+		return c.Configuration.Oauth2Proxy.Scope
+	}
+
+	return ""
+}
+
+// GetRedirectURL returns the OIDC Provider redirect URL for the given workload target
+func (c *OIDCAppsControllerConfig) GetRedirectURL(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.RedirectURL != "" {
+		return t.Configuration.Oauth2Proxy.RedirectURL
+	}
+
+	// The redirect URL shall not default to the global one.
+	// Instead, it shall be constructed as below code */
+	// If the target oidc configuration does not define a redirect URL
+	// it will be constructed as https://{name}-{namespace}.domainName/{proxyPrefix}/callback
+	return "https://" + c.GetHost(object) + c.GetOauth2ProxyPrefix(object) + "/callback"
+}
+
+// GetOauth2ProxyPrefix returns the oauth2-proxy endpoints prefix, which is nested under the ingress base path
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyPrefix(object client.Object) string {
+	return path.Join(c.GetIngressPath(object), "oauth2")
+}
+
+// GetOauth2ProxyWhitelistDomains returns the domains oauth2-proxy is allowed to redirect to after a successful login.
+// These are the computed ingress host, the wildcard of the parent domain of the per-pod hosts of a target with per-pod
+// ingresses and any additional domains listed in the whitelist-domains annotation of the target.
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyWhitelistDomains(object client.Object) []string {
+	host := c.GetHost(object)
+	domains := []string{host}
+	_, domain, _ := strings.Cut(host, ".")
+
+	// The per-pod hosts share the parent domain of the host. They are not listed one by one, as the configuration and
+	// with it the pods would otherwise change upon every scaling of the target.
+	if domain != "" && c.GetPerPodIngress(object) {
+		domains = append(domains, "*."+domain)
+	}
+
+	if extra, ok := object.GetAnnotations()[constants.AnnotationWhitelistDomainsKey]; ok {
+		for _, d := range strings.Split(extra, ",") {
+			if d = strings.TrimSpace(d); d != "" && !slices.Contains(domains, d) {
+				domains = append(domains, d)
+			}
+		}
+	}
+
+	return domains
+}
+
+// GetOidcIssuerURL returns the OIDC Provider URL for the given workload target
+func (c *OIDCAppsControllerConfig) GetOidcIssuerURL(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil &&
+		t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.OidcIssuerURL != "" {
+		return t.Configuration.Oauth2Proxy.OidcIssuerURL
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.OidcIssuerURL != "" {
+		return c.Configuration.Oauth2Proxy.OidcIssuerURL
+	}
+
+	return ""
+}
+
+// GetSslInsecureSkipVerify designates if oauth2-proxy shall skip upstream ssl validation. The insecure-skip-verify
+// annotation of the workload takes precedence over the configuration.
+func (c *OIDCAppsControllerConfig) GetSslInsecureSkipVerify(object client.Object) bool {
+	if v, found := object.GetAnnotations()[constants.AnnotationInsecureSkipVerifyKey]; found {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil &&
+		t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.SSLInsecureSkipVerify != nil {
+		return ptr.Deref(t.Configuration.Oauth2Proxy.SSLInsecureSkipVerify, false)
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.SSLInsecureSkipVerify != nil {
+		return ptr.Deref(c.Configuration.Oauth2Proxy.SSLInsecureSkipVerify, false)
+	}
+
+	return false
+}
+
+// GetInsecureOidcSkipIssuerVerification designates if oauth2-proxy shall skip OIDC Provider certificate validation
+func (c *OIDCAppsControllerConfig) GetInsecureOidcSkipIssuerVerification(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.InsecureOidcSkipIssuerVerification != nil {
+		return ptr.Deref(t.Configuration.Oauth2Proxy.InsecureOidcSkipIssuerVerification, false)
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.InsecureOidcSkipIssuerVerification != nil {
+		return ptr.Deref(c.Configuration.Oauth2Proxy.InsecureOidcSkipIssuerVerification, false)
+	}
+
+	return false
+}
+
+// GetInsecureOidcSkipNonce designates if oauth2-proxy shall skip OIDC nonce request parameter
+func (c *OIDCAppsControllerConfig) GetInsecureOidcSkipNonce(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.InsecureOidcSkipNonce != nil {
+		return ptr.Deref(t.Configuration.Oauth2Proxy.InsecureOidcSkipNonce, false)
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.InsecureOidcSkipNonce != nil {
+		return ptr.Deref(c.Configuration.Oauth2Proxy.InsecureOidcSkipNonce, false)
+	}
+
+	return false
+}
+
+// GetOauth2ProxyMetricsPort returns the port on which oauth2-proxy exposes its metrics, 0 when metrics are disabled
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyMetricsPort(object client.Object) int32 {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.MetricsPort > 0 {
+		return t.Configuration.Oauth2Proxy.MetricsPort
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.MetricsPort > 0 {
+		return c.Configuration.Oauth2Proxy.MetricsPort
+	}
+
+	return 0
+}
+
+// GetOauth2ProxyMetricsScrapers returns the peers allowed to access the oauth2-proxy metrics port, nil when the
+// metrics port is not restricted
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyMetricsScrapers(
+	object client.Object) []networkingv1.NetworkPolicyPeer {
+	if c.GetOauth2ProxyMetricsPort(object) == 0 {
+		return nil
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		len(t.Configuration.Oauth2Proxy.MetricsScrapers) > 0 {
+		return t.Configuration.Oauth2Proxy.MetricsScrapers
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.MetricsScrapers
+	}
+
+	return nil
+}
+
+// GetOauth2ProxyUpstreamTimeout returns the oauth2-proxy upstream timeout, empty for the oauth2-proxy default
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyUpstreamTimeout(object client.Object) string {
+	return c.getOauth2ProxyDuration(object, constants.AnnotationUpstreamTimeoutKey,
+		func(o *Oauth2ProxyConfig) string { return o.UpstreamTimeout })
+}
+
+// GetOauth2ProxyCookieRefresh returns the period after which the session of an active user is refreshed, empty for
+// the refresh period set by the sidecar flags
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCookieRefresh(object client.Object) string {
+	return c.getOauth2ProxyString(object, func(o *Oauth2ProxyConfig) string { return o.CookieRefresh })
+}
+
+// GetOauth2ProxyCookieExpire returns the lifetime of the session cookie, empty for the oauth2-proxy default
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCookieExpire(object client.Object) string {
+	return c.getOauth2ProxyString(object, func(o *Oauth2ProxyConfig) string { return o.CookieExpire })
+}
+
+// GetOauth2ProxyCookiePath returns the path of the session cookie, the ingress base path unless it is overridden by the
+// cookie-path annotation, so that the cookie is sent on the navigation within an app served under a base path
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCookiePath(object client.Object) string {
+	if p, ok := object.GetAnnotations()[constants.AnnotationCookiePathKey]; ok {
+		if strings.HasPrefix(p, "/") {
+			return path.Clean(p)
+		}
+
+		c.log.Info("Ignoring invalid cookie path annotation, the path must start with /", "annotation",
+			constants.AnnotationCookiePathKey, "object", object.GetNamespace()+"/"+object.GetName())
+	}
+
+	return c.GetIngressPath(object)
+}
+
+// GetOauth2ProxyFlushInterval returns the oauth2-proxy response flush interval, empty for the oauth2-proxy default
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyFlushInterval(object client.Object) string {
+	return c.getOauth2ProxyDuration(object, constants.AnnotationFlushIntervalKey,
+		func(o *Oauth2ProxyConfig) string { return o.FlushInterval })
+}
+
+// GetOauth2ProxyShutdownDrain returns the period the proxies keep serving upon the termination of a pod, zero when they
+// terminate right away
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyShutdownDrain(object client.Object) time.Duration {
+	d, err := time.ParseDuration(c.getOauth2ProxyDuration(object, constants.AnnotationShutdownDrainKey,
+		func(o *Oauth2ProxyConfig) string { return o.ShutdownDrain }))
+	if err != nil {
+		return 0
+	}
+
+	return d
+}
+
+// GetOauth2ProxyCookieSameSite returns the SameSite attribute of the cookies, empty for the browser default. The
+// cookie-samesite annotation takes precedence over the configuration.
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCookieSameSite(object client.Object) string {
+	if m, ok := object.GetAnnotations()[constants.AnnotationCookieSameSiteKey]; ok {
+		if m = strings.ToLower(m); slices.Contains(cookieSameSiteModes, m) {
+			return m
+		}
+
+		c.log.Info("Ignoring invalid cookie samesite annotation", "annotation", constants.AnnotationCookieSameSiteKey,
+			"allowed", strings.Join(cookieSameSiteModes, ", "), "object", object.GetNamespace()+"/"+object.GetName())
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil && t.Configuration.Oauth2Proxy.CookieSameSite != "" {
+		return t.Configuration.Oauth2Proxy.CookieSameSite
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.CookieSameSite
+	}
+
+	return ""
+}
+
+// GetOauth2ProxyCookieSecretMaxAge returns the maximum age of the cookie secret, zero when it is never rotated
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCookieSecretMaxAge(object client.Object) time.Duration {
+	d, err := time.ParseDuration(c.getOauth2ProxyDuration(object, constants.AnnotationCookieSecretMaxAgeKey,
+		func(o *Oauth2ProxyConfig) string { return o.CookieSecretMaxAge }))
+	if err != nil {
+		return 0
+	}
+
+	return d
+}
+
+// GetOauth2ProxyCookieCSRFPerRequest returns true when oauth2-proxy shall issue a CSRF cookie per authentication
+// request. It defaults to true for StatefulSet targets, which are exposed through multiple per-pod hosts, unless they
+// are exposed through a single ingress.
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCookieCSRFPerRequest(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.CookieCSRFPerRequest != nil {
+		return *t.Configuration.Oauth2Proxy.CookieCSRFPerRequest
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.CookieCSRFPerRequest != nil {
+		return *c.Configuration.Oauth2Proxy.CookieCSRFPerRequest
+	}
+
+	return c.GetPerPodIngress(object)
+}
+
+// GetOauth2ProxyCookieCSRFExpire returns the lifetime of the oauth2-proxy CSRF cookie, empty for the oauth2-proxy default
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCookieCSRFExpire(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.CookieCSRFExpire != "" {
+		return t.Configuration.Oauth2Proxy.CookieCSRFExpire
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.CookieCSRFExpire
+	}
+
+	return ""
+}
+
+// GetOauth2ProxyUpstreamHealthPath returns the upstream health path served without authentication, empty if none
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyUpstreamHealthPath(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.UpstreamHealthPath != "" {
+		return t.Configuration.Oauth2Proxy.UpstreamHealthPath
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.UpstreamHealthPath
+	}
+
+	return ""
+}
+
+// GetOauth2ProxyExtraAudiences returns the token audiences accepted in addition to the client id
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyExtraAudiences(object client.Object) []string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		len(t.Configuration.Oauth2Proxy.ExtraAudiences) > 0 {
+		return t.Configuration.Oauth2Proxy.ExtraAudiences
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.ExtraAudiences
+	}
+
+	return nil
+}
+
+// GetOauth2ProxyAudienceClaims returns the token claims holding the audience, empty for the oauth2-proxy default
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyAudienceClaims(object client.Object) []string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		len(t.Configuration.Oauth2Proxy.AudienceClaims) > 0 {
+		return t.Configuration.Oauth2Proxy.AudienceClaims
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.AudienceClaims
+	}
+
+	return nil
+}
+
+// GetOauth2ProxySkipJWTBearerTokens returns true when oauth2-proxy shall accept the bearer JWTs of API clients, which
+// is the default
+func (c *OIDCAppsControllerConfig) GetOauth2ProxySkipJWTBearerTokens(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.SkipJWTBearerTokens != nil {
+		return *t.Configuration.Oauth2Proxy.SkipJWTBearerTokens
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.SkipJWTBearerTokens != nil {
+		return *c.Configuration.Oauth2Proxy.SkipJWTBearerTokens
+	}
+
+	return true
+}
+
+// GetOauth2ProxyExtraJWTIssuers returns the additional issuers of the accepted bearer JWTs as <issuer URL>=<audience>
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyExtraJWTIssuers(object client.Object) []string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		len(t.Configuration.Oauth2Proxy.ExtraJWTIssuers) > 0 {
+		return t.Configuration.Oauth2Proxy.ExtraJWTIssuers
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.ExtraJWTIssuers
+	}
+
+	return nil
+}
+
+// GetOauth2ProxyAPIRoutes returns the regular expressions of the request paths, which are not redirected to the login
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyAPIRoutes(object client.Object) []string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		len(t.Configuration.Oauth2Proxy.APIRoutes) > 0 {
+		return t.Configuration.Oauth2Proxy.APIRoutes
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.APIRoutes
+	}
+
+	return nil
+}
+
+// GetOauth2ProxyRealClientIPHeader returns the header holding the client IP, empty for the oauth2-proxy default
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyRealClientIPHeader(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.RealClientIPHeader != "" {
+		return t.Configuration.Oauth2Proxy.RealClientIPHeader
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.RealClientIPHeader
+	}
+
+	return ""
+}
+
+// GetOauth2ProxyCookieName returns the name of the session cookie, defaulting to a name unique for the target
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCookieName(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil && t.Configuration.Oauth2Proxy.CookieName != "" {
+		return t.Configuration.Oauth2Proxy.CookieName
+	}
+
+	if c.Configuration.Oauth2Proxy != nil && c.Configuration.Oauth2Proxy.CookieName != "" {
+		return c.Configuration.Oauth2Proxy.CookieName
+	}
+
+	return "_oauth2_proxy_" + rand.GenerateSha256(object.GetName()+"-"+object.GetNamespace())
+}
+
+// GetOauth2ProxyCodeChallengeMethod returns the PKCE code challenge method, defaults to S256
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCodeChallengeMethod(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.CodeChallengeMethod != "" {
+		return t.Configuration.Oauth2Proxy.CodeChallengeMethod
+	}
+
+	if c.Configuration.Oauth2Proxy != nil && c.Configuration.Oauth2Proxy.CodeChallengeMethod != "" {
+		return c.Configuration.Oauth2Proxy.CodeChallengeMethod
+	}
+
+	return "S256"
+}
+
+// GetOauth2ProxyProvider returns the oauth2-proxy provider type. The provider annotation of the target takes
+// precedence over the configuration, unsupported types fall back to oidc.
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyProvider(object client.Object) string {
+	if p, found := object.GetAnnotations()[constants.AnnotationProviderKey]; found {
+		if slices.Contains(providers, p) {
+			return p
+		}
+
+		c.log.Info("Ignoring unsupported provider annotation", "value", p,
+			"object", object.GetNamespace()+"/"+object.GetName())
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil && t.Configuration.Oauth2Proxy.Provider != "" {
+		return t.Configuration.Oauth2Proxy.Provider
+	}
+
+	if c.Configuration.Oauth2Proxy != nil && c.Configuration.Oauth2Proxy.Provider != "" {
+		return c.Configuration.Oauth2Proxy.Provider
+	}
+
+	return ProviderOIDC
+}
+
+// GetOauth2ProxyGitHubOrg returns the organization the logins of the github provider are restricted to
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyGitHubOrg(object client.Object) string {
+	return c.getOauth2ProxyString(object, func(o *Oauth2ProxyConfig) string { return o.GitHubOrg })
+}
+
+// GetOauth2ProxyGitHubTeam returns the teams the logins of the github provider are restricted to, comma separated
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyGitHubTeam(object client.Object) string {
+	return c.getOauth2ProxyString(object, func(o *Oauth2ProxyConfig) string { return o.GitHubTeam })
+}
+
+// GetOauth2ProxyAzureTenant returns the directory tenant of the azure provider
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyAzureTenant(object client.Object) string {
+	return c.getOauth2ProxyString(object, func(o *Oauth2ProxyConfig) string { return o.AzureTenant })
+}
+
+// GetOauth2ProxySkipOidcDiscovery returns whether the OIDC discovery is skipped in favour of the static endpoints
+func (c *OIDCAppsControllerConfig) GetOauth2ProxySkipOidcDiscovery(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.SkipOidcDiscovery != nil {
+		return *t.Configuration.Oauth2Proxy.SkipOidcDiscovery
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.SkipOidcDiscovery != nil {
+		return *c.Configuration.Oauth2Proxy.SkipOidcDiscovery
+	}
+
+	return false
+}
+
+// GetOauth2ProxyOidcEndpoints returns the static endpoints of the OIDC provider, empty when they are not configured
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyOidcEndpoints(object client.Object) OidcEndpointsConf {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.OidcEndpoints != nil {
+		return *t.Configuration.Oauth2Proxy.OidcEndpoints
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.OidcEndpoints != nil {
+		return *c.Configuration.Oauth2Proxy.OidcEndpoints
+	}
+
+	return OidcEndpointsConf{}
+}
+
+// GetOauth2ProxyUpstreamPathRewrite returns the rewrite of the request paths forwarded to the upstream, nil if the
+// paths are forwarded unchanged. The path defaults to the ingress base path, which is stripped by the default rewrite
+// target.
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyUpstreamPathRewrite(object client.Object) *UpstreamPathRewriteConf {
+	var rewrite UpstreamPathRewriteConf
+
+	t := c.fetchTarget(object)
+
+	switch {
+	case t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.UpstreamPathRewrite != nil:
+		rewrite = *t.Configuration.Oauth2Proxy.UpstreamPathRewrite
+	case c.Configuration.Oauth2Proxy != nil && c.Configuration.Oauth2Proxy.UpstreamPathRewrite != nil:
+		rewrite = *c.Configuration.Oauth2Proxy.UpstreamPathRewrite
+	default:
+		return nil
+	}
+
+	if rewrite.Path == "" {
+		rewrite.Path = "^" + regexp.QuoteMeta(strings.TrimSuffix(c.GetIngressPath(object), "/")) + "(?:/(.*))?$"
+	}
+
+	if rewrite.RewriteTarget == "" {
+		rewrite.RewriteTarget = "/$1"
+	}
+
+	return &rewrite
+}
+
+// getOauth2ProxyString returns a setting from the target configuration or else from the global configuration
+func (c *OIDCAppsControllerConfig) getOauth2ProxyString(object client.Object,
+	value func(*Oauth2ProxyConfig) string) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil && value(t.Configuration.Oauth2Proxy) != "" {
+		return value(t.Configuration.Oauth2Proxy)
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return value(c.Configuration.Oauth2Proxy)
+	}
+
+	return ""
+}
+
+// GetOauth2ProxyCustomTemplatesName returns the name of the config map with the custom oauth2-proxy templates, empty
+// when the built-in templates are used
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyCustomTemplatesName(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.CustomTemplatesRef != nil {
+		return t.Configuration.Oauth2Proxy.CustomTemplatesRef.Name
+	}
+
+	if c.Configuration.Oauth2Proxy != nil && c.Configuration.Oauth2Proxy.CustomTemplatesRef != nil {
+		return c.Configuration.Oauth2Proxy.CustomTemplatesRef.Name
+	}
+
+	return ""
+}
+
+// GetOauth2ProxySkipProviderButton returns true when oauth2-proxy shall skip its sign-in page, defaults to true. The
+// skip-provider-button annotation of the target takes precedence over the configuration.
+func (c *OIDCAppsControllerConfig) GetOauth2ProxySkipProviderButton(object client.Object) bool {
+	if v, found := object.GetAnnotations()[constants.AnnotationSkipProviderButtonKey]; found {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+
+		c.log.Info("Ignoring invalid skip-provider-button annotation", "value", v,
+			"object", object.GetNamespace()+"/"+object.GetName())
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.SkipProviderButton != nil {
+		return *t.Configuration.Oauth2Proxy.SkipProviderButton
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.SkipProviderButton != nil {
+		return *c.Configuration.Oauth2Proxy.SkipProviderButton
+	}
+
+	return true
+}
+
+// GetOauth2ProxyReverseProxy returns true when oauth2-proxy shall trust the X-Forwarded-* headers
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyReverseProxy(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.ReverseProxy != nil {
+		return *t.Configuration.Oauth2Proxy.ReverseProxy
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.ReverseProxy != nil {
+		return *c.Configuration.Oauth2Proxy.ReverseProxy
+	}
+
+	return true
+}
+
+// GetOauth2ProxyAllowQuerySemicolons returns true when the semicolons of the query strings are kept as separators
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyAllowQuerySemicolons(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.AllowQuerySemicolons != nil {
+		return *t.Configuration.Oauth2Proxy.AllowQuerySemicolons
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.AllowQuerySemicolons != nil {
+		return *c.Configuration.Oauth2Proxy.AllowQuerySemicolons
+	}
+
+	return false
+}
+
+// GetOauth2ProxySetXAuthRequest returns true when the user identity is passed to the upstream in the X-Auth-Request
+// headers
+func (c *OIDCAppsControllerConfig) GetOauth2ProxySetXAuthRequest(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.SetXAuthRequest != nil {
+		return *t.Configuration.Oauth2Proxy.SetXAuthRequest
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.SetXAuthRequest != nil {
+		return *c.Configuration.Oauth2Proxy.SetXAuthRequest
+	}
+
+	return false
+}
+
+// GetOauth2ProxyXAuthRequestClaims returns the additional token claims passed to the upstream as X-Auth-Request
+// headers, none unless the X-Auth-Request headers are set
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyXAuthRequestClaims(object client.Object) []string {
+	if !c.GetOauth2ProxySetXAuthRequest(object) {
+		return nil
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		len(t.Configuration.Oauth2Proxy.XAuthRequestClaims) > 0 {
+		return t.Configuration.Oauth2Proxy.XAuthRequestClaims
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.XAuthRequestClaims
+	}
+
+	return nil
+}
+
+// GetOauth2ProxyPassUserHeaders returns true when the user identity is passed to the upstream in the X-Forwarded-*
+// headers, which is the default
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyPassUserHeaders(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.PassUserHeaders != nil {
+		return *t.Configuration.Oauth2Proxy.PassUserHeaders
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.PassUserHeaders != nil {
+		return *c.Configuration.Oauth2Proxy.PassUserHeaders
+	}
+
+	return true
+}
+
+// GetOauth2ProxyPassBasicAuth returns true when the user is passed to the upstream in a basic Authorization header
+// instead of the ID token
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyPassBasicAuth(object client.Object) bool {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.PassBasicAuth != nil {
+		return *t.Configuration.Oauth2Proxy.PassBasicAuth
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.PassBasicAuth != nil {
+		return *c.Configuration.Oauth2Proxy.PassBasicAuth
+	}
+
+	return false
+}
+
+// GetOauth2ProxyBasicAuthPassword returns the password of the basic Authorization header passed to the upstream, empty
+// unless the basic auth is passed
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyBasicAuthPassword(object client.Object) string {
+	if !c.GetOauth2ProxyPassBasicAuth(object) {
+		return ""
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.BasicAuthPassword != "" {
+		return t.Configuration.Oauth2Proxy.BasicAuthPassword
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return c.Configuration.Oauth2Proxy.BasicAuthPassword
+	}
+
+	return ""
+}
+
+// XAuthRequestHeader returns the name of the X-Auth-Request header of a token claim
+func XAuthRequestHeader(claim string) string {
+	return http.CanonicalHeaderKey("X-Auth-Request-" + strings.ReplaceAll(claim, "_", "-"))
+}
+
+// GetOauth2ProxyAlphaConfig returns true when the oauth2-proxy is configured through an additional alpha
+// configuration, as the upstream paths are rewritten, the X-Auth-Request headers are set or there are additional
+// upstreams
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyAlphaConfig(object client.Object) bool {
+	return c.GetOauth2ProxyUpstreamPathRewrite(object) != nil || c.GetOauth2ProxySetXAuthRequest(object) ||
+		len(c.GetUpstreams(object)) > 0
+}
+
+// GetOauth2ProxySignInPage returns the oauth2-proxy sign-in page customizations, an empty one for the defaults
+func (c *OIDCAppsControllerConfig) GetOauth2ProxySignInPage(object client.Object) SignInPageConf {
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.SignInPage != nil {
+		return *t.Configuration.Oauth2Proxy.SignInPage
+	}
+
+	if c.Configuration.Oauth2Proxy != nil &&
+		c.Configuration.Oauth2Proxy.SignInPage != nil {
+		return *c.Configuration.Oauth2Proxy.SignInPage
+	}
+
+	return SignInPageConf{}
+}
+
+// GetOauth2ProxySignInPageConfigMapName returns the name of the config map with the sign-in page banner and footer,
+// empty when it is not configured
+func (c *OIDCAppsControllerConfig) GetOauth2ProxySignInPageConfigMapName(object client.Object) string {
+	if ref := c.GetOauth2ProxySignInPage(object).ConfigMapRef; ref != nil {
+		return ref.Name
+	}
+
+	return ""
+}
+
+// getOauth2ProxyDuration returns a duration setting from the target annotation, the target configuration or the
+// global configuration, in this order. Invalid annotation values are ignored.
+func (c *OIDCAppsControllerConfig) getOauth2ProxyDuration(object client.Object, annotation string,
+	value func(*Oauth2ProxyConfig) string) string {
+	if d, ok := object.GetAnnotations()[annotation]; ok {
+		err := validateDuration(d)
+		if err == nil {
+			return d
+		}
+
+		c.log.Error(err, "Ignoring invalid duration annotation", "annotation", annotation,
+			"object", object.GetNamespace()+"/"+object.GetName())
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		value(t.Configuration.Oauth2Proxy) != "" {
+		return value(t.Configuration.Oauth2Proxy)
+	}
+
+	if c.Configuration.Oauth2Proxy != nil {
+		return value(c.Configuration.Oauth2Proxy)
+	}
+
+	return ""
+}
+
+// GetOauth2ProxyStartupProbe returns the startup probe of the oauth2-proxy sidecar, nil when it is not enabled.
+// The probe gives the proxy time to complete the OIDC discovery against slow providers.
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyStartupProbe(object client.Object) *corev1.Probe {
+	var conf *StartupProbeConf
+
+	t := c.fetchTarget(object)
+	if c.Configuration.Oauth2Proxy != nil && c.Configuration.Oauth2Proxy.StartupProbe != nil {
+		conf = c.Configuration.Oauth2Proxy.StartupProbe
+	}
+
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.StartupProbe != nil {
+		conf = t.Configuration.Oauth2Proxy.StartupProbe
+	}
+
+	if conf == nil || !conf.Enabled {
+		return nil
+	}
+
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "/ping",
+				Port: intstr.FromString("oauth2"),
+			},
+		},
+		PeriodSeconds:    10,
+		FailureThreshold: 30,
+	}
+
+	if conf.PeriodSeconds > 0 {
+		probe.PeriodSeconds = conf.PeriodSeconds
+	}
+
+	if conf.FailureThreshold > 0 {
+		probe.FailureThreshold = conf.FailureThreshold
+	}
+
+	return probe
+}
+
+// GetOauth2ProxyReadinessProbe returns the readiness probe of the oauth2-proxy sidecar, nil when the upstream readiness
+// is not enabled. The probe requests the upstream health path through both proxies, hence it fails also when the
+// upstream is down.
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyReadinessProbe(object client.Object) *corev1.Probe {
+	enabled := false
+
+	if c.Configuration.Oauth2Proxy != nil && c.Configuration.Oauth2Proxy.UpstreamReadiness != nil {
+		enabled = *c.Configuration.Oauth2Proxy.UpstreamReadiness
+	}
+
+	t := c.fetchTarget(object)
+	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
+		t.Configuration.Oauth2Proxy.UpstreamReadiness != nil {
+		enabled = *t.Configuration.Oauth2Proxy.UpstreamReadiness
+	}
+
+	healthPath := c.GetOauth2ProxyUpstreamHealthPath(object)
+	if !enabled || healthPath == "" {
+		return nil
+	}
+
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: healthPath,
+				Port: intstr.FromString("oauth2"),
+			},
+		},
+		PeriodSeconds:    10,
+		FailureThreshold: 3,
+	}
+}
+
+// GetIngressTLSSecretName return the tls secret for the ingress serving certificate for the given workload
+func (c *OIDCAppsControllerConfig) GetIngressTLSSecretName(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Ingress != nil && t.Ingress.TLSSecretRef.Name != "" {
+		return t.Ingress.TLSSecretRef.Name
+	}
+
+	return ""
+}
+
+// GetIngressClassName return the ingress class name for the given target, defaults to the first of the
+// IngressClassNames
+func (c *OIDCAppsControllerConfig) GetIngressClassName(object client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Ingress == nil {
+		return ""
 	}
 
-	prefix := object.GetName() + "-" + object.GetNamespace()
-	if t.Ingress != nil && t.Ingress.HostPrefix != "" {
-		prefix = t.Ingress.HostPrefix + "-" + rand.GenerateSha256(object.GetName()+"-"+object.GetNamespace())
+	if t.Ingress.IngressClassName == "" && len(t.Ingress.IngressClassNames) > 0 {
+		return t.Ingress.IngressClassNames[0]
 	}
 
-	if t.Ingress != nil && t.Ingress.Host != "" {
-		prefix, domain, _ = strings.Cut(t.Ingress.Host, ".")
+	return t.Ingress.IngressClassName
+}
+
+// GetPodIngressClassName returns the ingress class name of the per-pod ingress of a statefulset pod. The pods are
+// assigned to the IngressClassNames of the target round-robin by their ordinal, or by the hash of their name.
+func (c *OIDCAppsControllerConfig) GetPodIngressClassName(object, pod client.Object) string {
+	t := c.fetchTarget(object)
+	if t.Ingress == nil || len(t.Ingress.IngressClassNames) == 0 {
+		return c.GetIngressClassName(object)
 	}
 
-	if domain == "" {
-		return prefix
+	classes := t.Ingress.IngressClassNames
+
+	if t.Ingress.IngressClassAssignment != IngressClassAssignmentHash {
+		if ordinal, ok := podOrdinal(pod); ok {
+			return classes[ordinal%len(classes)]
+		}
 	}
 
-	return strings.Join([]string{prefix, domain}, ".")
-}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(pod.GetName()))
 
-// GetUpstreamTarget returns the protocol and port tuple of the target workload
-func (c *OIDCAppsControllerConfig) GetUpstreamTarget(object client.Object) string {
-	b := strings.Builder{}
-	protocol := "http"
+	return classes[h.Sum32()%uint32(len(classes))] // #nosec G115 -- The number of class names fits into uint32
+}
 
-	t := c.fetchTarget(object)
-	if t.TargetProtocol == "https" {
-		protocol = "https"
+// podOrdinal returns the ordinal of a statefulset pod, taken from the pod index label or the pod name suffix
+func podOrdinal(pod client.Object) (int, bool) {
+	index, found := pod.GetLabels()[appsv1.PodIndexLabel]
+	if !found {
+		index = pod.GetName()[strings.LastIndex(pod.GetName(), "-")+1:]
 	}
 
-	b.Grow(9)
-	_, _ = b.WriteString("protocol=")
-	b.Grow(len(protocol))
-	_, _ = b.WriteString(protocol)
-	b.Grow(7)
-	_, _ = b.WriteString(", port=")
-	b.Grow(len(t.TargetPort.String()))
-	_, _ = b.WriteString(t.TargetPort.String())
+	ordinal, err := strconv.Atoi(index)
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
 
-	return b.String()
+	return ordinal, true
 }
 
-// GetKubeSecretName returns the kubeconfig secret name of the target workload
-func (c *OIDCAppsControllerConfig) GetKubeSecretName(object client.Object) string {
-	secretName := ""
-
+// GetIngressPath returns the ingress base path for the given target, defaults to "/". The workloads on a shared
+// ingress are told apart by their name appended to the base path.
+func (c *OIDCAppsControllerConfig) GetIngressPath(object client.Object) string {
 	t := c.fetchTarget(object)
-	if t.Configuration != nil &&
-		t.Configuration.KubeRbacProxy != nil &&
-		t.Configuration.KubeRbacProxy.KubeSecretRef != nil &&
-		t.Configuration.KubeRbacProxy.KubeSecretRef.Name != "" {
-		return t.Configuration.KubeRbacProxy.KubeSecretRef.Name
+	if c.GetIngressShared(object) {
+		return path.Join("/", t.Ingress.Path, object.GetName())
 	}
 
-	if c.Configuration.KubeRbacProxy != nil &&
-		c.Configuration.KubeRbacProxy.KubeSecretRef != nil &&
-		c.Configuration.KubeRbacProxy.KubeSecretRef.Name != "" {
-		secretName = c.Configuration.KubeRbacProxy.KubeSecretRef.Name
+	if t.Ingress != nil && t.Ingress.Path != "" {
+		return path.Clean("/" + t.Ingress.Path)
 	}
 
-	return secretName
+	return "/"
 }
 
-// GetKubeConfigStr returns the kubeconfig string of the target workload
-func (c *OIDCAppsControllerConfig) GetKubeConfigStr(object client.Object) string {
-	kubeConfig := ""
-
-	t := c.fetchTarget(object)
-	if t.Configuration != nil &&
-		t.Configuration.KubeRbacProxy != nil &&
-		t.Configuration.KubeRbacProxy.KubeConfigStr != "" {
-		return t.Configuration.KubeRbacProxy.KubeConfigStr
+// GetPerPodIngress returns true if the target is a statefulset, whose pods are exposed through a dedicated service and
+// ingress each. The ingress-mode annotation set to single exposes the statefulset through a single service and ingress,
+// the same as a deployment. Unsupported modes fall back to per-pod. The pods of a deployment are exposed through
+// dedicated services and ingresses only when the ingress-mode annotation is set to per-pod.
+func (c *OIDCAppsControllerConfig) GetPerPodIngress(object client.Object) bool {
+	if _, isDeployment := object.(*appsv1.Deployment); isDeployment {
+		return object.GetAnnotations()[constants.AnnotationIngressModeKey] == constants.IngressModePerPod
 	}
 
-	if c.Configuration.KubeRbacProxy != nil &&
-		c.Configuration.KubeRbacProxy.KubeConfigStr != "" {
-		kubeConfig = c.Configuration.KubeRbacProxy.KubeConfigStr
+	if _, isStatefulSet := object.(*appsv1.StatefulSet); !isStatefulSet {
+		return false
 	}
 
-	return kubeConfig
+	switch mode := object.GetAnnotations()[constants.AnnotationIngressModeKey]; mode {
+	case "", constants.IngressModePerPod:
+		return true
+	case constants.IngressModeSingle:
+		return false
+	default:
+		c.log.Info("unsupported ingress mode, using per-pod", "ingressMode", mode)
+
+		return true
+	}
 }
 
-// GetOidcCASecretName returns the secret name holding the trusts CA certificate of the OIDC Provider
-func (c *OIDCAppsControllerConfig) GetOidcCASecretName(object client.Object) string {
-	secretName := ""
+// GetIngressShared returns true if the target deployment is registered as a path rule of the shared ingress of its
+// host. The per-pod ingresses of the statefulsets and deployments are never shared.
+func (c *OIDCAppsControllerConfig) GetIngressShared(object client.Object) bool {
+	if _, isStatefulSet := object.(*appsv1.StatefulSet); isStatefulSet || c.GetPerPodIngress(object) {
+		return false
+	}
 
 	t := c.fetchTarget(object)
-	if t.Configuration != nil &&
-		t.Configuration.OidcCASecretRef != nil &&
-		t.Configuration.OidcCASecretRef.Name != "" {
-		return t.Configuration.OidcCASecretRef.Name
-	}
 
-	if c.Configuration.OidcCASecretRef != nil &&
-		c.Configuration.OidcCASecretRef.Name != "" {
-		secretName = c.Configuration.OidcCASecretRef.Name
+	return t.Ingress != nil && t.Ingress.Shared && t.Ingress.Host != ""
+}
+
+// GetIngressPathType returns the ingress path type for the given target, defaults to Prefix
+func (c *OIDCAppsControllerConfig) GetIngressPathType(object client.Object) networkingv1.PathType {
+	t := c.fetchTarget(object)
+	if t.Ingress == nil || t.Ingress.PathType == "" {
+		return networkingv1.PathTypePrefix
 	}
 
-	return secretName
+	switch pathType := networkingv1.PathType(t.Ingress.PathType); pathType {
+	case networkingv1.PathTypePrefix, networkingv1.PathTypeExact, networkingv1.PathTypeImplementationSpecific:
+		return pathType
+	default:
+		c.log.Info("unsupported ingress path type, using Prefix", "pathType", t.Ingress.PathType)
+
+		return networkingv1.PathTypePrefix
+	}
 }
 
-// GetOidcCABundle returns the trusted CA bundle certificates of the OIDC Provider
-func (c *OIDCAppsControllerConfig) GetOidcCABundle(object client.Object) string {
-	var (
-		decodedBytes []byte
-		err          error
-		oidcCABundle string
-	)
+// GetIngressAnnotations returns the ingress annotations for the given target
+func (c *OIDCAppsControllerConfig) GetIngressAnnotations(object client.Object) map[string]string {
+	annotations := make(map[string]string)
 
 	t := c.fetchTarget(object)
-	if t.Configuration != nil &&
-		t.Configuration.OidcCABundle != "" {
-		if decodedBytes, err = base64.StdEncoding.DecodeString(t.Configuration.OidcCABundle); err != nil {
-			c.log.Error(err, "failed to decode oidc ca bundle")
+	if t.Ingress != nil {
+		maps.Copy(annotations, t.Ingress.Annotations)
+	}
 
-			return ""
+	// Accept larger request bodies than the ingress-nginx default, unless the limit is explicitly configured
+	if _, found := annotations[constants.NginxProxyBodySizeAnnotation]; !found {
+		if size := c.GetIngressProxyBodySize(object); size != "" {
+			annotations[constants.NginxProxyBodySizeAnnotation] = size
 		}
-
-		return string(decodedBytes)
 	}
 
-	if c.Configuration.OidcCABundle != "" {
-		oidcCABundle = c.Configuration.OidcCABundle
+	// Keep the cookie sessions on the same oauth2-proxy replica, unless the affinity is explicitly configured
+	if t.Ingress != nil && t.Ingress.SessionAffinity {
+		for k, v := range sessionAffinityAnnotations {
+			if _, found := annotations[k]; !found {
+				annotations[k] = v
+			}
+		}
 	}
 
-	if decodedBytes, err = base64.StdEncoding.DecodeString(oidcCABundle); err != nil {
-		c.log.Error(err, "failed to decode oidc ca bundle")
-
-		return ""
+	if len(annotations) == 0 {
+		return nil
 	}
 
-	return string(decodedBytes)
+	return annotations
 }
 
-// GetClientID returns the OIDC Provider client_id for the given workload target
-func (c *OIDCAppsControllerConfig) GetClientID(object client.Object) string {
-	if len(os.Getenv(constants.GardenSeedOauth2ProxyClientID)) > 0 {
-		return os.Getenv(constants.GardenSeedOauth2ProxyClientID)
-	}
+// sessionAffinityAnnotations are the ingress-nginx annotations pinning a session to an oauth2-proxy replica
+var sessionAffinityAnnotations = map[string]string{
+	constants.NginxAffinityAnnotation:          "cookie",
+	constants.NginxAffinityModeAnnotation:      "persistent",
+	constants.NginxSessionCookieNameAnnotation: "oauth2-proxy-affinity",
+}
 
-	t := c.fetchTarget(object)
+// GetIngressProxyBodySize returns the maximum size of the request bodies accepted by the ingress, empty for the
+// ingress controller default. The proxy-body-size annotation of the target takes precedence over the target ingress
+// configuration.
+func (c *OIDCAppsControllerConfig) GetIngressProxyBodySize(object client.Object) string {
+	if size, found := object.GetAnnotations()[constants.AnnotationProxyBodySizeKey]; found {
+		if proxyBodySizePattern.MatchString(size) {
+			return size
+		}
 
-	if t.Configuration != nil &&
-		t.Configuration.Oauth2Proxy != nil &&
-		t.Configuration.Oauth2Proxy.ClientID != "" {
-		return t.Configuration.Oauth2Proxy.ClientID
+		c.log.Info("Ignoring invalid proxy body size annotation", "annotation", constants.AnnotationProxyBodySizeKey,
+			"object", object.GetNamespace()+"/"+object.GetName())
 	}
 
-	if c.Configuration.Oauth2Proxy != nil &&
-		c.Configuration.Oauth2Proxy.ClientID != "" {
-		return c.Configuration.Oauth2Proxy.ClientID
+	if t := c.fetchTarget(object); t.Ingress != nil {
+		return t.Ingress.ProxyBodySize
 	}
 
 	return ""
 }
 
-// GetClientSecret returns the OIDC Provider secret for the given target workload
-func (c *OIDCAppsControllerConfig) GetClientSecret(object client.Object) string {
-	t := c.fetchTarget(object)
-	if t.Configuration != nil &&
-		t.Configuration.Oauth2Proxy != nil &&
-		t.Configuration.Oauth2Proxy.ClientSecret != "" {
-		return t.Configuration.Oauth2Proxy.ClientSecret
+// GetNativeSidecars returns true if the proxies shall be injected as native sidecar init containers
+func (c *OIDCAppsControllerConfig) GetNativeSidecars(object client.Object) bool {
+	if t := c.fetchTarget(object); t.Configuration != nil && t.Configuration.NativeSidecars != nil {
+		return *t.Configuration.NativeSidecars
 	}
 
-	if c.Configuration.Oauth2Proxy != nil &&
-		c.Configuration.Oauth2Proxy.ClientSecret != "" {
-		return c.Configuration.Oauth2Proxy.ClientSecret
+	if c.Configuration.NativeSidecars != nil {
+		return *c.Configuration.NativeSidecars
 	}
 
-	return ""
+	return false
 }
 
-// GetScope returns the OIDC Provider scope for the given target workload
-func (c *OIDCAppsControllerConfig) GetScope(object client.Object) string {
-	t := c.fetchTarget(object)
-	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
-		t.Configuration.Oauth2Proxy.Scope != "" {
-		return t.Configuration.Oauth2Proxy.Scope
+// GetOidcPublicIssuer returns true if the OIDC issuer presents a publicly trusted certificate, which is verified
+// against the system trust store instead of the configured CA bundles
+func (c *OIDCAppsControllerConfig) GetOidcPublicIssuer(object client.Object) bool {
+	if t := c.fetchTarget(object); t.Configuration != nil && t.Configuration.OidcPublicIssuer != nil {
+		return *t.Configuration.OidcPublicIssuer
 	}
 
-	if c.Configuration.Oauth2Proxy != nil &&
-		c.Configuration.Oauth2Proxy.Scope != "" {
-		return c.Configuration.Oauth2Proxy.Scope
+	if c.Configuration.OidcPublicIssuer != nil {
+		return *c.Configuration.OidcPublicIssuer
 	}
 
-	return ""
+	return false
 }
 
-// GetRedirectURL returns the OIDC Provider redirect URL for the given workload target
-func (c *OIDCAppsControllerConfig) GetRedirectURL(object client.Object) string {
-	t := c.fetchTarget(object)
-	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
-		t.Configuration.Oauth2Proxy.RedirectURL != "" {
-		return t.Configuration.Oauth2Proxy.RedirectURL
+// GetPropagatedAnnotations returns the keys of the container scoped pod annotations, which are propagated from the
+// application container to the injected proxies
+func (c *OIDCAppsControllerConfig) GetPropagatedAnnotations(object client.Object) []string {
+	if t := c.fetchTarget(object); t.Configuration != nil && t.Configuration.PropagatedAnnotations != nil {
+		return t.Configuration.PropagatedAnnotations
 	}
 
-	// The redirect URL shall not default to the global one.
-	// Instead, it shall be constructed as below code */
-	// If the target oidc configuration does not define a redirect URL
-	// it will be constructed as https://{name}-{namespace}.domainName/oauth2/callback
-	return "https://" + c.GetHost(object) + "/oauth2/callback"
+	return c.Configuration.PropagatedAnnotations
 }
 
-// GetOidcIssuerURL returns the OIDC Provider URL for the given workload target
-func (c *OIDCAppsControllerConfig) GetOidcIssuerURL(object client.Object) string {
-	t := c.fetchTarget(object)
-	if t.Configuration != nil &&
-		t.Configuration.Oauth2Proxy != nil &&
-		t.Configuration.Oauth2Proxy.OidcIssuerURL != "" {
-		return t.Configuration.Oauth2Proxy.OidcIssuerURL
+// GetDisableOwnerReferences returns true if the generated resources shall not carry owner references. The
+// disable-owner-references annotation of the target takes precedence over the configuration.
+func (c *OIDCAppsControllerConfig) GetDisableOwnerReferences(object client.Object) bool {
+	if v, found := object.GetAnnotations()[constants.AnnotationDisableOwnerReferencesKey]; found {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
 	}
 
-	if c.Configuration.Oauth2Proxy != nil &&
-		c.Configuration.Oauth2Proxy.OidcIssuerURL != "" {
-		return c.Configuration.Oauth2Proxy.OidcIssuerURL
+	if t := c.fetchTarget(object); t.Configuration != nil && t.Configuration.DisableOwnerReferences != nil {
+		return *t.Configuration.DisableOwnerReferences
 	}
 
-	return ""
+	if c.Configuration.DisableOwnerReferences != nil {
+		return *c.Configuration.DisableOwnerReferences
+	}
+
+	return false
 }
 
-// GetSslInsecureSkipVerify designates if oauth2-proxy shall skip upstream ssl validation
-func (c *OIDCAppsControllerConfig) GetSslInsecureSkipVerify(object client.Object) bool {
-	t := c.fetchTarget(object)
-	if t.Configuration != nil &&
-		t.Configuration.Oauth2Proxy != nil &&
-		t.Configuration.Oauth2Proxy.SSLInsecureSkipVerify != nil {
-		return ptr.Deref(t.Configuration.Oauth2Proxy.SSLInsecureSkipVerify, false)
+// GetResourceLabels returns the additional labels of the generated resources of the given target. The labels of the
+// target configuration override the global ones.
+func (c *OIDCAppsControllerConfig) GetResourceLabels(object client.Object) map[string]string {
+	resourceLabels := maps.Clone(c.Configuration.ResourceLabels)
+	if resourceLabels == nil {
+		resourceLabels = make(map[string]string)
 	}
 
-	if c.Configuration.Oauth2Proxy != nil &&
-		c.Configuration.Oauth2Proxy.SSLInsecureSkipVerify != nil {
-		return ptr.Deref(c.Configuration.Oauth2Proxy.SSLInsecureSkipVerify, false)
+	if t := c.fetchTarget(object); t.Configuration != nil {
+		maps.Copy(resourceLabels, t.Configuration.ResourceLabels)
 	}
 
-	return false
+	return resourceLabels
 }
 
-// GetInsecureOidcSkipIssuerVerification designates if oauth2-proxy shall skip OIDC Provider certificate validation
-func (c *OIDCAppsControllerConfig) GetInsecureOidcSkipIssuerVerification(object client.Object) bool {
-	t := c.fetchTarget(object)
-	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
-		t.Configuration.Oauth2Proxy.InsecureOidcSkipIssuerVerification != nil {
-		return ptr.Deref(t.Configuration.Oauth2Proxy.InsecureOidcSkipIssuerVerification, false)
-	}
-
-	if c.Configuration.Oauth2Proxy != nil &&
-		c.Configuration.Oauth2Proxy.InsecureOidcSkipIssuerVerification != nil {
-		return ptr.Deref(c.Configuration.Oauth2Proxy.InsecureOidcSkipIssuerVerification, false)
+// GetIngressExternalDNS returns true if the generated ingresses shall carry the external-dns hostname annotation
+func (c *OIDCAppsControllerConfig) GetIngressExternalDNS(object client.Object) bool {
+	if t := c.fetchTarget(object); t.Ingress != nil {
+		return t.Ingress.ExternalDNS
 	}
 
 	return false
 }
 
-// GetInsecureOidcSkipNonce designates if oauth2-proxy shall skip OIDC nonce request parameter
-func (c *OIDCAppsControllerConfig) GetInsecureOidcSkipNonce(object client.Object) bool {
-	t := c.fetchTarget(object)
-	if t.Configuration != nil && t.Configuration.Oauth2Proxy != nil &&
-		t.Configuration.Oauth2Proxy.InsecureOidcSkipNonce != nil {
-		return ptr.Deref(t.Configuration.Oauth2Proxy.InsecureOidcSkipNonce, false)
+// GetIngressServiceName returns the name of the existing target service, which is reused instead of a generated
+// oauth2 service. Statefulsets keep their per-pod services.
+func (c *OIDCAppsControllerConfig) GetIngressServiceName(object client.Object) string {
+	if _, ok := object.(*appsv1.StatefulSet); ok {
+		return ""
 	}
 
-	if c.Configuration.Oauth2Proxy != nil &&
-		c.Configuration.Oauth2Proxy.InsecureOidcSkipNonce != nil {
-		return ptr.Deref(c.Configuration.Oauth2Proxy.InsecureOidcSkipNonce, false)
+	if t := c.fetchTarget(object); t.Ingress != nil {
+		return t.Ingress.ServiceName
 	}
 
-	return false
+	return ""
 }
 
-// GetIngressTLSSecretName return the tls secret for the ingress serving certificate for the given workload
-func (c *OIDCAppsControllerConfig) GetIngressTLSSecretName(object client.Object) string {
-	t := c.fetchTarget(object)
-	if t.Ingress != nil && t.Ingress.TLSSecretRef.Name != "" {
-		return t.Ingress.TLSSecretRef.Name
+// GetIngressServicePort returns the port of the oauth2-proxy added to the existing service of the target, defaults to
+// 8080
+func (c *OIDCAppsControllerConfig) GetIngressServicePort(object client.Object) int32 {
+	if t := c.fetchTarget(object); t.Ingress != nil && t.Ingress.ServicePort > 0 {
+		return t.Ingress.ServicePort
 	}
 
-	return ""
+	return 8080
 }
 
-// GetIngressClassName return the ingress class name for the given target
-func (c *OIDCAppsControllerConfig) GetIngressClassName(object client.Object) string {
-	t := c.fetchTarget(object)
-	if t.Ingress != nil {
-		return t.Ingress.IngressClassName
+// GetServiceType returns the type of the generated oauth2 service. The service-type annotation of the target takes
+// precedence over the target service configuration, unsupported types fall back to ClusterIP.
+func (c *OIDCAppsControllerConfig) GetServiceType(object client.Object) corev1.ServiceType {
+	serviceType, found := object.GetAnnotations()[constants.AnnotationServiceTypeKey]
+	if !found {
+		if t := c.fetchTarget(object); t.Service != nil {
+			serviceType = string(t.Service.Type)
+		}
 	}
 
-	return ""
+	if serviceType == "" {
+		return corev1.ServiceTypeClusterIP
+	}
+
+	if !slices.Contains(serviceTypes, corev1.ServiceType(serviceType)) {
+		c.log.Info("unsupported service type, using ClusterIP", "serviceType", serviceType)
+
+		return corev1.ServiceTypeClusterIP
+	}
+
+	return corev1.ServiceType(serviceType)
 }
 
-// GetIngressAnnotations returns the ingress annotations for the given target
-func (c *OIDCAppsControllerConfig) GetIngressAnnotations(object client.Object) map[string]string {
-	t := c.fetchTarget(object)
-	if t.Ingress != nil && t.Ingress.Annotations != nil {
-		return t.Ingress.Annotations
+// GetSkipIngress returns true if the ingress of the target is omitted, as the oauth2-proxy is exposed directly by a
+// generated NodePort or LoadBalancer service
+func (c *OIDCAppsControllerConfig) GetSkipIngress(object client.Object) bool {
+	if c.GetServiceType(object) == corev1.ServiceTypeClusterIP || c.GetIngressServiceName(object) != "" {
+		return false
 	}
 
-	return nil
+	if t := c.fetchTarget(object); t.Service != nil {
+		return t.Service.SkipIngress
+	}
+
+	return false
 }
 
 func (c *OIDCAppsControllerConfig) fetchTarget(o client.Object) Target {
@@ -546,3 +2599,84 @@ func (c *OIDCAppsControllerConfig) GetTargetLabelSelector(o client.Object) *meta
 
 	return nil
 }
+
+// The supported assignments of the per-pod ingresses to the ingress classes
+const (
+	IngressClassAssignmentRoundRobin = "RoundRobin"
+	IngressClassAssignmentHash       = "Hash"
+)
+
+var ingressClassAssignments = []string{IngressClassAssignmentRoundRobin, IngressClassAssignmentHash}
+
+// serviceTypes are the supported types of the generated oauth2 service
+var serviceTypes = []corev1.ServiceType{
+	corev1.ServiceTypeClusterIP,
+	corev1.ServiceTypeNodePort,
+	corev1.ServiceTypeLoadBalancer,
+}
+
+// deletionPropagationPolicies are the supported propagation policies of the cleanup deletions
+var deletionPropagationPolicies = []metav1.DeletionPropagation{
+	metav1.DeletePropagationForeground,
+	metav1.DeletePropagationBackground,
+	metav1.DeletePropagationOrphan,
+}
+
+// GetDeletionPropagationPolicy returns the propagation policy of the deletions cleaning up the generated resources,
+// defaulting to Background
+func (c *OIDCAppsControllerConfig) GetDeletionPropagationPolicy() metav1.DeletionPropagation {
+	if c.Configuration.DeletionPropagationPolicy != "" {
+		return c.Configuration.DeletionPropagationPolicy
+	}
+
+	return metav1.DeletePropagationBackground
+}
+
+// GetRecreateServicesOnImmutableChange returns true if the generated services, which cannot be patched due to a change
+// of an immutable field, shall be deleted and recreated. Defaults to false.
+func (c *OIDCAppsControllerConfig) GetRecreateServicesOnImmutableChange() bool {
+	return ptr.Deref(c.Configuration.RecreateServicesOnImmutableChange, false)
+}
+
+// GetRestartOnConfigChange returns true if the target workloads shall be restarted upon a change of their oauth2-proxy
+// configuration. Defaults to false.
+func (c *OIDCAppsControllerConfig) GetRestartOnConfigChange() bool {
+	return ptr.Deref(c.Configuration.RestartOnConfigChange, false)
+}
+
+// GetMaxPerPodResources returns the number of StatefulSet pods, which get a dedicated ingress and service, zero when
+// there is no cap
+func (c *OIDCAppsControllerConfig) GetMaxPerPodResources() int32 {
+	return c.Configuration.MaxPerPodResources
+}
+
+// GetGenerateNames returns true if the generated ingresses and network policies shall be named per workload generation.
+// Defaults to false.
+func (c *OIDCAppsControllerConfig) GetGenerateNames() bool {
+	return ptr.Deref(c.Configuration.GenerateNames, false)
+}
+
+// GetRetryBackoff returns the backoff of the retried updates of the generated resources on conflicts. The unset values
+// default to retry.DefaultRetry.
+func (c *OIDCAppsControllerConfig) GetRetryBackoff() wait.Backoff {
+	backoff := retry.DefaultRetry
+
+	r := c.Configuration.RetryBackoff
+	if r == nil {
+		return backoff
+	}
+
+	if r.Steps > 0 {
+		backoff.Steps = r.Steps
+	}
+
+	if d, err := time.ParseDuration(r.Duration); err == nil && d > 0 {
+		backoff.Duration = d
+	}
+
+	if r.Factor > 0 {
+		backoff.Factor = r.Factor
+	}
+
+	return backoff
+}
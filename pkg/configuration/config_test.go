@@ -16,16 +16,26 @@ package configuration
 
 import (
 	_ "embed"
+	"encoding/base64"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
 )
 
 //go:embed test/configuration.yaml
@@ -178,6 +188,247 @@ func TestTargetConfiguration(t *testing.T) {
 	g.Expect(extensionConfig.GetKubeSecretName(target)).To(Equal("target-kubeconfig"))
 }
 
+func TestTargetIngressPath(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-03"), getDeployment("test-04")).
+		Build()
+
+	target := getDeployment("test-03")
+	g.Expect(extensionConfig.GetIngressPath(target)).To(Equal("/app"))
+	g.Expect(extensionConfig.GetIngressPathType(target)).To(Equal(networkingv1.PathTypeExact))
+	g.Expect(extensionConfig.GetOauth2ProxyPrefix(target)).To(Equal("/app/oauth2"))
+	g.Expect(extensionConfig.GetRedirectURL(target)).To(Equal("https://this.overwrites/app/oauth2/callback"))
+
+	target = getDeployment("test-04")
+	g.Expect(extensionConfig.GetIngressPath(target)).To(Equal("/"))
+	g.Expect(extensionConfig.GetIngressPathType(target)).To(Equal(networkingv1.PathTypePrefix))
+	g.Expect(extensionConfig.GetOauth2ProxyPrefix(target)).To(Equal("/oauth2"))
+
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(getDeployment("test-03"))...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`proxy_prefix="/app/oauth2"`))
+	g.Expect(cfg).To(ContainSubstring(`redirect_url="https://this.overwrites/app/oauth2/callback"`))
+}
+
+func TestTargetStartupProbe(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-02"), getDeployment("test-04")).
+		Build()
+
+	probe := extensionConfig.GetOauth2ProxyStartupProbe(getDeployment("test-02"))
+	g.Expect(probe).NotTo(BeNil())
+	g.Expect(probe.HTTPGet.Path).To(Equal("/ping"))
+	g.Expect(probe.HTTPGet.Port).To(Equal(intstr.FromString("oauth2")))
+	g.Expect(probe.FailureThreshold).To(Equal(int32(60)))
+	g.Expect(probe.PeriodSeconds).To(Equal(int32(10)))
+
+	g.Expect(extensionConfig.GetOauth2ProxyStartupProbe(getDeployment("test-04"))).To(BeNil())
+}
+
+func TestTargetUpstreamTimeouts(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-02"), getDeployment("test-04")).
+		Build()
+
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(getDeployment("test-02"))...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`upstream_timeout="5m"`))
+	g.Expect(cfg).To(ContainSubstring(`flush_interval="100ms"`))
+
+	// Without configuration the oauth2-proxy defaults apply
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(getDeployment("test-04"))...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("upstream_timeout"))
+	g.Expect(cfg).NotTo(ContainSubstring("flush_interval"))
+
+	// Annotations take precedence, invalid values are ignored
+	target := getDeployment("test-02")
+	target.SetAnnotations(map[string]string{
+		"oidc-application-controller/upstream-timeout": "1h",
+		"oidc-application-controller/flush-interval":   "never",
+	})
+	g.Expect(extensionConfig.GetOauth2ProxyUpstreamTimeout(target)).To(Equal("1h"))
+	g.Expect(extensionConfig.GetOauth2ProxyFlushInterval(target)).To(Equal("100ms"))
+}
+
+func TestValidateDurations(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{Oauth2Proxy: &Oauth2ProxyConfig{UpstreamTimeout: "30s"}},
+		Targets: []Target{
+			{Name: "valid", Configuration: &Configuration{Oauth2Proxy: &Oauth2ProxyConfig{FlushInterval: "1s"}}},
+		},
+	}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	extensionConfig.Configuration.Oauth2Proxy.UpstreamTimeout = "30 seconds"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("upstreamTimeout")))
+
+	extensionConfig.Configuration.Oauth2Proxy.UpstreamTimeout = ""
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.FlushInterval = "-1s"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("target valid: flushInterval")))
+}
+
+func TestTargetSignInPage(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// The defaults skip the oauth2-proxy sign-in page
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`skip_provider_button="true"`))
+	g.Expect(cfg).NotTo(ContainSubstring("banner"))
+	g.Expect(cfg).NotTo(ContainSubstring("footer"))
+	g.Expect(cfg).NotTo(ContainSubstring("custom_sign_in_logo"))
+
+	extensionConfig.Configuration.Oauth2Proxy.SkipProviderButton = ptr.To(false)
+	extensionConfig.Configuration.Oauth2Proxy.SignInPage = &SignInPageConf{
+		Banner: `Sign in with the "corporate" account`,
+		Footer: "-",
+		Logo:   "https://example.org/logo.svg",
+	}
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`skip_provider_button="false"`))
+	g.Expect(cfg).To(ContainSubstring(`banner="Sign in with the \"corporate\" account"`))
+	g.Expect(cfg).To(ContainSubstring(`footer="-"`))
+	g.Expect(cfg).To(ContainSubstring(`custom_sign_in_logo="https://example.org/logo.svg"`))
+
+	// The annotation takes precedence over the configuration
+	target.SetAnnotations(map[string]string{"oidc-application-controller/skip-provider-button": "true"})
+	g.Expect(extensionConfig.GetOauth2ProxySkipProviderButton(target)).To(BeTrue())
+}
+
+func TestTargetReverseProxy(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`reverse_proxy="true"`))
+
+	extensionConfig.Configuration.Oauth2Proxy.ReverseProxy = ptr.To(false)
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`reverse_proxy="false"`))
+}
+
+func TestTargetUpstreamHealthPath(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("skip_auth_routes"))
+
+	extensionConfig.Configuration.Oauth2Proxy.UpstreamHealthPath = "/api/v1.0/healthz"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`skip_auth_routes=["GET=^/api/v1\\.0/healthz$"]`))
+
+	extensionConfig.Configuration.Oauth2Proxy.UpstreamHealthPath = "healthz"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("upstreamHealthPath")))
+}
+
+func TestTargetAudiences(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// By default, the tokens are validated against the client id audience only
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`client_id="` + extensionConfig.GetClientID(target) + `"`))
+	g.Expect(cfg).NotTo(ContainSubstring("oidc_extra_audiences"))
+	g.Expect(cfg).NotTo(ContainSubstring("oidc_audience_claims"))
+
+	extensionConfig.Configuration.Oauth2Proxy.ExtraAudiences = []string{"kube-apiserver", "observability"}
+	extensionConfig.Configuration.Oauth2Proxy.AudienceClaims = []string{"aud", "azp"}
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`oidc_extra_audiences=["kube-apiserver", "observability"]`))
+	g.Expect(cfg).To(ContainSubstring(`oidc_audience_claims=["aud", "azp"]`))
+}
+
+func TestTargetRealClientIPHeader(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("real_client_ip_header"))
+
+	extensionConfig.Configuration.Oauth2Proxy.RealClientIPHeader = "x-forwarded-for"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`real_client_ip_header="x-forwarded-for"`))
+
+	extensionConfig.Configuration.Oauth2Proxy.RealClientIPHeader = "X-Client-IP"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("realClientIpHeader")))
+
+	extensionConfig.Configuration.Oauth2Proxy.RealClientIPHeader = "X-Real-IP\nX-Injected: true"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("realClientIpHeader")))
+}
+
 func TestGardenConfig(t *testing.T) {
 	extensionConfig := OIDCAppsControllerConfig{}
 	g := NewWithT(t)
@@ -232,3 +483,1161 @@ func getTestNamespace() *corev1.Namespace {
 		},
 	}
 }
+
+func TestDeletionPropagationPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+	g.Expect(extensionConfig.GetDeletionPropagationPolicy()).To(Equal(metav1.DeletePropagationBackground))
+
+	extensionConfig.Configuration.DeletionPropagationPolicy = metav1.DeletePropagationForeground
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+	g.Expect(extensionConfig.GetDeletionPropagationPolicy()).To(Equal(metav1.DeletePropagationForeground))
+
+	extensionConfig.Configuration.DeletionPropagationPolicy = "Cascade"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("deletionPropagationPolicy")))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{}
+	g.Expect(extensionConfig.GetRetryBackoff()).To(Equal(retry.DefaultRetry))
+
+	extensionConfig.Configuration.RetryBackoff = &RetryBackoffConfig{Steps: 3, Duration: "50ms"}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	backoff := extensionConfig.GetRetryBackoff()
+	g.Expect(backoff.Steps).To(Equal(3))
+	g.Expect(backoff.Duration).To(Equal(50 * time.Millisecond))
+	g.Expect(backoff.Factor).To(Equal(retry.DefaultRetry.Factor))
+	g.Expect(backoff.Jitter).To(Equal(retry.DefaultRetry.Jitter))
+
+	extensionConfig.Configuration.RetryBackoff = &RetryBackoffConfig{Duration: "fast"}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("retryBackoff")))
+
+	extensionConfig.Configuration.RetryBackoff = &RetryBackoffConfig{Steps: -1}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("steps")))
+}
+
+func TestTargetCookieName(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04"), getDeployment("test-05")).
+		Build()
+
+	// The default cookie names are unique per workload
+	first := extensionConfig.GetOauth2ProxyCookieName(getDeployment("test-04"))
+	second := extensionConfig.GetOauth2ProxyCookieName(getDeployment("test-05"))
+	g.Expect(first).To(HavePrefix("_oauth2_proxy_"))
+	g.Expect(first).NotTo(Equal(second))
+
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(getDeployment("test-04"))...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`cookie_name="` + first + `"`))
+
+	extensionConfig.Configuration.Oauth2Proxy.CookieName = "_custom"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(getDeployment("test-04"))...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`cookie_name="_custom"`))
+
+	extensionConfig.Configuration.Oauth2Proxy.CookieName = "session;id"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("cookieName")))
+}
+
+func TestResourceLabels(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	g.Expect(extensionConfig.GetResourceLabels(getDeployment("test-04"))).To(BeEmpty())
+
+	extensionConfig.Configuration.ResourceLabels = map[string]string{"app.kubernetes.io/part-of": "apps", "team": "a"}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+	g.Expect(extensionConfig.GetResourceLabels(getDeployment("test-04"))).To(Equal(map[string]string{
+		"app.kubernetes.io/part-of": "apps", "team": "a",
+	}))
+
+	// The target labels override the global ones
+	name := extensionConfig.fetchTarget(getDeployment("test-04")).Name
+	idx := slices.IndexFunc(extensionConfig.Targets, func(t Target) bool { return t.Name == name })
+	target := &extensionConfig.Targets[idx]
+	target.Configuration = &Configuration{ResourceLabels: map[string]string{"team": "b"}}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+	g.Expect(extensionConfig.GetResourceLabels(getDeployment("test-04"))).To(Equal(map[string]string{
+		"app.kubernetes.io/part-of": "apps", "team": "b",
+	}))
+
+	// The global labels are not modified
+	g.Expect(extensionConfig.Configuration.ResourceLabels).To(HaveKeyWithValue("team", "a"))
+
+	target.Configuration.ResourceLabels = map[string]string{"team": "a b"}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("resourceLabels of target")))
+
+	target.Configuration.ResourceLabels = nil
+	extensionConfig.Configuration.ResourceLabels = map[string]string{"-team": "a"}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("invalid resourceLabels")))
+}
+
+func TestIngressProxyBodySizeValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, size := range []string{"0", "1024", "100m", "2G"} {
+		extensionConfig := OIDCAppsControllerConfig{Targets: []Target{{
+			Name:    "test",
+			Ingress: &IngressConf{ProxyBodySize: size},
+		}}}
+		g.Expect(extensionConfig.Validate()).To(Succeed(), size)
+	}
+
+	for _, size := range []string{"-1", "100 m", "100mb", "1.5g"} {
+		extensionConfig := OIDCAppsControllerConfig{Targets: []Target{{
+			Name:    "test",
+			Ingress: &IngressConf{ProxyBodySize: size},
+		}}}
+		g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("proxyBodySize")), size)
+	}
+}
+
+func TestIngressServicePortValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, port := range []int32{-1, 65536} {
+		extensionConfig := OIDCAppsControllerConfig{Targets: []Target{{
+			Name:    "test",
+			Ingress: &IngressConf{ServiceName: "nginx", ServicePort: port},
+		}}}
+		g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("servicePort")), port)
+	}
+
+	extensionConfig := OIDCAppsControllerConfig{Targets: []Target{{
+		Name:    "test",
+		Ingress: &IngressConf{ServiceName: "nginx", ServicePort: 8443},
+	}}}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+}
+
+func TestIngressClassAssignment(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{Targets: []Target{{
+		Name:    "test",
+		Ingress: &IngressConf{IngressClassNames: []string{"a", "b"}},
+	}}}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	extensionConfig.Targets[0].Ingress.IngressClassAssignment = IngressClassAssignmentHash
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	extensionConfig.Targets[0].Ingress.IngressClassAssignment = "LeastConnections"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("ingressClassAssignment")))
+
+	extensionConfig.Targets[0].Ingress.IngressClassAssignment = ""
+	extensionConfig.Targets[0].Ingress.IngressClassNames = []string{"a", ""}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("ingressClassNames")))
+}
+
+func TestTargetCodeChallengeMethod(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// PKCE is enabled with S256 by default
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`code_challenge_method="S256"`))
+
+	extensionConfig.Configuration.Oauth2Proxy.CodeChallengeMethod = "plain"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`code_challenge_method="plain"`))
+
+	extensionConfig.Configuration.Oauth2Proxy.CodeChallengeMethod = "s256"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("codeChallengeMethod")))
+
+	extensionConfig.Configuration.Oauth2Proxy.CodeChallengeMethod = "S512"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("codeChallengeMethod")))
+}
+
+func TestTargetCustomTemplates(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// The built-in templates are used by default
+	target := getDeployment("test-04")
+	g.Expect(extensionConfig.GetOauth2ProxyCustomTemplatesName(target)).To(BeEmpty())
+
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("custom_templates_dir"))
+
+	extensionConfig.Configuration.Oauth2Proxy.CustomTemplatesRef = &corev1.LocalObjectReference{Name: "templates"}
+	g.Expect(extensionConfig.GetOauth2ProxyCustomTemplatesName(target)).To(Equal("templates"))
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`custom_templates_dir="` + constants.Oauth2TemplatesDir + `"`))
+}
+
+func TestPropagatedAnnotationsValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{PropagatedAnnotations: []string{"container.apparmor.security.beta.kubernetes.io"}},
+		Targets:       []Target{{Name: "test", Configuration: &Configuration{}}},
+	}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	extensionConfig.Configuration.PropagatedAnnotations = []string{"invalid_prefix"}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("invalid propagatedAnnotations")))
+
+	extensionConfig.Configuration.PropagatedAnnotations = nil
+	extensionConfig.Targets[0].Configuration.PropagatedAnnotations = []string{"example.com/nested"}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("propagatedAnnotations of target")))
+}
+
+func TestServiceTypeValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Targets: []Target{{Name: "test", Service: &ServiceConf{Type: corev1.ServiceTypeNodePort}}},
+	}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	extensionConfig.Targets[0].Service.Type = corev1.ServiceTypeExternalName
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("invalid service configuration of target")))
+}
+
+func TestTargetSkipJWTBearerTokens(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// By default, the bearer tokens are accepted
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`skip_jwt_bearer_tokens="true"`))
+	g.Expect(cfg).NotTo(ContainSubstring("extra_jwt_issuers"))
+
+	extensionConfig.Configuration.Oauth2Proxy.ExtraJWTIssuers = []string{"https://issuer.example.com=api"}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`extra_jwt_issuers=["https://issuer.example.com=api"]`))
+
+	// The extra issuers are rendered only together with the skipped bearer tokens
+	extensionConfig.Configuration.Oauth2Proxy.SkipJWTBearerTokens = ptr.To(false)
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`skip_jwt_bearer_tokens="false"`))
+	g.Expect(cfg).NotTo(ContainSubstring("extra_jwt_issuers"))
+
+	extensionConfig.Configuration.Oauth2Proxy.ExtraJWTIssuers = []string{"https://issuer.example.com"}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("extraJwtIssuers")))
+}
+
+func TestMaxPerPodResourcesValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{Configuration: Configuration{MaxPerPodResources: 100}}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+	g.Expect(extensionConfig.GetMaxPerPodResources()).To(Equal(int32(100)))
+
+	extensionConfig.Configuration.MaxPerPodResources = -1
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("invalid maxPerPodResources")))
+}
+
+func TestTargetProvider(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// The provider defaults to oidc
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`provider="oidc"`))
+	g.Expect(cfg).NotTo(ContainSubstring("github_org"))
+
+	extensionConfig.Configuration.Oauth2Proxy.Provider = ProviderGitHub
+	extensionConfig.Configuration.Oauth2Proxy.GitHubOrg = "gardener"
+	extensionConfig.Configuration.Oauth2Proxy.GitHubTeam = "oidc-apps"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`provider="github"`))
+	g.Expect(cfg).To(ContainSubstring(`github_org="gardener"`))
+	g.Expect(cfg).To(ContainSubstring(`github_team="oidc-apps"`))
+
+	// The provider annotation takes precedence, unsupported providers are ignored
+	target.SetAnnotations(map[string]string{constants.AnnotationProviderKey: ProviderGoogle})
+	g.Expect(extensionConfig.GetOauth2ProxyProvider(target)).To(Equal(ProviderGoogle))
+
+	target.SetAnnotations(map[string]string{constants.AnnotationProviderKey: "keycloak"})
+	g.Expect(extensionConfig.GetOauth2ProxyProvider(target)).To(Equal(ProviderGitHub))
+}
+
+func TestProviderValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{Oauth2Proxy: &Oauth2ProxyConfig{Provider: ProviderOIDC}},
+		Targets:       []Target{{Name: "test", Configuration: &Configuration{Oauth2Proxy: &Oauth2ProxyConfig{}}}},
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("oidc requires oidcIssuerUrl")))
+
+	extensionConfig.Configuration.Oauth2Proxy.OidcIssuerURL = "https://oidc-provider.org"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	// The required fields of a target provider are taken from the target or else from the global configuration
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.Provider = ProviderGitHub
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("github requires githubOrg")))
+
+	extensionConfig.Configuration.Oauth2Proxy.GitHubOrg = "gardener"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.Provider = ProviderAzure
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("azure requires azureTenant")))
+
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.Provider = "keycloak"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring(`"keycloak" is not one of`)))
+}
+
+func TestTargetStaticOidcEndpoints(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	endpoints := &OidcEndpointsConf{
+		AuthorizationURL: "https://idp.example.org/authorize",
+		TokenURL:         "https://idp.example.org/token",
+		JWKSURL:          "https://idp.example.org/keys",
+		UserInfoURL:      "https://idp.example.org/userinfo",
+	}
+
+	// The static endpoints are not rendered without skipping the discovery
+	target := getDeployment("test-04")
+	extensionConfig.Configuration.Oauth2Proxy.OidcEndpoints = endpoints
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`skip_oidc_discovery="false"`))
+	g.Expect(cfg).NotTo(ContainSubstring("login_url"))
+	g.Expect(cfg).NotTo(ContainSubstring("oidc_jwks_url"))
+
+	extensionConfig.Configuration.Oauth2Proxy.SkipOidcDiscovery = ptr.To(true)
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`skip_oidc_discovery="true"`))
+	g.Expect(cfg).To(ContainSubstring(`login_url="https://idp.example.org/authorize"`))
+	g.Expect(cfg).To(ContainSubstring(`redeem_url="https://idp.example.org/token"`))
+	g.Expect(cfg).To(ContainSubstring(`oidc_jwks_url="https://idp.example.org/keys"`))
+	g.Expect(cfg).To(ContainSubstring(`profile_url="https://idp.example.org/userinfo"`))
+
+	// The optional user info endpoint is omitted
+	endpoints.UserInfoURL = ""
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("profile_url"))
+}
+
+func TestOidcDiscoveryValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{Oauth2Proxy: &Oauth2ProxyConfig{SkipOidcDiscovery: ptr.To(true)}},
+		Targets:       []Target{{Name: "test", Configuration: &Configuration{Oauth2Proxy: &Oauth2ProxyConfig{}}}},
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("oidcEndpoints are required")))
+
+	extensionConfig.Configuration.Oauth2Proxy.OidcEndpoints = &OidcEndpointsConf{
+		AuthorizationURL: "https://idp.example.org/authorize",
+		TokenURL:         "https://idp.example.org/token",
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("oidcEndpoints.jwksUrl is required")))
+
+	extensionConfig.Configuration.Oauth2Proxy.OidcEndpoints.JWKSURL = "https://idp.example.org/keys"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	// The endpoints of a target replace the global ones
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.OidcEndpoints = &OidcEndpointsConf{
+		AuthorizationURL: "https://other-idp.example.org/authorize",
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("of target test")))
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("oidcEndpoints.tokenUrl is required")))
+
+	// A target may enable the discovery again
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.SkipOidcDiscovery = ptr.To(false)
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+}
+
+func TestTargetAllowQuerySemicolons(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// The setting is not rendered by default
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("allow_query_semicolons"))
+
+	extensionConfig.Configuration.Oauth2Proxy.AllowQuerySemicolons = ptr.To(true)
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`allow_query_semicolons="true"`))
+}
+
+func TestTargetSharedIngress(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-03")).
+		Build()
+
+	for i := range extensionConfig.Targets {
+		if extensionConfig.Targets[i].Name == "test-03" {
+			extensionConfig.Targets[i].Ingress.Shared = true
+		}
+	}
+
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	// The workload name is appended to the base path of the shared ingress
+	target := getDeployment("test-03")
+	g.Expect(extensionConfig.GetIngressShared(target)).To(BeTrue())
+	g.Expect(extensionConfig.GetIngressPath(target)).To(Equal("/app/test-03"))
+	g.Expect(extensionConfig.GetOauth2ProxyPrefix(target)).To(Equal("/app/test-03/oauth2"))
+	g.Expect(extensionConfig.GetRedirectURL(target)).To(Equal("https://this.overwrites/app/test-03/oauth2/callback"))
+
+	// The host of the shared ingress is required
+	extensionConfig.Targets = []Target{{Name: "test", Ingress: &IngressConf{Shared: true}}}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("the host of the shared ingress is required")))
+}
+
+func TestUpstreamReadinessValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{Oauth2Proxy: &Oauth2ProxyConfig{UpstreamReadiness: ptr.To(true)}},
+		Targets:       []Target{{Name: "test", Configuration: &Configuration{Oauth2Proxy: &Oauth2ProxyConfig{}}}},
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("upstreamHealthPath is required")))
+
+	// The upstream readiness of a target requires the health path of the target or of the global configuration
+	extensionConfig.Configuration.Oauth2Proxy.UpstreamReadiness = nil
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.UpstreamReadiness = ptr.To(true)
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("of target test")))
+
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.UpstreamHealthPath = "/healthz"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+}
+
+func TestTargetUpstreamPathRewrite(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-03")).
+		Build()
+
+	extensionConfig.Configuration.Oauth2Proxy.UpstreamTimeout = "60s"
+
+	// The paths are forwarded unchanged by default, without an alpha configuration
+	target := getDeployment("test-03")
+	g.Expect(NewOAuth2AlphaConfig(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()).To(BeEmpty())
+	g.Expect(NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()).
+		To(ContainSubstring(`upstream_timeout="60s"`))
+
+	// The ingress base path is stripped by default
+	extensionConfig.Configuration.Oauth2Proxy.UpstreamPathRewrite = &UpstreamPathRewriteConf{}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	alpha := NewOAuth2AlphaConfig(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(alpha).To(ContainSubstring(`path: ^/app(?:/(.*))?$`))
+	g.Expect(alpha).To(ContainSubstring(`rewriteTarget: /$1`))
+	g.Expect(alpha).To(ContainSubstring(`uri: http://127.0.0.1:8100`))
+	g.Expect(alpha).To(ContainSubstring(`timeout: 60s`))
+	g.Expect(alpha).To(ContainSubstring(`BindAddress: 0.0.0.0:8000`))
+	g.Expect(alpha).To(ContainSubstring(`claim: id_token`))
+	g.Expect(alpha).NotTo(ContainSubstring(`metricsServer`))
+
+	// The upstream settings are not allowed in the legacy configuration next to the alpha one
+	g.Expect(NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()).
+		NotTo(ContainSubstring("upstream_timeout"))
+
+	// The target rewrite takes precedence
+	for i := range extensionConfig.Targets {
+		if extensionConfig.Targets[i].Name == "test-03" {
+			extensionConfig.Targets[i].Configuration = &Configuration{Oauth2Proxy: &Oauth2ProxyConfig{
+				UpstreamPathRewrite: &UpstreamPathRewriteConf{Path: "^/app/api/(.*)$", RewriteTarget: "/v1/$1"},
+				MetricsPort:         9090,
+			}}
+		}
+	}
+
+	alpha = NewOAuth2AlphaConfig(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(alpha).To(ContainSubstring(`path: ^/app/api/(.*)$`))
+	g.Expect(alpha).To(ContainSubstring(`rewriteTarget: /v1/$1`))
+	g.Expect(alpha).To(ContainSubstring(`BindAddress: 0.0.0.0:9090`))
+}
+
+func TestUpstreamPathRewriteValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	rewrite := &UpstreamPathRewriteConf{Path: "/app/(.*)"}
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{Oauth2Proxy: &Oauth2ProxyConfig{UpstreamPathRewrite: rewrite}},
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("must start with ^")))
+
+	rewrite.Path = "^/app/(.*$"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("upstreamPathRewrite")))
+
+	rewrite.Path = "^/app/(.*)$"
+	rewrite.RewriteTarget = "$1"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("must start with /")))
+
+	rewrite.RewriteTarget = "/$1"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+}
+
+func TestTargetCookieRefresh(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// The settings are not rendered by default
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("cookie_refresh"))
+	g.Expect(cfg).NotTo(ContainSubstring("cookie_expire="))
+
+	extensionConfig.Configuration.Oauth2Proxy.CookieRefresh = "15m"
+	extensionConfig.Configuration.Oauth2Proxy.CookieExpire = "12h"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`cookie_refresh="15m"`))
+	g.Expect(cfg).To(ContainSubstring(`cookie_expire="12h"`))
+}
+
+func TestCookieRefreshValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{Oauth2Proxy: &Oauth2ProxyConfig{CookieRefresh: "soon"}},
+		Targets:       []Target{{Name: "test", Configuration: &Configuration{Oauth2Proxy: &Oauth2ProxyConfig{}}}},
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("cookieRefresh")))
+
+	// The default refresh period of 1h requires a longer cookie expiration
+	extensionConfig.Configuration.Oauth2Proxy.CookieRefresh = ""
+	extensionConfig.Configuration.Oauth2Proxy.CookieExpire = "30m"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("must be shorter than the cookie expiration")))
+
+	// The refresh period of a target is validated against the global cookie expiration
+	extensionConfig.Configuration.Oauth2Proxy.CookieExpire = "2h"
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.CookieRefresh = "3h"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("of target test")))
+
+	extensionConfig.Targets[0].Configuration.Oauth2Proxy.CookieRefresh = "10m"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+}
+
+func TestCookieSecretMaxAgeValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{Oauth2Proxy: &Oauth2ProxyConfig{CookieSecretMaxAge: "monthly"}},
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("cookieSecretMaxAge")))
+
+	extensionConfig.Configuration.Oauth2Proxy.CookieSecretMaxAge = "720h"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+	g.Expect(extensionConfig.GetOauth2ProxyCookieSecretMaxAge(getDeployment("test"))).To(Equal(720 * time.Hour))
+}
+
+func TestTargetAPIRoutes(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// By default, there are no api routes
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("api_routes"))
+
+	extensionConfig.Configuration.Oauth2Proxy.APIRoutes = []string{"^/api/", `^/v[0-9]+/metrics$`}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`api_routes=["^/api/", "^/v[0-9]+/metrics$"]`))
+}
+
+func TestAPIRoutesValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, route := range []string{"", "^/api/(", "^/[a-"} {
+		extensionConfig := OIDCAppsControllerConfig{Configuration: Configuration{
+			Oauth2Proxy: &Oauth2ProxyConfig{APIRoutes: []string{"^/api/", route}},
+		}}
+		g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("apiRoutes")), route)
+	}
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Targets: []Target{{Name: "test", Configuration: &Configuration{
+			Oauth2Proxy: &Oauth2ProxyConfig{APIRoutes: []string{"*"}},
+		}}},
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("invalid oauth2Proxy configuration of target")))
+}
+
+func TestTargetCookiePath(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-03"), getDeployment("test-04")).
+		Build()
+
+	// The cookie path is left to the oauth2-proxy default at the root path
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(getDeployment("test-04"))...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("cookie_path"))
+
+	// The cookie path matches the ingress base path
+	target := getDeployment("test-03")
+	g.Expect(extensionConfig.GetOauth2ProxyCookiePath(target)).To(Equal(extensionConfig.GetIngressPath(target)))
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`cookie_path="/app"`))
+
+	// The annotation overrides the base path, an invalid one is ignored
+	target.SetAnnotations(map[string]string{constants.AnnotationCookiePathKey: "/app/ui/"})
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`cookie_path="/app/ui"`))
+
+	target.SetAnnotations(map[string]string{constants.AnnotationCookiePathKey: "app/ui"})
+	g.Expect(extensionConfig.GetOauth2ProxyCookiePath(target)).To(Equal("/app"))
+}
+
+func TestGetOidcIssuers(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{
+			OidcCABundle: base64.StdEncoding.EncodeToString([]byte("global-ca")),
+			Oauth2Proxy:  &Oauth2ProxyConfig{OidcIssuerURL: "https://issuer.example.com"},
+		},
+		Targets: []Target{
+			{Name: "same-issuer"},
+			{Name: "own-issuer", Configuration: &Configuration{
+				OidcCABundle: base64.StdEncoding.EncodeToString([]byte("target-ca")),
+				Oauth2Proxy:  &Oauth2ProxyConfig{OidcIssuerURL: "https://other.example.com"},
+			}},
+			{Name: "static-endpoints", Configuration: &Configuration{
+				Oauth2Proxy: &Oauth2ProxyConfig{OidcIssuerURL: "https://legacy.example.com", SkipOidcDiscovery: ptr.To(true)},
+			}},
+		},
+	}
+
+	g.Expect(extensionConfig.GetOidcIssuers()).To(Equal([]OidcIssuer{
+		{URL: "https://issuer.example.com", CABundle: "global-ca"},
+		{URL: "https://other.example.com", CABundle: "target-ca"},
+	}))
+}
+
+func TestOidcPublicIssuer(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	target := getDeployment("test-02")
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(target).
+		Build()
+
+	extensionConfig.Configuration.OidcCABundle = base64.StdEncoding.EncodeToString([]byte("global-ca"))
+	g.Expect(extensionConfig.GetOidcPublicIssuer(target)).To(BeFalse())
+	g.Expect(extensionConfig.GetOidcCASecretName(target)).To(Equal("target-oidc-ca"))
+	g.Expect(extensionConfig.GetOidcCABundle(target)).To(Equal("global-ca"))
+
+	// A public issuer is verified against the system trust store, the configured CA bundles are ignored
+	extensionConfig.Targets[1].Configuration.OidcPublicIssuer = ptr.To(true)
+	g.Expect(extensionConfig.GetOidcPublicIssuer(target)).To(BeTrue())
+	g.Expect(extensionConfig.GetOidcCASecretName(target)).To(BeEmpty())
+	g.Expect(extensionConfig.GetOidcCABundle(target)).To(BeEmpty())
+
+	// The CA bundle referenced by the workload itself still applies
+	target.SetAnnotations(map[string]string{constants.AnnotationOidcCASecretKey: "issuer-ca"})
+	g.Expect(extensionConfig.GetOidcCASecretKeyRef(target)).To(Equal(&corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "issuer-ca"}, Key: "ca.crt",
+	}))
+
+	// The issuer check of a public issuer uses the system trust store
+	issuersConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{
+			OidcCABundle: base64.StdEncoding.EncodeToString([]byte("global-ca")),
+			Oauth2Proxy:  &Oauth2ProxyConfig{OidcIssuerURL: "https://issuer.example.com"},
+		},
+		Targets: []Target{
+			{Name: "public-issuer", Configuration: &Configuration{
+				OidcPublicIssuer: ptr.To(true),
+				Oauth2Proxy:      &Oauth2ProxyConfig{OidcIssuerURL: "https://accounts.google.com"},
+			}},
+		},
+	}
+	g.Expect(issuersConfig.GetOidcIssuers()).To(Equal([]OidcIssuer{
+		{URL: "https://issuer.example.com", CABundle: "global-ca"},
+		{URL: "https://accounts.google.com"},
+	}))
+}
+
+func TestTargetXAuthRequest(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	extensionConfig.Configuration.Oauth2Proxy.UpstreamTimeout = "60s"
+
+	// The X-Auth-Request headers are not set by default, the claims are taken into account only together with them
+	target := getDeployment("test-04")
+	extensionConfig.Configuration.Oauth2Proxy.XAuthRequestClaims = []string{"tenant_id"}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+	g.Expect(extensionConfig.GetOauth2ProxyAlphaConfig(target)).To(BeFalse())
+	g.Expect(NewOAuth2AlphaConfig(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()).To(BeEmpty())
+
+	// The headers are passed to the upstream and set on the responses through the alpha configuration
+	extensionConfig.Configuration.Oauth2Proxy.SetXAuthRequest = ptr.To(true)
+	g.Expect(extensionConfig.GetOauth2ProxyAlphaConfig(target)).To(BeTrue())
+
+	alpha := NewOAuth2AlphaConfig(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(alpha).To(ContainSubstring("path: /\n"))
+	g.Expect(alpha).NotTo(ContainSubstring("rewriteTarget"))
+	g.Expect(alpha).To(ContainSubstring(`timeout: 60s`))
+
+	alphaConfig := struct {
+		InjectRequestHeaders  []alphaHeader `json:"injectRequestHeaders"`
+		InjectResponseHeaders []alphaHeader `json:"injectResponseHeaders"`
+	}{}
+	g.Expect(yaml.Unmarshal([]byte(alpha), &alphaConfig)).To(Succeed())
+
+	header := func(name, claim string) alphaHeader {
+		return alphaHeader{Name: name, Values: []alphaHeaderValue{{ClaimSource: alphaClaimSource{Claim: claim}}}}
+	}
+	xAuthRequestHeaders := []alphaHeader{
+		header("X-Auth-Request-User", "user"),
+		header("X-Auth-Request-Email", "email"),
+		header("X-Auth-Request-Groups", "groups"),
+		header("X-Auth-Request-Preferred-Username", "preferred_username"),
+	}
+	g.Expect(alphaConfig.InjectRequestHeaders).To(ContainElements(xAuthRequestHeaders))
+	g.Expect(alphaConfig.InjectRequestHeaders).To(ContainElement(header("X-Auth-Request-Tenant-Id", "tenant_id")))
+	g.Expect(alphaConfig.InjectResponseHeaders).To(Equal(xAuthRequestHeaders))
+
+	// The upstream settings are not allowed in the legacy configuration next to the alpha one
+	g.Expect(NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()).
+		NotTo(ContainSubstring("upstream_timeout"))
+}
+
+func TestXAuthRequestClaimsValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{Configuration: Configuration{
+		Oauth2Proxy: &Oauth2ProxyConfig{SetXAuthRequest: ptr.To(true), XAuthRequestClaims: []string{"tenant_id", "org_unit"}},
+	}}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	for _, claim := range []string{"", "tenant id", "org.unit"} {
+		extensionConfig.Configuration.Oauth2Proxy.XAuthRequestClaims = []string{claim}
+		g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("xAuthRequestClaims")), claim)
+	}
+}
+
+func TestTargetPassUserHeadersAndBasicAuth(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// By default, the oauth2-proxy defaults pass the user headers without basic auth
+	target := getDeployment("test-04")
+	g.Expect(extensionConfig.GetOauth2ProxyPassUserHeaders(target)).To(BeTrue())
+	g.Expect(extensionConfig.GetOauth2ProxyPassBasicAuth(target)).To(BeFalse())
+
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("pass_user_headers"))
+	g.Expect(cfg).NotTo(ContainSubstring("pass_basic_auth"))
+	g.Expect(cfg).NotTo(ContainSubstring("basic_auth_password"))
+
+	// Skipping the user headers disables also the basic auth, which implies them
+	extensionConfig.Configuration.Oauth2Proxy.PassUserHeaders = ptr.To(false)
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`pass_user_headers="false"`))
+	g.Expect(cfg).To(ContainSubstring(`pass_basic_auth="false"`))
+
+	// The password is quoted in the configuration secret
+	extensionConfig.Configuration.Oauth2Proxy.PassBasicAuth = ptr.To(true)
+	extensionConfig.Configuration.Oauth2Proxy.BasicAuthPassword = `pa"ss\word`
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+	g.Expect(extensionConfig.GetOauth2ProxyBasicAuthPassword(target)).To(Equal(`pa"ss\word`))
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("pass_user_headers"))
+	g.Expect(cfg).To(ContainSubstring(`pass_basic_auth="true"`))
+	g.Expect(cfg).To(ContainSubstring(`basic_auth_password="pa\"ss\\word"`))
+	g.Expect(NewOAuth2AlphaConfig(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()).To(BeEmpty())
+
+	// The headers move to the alpha configuration next to the X-Auth-Request headers
+	extensionConfig.Configuration.Oauth2Proxy.SetXAuthRequest = ptr.To(true)
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("pass_basic_auth"))
+	g.Expect(cfg).NotTo(ContainSubstring("basic_auth_password"))
+
+	alphaConfig := struct {
+		InjectRequestHeaders []alphaHeader `json:"injectRequestHeaders"`
+	}{}
+	g.Expect(yaml.Unmarshal([]byte(NewOAuth2AlphaConfig(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()),
+		&alphaConfig)).To(Succeed())
+	g.Expect(alphaConfig.InjectRequestHeaders).To(ContainElement(alphaHeader{Name: "Authorization",
+		Values: []alphaHeaderValue{{ClaimSource: alphaClaimSource{Claim: "user",
+			BasicAuthPassword: &alphaSecretSource{Value: []byte(`pa"ss\word`)}}}}}))
+	g.Expect(alphaConfig.InjectRequestHeaders).To(ContainElement(HaveField("Name", "X-Forwarded-User")))
+	g.Expect(alphaConfig.InjectRequestHeaders).NotTo(ContainElement(HaveField("Values",
+		ContainElement(HaveField("ClaimSource.Claim", "id_token")))))
+
+	// Without the basic auth, the user headers are skipped also in the alpha configuration
+	extensionConfig.Configuration.Oauth2Proxy.PassBasicAuth = nil
+	alphaConfig.InjectRequestHeaders = nil
+	g.Expect(yaml.Unmarshal([]byte(NewOAuth2AlphaConfig(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()),
+		&alphaConfig)).To(Succeed())
+	g.Expect(alphaConfig.InjectRequestHeaders).NotTo(ContainElement(HaveField("Name", "X-Forwarded-User")))
+	g.Expect(alphaConfig.InjectRequestHeaders).To(ContainElement(HaveField("Name", "Authorization")))
+}
+
+func TestBasicAuthValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{Configuration: Configuration{
+		Oauth2Proxy: &Oauth2ProxyConfig{PassBasicAuth: ptr.To(true)},
+	}}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("basicAuthPassword is required")))
+
+	extensionConfig.Configuration.Oauth2Proxy.BasicAuthPassword = "pass\nword"
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("basicAuthPassword")))
+
+	extensionConfig.Configuration.Oauth2Proxy.BasicAuthPassword = "password"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	// Without the basic auth, the password is not passed
+	extensionConfig.Configuration.Oauth2Proxy.PassBasicAuth = ptr.To(false)
+	g.Expect(extensionConfig.GetOauth2ProxyBasicAuthPassword(getDeployment("test-04"))).To(BeEmpty())
+}
+
+func TestInsecureSkipVerifyAnnotation(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`ssl_insecure_skip_verify="false"`))
+
+	// The workload opts in explicitly
+	target.SetAnnotations(map[string]string{constants.AnnotationInsecureSkipVerifyKey: "true"})
+	g.Expect(extensionConfig.GetSslInsecureSkipVerify(target)).To(BeTrue())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`ssl_insecure_skip_verify="true"`))
+
+	// An invalid value falls back to the configuration
+	target.SetAnnotations(map[string]string{constants.AnnotationInsecureSkipVerifyKey: "yes"})
+	g.Expect(extensionConfig.GetSslInsecureSkipVerify(target)).To(BeFalse())
+}
+
+func TestTargetCookieSameSite(t *testing.T) {
+	extensionConfig := OIDCAppsControllerConfig{}
+	g := NewWithT(t)
+	err := yaml.Unmarshal([]byte(configYaml), &extensionConfig)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// Create a fake client
+	extensionConfig.client = fake.NewClientBuilder().
+		WithObjects(getTestNamespace()).
+		WithObjects(getDeployment("test-04")).
+		Build()
+
+	// By default, the browser default applies
+	target := getDeployment("test-04")
+	cfg := NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).NotTo(ContainSubstring("cookie_samesite"))
+	g.Expect(cfg).NotTo(ContainSubstring("cookie_secure"))
+
+	for _, mode := range []string{"lax", "strict"} {
+		extensionConfig.Configuration.Oauth2Proxy.CookieSameSite = mode
+		g.Expect(extensionConfig.Validate()).To(Succeed())
+
+		cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+		g.Expect(cfg).To(ContainSubstring(`cookie_samesite="` + mode + `"`))
+		g.Expect(cfg).NotTo(ContainSubstring("cookie_secure"))
+	}
+
+	// The cross-site cookies enforce the Secure attribute
+	extensionConfig.Configuration.Oauth2Proxy.CookieSameSite = "none"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`cookie_samesite="none"`))
+	g.Expect(cfg).To(ContainSubstring(`cookie_secure="true"`))
+
+	// The annotation of the workload takes precedence, an invalid one is ignored
+	target.SetAnnotations(map[string]string{constants.AnnotationCookieSameSiteKey: "Strict"})
+	g.Expect(extensionConfig.GetOauth2ProxyCookieSameSite(target)).To(Equal("strict"))
+
+	cfg = NewOAuth2Config(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(cfg).To(ContainSubstring(`cookie_samesite="strict"`))
+	g.Expect(cfg).NotTo(ContainSubstring("cookie_secure"))
+
+	target.SetAnnotations(map[string]string{constants.AnnotationCookieSameSiteKey: "relaxed"})
+	g.Expect(extensionConfig.GetOauth2ProxyCookieSameSite(target)).To(Equal("none"))
+}
+
+func TestCookieSameSiteValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, mode := range []string{"Lax", "relaxed", "None; Secure"} {
+		extensionConfig := OIDCAppsControllerConfig{Configuration: Configuration{
+			Oauth2Proxy: &Oauth2ProxyConfig{CookieSameSite: mode},
+		}}
+		g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("cookieSameSite")), mode)
+	}
+}
+
+func TestMetricsScrapersValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	monitoring := &metav1.LabelSelector{MatchLabels: map[string]string{"role": "monitoring"}}
+	extensionConfig := OIDCAppsControllerConfig{Configuration: Configuration{
+		Oauth2Proxy: &Oauth2ProxyConfig{MetricsPort: 9090, MetricsScrapers: []networkingv1.NetworkPolicyPeer{
+			{NamespaceSelector: monitoring},
+			{IPBlock: &networkingv1.IPBlock{CIDR: "10.250.0.0/16", Except: []string{"10.250.1.0/24"}}},
+		}},
+	}}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+	g.Expect(extensionConfig.GetOauth2ProxyMetricsScrapers(getDeployment("test-04"))).To(HaveLen(2))
+
+	// The scrapers are not taken into account without a metrics port
+	extensionConfig.Configuration.Oauth2Proxy.MetricsPort = 0
+	g.Expect(extensionConfig.GetOauth2ProxyMetricsScrapers(getDeployment("test-04"))).To(BeNil())
+
+	for _, peer := range []networkingv1.NetworkPolicyPeer{
+		{},
+		{IPBlock: &networkingv1.IPBlock{CIDR: "10.250.0.1"}},
+		{IPBlock: &networkingv1.IPBlock{CIDR: "10.250.0.0/16", Except: []string{"monitoring"}}},
+		{IPBlock: &networkingv1.IPBlock{CIDR: "10.250.0.0/16"}, NamespaceSelector: monitoring},
+		{PodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "role", Operator: "Unknown"},
+		}}},
+	} {
+		extensionConfig.Configuration.Oauth2Proxy.MetricsScrapers = []networkingv1.NetworkPolicyPeer{peer}
+		g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("metricsScrapers")), "%+v", peer)
+	}
+}
+
+func TestTargetServerName(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Targets: []Target{{Name: "test", TargetProtocol: "https", TargetServerName: "app.example.org"}},
+	}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	for _, target := range []Target{
+		{Name: "test", TargetProtocol: "https", TargetServerName: "app_example.org"},
+		{Name: "test", TargetProtocol: "https", TargetServerName: "https://app.example.org"},
+		{Name: "test", TargetServerName: "app.example.org"},
+	} {
+		extensionConfig.Targets = []Target{target}
+		g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("invalid targetServerName of target test")),
+			target.TargetServerName)
+	}
+}
+
+func TestTargetUpstreams(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{Oauth2Proxy: &Oauth2ProxyConfig{UpstreamTimeout: "60s"}},
+		Targets: []Target{{
+			Name:            "test",
+			LabelSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"app": "multi"}},
+			TargetPort:      intstr.FromString("http"),
+			TargetContainer: "app",
+			Upstreams: []UpstreamConf{
+				{Path: "/api/", TargetPort: intstr.FromString("http"), TargetContainer: "api"},
+				{Path: "/admin/", TargetPort: intstr.FromInt32(9443), TargetProtocol: "https"},
+			},
+		}},
+	}
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	target := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name: "multi", Namespace: "default", Labels: map[string]string{"app": "multi"},
+	}}
+	g.Expect(extensionConfig.GetTargetContainer(target)).To(Equal("app"))
+	g.Expect(extensionConfig.GetUpstreams(target)).To(HaveLen(2))
+	g.Expect(extensionConfig.GetUpstreams(target)[1].Target()).To(Equal("protocol=https, port=9443"))
+	g.Expect(extensionConfig.GetOauth2ProxyAlphaConfig(target)).To(BeTrue())
+
+	// The additional upstreams are routed to their kube-rbac-proxy sidecars ahead of the upstream of the target port
+	alphaConfig := alphaConfig{}
+	alpha := NewOAuth2AlphaConfig(extensionConfig.GetOauth2ProxyOptions(target)...).Parse()
+	g.Expect(yaml.Unmarshal([]byte(alpha), &alphaConfig)).To(Succeed())
+	g.Expect(alphaConfig.UpstreamConfig.Upstreams).To(Equal([]alphaUpstream{
+		{ID: "upstream-1", Path: "/api/", URI: "http://127.0.0.1:8101", Timeout: "60s"},
+		{ID: "upstream-2", Path: "/admin/", URI: "http://127.0.0.1:8102", Timeout: "60s"},
+		{ID: "upstream", Path: "/", URI: oauth2ProxyUpstreamURL, Timeout: "60s"},
+	}))
+}
+
+func TestTargetUpstreamsValidation(t *testing.T) {
+	g := NewWithT(t)
+
+	port := intstr.FromInt32(8080)
+	for _, target := range []Target{
+		{Name: "test", Upstreams: []UpstreamConf{{Path: "api", TargetPort: port}}},
+		{Name: "test", Upstreams: []UpstreamConf{{Path: "/", TargetPort: port}}},
+		{Name: "test", Upstreams: []UpstreamConf{{Path: "/api/", TargetPort: port}, {Path: "/api/", TargetPort: port}}},
+		{Name: "test", Upstreams: []UpstreamConf{{Path: "/api/"}}},
+		{Name: "test", Upstreams: []UpstreamConf{{Path: "/api/", TargetPort: intstr.FromInt32(70000)}}},
+		{Name: "test", Upstreams: []UpstreamConf{{Path: "/api/", TargetPort: port, TargetProtocol: "grpc"}}},
+		{Name: "test", Upstreams: []UpstreamConf{{Path: "/api/", TargetPort: port, TargetContainer: "API"}}},
+		{Name: "test", TargetContainer: "app_1"},
+		{Name: "test", Upstreams: slices.Repeat([]UpstreamConf{{Path: "/api/", TargetPort: port}}, 11)},
+	} {
+		extensionConfig := OIDCAppsControllerConfig{Targets: []Target{target}}
+		g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("invalid upstreams of target test")),
+			fmt.Sprintf("%+v", target))
+	}
+}
+
+func TestShutdownDrain(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := OIDCAppsControllerConfig{
+		Configuration: Configuration{Oauth2Proxy: &Oauth2ProxyConfig{ShutdownDrain: "a while"}},
+	}
+	g.Expect(extensionConfig.Validate()).To(MatchError(ContainSubstring("shutdownDrain")))
+
+	extensionConfig.Configuration.Oauth2Proxy.ShutdownDrain = "15s"
+	g.Expect(extensionConfig.Validate()).To(Succeed())
+
+	deployment := getDeployment("test")
+	g.Expect(extensionConfig.GetOauth2ProxyShutdownDrain(deployment)).To(Equal(15 * time.Second))
+
+	// The annotation of the workload takes precedence
+	deployment.SetAnnotations(map[string]string{constants.AnnotationShutdownDrainKey: "1m"})
+	g.Expect(extensionConfig.GetOauth2ProxyShutdownDrain(deployment)).To(Equal(time.Minute))
+}
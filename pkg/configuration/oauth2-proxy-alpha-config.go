@@ -0,0 +1,185 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configuration
+
+import (
+	"strconv"
+
+	"sigs.k8s.io/yaml"
+)
+
+// oauth2ProxyUpstreamURL is the kube-rbac-proxy sidecar, which the oauth2-proxy forwards the requests to
+const oauth2ProxyUpstreamURL = "http://127.0.0.1:8100"
+
+// UpstreamProxyPort returns the port of the kube-rbac-proxy sidecar of the additional upstream with the given index,
+// following the port of the kube-rbac-proxy sidecar of the target port
+func UpstreamProxyPort(index int) int32 {
+	return 8101 + int32(index) // #nosec G115 -- bounded by the validated maximum of upstreams
+}
+
+// The subset of the oauth2-proxy alpha configuration, which replaces the legacy upstream, header and server settings
+type alphaConfig struct {
+	UpstreamConfig        alphaUpstreamConfig `json:"upstreamConfig"`
+	InjectRequestHeaders  []alphaHeader       `json:"injectRequestHeaders"`
+	InjectResponseHeaders []alphaHeader       `json:"injectResponseHeaders,omitempty"`
+	Server                alphaServer         `json:"server"`
+	MetricsServer         *alphaServer        `json:"metricsServer,omitempty"`
+}
+
+type alphaUpstreamConfig struct {
+	Upstreams []alphaUpstream `json:"upstreams"`
+}
+
+type alphaUpstream struct {
+	ID            string `json:"id"`
+	Path          string `json:"path"`
+	RewriteTarget string `json:"rewriteTarget,omitempty"`
+	URI           string `json:"uri"`
+	FlushInterval string `json:"flushInterval,omitempty"`
+	Timeout       string `json:"timeout,omitempty"`
+}
+
+type alphaHeader struct {
+	Name   string             `json:"name"`
+	Values []alphaHeaderValue `json:"values"`
+}
+
+type alphaHeaderValue struct {
+	ClaimSource alphaClaimSource `json:"claimSource"`
+}
+
+type alphaClaimSource struct {
+	Claim             string             `json:"claim"`
+	Prefix            string             `json:"prefix,omitempty"`
+	BasicAuthPassword *alphaSecretSource `json:"basicAuthPassword,omitempty"`
+}
+
+// alphaSecretSource is an inline secret value, base64 encoded as a byte slice
+type alphaSecretSource struct {
+	Value []byte `json:"value"`
+}
+
+// alphaServer is the server configuration, whose keys are capitalized by oauth2-proxy
+type alphaServer struct {
+	BindAddress string `json:"BindAddress"`
+}
+
+// oauth2AlphaConfig renders the oauth2-proxy alpha configuration
+type oauth2AlphaConfig struct {
+	oauth2Config
+}
+
+// NewOAuth2AlphaConfig returns a new oauth2-proxy alpha config. It is rendered only when the upstream paths are
+// rewritten, the X-Auth-Request headers are passed to the upstream or there are additional upstreams, which the legacy
+// configuration does not support.
+func NewOAuth2AlphaConfig(opts ...OptOauth2) configParser {
+	cfg := oauth2AlphaConfig{}
+	for _, o := range opts {
+		o(&cfg.oauth2Config)
+	}
+
+	return &cfg
+}
+
+// Parse returns the parsed oauth2-proxy alpha config, empty if it is not enabled. It takes over the upstream, the
+// injected headers and the listen addresses of the legacy flags and settings.
+func (o *oauth2AlphaConfig) Parse() string {
+	if !o.alphaConfigEnabled() {
+		return ""
+	}
+
+	claimHeader := func(name, claim, prefix string) alphaHeader {
+		return alphaHeader{Name: name, Values: []alphaHeaderValue{
+			{ClaimSource: alphaClaimSource{Claim: claim, Prefix: prefix}},
+		}}
+	}
+
+	upstream := alphaUpstream{
+		ID:            "upstream",
+		Path:          "/",
+		URI:           oauth2ProxyUpstreamURL,
+		FlushInterval: o.flushInterval,
+		Timeout:       o.upstreamTimeout,
+	}
+
+	if o.upstreamPathRewrite != nil {
+		upstream.Path, upstream.RewriteTarget = o.upstreamPathRewrite.Path, o.upstreamPathRewrite.RewriteTarget
+	}
+
+	// The additional upstreams precede the upstream of the target port, which serves the remaining paths
+	upstreams := make([]alphaUpstream, 0, len(o.upstreams)+1)
+	for i, u := range o.upstreams {
+		upstreams = append(upstreams, alphaUpstream{
+			ID:            "upstream-" + strconv.Itoa(i+1),
+			Path:          u.Path,
+			URI:           "http://127.0.0.1:" + strconv.Itoa(int(UpstreamProxyPort(i))),
+			FlushInterval: o.flushInterval,
+			Timeout:       o.upstreamTimeout,
+		})
+	}
+
+	cfg := alphaConfig{
+		UpstreamConfig: alphaUpstreamConfig{Upstreams: append(upstreams, upstream)},
+		// The header of the legacy pass-authorization-header setting
+		InjectRequestHeaders: []alphaHeader{claimHeader("Authorization", "id_token", "Bearer ")},
+		Server:               alphaServer{BindAddress: "0.0.0.0:8000"},
+	}
+
+	// The header of the legacy pass-basic-auth setting, which implies the user headers
+	if o.basicAuthPassword != "" {
+		cfg.InjectRequestHeaders = []alphaHeader{{Name: "Authorization", Values: []alphaHeaderValue{
+			{ClaimSource: alphaClaimSource{Claim: "user",
+				BasicAuthPassword: &alphaSecretSource{Value: []byte(o.basicAuthPassword)}}},
+		}}}
+	}
+
+	// The headers of the legacy pass-user-headers setting
+	if !o.skipUserHeaders || o.basicAuthPassword != "" {
+		cfg.InjectRequestHeaders = append(cfg.InjectRequestHeaders,
+			claimHeader("X-Forwarded-User", "user", ""),
+			claimHeader("X-Forwarded-Email", "email", ""),
+			claimHeader("X-Forwarded-Groups", "groups", ""),
+			claimHeader("X-Forwarded-Preferred-Username", "preferred_username", ""),
+		)
+	}
+
+	// The headers of the legacy set-xauthrequest setting, passed also to the upstream together with the extra claims
+	if o.setXAuthRequest {
+		xAuthRequestHeaders := []alphaHeader{
+			claimHeader("X-Auth-Request-User", "user", ""),
+			claimHeader("X-Auth-Request-Email", "email", ""),
+			claimHeader("X-Auth-Request-Groups", "groups", ""),
+			claimHeader("X-Auth-Request-Preferred-Username", "preferred_username", ""),
+		}
+
+		cfg.InjectResponseHeaders = xAuthRequestHeaders
+		cfg.InjectRequestHeaders = append(cfg.InjectRequestHeaders, xAuthRequestHeaders...)
+
+		for _, claim := range o.xAuthRequestClaims {
+			cfg.InjectRequestHeaders = append(cfg.InjectRequestHeaders, claimHeader(XAuthRequestHeader(claim), claim, ""))
+		}
+	}
+
+	if o.metricsPort > 0 {
+		cfg.MetricsServer = &alphaServer{BindAddress: "0.0.0.0:" + strconv.Itoa(int(o.metricsPort))}
+	}
+
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+
+	return string(b)
+}
@@ -17,8 +17,13 @@ package configuration
 import (
 	"bufio"
 	_ "embed"
+	"regexp"
 	"strconv"
 	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
 )
 
 //go:embed templates/oauth2-proxy.cfg
@@ -41,6 +46,43 @@ type oauth2Config struct {
 	sslInsecureSkipVerify              bool
 	insecureOidcSkipIssuerVerification bool
 	insecureOidcSkipNonce              bool
+	proxyPrefix                        string
+	whitelistDomains                   []string
+	upstreamTimeout                    string
+	flushInterval                      string
+	cookieCSRFPerRequest               bool
+	cookieCSRFExpire                   string
+	cookieExpire                       string
+	cookieRefresh                      string
+	skipProviderButton                 bool
+	signInPage                         SignInPageConf
+	reverseProxy                       bool
+	upstreamHealthPath                 string
+	extraAudiences                     []string
+	audienceClaims                     []string
+	realClientIPHeader                 string
+	cookieName                         string
+	codeChallengeMethod                string
+	customTemplatesDir                 string
+	skipJWTBearerTokens                bool
+	extraJWTIssuers                    []string
+	provider                           string
+	githubOrg                          string
+	githubTeam                         string
+	azureTenant                        string
+	skipOidcDiscovery                  bool
+	oidcEndpoints                      OidcEndpointsConf
+	allowQuerySemicolons               bool
+	upstreamPathRewrite                *UpstreamPathRewriteConf
+	metricsPort                        int32
+	apiRoutes                          []string
+	cookiePath                         string
+	cookieSameSite                     string
+	setXAuthRequest                    bool
+	xAuthRequestClaims                 []string
+	skipUserHeaders                    bool
+	basicAuthPassword                  string
+	upstreams                          []UpstreamConf
 }
 
 // Parse returns the parsed oauth2 config
@@ -60,6 +102,14 @@ func (o *oauth2Config) Parse() string {
 			if len(parts) == 2 {
 				l := strings.TrimSpace(parts[0])
 				switch l {
+				case "provider":
+					line = quotedOrEmpty(l, o.provider)
+				case "github_org":
+					line = quotedOrEmpty(l, o.githubOrg)
+				case "github_team":
+					line = quotedOrEmpty(l, o.githubTeam)
+				case "azure_tenant":
+					line = quotedOrEmpty(l, o.azureTenant)
 				case "scope":
 					line = l + "=" + "\"" + o.scope + "\""
 				case "client_id":
@@ -80,12 +130,144 @@ func (o *oauth2Config) Parse() string {
 					line = l + "=" + "\"" + o.redirectURL + "\""
 				case "oidc_issuer_url":
 					line = l + "=" + "\"" + o.oidcIssuerURL + "\""
+				// The static endpoints are only taken into account when the discovery is skipped
+				case "skip_oidc_discovery":
+					line = l + "=" + "\"" + strconv.FormatBool(o.skipOidcDiscovery) + "\""
+				case "login_url", "redeem_url", "oidc_jwks_url", "profile_url":
+					if o.skipOidcDiscovery {
+						line = quotedOrEmpty(l, o.oidcEndpoint(l))
+					} else {
+						line = ""
+					}
+				case "oidc_extra_audiences":
+					line = quotedListOrEmpty(l, o.extraAudiences)
+				case "oidc_audience_claims":
+					line = quotedListOrEmpty(l, o.audienceClaims)
+				case "skip_jwt_bearer_tokens":
+					line = l + "=" + "\"" + strconv.FormatBool(o.skipJWTBearerTokens) + "\""
+				// The extra issuers are only taken into account for the skipped bearer tokens
+				case "extra_jwt_issuers":
+					if o.skipJWTBearerTokens {
+						line = quotedListOrEmpty(l, o.extraJWTIssuers)
+					} else {
+						line = ""
+					}
 				case "ssl_insecure_skip_verify":
 					line = l + "=" + "\"" + strconv.FormatBool(o.sslInsecureSkipVerify) + "\""
 				case "insecure_oidc_skip_issuer_verification":
 					line = l + "=" + "\"" + strconv.FormatBool(o.insecureOidcSkipIssuerVerification) + "\""
 				case "insecure_oidc_skip_nonce":
 					line = l + "=" + "\"" + strconv.FormatBool(o.insecureOidcSkipNonce) + "\""
+				case "proxy_prefix":
+					if o.proxyPrefix != "" {
+						line = l + "=" + "\"" + o.proxyPrefix + "\""
+					}
+				case "whitelist_domains":
+					if len(o.whitelistDomains) > 0 {
+						line = l + "=" + "[\"" + strings.Join(o.whitelistDomains, "\", \"") + "\"]"
+					} else {
+						line = ""
+					}
+				// The upstream settings move to the alpha configuration, when it is rendered
+				case "upstream_timeout":
+					if o.upstreamTimeout != "" && !o.alphaConfigEnabled() {
+						line = l + "=" + "\"" + o.upstreamTimeout + "\""
+					} else {
+						line = ""
+					}
+				case "flush_interval":
+					if o.flushInterval != "" && !o.alphaConfigEnabled() {
+						line = l + "=" + "\"" + o.flushInterval + "\""
+					} else {
+						line = ""
+					}
+				case "cookie_csrf_per_request":
+					line = l + "=" + "\"" + strconv.FormatBool(o.cookieCSRFPerRequest) + "\""
+				case "cookie_csrf_expire":
+					if o.cookieCSRFExpire != "" {
+						line = l + "=" + "\"" + o.cookieCSRFExpire + "\""
+					} else {
+						line = ""
+					}
+				case "cookie_expire":
+					line = quotedOrEmpty(l, o.cookieExpire)
+				case "cookie_refresh":
+					line = quotedOrEmpty(l, o.cookieRefresh)
+				case "skip_provider_button":
+					line = l + "=" + "\"" + strconv.FormatBool(o.skipProviderButton) + "\""
+				// The sign-in page texts are free-form, hence they are quoted and escaped
+				case "banner":
+					line = quotedOrEmpty(l, o.signInPage.Banner)
+				case "footer":
+					line = quotedOrEmpty(l, o.signInPage.Footer)
+				case "custom_sign_in_logo":
+					line = quotedOrEmpty(l, o.signInPage.Logo)
+				// Rendered only when enabled, so that the configurations of the existing targets are unchanged
+				case "allow_query_semicolons":
+					if o.allowQuerySemicolons {
+						line = l + "=" + "\"true\""
+					} else {
+						line = ""
+					}
+				case "reverse_proxy":
+					line = l + "=" + "\"" + strconv.FormatBool(o.reverseProxy) + "\""
+				case "real_client_ip_header":
+					line = quotedOrEmpty(l, o.realClientIPHeader)
+				case "cookie_name":
+					line = quotedOrEmpty(l, o.cookieName)
+				// Rendered only for a base path, so that the configurations of the targets at the root path are unchanged
+				case "cookie_path":
+					if o.cookiePath != "/" {
+						line = quotedOrEmpty(l, o.cookiePath)
+					} else {
+						line = ""
+					}
+				case "cookie_samesite":
+					line = quotedOrEmpty(l, o.cookieSameSite)
+				// The browsers reject the cookies with SameSite=None without the Secure attribute
+				case "cookie_secure":
+					if o.cookieSameSite == "none" {
+						line = l + "=" + "\"true\""
+					} else {
+						line = ""
+					}
+				case "code_challenge_method":
+					line = quotedOrEmpty(l, o.codeChallengeMethod)
+				case "custom_templates_dir":
+					line = quotedOrEmpty(l, o.customTemplatesDir)
+				case "api_routes":
+					line = quotedListOrEmpty(l, o.apiRoutes)
+				// The headers move to the alpha configuration, when it is rendered. The legacy basic auth implies the user
+				// headers, hence both are disabled to skip the user headers.
+				case "pass_user_headers":
+					if o.skipUserHeaders && o.basicAuthPassword == "" && !o.alphaConfigEnabled() {
+						line = l + "=" + "\"false\""
+					} else {
+						line = ""
+					}
+				case "pass_basic_auth":
+					switch {
+					case o.alphaConfigEnabled():
+						line = ""
+					case o.basicAuthPassword != "":
+						line = l + "=" + "\"true\""
+					case o.skipUserHeaders:
+						line = l + "=" + "\"false\""
+					default:
+						line = ""
+					}
+				case "basic_auth_password":
+					if !o.alphaConfigEnabled() {
+						line = quotedOrEmpty(l, o.basicAuthPassword)
+					} else {
+						line = ""
+					}
+				case "skip_auth_routes":
+					if o.upstreamHealthPath != "" {
+						line = l + "=" + "[" + strconv.Quote("GET=^"+regexp.QuoteMeta(o.upstreamHealthPath)+"$") + "]"
+					} else {
+						line = ""
+					}
 				}
 			}
 		}
@@ -100,6 +282,107 @@ func (o *oauth2Config) Parse() string {
 	return strings.TrimSuffix(b, "\n")
 }
 
+// alphaConfigEnabled returns true when the proxy is configured through an additional alpha configuration, for the
+// settings which the legacy configuration does not support
+func (o *oauth2Config) alphaConfigEnabled() bool {
+	return o.upstreamPathRewrite != nil || o.setXAuthRequest || len(o.upstreams) > 0
+}
+
+// oidcEndpoint returns the static endpoint of an oauth2-proxy setting
+func (o *oauth2Config) oidcEndpoint(key string) string {
+	switch key {
+	case "login_url":
+		return o.oidcEndpoints.AuthorizationURL
+	case "redeem_url":
+		return o.oidcEndpoints.TokenURL
+	case "oidc_jwks_url":
+		return o.oidcEndpoints.JWKSURL
+	case "profile_url":
+		return o.oidcEndpoints.UserInfoURL
+	}
+
+	return ""
+}
+
+func quotedOrEmpty(key, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return key + "=" + strconv.Quote(value)
+}
+
+func quotedListOrEmpty(key string, values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+
+	return key + "=" + "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// GetOauth2ProxyOptions returns the oauth2-proxy configuration options for the given workload target
+func (c *OIDCAppsControllerConfig) GetOauth2ProxyOptions(object client.Object) []OptOauth2 {
+	return []OptOauth2{
+		WithClientID(c.GetClientID(object)),
+		// The client secret file is rendered only when there is no client secret
+		WithClientSecretFile("/dev/null"),
+		WithClientSecret(c.GetClientSecret(object)),
+		WithScope(c.GetScope(object)),
+		WithRedirectURL(c.GetRedirectURL(object)),
+		WithOidcIssuerURL(c.GetOidcIssuerURL(object)),
+		WithExtraAudiences(c.GetOauth2ProxyExtraAudiences(object)...),
+		WithAudienceClaims(c.GetOauth2ProxyAudienceClaims(object)...),
+		EnableSslInsecureSkipVerify(c.GetSslInsecureSkipVerify(object)),
+		EnableInsecureOidcSkipIssuerVerification(c.GetInsecureOidcSkipIssuerVerification(object)),
+		EnableInsecureOidcSkipNonce(c.GetInsecureOidcSkipNonce(object)),
+		WithProxyPrefix(c.GetOauth2ProxyPrefix(object)),
+		WithWhitelistDomains(c.GetOauth2ProxyWhitelistDomains(object)...),
+		WithUpstreamTimeout(c.GetOauth2ProxyUpstreamTimeout(object)),
+		WithFlushInterval(c.GetOauth2ProxyFlushInterval(object)),
+		EnableCookieCSRFPerRequest(c.GetOauth2ProxyCookieCSRFPerRequest(object)),
+		WithCookieCSRFExpire(c.GetOauth2ProxyCookieCSRFExpire(object)),
+		WithCookieExpiration(c.GetOauth2ProxyCookieExpire(object), c.GetOauth2ProxyCookieRefresh(object)),
+		EnableSkipProviderButton(c.GetOauth2ProxySkipProviderButton(object)),
+		WithSignInPage(c.GetOauth2ProxySignInPage(object)),
+		EnableReverseProxy(c.GetOauth2ProxyReverseProxy(object)),
+		WithRealClientIPHeader(c.GetOauth2ProxyRealClientIPHeader(object)),
+		WithUpstreamHealthPath(c.GetOauth2ProxyUpstreamHealthPath(object)),
+		WithCookieName(c.GetOauth2ProxyCookieName(object)),
+		WithCodeChallengeMethod(c.GetOauth2ProxyCodeChallengeMethod(object)),
+		WithCustomTemplatesDir(c.getOauth2ProxyCustomTemplatesDir(object)),
+		EnableSkipJWTBearerTokens(c.GetOauth2ProxySkipJWTBearerTokens(object)),
+		WithExtraJWTIssuers(c.GetOauth2ProxyExtraJWTIssuers(object)...),
+		WithProvider(c.GetOauth2ProxyProvider(object)),
+		WithGitHubOrg(c.GetOauth2ProxyGitHubOrg(object), c.GetOauth2ProxyGitHubTeam(object)),
+		WithAzureTenant(c.GetOauth2ProxyAzureTenant(object)),
+		WithStaticOidcEndpoints(c.GetOauth2ProxySkipOidcDiscovery(object), c.GetOauth2ProxyOidcEndpoints(object)),
+		EnableAllowQuerySemicolons(c.GetOauth2ProxyAllowQuerySemicolons(object)),
+		WithUpstreamPathRewrite(c.GetOauth2ProxyUpstreamPathRewrite(object)),
+		WithMetricsPort(c.GetOauth2ProxyMetricsPort(object)),
+		WithAPIRoutes(c.GetOauth2ProxyAPIRoutes(object)...),
+		WithCookiePath(c.GetOauth2ProxyCookiePath(object)),
+		WithCookieSameSite(c.GetOauth2ProxyCookieSameSite(object)),
+		WithXAuthRequest(c.GetOauth2ProxySetXAuthRequest(object), c.GetOauth2ProxyXAuthRequestClaims(object)...),
+		WithUpstreams(c.GetUpstreams(object)...),
+		WithPassUserHeaders(c.GetOauth2ProxyPassUserHeaders(object)),
+		WithBasicAuthPassword(c.GetOauth2ProxyBasicAuthPassword(object)),
+	}
+}
+
+// getOauth2ProxyCustomTemplatesDir returns the mount path of the custom templates, when they are configured
+func (c *OIDCAppsControllerConfig) getOauth2ProxyCustomTemplatesDir(object client.Object) string {
+	if c.GetOauth2ProxyCustomTemplatesName(object) == "" {
+		return ""
+	}
+
+	return constants.Oauth2TemplatesDir
+}
+
 // NewOAuth2Config returns a new oauth2 config
 func NewOAuth2Config(opts ...OptOauth2) configParser {
 	cfg := oauth2Config{}
@@ -172,3 +455,242 @@ func EnableInsecureOidcSkipNonce(b bool) OptOauth2 {
 		o.insecureOidcSkipNonce = b
 	}
 }
+
+// WithProxyPrefix sets the oauth2-proxy endpoints prefix
+func WithProxyPrefix(prefix string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.proxyPrefix = prefix
+	}
+}
+
+// WithWhitelistDomains sets the domains allowed as redirect targets after login
+func WithWhitelistDomains(domains ...string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.whitelistDomains = domains
+	}
+}
+
+// WithUpstreamTimeout sets the maximum duration of an upstream request
+func WithUpstreamTimeout(d string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.upstreamTimeout = d
+	}
+}
+
+// WithFlushInterval sets the period of flushing the upstream response
+func WithFlushInterval(d string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.flushInterval = d
+	}
+}
+
+// EnableCookieCSRFPerRequest sets a unique CSRF cookie per authentication request
+func EnableCookieCSRFPerRequest(b bool) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.cookieCSRFPerRequest = b
+	}
+}
+
+// WithCookieCSRFExpire sets the lifetime of the CSRF cookie
+func WithCookieCSRFExpire(d string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.cookieCSRFExpire = d
+	}
+}
+
+// EnableSkipProviderButton sets skipping of the oauth2-proxy sign-in page
+func EnableSkipProviderButton(b bool) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.skipProviderButton = b
+	}
+}
+
+// WithSignInPage sets the oauth2-proxy sign-in page customizations
+func WithSignInPage(page SignInPageConf) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.signInPage = page
+	}
+}
+
+// EnableReverseProxy sets trusting the X-Forwarded-* headers of the fronting ingress controller
+func EnableReverseProxy(b bool) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.reverseProxy = b
+	}
+}
+
+// WithRealClientIPHeader sets the header holding the client IP
+func WithRealClientIPHeader(header string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.realClientIPHeader = header
+	}
+}
+
+// WithUpstreamHealthPath sets the upstream health path, which is served without authentication
+func WithUpstreamHealthPath(path string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.upstreamHealthPath = path
+	}
+}
+
+// WithExtraAudiences sets the token audiences accepted in addition to the client id
+func WithExtraAudiences(audiences ...string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.extraAudiences = audiences
+	}
+}
+
+// WithAudienceClaims sets the token claims holding the audience
+func WithAudienceClaims(claims ...string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.audienceClaims = claims
+	}
+}
+
+// WithCookieName sets the name of the session cookie
+func WithCookieName(name string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.cookieName = name
+	}
+}
+
+// WithCodeChallengeMethod sets the PKCE code challenge method
+func WithCodeChallengeMethod(method string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.codeChallengeMethod = method
+	}
+}
+
+// WithCustomTemplatesDir sets the directory of the custom sign-in and error page templates
+func WithCustomTemplatesDir(dir string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.customTemplatesDir = dir
+	}
+}
+
+// EnableSkipJWTBearerTokens sets accepting the bearer JWTs of API clients without a login redirect
+func EnableSkipJWTBearerTokens(b bool) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.skipJWTBearerTokens = b
+	}
+}
+
+// WithExtraJWTIssuers sets the additional issuers of the accepted bearer JWTs as <issuer URL>=<audience>
+func WithExtraJWTIssuers(issuers ...string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.extraJWTIssuers = issuers
+	}
+}
+
+// WithProvider sets the oauth2-proxy provider type
+func WithProvider(provider string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.provider = provider
+	}
+}
+
+// WithGitHubOrg sets the organization and the teams the logins of the github provider are restricted to
+func WithGitHubOrg(org, team string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.githubOrg = org
+		o.githubTeam = team
+	}
+}
+
+// WithAzureTenant sets the directory tenant of the azure provider
+func WithAzureTenant(tenant string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.azureTenant = tenant
+	}
+}
+
+// WithStaticOidcEndpoints skips the OIDC discovery and sets the static endpoints of the OIDC provider
+func WithStaticOidcEndpoints(skipDiscovery bool, endpoints OidcEndpointsConf) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.skipOidcDiscovery = skipDiscovery
+		o.oidcEndpoints = endpoints
+	}
+}
+
+// EnableAllowQuerySemicolons sets keeping the semicolons of the query strings as separators
+func EnableAllowQuerySemicolons(b bool) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.allowQuerySemicolons = b
+	}
+}
+
+// WithUpstreamPathRewrite sets the rewrite of the request paths forwarded to the upstream, nil for none
+func WithUpstreamPathRewrite(rewrite *UpstreamPathRewriteConf) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.upstreamPathRewrite = rewrite
+	}
+}
+
+// WithMetricsPort sets the port of the metrics endpoint, zero for none. It is rendered in the alpha configuration only.
+func WithMetricsPort(port int32) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.metricsPort = port
+	}
+}
+
+// WithCookieExpiration sets the lifetime of the session cookie and the period after which the session of an active
+// user is refreshed, empty for the defaults
+func WithCookieExpiration(expire, refresh string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.cookieExpire = expire
+		o.cookieRefresh = refresh
+	}
+}
+
+// WithUpstreams sets the additional upstreams, which are routed to by their path through their kube-rbac-proxy sidecars
+func WithUpstreams(upstreams ...UpstreamConf) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.upstreams = upstreams
+	}
+}
+
+// WithAPIRoutes sets the regular expressions of the API request paths, which respond with a 401 instead of redirecting
+// to the login
+func WithAPIRoutes(routes ...string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.apiRoutes = routes
+	}
+}
+
+// WithCookiePath sets the path of the session cookie, empty or "/" for the oauth2-proxy default
+func WithCookiePath(p string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.cookiePath = p
+	}
+}
+
+// WithCookieSameSite sets the SameSite attribute of the cookies, empty for the browser default
+func WithCookieSameSite(mode string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.cookieSameSite = mode
+	}
+}
+
+// WithPassUserHeaders sets passing the user identity to the upstream in the X-Forwarded-* headers
+func WithPassUserHeaders(enabled bool) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.skipUserHeaders = !enabled
+	}
+}
+
+// WithBasicAuthPassword sets passing the user to the upstream in a basic Authorization header with the given password,
+// disabled when empty
+func WithBasicAuthPassword(password string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.basicAuthPassword = password
+	}
+}
+
+// WithXAuthRequest sets passing the user identity and the additional token claims to the upstream in the
+// X-Auth-Request headers
+func WithXAuthRequest(enabled bool, claims ...string) OptOauth2 {
+	return func(o *oauth2Config) {
+		o.setXAuthRequest = enabled
+		o.xAuthRequestClaims = claims
+	}
+}
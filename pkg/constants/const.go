@@ -23,12 +23,92 @@ const (
 	AnnotationKey = "oidc-application-controller/component"
 	// AnnotationSuffixKey holds the name suffix of the mounted confguration secrets
 	AnnotationSuffixKey = "oidc-application-controller/suffix"
+	// AnnotationAppliedSuffixKey holds the name suffix of the resources reconciled for the target workload
+	AnnotationAppliedSuffixKey = "oidc-application-controller/applied-suffix"
+	// AnnotationAppliedOauth2ChecksumKey holds the checksum of the oauth2-proxy configuration the pods of the target
+	// workload were last restarted for
+	AnnotationAppliedOauth2ChecksumKey = "oidc-application-controller/applied-oauth2-secret-checksum"
+	// AnnotationStatusKey holds the JSON summary of the auth-injection state of the target workload, as of its last
+	// reconcile
+	AnnotationStatusKey = "oidc-application-controller/status"
+	// AnnotationOauth2PortOwnerKey holds the owner, in the form of the owner label, of the oauth2-proxy port added to
+	// a reused target service
+	AnnotationOauth2PortOwnerKey = "oidc-application-controller/oauth2-port-owner"
+	// AnnotationWhitelistDomainsKey holds a comma separated list of additional oauth2-proxy redirect whitelist domains
+	AnnotationWhitelistDomainsKey = "oidc-application-controller/whitelist-domains"
+	// AnnotationAllowLabelRemovalKey allows the removal of the oidc-apps label from a protected workload when set to "true"
+	AnnotationAllowLabelRemovalKey = "oidc-application-controller/allow-label-removal"
+	// AnnotationPendingRestartKey marks a former target workload, whose pods are still to be restarted without the
+	// proxies
+	AnnotationPendingRestartKey = "oidc-application-controller/pending-restart"
+	// AnnotationUpstreamTimeoutKey overrides the oauth2-proxy upstream timeout of the target workload
+	AnnotationUpstreamTimeoutKey = "oidc-application-controller/upstream-timeout"
+	// AnnotationFlushIntervalKey overrides the oauth2-proxy response flush interval of the target workload
+	AnnotationFlushIntervalKey = "oidc-application-controller/flush-interval"
+	// AnnotationShutdownDrainKey overrides the drain period of the proxies of the target workload upon the termination
+	// of its pods
+	AnnotationShutdownDrainKey = "oidc-application-controller/shutdown-drain"
+	// AnnotationCookiePathKey overrides the path of the oauth2-proxy session cookie, which defaults to the ingress base
+	// path of the target workload
+	AnnotationCookiePathKey = "oidc-application-controller/cookie-path"
+	// AnnotationCookieSameSiteKey overrides the SameSite attribute of the oauth2-proxy cookies, lax, strict or none
+	AnnotationCookieSameSiteKey = "oidc-application-controller/cookie-samesite"
+	// AnnotationCookieSecretMaxAgeKey overrides the maximum age of the oauth2-proxy cookie secret of the target workload
+	AnnotationCookieSecretMaxAgeKey = "oidc-application-controller/cookie-secret-max-age"
+	// AnnotationCookieSecretRotatedAtKey holds the RFC 3339 time of the generation of the cookie secret in the oauth2
+	// proxy configuration secret
+	AnnotationCookieSecretRotatedAtKey = "oidc-application-controller/cookie-secret-rotated-at" // #nosec G101 -- This is a false positive
+	// AnnotationProxyBodySizeKey overrides the maximum request body size accepted by the ingress, e.g. 100m
+	AnnotationProxyBodySizeKey = "oidc-application-controller/proxy-body-size"
+	// AnnotationSkipProviderButtonKey overrides whether oauth2-proxy skips its sign-in page, "true" or "false"
+	AnnotationSkipProviderButtonKey = "oidc-application-controller/skip-provider-button"
+	// AnnotationProviderKey overrides the oauth2-proxy provider type of the target workload, e.g. oidc or github
+	AnnotationProviderKey = "oidc-application-controller/provider"
+	// AnnotationServiceTypeKey overrides the type of the generated oauth2 service, ClusterIP, NodePort or LoadBalancer
+	AnnotationServiceTypeKey = "oidc-application-controller/service-type"
+	// AnnotationDisableOwnerReferencesKey omits the owner references on the generated resources when set to "true"
+	AnnotationDisableOwnerReferencesKey = "oidc-application-controller/disable-owner-references"
+	// AnnotationResourceAttributesNamespaceKey overrides the namespace of the kube-rbac-proxy resource attributes, an
+	// empty value means cluster scoped
+	AnnotationResourceAttributesNamespaceKey = "oidc-apps.extensions.gardener.cloud/resource-attributes-namespace"
+	// AnnotationOidcCASecretKey references the secret key holding the trusted CA bundle of the OIDC issuer of a
+	// workload as <secret name>/<key>, where the key defaults to ca.crt. It overrides the configured oidc ca bundle.
+	AnnotationOidcCASecretKey = "oidc-apps.extensions.gardener.cloud/oidc-ca-secret"
+	// AnnotationInsecureSkipVerifyKey skips the TLS verification of the OIDC issuer by the oauth2-proxy when set to
+	// "true", e.g. for self-signed IdPs in development landscapes
+	AnnotationInsecureSkipVerifyKey = "oidc-apps.extensions.gardener.cloud/insecure-skip-verify"
+	// AnnotationManagedByVersionKey holds the version of the controller which last reconciled a generated resource
+	AnnotationManagedByVersionKey = "oidc-application-controller/managed-by-version"
+	// AnnotationManagedLabelsKey holds the label keys set by the controller on a generated resource
+	AnnotationManagedLabelsKey = "oidc-application-controller/managed-labels"
+	// AnnotationManagedAnnotationsKey holds the annotation keys set by the controller on a generated resource
+	AnnotationManagedAnnotationsKey = "oidc-application-controller/managed-annotations"
 	// AnnotationOauth2SecertCehcksumKey holds the checksum of the ouath2 proxy confguration secret
 	AnnotationOauth2SecertCehcksumKey = "oidc-application-controller/oauth2-secret-checksum"
+	// AnnotationIngressClassKey holds the ingress class assigned to a generated ingress, resolved from the target
+	// configuration, e.g. by the round-robin assignment of the per-pod ingresses
+	AnnotationIngressClassKey = "oidc-application-controller/ingress-class"
+	// AnnotationRotatedAtKey holds the RFC 3339 time of the creation or the last content change of the oauth2 proxy
+	// configuration secret
+	AnnotationRotatedAtKey = "oidc-application-controller/rotated-at"
+	// AnnotationGeneratedFromKey holds the deterministic name of a generated resource named by the API server, which
+	// refers it back to its desired resource
+	AnnotationGeneratedFromKey = "oidc-application-controller/generated-from"
+	// AnnotationIngressModeKey selects how the pods of a target statefulset or deployment are exposed, a service and
+	// ingress per pod (per-pod) or a single service and ingress for the workload (single). The statefulsets default to
+	// per-pod, the deployments to single.
+	AnnotationIngressModeKey = "oidc-application-controller/ingress-mode"
+	// AnnotationSharedIngressOwnerPrefix prefixes the annotations of a shared ingress, which hold the base path
+	// registered by a workload. The annotation name is the owner label value of the workload.
+	AnnotationSharedIngressOwnerPrefix = "shared-ingress.oidc-application-controller/"
 	// PodWebHookPath is the context path of the mutating webhook for pods
 	PodWebHookPath = "/oidc-mutate-v1-pod"
 	// VpaWebHookPath is the context path of the mutating webhook for pods
 	VpaWebHookPath = "/oidc-mutate-v1-vpa"
+	// WorkloadWebHookPath is the context path of the validating webhook for the target workloads
+	WorkloadWebHookPath = "/oidc-validate-v1-workload"
+	// DebugOauth2ConfigPath is the context path of the debug endpoint serving the rendered oauth2-proxy configuration
+	DebugOauth2ConfigPath = "/debug/oauth2-config"
 	// NAMESPACE is the name of the required environment variable
 	NAMESPACE = "NAMESPACE"
 
@@ -48,11 +128,25 @@ const (
 	ServiceNameOauth2Service = "oauth2-service"
 	// IngressName is the name of the oauth2 ingress
 	IngressName = "oauth2-ingress"
+	// SharedIngressName is the name prefix of the shared oauth2 ingresses, suffixed with the hash of their host
+	SharedIngressName = "oauth2-ingress-shared"
+	// NetworkPolicyNameOauth2Metrics is the name of the network policy restricting the access to the oauth2-proxy
+	// metrics port
+	NetworkPolicyNameOauth2Metrics = "oauth2-metrics"
+	// ServicePortNameOauth2Proxy is the name of the oauth2-proxy port added to a reused target service
+	ServicePortNameOauth2Proxy = "oauth2-proxy"
 
 	// LabelKey is the label added to dependent configuration secrets
 	LabelKey = "oidc-application-controller/component"
 	// LabelValue is the label added to dependent configuration secrets
 	LabelValue = "oidc-apps"
+	// LabelAppNameKey is the well-known label with the name of the application run by a workload
+	LabelAppNameKey = "app.kubernetes.io/name"
+	// LabelPodNameHashKey holds the hash of the name of a deployment pod exposed through a dedicated service and
+	// ingress. It distinguishes the pod host, as the deployment pods have no stable ordinal.
+	LabelPodNameHashKey = "oidc-application-controller/pod-name-hash"
+	// LabelOwnerKey identifies the target workload owning a dependent resource, also after the workload is deleted
+	LabelOwnerKey = "oidc-application-controller/owner"
 	// SecretLabelKey is the label added to dependent configuration secrets
 	SecretLabelKey = "oidc-application-controller/secret"
 	// Oauth2LabelValue is the value of the Label
@@ -73,17 +167,69 @@ const (
 
 	// Oauth2VolumeName is the volume name of the oauth2-proxy configuration
 	Oauth2VolumeName = "oauth2-proxy"
+	// Oauth2CookieSecretKey is the key of the cookie secret in the oauth2 secret
+	Oauth2CookieSecretKey = "cookie-secret" // #nosec G101 -- This is a false positive
+	// Oauth2AlphaConfigKey is the oauth2 secret key of the oauth2-proxy alpha configuration, which is present only
+	// when the upstream paths are rewritten or the X-Auth-Request headers are set
+	Oauth2AlphaConfigKey = "oauth2-proxy-alpha.yaml"
+	// Oauth2TemplatesVolumeName is the volume name of the oauth2-proxy custom templates
+	Oauth2TemplatesVolumeName = "oauth2-proxy-templates"
+	// Oauth2TemplatesDir is the mount path of the oauth2-proxy custom templates
+	Oauth2TemplatesDir = "/etc/oauth2-proxy-templates"
+	// SignInPageBannerKey is the key of the sign-in page banner in the referenced config map
+	SignInPageBannerKey = "banner"
+	// SignInPageFooterKey is the key of the sign-in page footer in the referenced config map
+	SignInPageFooterKey = "footer"
 	// KubeRbacProxyVolumeName is the volume name of the kube-rbac-proxy configuration
 	KubeRbacProxyVolumeName = "kube-rbac-proxy"
+	// KubeRbacProxyAPIAccessVolumeName is the volume name of the kube-rbac-proxy service account token trusting a
+	// custom API server CA
+	KubeRbacProxyAPIAccessVolumeName = "kube-rbac-proxy-api-access"
 
 	// GardenKubeconfig is an environment variable pointing at the default extension access token, if the custom one is not provided
 	GardenKubeconfig = "GARDEN_KUBECONFIG"
 	// GardenAccessToken is an environment variable pointing at a custom access token
 	GardenAccessToken = "GARDEN_ACCESS_TOKEN"
+	// GardenServerURL is an environment variable with the garden API server URL, used together with the access token
+	// when there is no mounted garden kubeconfig
+	GardenServerURL = "GARDEN_SERVER_URL"
+	// GardenCAFile is an environment variable pointing at the garden API server CA bundle
+	GardenCAFile = "GARDEN_CA_FILE"
+	// GardenClusterNamespace is an environment variable scoping the lookup of the Cluster resources to a namespace,
+	// they are looked up cluster-wide when it is not set
+	GardenClusterNamespace = "GARDEN_CLUSTER_NAMESPACE"
+	// GardenClientTimeout is an environment variable with the deadline of the Cluster resource lookups, e.g. 10s
+	GardenClientTimeout = "GARDEN_CLIENT_TIMEOUT"
 	// GardenNamespace is the default k8s namespace containing seed workloads
 	GardenNamespace = "garden"
+	// GardenNamespaces is an environment variable with the comma separated namespaces containing seed workloads, where
+	// an entry ending with * matches the namespaces by prefix, e.g. garden,garden-*. Defaults to GardenNamespace
+	GardenNamespaces = "GARDEN_NAMESPACES"
 	// GardenSeedDomainName is the default domain name of the seed cluster, where the extension is running
 	GardenSeedDomainName = "GARDEN_SEED_DOMAIN_NAME"
 	// GardenSeedOauth2ProxyClientID is the oidc clientId for the seed cluster, where the extension is running
 	GardenSeedOauth2ProxyClientID = "GARDEN_SEED_OAUTH2_PROXY_CLIENT_ID"
+
+	// NginxAffinityAnnotation is the ingress-nginx annotation enabling the session affinity towards the backend pods
+	NginxAffinityAnnotation = "nginx.ingress.kubernetes.io/affinity"
+	// NginxAffinityModeAnnotation is the ingress-nginx annotation setting the session affinity mode
+	NginxAffinityModeAnnotation = "nginx.ingress.kubernetes.io/affinity-mode"
+	// NginxSessionCookieNameAnnotation is the ingress-nginx annotation naming the session affinity cookie
+	NginxSessionCookieNameAnnotation = "nginx.ingress.kubernetes.io/session-cookie-name"
+	// IngressClassAnnotation is the deprecated ingress class annotation, which takes precedence over the ingress class
+	// name with some ingress controllers, e.g. ingress-nginx
+	IngressClassAnnotation = "kubernetes.io/ingress.class"
+	// NginxProxyBodySizeAnnotation is the ingress-nginx annotation limiting the size of the request bodies
+	NginxProxyBodySizeAnnotation = "nginx.ingress.kubernetes.io/proxy-body-size"
+	// ExternalDNSHostnameAnnotation is the external-dns annotation listing the DNS records to create for an ingress
+	ExternalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+	// IngressModePerPod exposes each pod of a statefulset or a deployment through a dedicated service and ingress
+	IngressModePerPod = "per-pod"
+	// IngressModeSingle exposes a workload through a single service and ingress
+	IngressModeSingle = "single"
+
+	// RolloutAPIVersion is the api version of the Argo Rollouts custom workload
+	RolloutAPIVersion = "argoproj.io/v1alpha1"
+	// RolloutKind is the kind of the Argo Rollouts custom workload
+	RolloutKind = "Rollout"
 )
@@ -0,0 +1,31 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package constants
+
+// ResourceName returns the deterministic name of a managed resource, the base name followed by the name suffix of the
+// target: <base>-<suffix>
+func ResourceName(base, suffix string) string {
+	return base + "-" + suffix
+}
+
+// PodResourceName returns the deterministic name of a managed resource of a statefulset pod, the base name followed by
+// the pod ordinal and the name suffix: <base>-<index>-<suffix>. Without an index it equals the ResourceName.
+func PodResourceName(base, index, suffix string) string {
+	if index == "" {
+		return ResourceName(base, suffix)
+	}
+
+	return ResourceName(base+"-"+index, suffix)
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// restartOnConfigChange restarts the workload, when the checksum of its desired oauth2-proxy configuration differs from
+// the one its pods were last restarted for. The oauth2-proxy reads its configuration only upon start, hence the pods
+// have to be rolled out to pick up the changed secret. The checksum is recorded on the workload in the same patch as
+// the restart annotation, the first reconcile only records it.
+func restartOnConfigChange(ctx context.Context, c client.Client, object client.Object, desired []client.Object) error {
+	if !configuration.GetOIDCAppsControllerConfig().GetRestartOnConfigChange() {
+		return nil
+	}
+
+	checksum := desiredOauth2Checksum(desired)
+	applied, found := object.GetAnnotations()[constants.AnnotationAppliedOauth2ChecksumKey]
+
+	if checksum == "" || applied == checksum {
+		return nil
+	}
+
+	if found && hasOidcAppsPods(ctx, c, object) {
+		log.FromContext(ctx).Info("Restarting the workload upon an oauth2-proxy configuration change")
+
+		return restartWorkload(ctx, c, object, map[string]string{constants.AnnotationAppliedOauth2ChecksumKey: checksum})
+	}
+
+	// Without a previous checksum or running proxies, the checksum is only recorded
+	patch := client.MergeFrom(object.DeepCopyObject().(client.Object))
+	object.SetAnnotations(withAnnotation(object.GetAnnotations(), constants.AnnotationAppliedOauth2ChecksumKey, checksum))
+
+	if err := c.Patch(ctx, object, patch); err != nil {
+		return fmt.Errorf("failed to record the oauth2-proxy configuration checksum: %w", err)
+	}
+
+	return nil
+}
+
+// desiredOauth2Checksum returns the checksum of the desired oauth2-proxy configuration secret
+func desiredOauth2Checksum(desired []client.Object) string {
+	for _, d := range desired {
+		if s, ok := d.(*corev1.Secret); ok {
+			if checksum, found := s.GetAnnotations()[constants.AnnotationOauth2SecertCehcksumKey]; found {
+				return checksum
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func rolloutRestartedAt(g *WithT, rollout *unstructured.Unstructured) string {
+	restartedAt, _, err := unstructured.NestedString(rollout.Object,
+		"spec", "template", "metadata", "annotations", restartedAtAnnotation)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	return restartedAt
+}
+
+func TestRestartOnConfigChange(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.RestartOnConfigChange = ptr.To(true)
+
+	t.Cleanup(func() {
+		cfg.Configuration.RestartOnConfigChange = nil
+		cfg.Configuration.Oauth2Proxy.CookieCSRFExpire = ""
+	})
+
+	rollout := getTargetRollout()
+	replicaSet, pod := getRolloutPod()
+	c := newFakeClient(g, rollout, replicaSet, pod)
+
+	r := &RolloutReconciler{Client: c}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(rollout)}
+	_, err := r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The first reconcile only records the checksum, without restarting the pods
+	g.Expect(c.Get(ctx, request.NamespacedName, rollout)).To(Succeed())
+	checksum := rollout.GetAnnotations()[constants.AnnotationAppliedOauth2ChecksumKey]
+	g.Expect(checksum).NotTo(BeEmpty())
+	g.Expect(rolloutRestartedAt(g, rollout)).To(BeEmpty())
+
+	// An unchanged configuration does not restart the pods
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(ctx, request.NamespacedName, rollout)).To(Succeed())
+	g.Expect(rolloutRestartedAt(g, rollout)).To(BeEmpty())
+
+	// A changed configuration restarts the pods through the pod template annotation, the controller does not bump the
+	// generation itself
+	generation := rollout.GetGeneration()
+	cfg.Configuration.Oauth2Proxy.CookieCSRFExpire = "5m"
+
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(c.Get(ctx, request.NamespacedName, rollout)).To(Succeed())
+	g.Expect(rolloutRestartedAt(g, rollout)).NotTo(BeEmpty())
+	g.Expect(rollout.GetAnnotations()).To(HaveKey(constants.AnnotationAppliedOauth2ChecksumKey))
+	g.Expect(rollout.GetAnnotations()[constants.AnnotationAppliedOauth2ChecksumKey]).NotTo(Equal(checksum))
+	g.Expect(rollout.GetGeneration()).To(Equal(generation))
+}
+
+func TestRestartOnConfigChangeDisabled(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	// Neither the checksum is recorded nor the pods are restarted
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+	g.Expect(deployment.GetAnnotations()).NotTo(HaveKey(constants.AnnotationAppliedOauth2ChecksumKey))
+	g.Expect(deployment.Spec.Template.GetAnnotations()).NotTo(HaveKey(restartedAtAnnotation))
+}
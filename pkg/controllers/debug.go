@@ -0,0 +1,140 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// redacted replaces the sensitive values of the rendered configurations
+const redacted = "REDACTED"
+
+// oauth2ConfigLogLevel is the verbosity of the redacted oauth2-proxy configuration logged upon a failed reconcile, it
+// is above the default --zap-log-level=2 of the chart
+const oauth2ConfigLogLevel = 5
+
+// maxLoggedOauth2ConfigBytes is the maximal length of the logged oauth2-proxy configuration
+const maxLoggedOauth2ConfigBytes = 4096
+
+// Oauth2ConfigHandler serves the oauth2-proxy configuration rendered for a target workload, with the sensitive values
+// redacted. The workload is selected by the kind (Deployment, StatefulSet or Rollout, defaults to Deployment),
+// namespace and name query parameters.
+type Oauth2ConfigHandler struct {
+	Client client.Client
+}
+
+func (h *Oauth2ConfigHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	query := r.URL.Query()
+
+	key := client.ObjectKey{Namespace: query.Get("namespace"), Name: query.Get("name")}
+	if key.Namespace == "" || key.Name == "" {
+		http.Error(w, "namespace and name query parameters are required", http.StatusBadRequest)
+
+		return
+	}
+
+	var object client.Object
+
+	switch query.Get("kind") {
+	case "", "Deployment":
+		object = &appsv1.Deployment{}
+	case "StatefulSet":
+		object = &appsv1.StatefulSet{}
+	case constants.RolloutKind:
+		object = NewRollout()
+	default:
+		http.Error(w, "kind shall be one of Deployment, StatefulSet or Rollout", http.StatusBadRequest)
+
+		return
+	}
+
+	if err := h.Client.Get(r.Context(), key, object); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+
+			return
+		}
+
+		log.FromContext(r.Context()).Error(err, "failed to get the workload", "workload", key)
+		http.Error(w, "failed to get the workload", http.StatusInternalServerError)
+
+		return
+	}
+
+	if !configuration.GetOIDCAppsControllerConfig().Match(object) {
+		http.Error(w, key.String()+" is not an oidc-apps target", http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(redactedOauth2Config(object) + "\n"))
+}
+
+// redactedOauth2ConfigKeys are the keys of the oauth2-proxy configuration holding secrets
+var redactedOauth2ConfigKeys = []string{"client_secret", "basic_auth_password"}
+
+// redactedOauth2Config renders the oauth2-proxy configuration of the target with the values of the secret-bearing keys
+// redacted
+func redactedOauth2Config(object client.Object) string {
+	cfg := configuration.NewOAuth2Config(configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyOptions(object)...).
+		Parse()
+
+	lines := strings.Split(cfg, "\n")
+	for i, line := range lines {
+		key, value, found := strings.Cut(line, "=")
+		if !found || !slices.Contains(redactedOauth2ConfigKeys, strings.TrimSpace(key)) ||
+			strings.TrimSpace(value) == `""` {
+			continue
+		}
+
+		lines[i] = key + "=" + `"` + redacted + `"`
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// logRedactedOauth2Config logs the first bytes of the redacted oauth2-proxy configuration of a target, which failed to
+// reconcile, for troubleshooting
+func logRedactedOauth2Config(ctx context.Context, object client.Object) {
+	_log := log.FromContext(ctx).V(oauth2ConfigLogLevel)
+	if !_log.Enabled() {
+		return
+	}
+
+	cfg := redactedOauth2Config(object)
+	if len(cfg) > maxLoggedOauth2ConfigBytes {
+		cfg = cfg[:maxLoggedOauth2ConfigBytes] + "..."
+	}
+
+	_log.Info("Rendered oauth2-proxy configuration of the failed reconcile", "config", cfg)
+}
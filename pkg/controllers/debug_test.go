@@ -0,0 +1,157 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+)
+
+func TestOauth2ConfigHandler(t *testing.T) {
+	g := NewWithT(t)
+
+	oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Configuration.Oauth2Proxy
+	oauth2Proxy.ClientSecret = "client-secret"
+
+	t.Cleanup(func() { oauth2Proxy.ClientSecret = "" })
+
+	nonTarget := getTargetDeployment()
+	nonTarget.SetName("other")
+	nonTarget.SetLabels(map[string]string{"app": "other"})
+
+	h := &Oauth2ConfigHandler{Client: newFakeClient(g, getTargetDeployment(), getTargetStatefulSet(), nonTarget)}
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+
+		return rec
+	}
+
+	// The sensitive values are redacted
+	rec := get("/debug/oauth2-config?namespace=default&name=nginx")
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Body.String()).To(ContainSubstring(`client_id="client-id"`))
+	g.Expect(rec.Body.String()).To(ContainSubstring(`client_secret="REDACTED"`))
+	g.Expect(rec.Body.String()).NotTo(ContainSubstring("client-secret"))
+	g.Expect(rec.Body.String()).To(ContainSubstring(`oidc_issuer_url="https://oidc-provider.org"`))
+
+	rec = get("/debug/oauth2-config?kind=StatefulSet&namespace=default&name=nginx")
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Body.String()).To(ContainSubstring(`client_secret="REDACTED"`))
+
+	g.Expect(get("/debug/oauth2-config?namespace=default").Code).To(Equal(http.StatusBadRequest))
+	g.Expect(get("/debug/oauth2-config?kind=Pod&namespace=default&name=nginx").Code).To(Equal(http.StatusBadRequest))
+	g.Expect(get("/debug/oauth2-config?namespace=default&name=missing").Code).To(Equal(http.StatusNotFound))
+	g.Expect(get("/debug/oauth2-config?namespace=default&name=other").Code).To(Equal(http.StatusNotFound))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/oauth2-config?namespace=default&name=nginx", nil))
+	g.Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+}
+
+func TestRedactedOauth2ConfigWithoutClientSecret(t *testing.T) {
+	g := NewWithT(t)
+
+	// Without a client secret the client secret file is rendered, which is not redacted
+	cfg := redactedOauth2Config(getTargetDeployment())
+	g.Expect(cfg).NotTo(ContainSubstring("client_secret="))
+	g.Expect(cfg).To(ContainSubstring(`client_secret_file="/dev/null"`))
+}
+
+func TestRedactedOauth2ConfigBasicAuthPassword(t *testing.T) {
+	g := NewWithT(t)
+
+	oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Configuration.Oauth2Proxy
+	oauth2Proxy.ClientSecret = "top-secret"
+	oauth2Proxy.PassBasicAuth = ptr.To(true)
+	oauth2Proxy.BasicAuthPassword = "basic-auth-secret"
+
+	t.Cleanup(func() {
+		oauth2Proxy.ClientSecret = ""
+		oauth2Proxy.PassBasicAuth = nil
+		oauth2Proxy.BasicAuthPassword = ""
+	})
+
+	// None of the secrets is ever served by the debug endpoint
+	h := &Oauth2ConfigHandler{Client: newFakeClient(g, getTargetDeployment())}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/oauth2-config?namespace=default&name=nginx", nil))
+
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Body.String()).To(ContainSubstring(`basic_auth_password="REDACTED"`))
+	g.Expect(rec.Body.String()).To(ContainSubstring(`client_secret="REDACTED"`))
+	g.Expect(rec.Body.String()).NotTo(ContainSubstring("basic-auth-secret"))
+	g.Expect(rec.Body.String()).NotTo(ContainSubstring("top-secret"))
+}
+
+func TestLogRedactedOauth2ConfigOnFailedReconcile(t *testing.T) {
+	g := NewWithT(t)
+
+	oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Configuration.Oauth2Proxy
+	oauth2Proxy.ClientSecret = "top-secret"
+
+	t.Cleanup(func() { oauth2Proxy.ClientSecret = "" })
+
+	statefulSet := getTargetStatefulSet()
+	fakeClient := newFakeClient(g, statefulSet, getStatefulSetPod(0))
+	c := interceptor.NewClient(fakeClient.(client.WithWatch), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*corev1.Secret); ok {
+				return errors.New("secret creation failed")
+			}
+
+			return c.Create(ctx, obj, opts...)
+		},
+	})
+
+	r := &StatefulSetReconciler{Client: c, Recorder: record.NewFakeRecorder(10)}
+	reconcileWithVerbosity := func(verbosity int) string {
+		buf := &bytes.Buffer{}
+		logger := funcr.New(func(prefix, args string) {
+			buf.WriteString(args + "\n")
+		}, funcr.Options{Verbosity: verbosity})
+
+		ctx := log.IntoContext(context.TODO(), logger)
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(statefulSet)})
+		g.Expect(err).To(MatchError(ContainSubstring("secret creation failed")))
+
+		return buf.String()
+	}
+
+	// The configuration is not logged below its verbosity level
+	g.Expect(reconcileWithVerbosity(0)).NotTo(ContainSubstring("client_id"))
+
+	logs := reconcileWithVerbosity(oauth2ConfigLogLevel)
+	g.Expect(logs).To(ContainSubstring(`client_id=\"client-id\"`))
+	g.Expect(logs).To(ContainSubstring(`client_secret=\"REDACTED\"`))
+	g.Expect(logs).NotTo(ContainSubstring("top-secret"))
+}
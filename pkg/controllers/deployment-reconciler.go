@@ -16,8 +16,11 @@ package controllers
 
 import (
 	"context"
+	"errors"
 
 	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -28,13 +31,36 @@ import (
 // DeploymentReconciler holds configuration for the reconciler
 type DeploymentReconciler struct {
 	Client client.Client
+	// Recorder emits the events of the reconciled targets
+	Recorder record.EventRecorder
+	// RetryBudget is the number of consecutive permanent failures, after which a target is no longer requeued until its
+	// spec changes. Zero requeues forever.
+	RetryBudget int
+	// Shard selects the workloads reconciled by this controller instance
+	Shard Shard
+	// VerifyIngressDNS enables the warning events for the ingress hosts, which do not resolve to the ingress load
+	// balancer. It is disabled by default, as it performs DNS lookups.
+	VerifyIngressDNS bool
 }
 
 // Reconcile creates the auth & zutz secrets mounted to the target deployment
 func (d *DeploymentReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// The workloads of the other shards are reconciled by the other controller instances
+	if !d.Shard.Contains(request.NamespacedName) {
+		return reconcile.Result{}, nil
+	}
+
 	reconciledDeployment := &appsv1.Deployment{}
-	if err := d.Client.Get(ctx, request.NamespacedName, reconciledDeployment); client.IgnoreNotFound(err) != nil {
-		return reconcile.Result{}, err
+	if err := d.Client.Get(ctx, request.NamespacedName, reconciledDeployment); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+
+		forgetDeletedTarget(request.NamespacedName)
+
+		// Clean up the dependent resources, which are not garbage collected without owner references
+		return reconcile.Result{}, deleteOrphanedResources(ctx, d.Client, request.Namespace,
+			ownerLabelValue("Deployment", request.NamespacedName), deletionPropagation())
 	}
 
 	_log := log.FromContext(ctx).WithValues("resourceVersion", reconciledDeployment.GetResourceVersion())
@@ -48,12 +74,11 @@ func (d *DeploymentReconciler) Reconcile(ctx context.Context, request reconcile.
 
 	_log.V(9).Info("handling deployment reconcile request")
 
-	if reconciledDeployment.GetLabels() != nil {
-		if !configuration.GetOIDCAppsControllerConfig().Match(reconciledDeployment) {
-			_log.V(9).Info("reconciled deployment is not an oidc-application-controller target, returning ...")
+	if !configuration.GetOIDCAppsControllerConfig().Match(reconciledDeployment) {
+		_log.V(9).Info("reconciled deployment is not an oidc-application-controller target, returning ...")
 
-			return reconcile.Result{}, nil
-		}
+		// Remove the proxies of a former target, e.g. after the oidc-apps label is removed
+		return reconcile.Result{}, removeAuthStack(ctx, d.Client, reconciledDeployment)
 	}
 
 	if !hasOidcAppsPods(ctx, d.Client, reconciledDeployment) {
@@ -64,18 +89,50 @@ func (d *DeploymentReconciler) Reconcile(ctx context.Context, request reconcile.
 	if !reconciledDeployment.GetDeletionTimestamp().IsZero() {
 		_log.V(9).Info("Remove owned resources")
 
-		if err := deleteOwnedResources(ctx, d.Client, reconciledDeployment); err != nil {
+		if err := deleteOwnedResources(ctx, d.Client, reconciledDeployment, deletionPropagation()); err != nil {
 			return reconcile.Result{}, err
 		}
 
+		forgetSuffix(reconciledDeployment)
+		forgetOutcome(reconciledDeployment)
+		forgetRetryBudget(reconciledDeployment)
+
 		return reconcile.Result{}, nil
 	}
 
-	if err := reconcileDeploymentDependencies(ctx, d.Client, reconciledDeployment); err != nil {
+	warnOnUnexposedUpstreamPort(d.Recorder, reconciledDeployment)
+	warnOnInsecureSkipVerify(ctx, d.Recorder, reconciledDeployment)
+
+	if err := reconcileDependencies(ctx, d.Client, reconciledDeployment); err != nil {
+		if !errors.Is(err, errClusterNotFound) {
+			logRedactedOauth2Config(ctx, reconciledDeployment)
+			warnOnSuffixConflict(d.Recorder, reconciledDeployment, err)
+
+			return reconcile.Result{}, spendRetryBudget(ctx, d.Recorder, d.RetryBudget, reconciledDeployment, err)
+		}
+
+		// Requeue with backoff until the Cluster resource of a freshly created shoot appears
+		_log.Info("Cluster resource not found, requeue", "namespace", reconciledDeployment.GetNamespace())
+
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	forgetRetryBudget(reconciledDeployment)
+
+	if d.VerifyIngressDNS {
+		warnOnUnresolvedIngressHosts(ctx, d.Client, d.Recorder, reconciledDeployment)
+	}
+
+	if err := migrateSuffix(ctx, d.Client, reconciledDeployment); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	requeueAfter, err := cookieSecretRotationDelay(ctx, d.Client, reconciledDeployment)
+	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	_log.Info("reconciled deployment successfully")
 
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
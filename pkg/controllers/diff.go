@@ -0,0 +1,243 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// managedResourcesDiff is the difference between the desired and the actual dependent resources of a target workload
+type managedResourcesDiff struct {
+	// Created are the desired resources which do not exist yet
+	Created []client.Object
+	// Updated are the desired resources which exist with a different content
+	Updated []client.Object
+	// Unchanged are the desired resources which exist with the desired content
+	Unchanged []client.Object
+	// Orphaned are the existing resources owned by the workload which are no longer desired
+	Orphaned []client.Object
+}
+
+// diffManagedResources renders the desired dependent resources of the target workload and compares them with the
+// actual resources in the cluster. Only the fields reconciled by the controller are compared.
+func diffManagedResources(ctx context.Context, c client.Client, object client.Object) (managedResourcesDiff, error) {
+	diff := managedResourcesDiff{}
+
+	desired, err := desiredResources(ctx, c, object)
+	if err != nil && !errors.Is(err, errClusterNotFound) {
+		return diff, err
+	}
+
+	actual, err := fetchOwnedResources(ctx, c, object)
+	if err != nil {
+		return diff, err
+	}
+
+	// The former generations of the workload are reported as orphaned
+	if _, err := resolveGeneratedNames(ctx, c, object, desired); err != nil {
+		return diff, err
+	}
+
+	for _, d := range desired {
+		key := client.ObjectKeyFromObject(d)
+
+		existing, ok := d.DeepCopyObject().(client.Object)
+		if !ok {
+			return diff, fmt.Errorf("unexpected object type %T", d)
+		}
+
+		if err := c.Get(ctx, key, existing); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return diff, fmt.Errorf("failed to get %s: %w", key, err)
+			}
+
+			diff.Created = append(diff.Created, d)
+
+			continue
+		}
+
+		if isUpToDate(existing, d) {
+			diff.Unchanged = append(diff.Unchanged, d)
+		} else {
+			diff.Updated = append(diff.Updated, d)
+		}
+	}
+
+	for _, a := range actual {
+		if !containsResource(desired, a) {
+			diff.Orphaned = append(diff.Orphaned, a)
+		}
+	}
+
+	return diff, nil
+}
+
+// fetchOwnedResources returns the oidc-apps secrets, services, ingresses and network policies owned by the target workload
+func fetchOwnedResources(ctx context.Context, c client.Client, object client.Object) ([]client.Object, error) {
+	var owned []client.Object
+
+	// Unlike fetchOidcAppsSecrets, all the generated secrets are fetched, not only the oauth2-proxy ones
+	secrets := &corev1.SecretList{}
+	if err := c.List(ctx, secrets,
+		client.InNamespace(object.GetNamespace()),
+		client.MatchingLabelsSelector{
+			Selector: labels.SelectorFromSet(map[string]string{constants.LabelKey: constants.LabelValue}),
+		},
+	); err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	for i := range secrets.Items {
+		if isAnOwnedResource(object, &secrets.Items[i]) {
+			owned = append(owned, &secrets.Items[i])
+		}
+	}
+
+	services, err := fetchOidcAppsServices(ctx, c, object)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range services.Items {
+		owned = append(owned, &services.Items[i])
+	}
+
+	ingresses, err := fetchOidcAppsIngress(ctx, c, object)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ingresses.Items {
+		owned = append(owned, &ingresses.Items[i])
+	}
+
+	networkPolicies, err := fetchOidcAppsNetworkPolicies(ctx, c, object)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range networkPolicies.Items {
+		owned = append(owned, &networkPolicies.Items[i])
+	}
+
+	return owned, nil
+}
+
+// isUpToDate returns true if the existing resource carries the desired labels, annotations, owner references and
+// content. Foreign labels and annotations on the existing resource are ignored, as they are preserved on patching.
+func isUpToDate(existing, desired client.Object) bool {
+	if !isSubset(existing.GetLabels(), desired.GetLabels()) ||
+		!isSubset(existing.GetAnnotations(), desired.GetAnnotations()) {
+		return false
+	}
+
+	for _, ref := range desired.GetOwnerReferences() {
+		if !slices.ContainsFunc(existing.GetOwnerReferences(), func(r metav1.OwnerReference) bool {
+			return r.UID == ref.UID && r.APIVersion == ref.APIVersion && r.Kind == ref.Kind
+		}) {
+			return false
+		}
+
+		if slices.ContainsFunc(existing.GetOwnerReferences(), func(r metav1.OwnerReference) bool {
+			return isFormerOwnerReference(r, ref)
+		}) {
+			return false
+		}
+	}
+
+	switch d := desired.(type) {
+	case *corev1.Secret:
+		e, ok := existing.(*corev1.Secret)
+
+		return ok && equality.Semantic.DeepEqual(secretContent(e), secretContent(d))
+	case *corev1.Service:
+		e, ok := existing.(*corev1.Service)
+
+		return ok && maps.Equal(e.Spec.Selector, d.Spec.Selector) &&
+			equality.Semantic.DeepEqual(normalizedPorts(e.Spec.Ports), normalizedPorts(d.Spec.Ports))
+	case *networkingv1.Ingress:
+		e, ok := existing.(*networkingv1.Ingress)
+
+		return ok && equality.Semantic.DeepEqual(e.Spec, d.Spec)
+	case *networkingv1.NetworkPolicy:
+		e, ok := existing.(*networkingv1.NetworkPolicy)
+
+		return ok && equality.Semantic.DeepEqual(e.Spec, d.Spec)
+	default:
+		return false
+	}
+}
+
+func isSubset(actual, desired map[string]string) bool {
+	for k, v := range desired {
+		if a, found := actual[k]; !found || a != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsResource(resources []client.Object, object client.Object) bool {
+	for _, r := range resources {
+		if r.GetName() == object.GetName() && r.GetNamespace() == object.GetNamespace() &&
+			fmt.Sprintf("%T", r) == fmt.Sprintf("%T", object) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// secretContent returns the data of the secret, with the string data the API server merges into it
+func secretContent(secret *corev1.Secret) map[string][]byte {
+	content := make(map[string][]byte, len(secret.Data)+len(secret.StringData))
+	maps.Copy(content, secret.Data)
+
+	for k, v := range secret.StringData {
+		content[k] = []byte(v)
+	}
+
+	return content
+}
+
+// normalizedPorts returns the service ports with the protocol defaulted by the API server
+func normalizedPorts(ports []corev1.ServicePort) []corev1.ServicePort {
+	normalized := make([]corev1.ServicePort, 0, len(ports))
+
+	for _, p := range ports {
+		if p.Protocol == "" {
+			p.Protocol = corev1.ProtocolTCP
+		}
+
+		normalized = append(normalized, p)
+	}
+
+	return normalized
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func resourceNames(objects []client.Object) []string {
+	names := make([]string, 0, len(objects))
+	for _, o := range objects {
+		names = append(names, o.GetName())
+	}
+
+	return names
+}
+
+func TestDiffManagedResources(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+	suffix := rand.GenerateSha256("nginx-default")
+
+	// Nothing is reconciled yet, hence all the desired resources are to be created
+	diff, err := diffManagedResources(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resourceNames(diff.Created)).To(ContainElements(
+		constants.SecretNameOauth2Proxy+"-"+suffix,
+		constants.SecretNameResourceAttributes+"-"+suffix,
+		constants.ServiceNameOauth2Service+"-"+suffix,
+		constants.IngressName+"-"+suffix,
+	))
+	g.Expect(diff.Updated).To(BeEmpty())
+	g.Expect(diff.Unchanged).To(BeEmpty())
+	g.Expect(diff.Orphaned).To(BeEmpty())
+
+	// Once reconciled, all the resources are unchanged
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	diff, err = diffManagedResources(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(diff.Created).To(BeEmpty())
+	g.Expect(diff.Updated).To(BeEmpty())
+	g.Expect(diff.Orphaned).To(BeEmpty())
+	g.Expect(diff.Unchanged).NotTo(BeEmpty())
+	g.Expect(resourceNames(diff.Unchanged)).To(ContainElement(constants.IngressName + "-" + suffix))
+
+	// A drifted ingress is to be updated, while foreign annotations do not count as a drift
+	ingress := &networkingv1.Ingress{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: constants.IngressName + "-" + suffix}, ingress)).
+		To(Succeed())
+	ingress.Spec.Rules[0].Host = "drifted.domain.org"
+	g.Expect(c.Update(ctx, ingress)).To(Succeed())
+
+	service := &corev1.Service{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: constants.ServiceNameOauth2Service + "-" + suffix},
+		service)).To(Succeed())
+	service.Annotations["foreign"] = "annotation"
+	g.Expect(c.Update(ctx, service)).To(Succeed())
+
+	// A secret owned by the deployment, which is no longer desired, is orphaned
+	orphan := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      constants.SecretNameOauth2Proxy + "-stale",
+			Namespace: "default",
+			Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: deployment.Name, UID: deployment.UID},
+			},
+		},
+	}
+	g.Expect(c.Create(ctx, orphan)).To(Succeed())
+
+	diff, err = diffManagedResources(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(diff.Created).To(BeEmpty())
+	g.Expect(resourceNames(diff.Updated)).To(ConsistOf(constants.IngressName + "-" + suffix))
+	g.Expect(resourceNames(diff.Unchanged)).To(ContainElement(constants.ServiceNameOauth2Service + "-" + suffix))
+	g.Expect(resourceNames(diff.Orphaned)).To(ConsistOf(orphan.Name))
+}
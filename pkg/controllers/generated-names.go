@@ -0,0 +1,131 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+// reconcileGeneratedNames names the desired ingresses and network policies after the workload generation and deletes
+// the resources of the former generations of the workload
+func reconcileGeneratedNames(ctx context.Context, c client.Client, object client.Object, desired []client.Object) error {
+	formerGenerations, err := resolveGeneratedNames(ctx, c, object, desired)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range formerGenerations {
+		log.FromContext(ctx).Info("Deleting the resource of a former generation of the workload", "name", f.GetName())
+
+		if err := c.Delete(ctx, f, deletionPropagation()); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete %s: %w", f.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// resolveGeneratedNames suffixes the deterministic names of the desired ingresses and network policies with the hash of
+// the workload UID, when enabled. The deterministic name is kept in the generated-from annotation, which refers the
+// existing resources back to them. The names do not depend on the existing resources, so that a stale cache does not
+// lead to duplicates. The existing resources of another name, i.e. of a former generation of the workload, a deleted
+// workload of the same name, are returned for the deletion.
+func resolveGeneratedNames(ctx context.Context, c client.Client, object client.Object, desired []client.Object) (
+	[]client.Object, error) {
+	if !configuration.GetOIDCAppsControllerConfig().GetGenerateNames() {
+		return nil, nil
+	}
+
+	existing, err := fetchGeneratedResources(ctx, c, object)
+	if err != nil {
+		return nil, err
+	}
+
+	var formerGenerations []client.Object
+
+	for _, d := range desired {
+		if !isGeneratedNameResource(d) {
+			continue
+		}
+
+		base := d.GetName()
+		d.SetAnnotations(withAnnotation(maps.Clone(d.GetAnnotations()), constants.AnnotationGeneratedFromKey, base))
+		d.SetName(generatedName(base, object))
+
+		for _, e := range existing {
+			if fmt.Sprintf("%T", e) != fmt.Sprintf("%T", d) ||
+				e.GetAnnotations()[constants.AnnotationGeneratedFromKey] != base ||
+				e.GetName() == d.GetName() || !e.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+
+			formerGenerations = append(formerGenerations, e)
+		}
+	}
+
+	return formerGenerations, nil
+}
+
+// generatedName returns the name of a generated resource of the current generation of the workload
+func generatedName(base string, object client.Object) string {
+	return base + "-" + rand.GenerateSha256(string(object.GetUID()))
+}
+
+// fetchGeneratedResources returns the oidc-apps ingresses and network policies of the workload and of its former
+// generations, which share the owner label
+func fetchGeneratedResources(ctx context.Context, c client.Client, object client.Object) ([]client.Object, error) {
+	ingresses, err := fetchOidcAppsIngress(ctx, c, object)
+	if err != nil {
+		return nil, err
+	}
+
+	networkPolicies, err := fetchOidcAppsNetworkPolicies(ctx, c, object)
+	if err != nil {
+		return nil, err
+	}
+
+	generated := make([]client.Object, 0, len(ingresses.Items)+len(networkPolicies.Items))
+
+	for i := range ingresses.Items {
+		generated = append(generated, &ingresses.Items[i])
+	}
+
+	for i := range networkPolicies.Items {
+		generated = append(generated, &networkPolicies.Items[i])
+	}
+
+	return generated, nil
+}
+
+// isGeneratedNameResource returns true for the resources, which are named per workload generation when enabled. The
+// secrets and the services are referenced by the pods and the ingresses, hence they keep their deterministic names.
+func isGeneratedNameResource(object client.Object) bool {
+	switch object.(type) {
+	case *networkingv1.Ingress, *networkingv1.NetworkPolicy:
+		return true
+	default:
+		return false
+	}
+}
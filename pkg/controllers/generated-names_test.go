@@ -0,0 +1,158 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func TestGeneratedNames(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.GenerateNames = ptr.To(true)
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("generated-names-deployment")
+
+	t.Cleanup(func() {
+		cfg.Configuration.GenerateNames = nil
+		forgetSuffix(deployment)
+		forgetOutcome(deployment)
+	})
+
+	c := newFakeClient(g, deployment)
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	base := resourceName(constants.IngressName, deployment)
+
+	ingresses, err := fetchOidcAppsIngress(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+
+	ingress := ingresses.Items[0]
+	g.Expect(ingress.GetName()).To(Equal(base + "-" + rand.GenerateSha256("generated-names-deployment")))
+	g.Expect(ingress.GetAnnotations()).To(HaveKeyWithValue(constants.AnnotationGeneratedFromKey, base))
+
+	// The secrets and the services keep their deterministic names
+	service := &corev1.Service{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default",
+		Name: resourceName(constants.ServiceNameOauth2Service, deployment)}, service)).To(Succeed())
+
+	// A subsequent reconcile patches the generated ingress
+	forgetOutcome(deployment)
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	ingresses, err = fetchOidcAppsIngress(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+	g.Expect(ingresses.Items[0].GetName()).To(Equal(ingress.GetName()))
+}
+
+func TestGeneratedNamesOfFormerGeneration(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.GenerateNames = ptr.To(true)
+
+	former := getTargetDeployment()
+	former.SetUID("generated-names-former")
+
+	successor := getTargetDeployment()
+	successor.SetUID("generated-names-successor")
+
+	t.Cleanup(func() {
+		cfg.Configuration.GenerateNames = nil
+		forgetSuffix(former)
+		forgetOutcome(former)
+		forgetSuffix(successor)
+		forgetOutcome(successor)
+	})
+
+	c := newFakeClient(g, former)
+	g.Expect(reconcileDependencies(ctx, c, former)).To(Succeed())
+
+	formerIngresses, err := fetchOidcAppsIngress(ctx, c, former)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(formerIngresses.Items).To(HaveLen(1))
+
+	// The deployment is recreated before its ingress is garbage collected
+	g.Expect(c.Delete(ctx, former)).To(Succeed())
+	g.Expect(c.Create(ctx, successor)).To(Succeed())
+	g.Expect(reconcileDependencies(ctx, c, successor)).To(Succeed())
+
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(&formerIngresses.Items[0]), &networkingv1.Ingress{})).
+		NotTo(Succeed())
+
+	ingresses, err := fetchOidcAppsIngress(ctx, c, successor)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+	g.Expect(ingresses.Items[0].GetName()).NotTo(Equal(formerIngresses.Items[0].GetName()))
+	g.Expect(ingresses.Items[0].GetOwnerReferences()).To(ContainElement(
+		HaveField("UID", successor.GetUID())))
+}
+
+func TestGeneratedNamesWithStaleCache(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.GenerateNames = ptr.To(true)
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("generated-names-stale-cache")
+
+	t.Cleanup(func() {
+		cfg.Configuration.GenerateNames = nil
+		forgetSuffix(deployment)
+		forgetOutcome(deployment)
+	})
+
+	fakeClient := newFakeClient(g, deployment)
+
+	// The cache does not list the ingress created by the previous reconcile yet
+	c := interceptor.NewClient(fakeClient.(client.WithWatch), interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*networkingv1.IngressList); ok {
+				return nil
+			}
+
+			return c.List(ctx, list, opts...)
+		},
+	})
+
+	for range 2 {
+		forgetOutcome(deployment)
+		g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	}
+
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(fakeClient.List(ctx, ingresses, client.InNamespace("default"))).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+}
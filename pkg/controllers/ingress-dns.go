@@ -0,0 +1,172 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// reasonIngressHostNotResolved is the reason of the warning event emitted for a target, whose ingress host does not
+// resolve to the load balancer of the ingress controller
+const reasonIngressHostNotResolved = "IngressHostNotResolved"
+
+// ingressDNSTimeout bounds the DNS lookups of the ingress hosts of a target
+const ingressDNSTimeout = 5 * time.Second
+
+// ingressDNSLookupTimeout bounds a single DNS lookup, so that an unresponsive name server does not stall the
+// reconcile of a target
+const ingressDNSLookupTimeout = time.Second
+
+// ingressDNSCacheTTL is the duration, for which the result of a DNS lookup is reused
+const ingressDNSCacheTTL = time.Minute
+
+// hostResolver resolves a host to its addresses
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// ingressHostResolver resolves the ingress hosts, replaced in tests to avoid external calls
+var ingressHostResolver hostResolver = newCachingResolver(net.DefaultResolver)
+
+// cachedLookup holds the result of a DNS lookup until it expires
+type cachedLookup struct {
+	addresses []string
+	err       error
+	expires   time.Time
+}
+
+// cachingResolver reuses the results, including the failures, of the DNS lookups for the ingressDNSCacheTTL and bounds
+// every lookup by the ingressDNSLookupTimeout
+type cachingResolver struct {
+	resolver hostResolver
+
+	mu      sync.Mutex
+	lookups map[string]cachedLookup
+}
+
+func newCachingResolver(resolver hostResolver) *cachingResolver {
+	return &cachingResolver{resolver: resolver, lookups: make(map[string]cachedLookup)}
+}
+
+// LookupHost implements the hostResolver
+func (r *cachingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	lookup, found := r.lookups[host]
+	r.mu.Unlock()
+
+	if found && time.Now().Before(lookup.expires) {
+		return lookup.addresses, lookup.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ingressDNSLookupTimeout)
+	defer cancel()
+
+	addresses, err := r.resolver.LookupHost(ctx, host)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The expired lookups of the hosts no longer checked are dropped along the way
+	now := time.Now()
+	maps.DeleteFunc(r.lookups, func(_ string, l cachedLookup) bool { return now.After(l.expires) })
+	r.lookups[host] = cachedLookup{addresses: addresses, err: err, expires: now.Add(ingressDNSCacheTTL)}
+
+	return addresses, err
+}
+
+// warnOnUnresolvedIngressHosts emits a warning event for every ingress host of the target, which does not resolve to
+// the load balancer of its ingress, e.g. due to a missing or outdated DNS record. The ingresses without an assigned
+// load balancer are skipped.
+func warnOnUnresolvedIngressHosts(ctx context.Context, c client.Client, recorder record.EventRecorder,
+	object client.Object) {
+	if recorder == nil {
+		return
+	}
+
+	ingresses, err := fetchOidcAppsIngress(ctx, c, object)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list the ingresses for the DNS check")
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ingressDNSTimeout)
+	defer cancel()
+
+	for _, ingress := range ingresses.Items {
+		lbAddresses := loadBalancerAddresses(ctx, ingress)
+		if len(lbAddresses) == 0 {
+			continue
+		}
+
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host == "" || strings.HasPrefix(rule.Host, "*") {
+				continue
+			}
+
+			addresses, err := ingressHostResolver.LookupHost(ctx, rule.Host)
+			if err != nil {
+				recorder.Event(object, corev1.EventTypeWarning, reasonIngressHostNotResolved,
+					fmt.Sprintf("The host %s of ingress %s does not resolve: %v", rule.Host, ingress.GetName(), err))
+
+				continue
+			}
+
+			if !slices.ContainsFunc(addresses, func(a string) bool { return slices.Contains(lbAddresses, a) }) {
+				recorder.Event(object, corev1.EventTypeWarning, reasonIngressHostNotResolved, fmt.Sprintf(
+					"The host %s of ingress %s resolves to %s instead of the ingress load balancer %s", rule.Host,
+					ingress.GetName(), strings.Join(addresses, ", "), strings.Join(lbAddresses, ", ")))
+			}
+		}
+	}
+}
+
+// loadBalancerAddresses returns the IP addresses of the load balancer of the ingress. The load balancer hostnames,
+// e.g. of the cloud providers without static IPs, are resolved as well.
+func loadBalancerAddresses(ctx context.Context, ingress networkingv1.Ingress) []string {
+	var addresses []string
+
+	for _, lb := range ingress.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			addresses = append(addresses, lb.IP)
+		}
+
+		if lb.Hostname != "" {
+			resolved, err := ingressHostResolver.LookupHost(ctx, lb.Hostname)
+			if err != nil {
+				log.FromContext(ctx).V(9).Info("Failed to resolve the load balancer hostname", "hostname", lb.Hostname)
+
+				continue
+			}
+
+			addresses = append(addresses, resolved...)
+		}
+	}
+
+	return addresses
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// fakeResolver resolves the hosts from a static map, the unknown hosts fail to resolve
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addresses, found := f[host]; found {
+		return addresses, nil
+	}
+
+	return nil, errors.New("no such host")
+}
+
+func useFakeResolver(t *testing.T, resolver fakeResolver) {
+	previous := ingressHostResolver
+	ingressHostResolver = resolver
+
+	t.Cleanup(func() { ingressHostResolver = previous })
+}
+
+func getTargetIngress(host string, lb ...networkingv1.IngressLoadBalancerIngress) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "oauth2-ingress-" + host,
+			Namespace: "default",
+			Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "nginx", UID: "target-deployment"},
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: host}},
+		},
+		Status: networkingv1.IngressStatus{
+			LoadBalancer: networkingv1.IngressLoadBalancerStatus{Ingress: lb},
+		},
+	}
+}
+
+func TestWarnOnUnresolvedIngressHosts(t *testing.T) {
+	useFakeResolver(t, fakeResolver{
+		"ok.example.com":    {"10.0.0.1"},
+		"stale.example.com": {"10.0.0.9"},
+		"lb.example.com":    {"10.0.0.2"},
+	})
+
+	tests := []struct {
+		name    string
+		ingress *networkingv1.Ingress
+		reason  string
+	}{
+		{
+			name:    "host resolving to the load balancer ip",
+			ingress: getTargetIngress("ok.example.com", networkingv1.IngressLoadBalancerIngress{IP: "10.0.0.1"}),
+		},
+		{
+			name: "host resolving to the load balancer hostname",
+			ingress: getTargetIngress("ok.example.com",
+				networkingv1.IngressLoadBalancerIngress{Hostname: "lb.example.com"},
+				networkingv1.IngressLoadBalancerIngress{IP: "10.0.0.1"}),
+		},
+		{
+			name:    "host resolving to another address",
+			ingress: getTargetIngress("stale.example.com", networkingv1.IngressLoadBalancerIngress{IP: "10.0.0.1"}),
+			reason:  reasonIngressHostNotResolved,
+		},
+		{
+			name:    "host not resolving",
+			ingress: getTargetIngress("missing.example.com", networkingv1.IngressLoadBalancerIngress{IP: "10.0.0.1"}),
+			reason:  reasonIngressHostNotResolved,
+		},
+		{
+			name:    "ingress without a load balancer",
+			ingress: getTargetIngress("missing.example.com"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			deployment := getTargetDeployment()
+			recorder := record.NewFakeRecorder(10)
+
+			warnOnUnresolvedIngressHosts(context.TODO(), newFakeClient(g, deployment, tt.ingress), recorder,
+				deployment)
+
+			if tt.reason == "" {
+				g.Expect(recorder.Events).To(BeEmpty())
+
+				return
+			}
+
+			g.Expect(recorder.Events).To(HaveLen(1))
+			g.Expect(<-recorder.Events).To(ContainSubstring(tt.reason))
+		})
+	}
+}
+
+func TestCachingResolver(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	var lookups int
+
+	resolver := newCachingResolver(countingResolver{resolver: fakeResolver{"ok.example.com": {"10.0.0.1"}},
+		lookups: &lookups})
+
+	for range 3 {
+		addresses, err := resolver.LookupHost(ctx, "ok.example.com")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(addresses).To(ConsistOf("10.0.0.1"))
+	}
+
+	g.Expect(lookups).To(Equal(1))
+
+	// The failed lookups are reused as well
+	for range 3 {
+		_, err := resolver.LookupHost(ctx, "missing.example.com")
+		g.Expect(err).To(HaveOccurred())
+	}
+
+	g.Expect(lookups).To(Equal(2))
+
+	// An expired lookup is repeated
+	resolver.lookups["ok.example.com"] = cachedLookup{expires: time.Now().Add(-time.Second)}
+
+	addresses, err := resolver.LookupHost(ctx, "ok.example.com")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(addresses).To(ConsistOf("10.0.0.1"))
+	g.Expect(lookups).To(Equal(3))
+}
+
+// countingResolver counts the lookups of the wrapped resolver and verifies that every lookup has a deadline
+type countingResolver struct {
+	resolver hostResolver
+	lookups  *int
+}
+
+func (c countingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	*c.lookups++
+
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, errors.New("lookup without a deadline")
+	}
+
+	return c.resolver.LookupHost(ctx, host)
+}
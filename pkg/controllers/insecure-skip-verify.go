@@ -0,0 +1,45 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+)
+
+// reasonInsecureSkipVerify is the reason of the warning event emitted for a target skipping the TLS verification
+const reasonInsecureSkipVerify = "InsecureSkipVerify"
+
+// warnOnInsecureSkipVerify logs and emits a warning event when the oauth2-proxy of the target skips the TLS
+// verification, so that the insecure mode of a development setup does not go unnoticed
+func warnOnInsecureSkipVerify(ctx context.Context, recorder record.EventRecorder, object client.Object) {
+	if !configuration.GetOIDCAppsControllerConfig().GetSslInsecureSkipVerify(object) {
+		return
+	}
+
+	const msg = "The oauth2-proxy skips the TLS verification of the OIDC issuer, which is insecure"
+
+	log.FromContext(ctx).Info(msg, "name", object.GetName(), "namespace", object.GetNamespace())
+
+	if recorder != nil {
+		recorder.Event(object, corev1.EventTypeWarning, reasonInsecureSkipVerify, msg)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func TestWarnOnInsecureSkipVerify(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	t.Cleanup(func() { cfg.Configuration.Oauth2Proxy.SSLInsecureSkipVerify = nil })
+
+	deployment := getTargetDeployment()
+	recorder := record.NewFakeRecorder(10)
+
+	// The TLS verification is not skipped by default
+	warnOnInsecureSkipVerify(ctx, recorder, deployment)
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	// The explicit opt-in of the workload emits a warning event
+	deployment.SetAnnotations(map[string]string{constants.AnnotationInsecureSkipVerifyKey: "true"})
+	warnOnInsecureSkipVerify(ctx, recorder, deployment)
+	g.Expect(recorder.Events).To(Receive(HavePrefix(corev1.EventTypeWarning + " " + reasonInsecureSkipVerify)))
+
+	// The annotation takes precedence over the configuration
+	cfg.Configuration.Oauth2Proxy.SSLInsecureSkipVerify = ptr.To(true)
+	deployment.SetAnnotations(map[string]string{constants.AnnotationInsecureSkipVerifyKey: "false"})
+	warnOnInsecureSkipVerify(ctx, recorder, deployment)
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	// The configured opt-in warns as well
+	deployment.SetAnnotations(nil)
+	warnOnInsecureSkipVerify(ctx, recorder, deployment)
+	g.Expect(recorder.Events).To(Receive(HavePrefix(corev1.EventTypeWarning + " " + reasonInsecureSkipVerify)))
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// restartedAtAnnotation is the pod template annotation stamped by kubectl rollout restart
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// LabelRemovalPredicate passes the updates of the workloads, which lose the oidc-apps label or stop matching a target.
+// The label removal does not change the workload generation, hence it is filtered out by the generation predicate.
+func LabelRemovalPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return false
+			}
+
+			_, hadLabel := e.ObjectOld.GetLabels()[constants.LabelKey]
+			_, hasLabel := e.ObjectNew.GetLabels()[constants.LabelKey]
+			if hadLabel && !hasLabel {
+				return true
+			}
+
+			cfg := configuration.GetOIDCAppsControllerConfig()
+
+			return cfg.Match(e.ObjectOld) && !cfg.Match(e.ObjectNew)
+		},
+	}
+}
+
+// removeAuthStack removes the authentication & authorization stack of a workload, which is no longer a target. The
+// generated resources are deleted before the workload is restarted, so that its pods are recreated without the proxies
+// once their resources are gone. The restart is marked as pending beforehand, hence a failed restart is retried on the
+// requeue, although the generated resources are already gone.
+func removeAuthStack(ctx context.Context, c client.Client, object client.Object) error {
+	owned, err := fetchOwnedResources(ctx, c, object)
+	if err != nil {
+		return err
+	}
+
+	// The workload is restarted only once, as long as the generated resources are present or the restart is pending
+	_, pending := object.GetAnnotations()[constants.AnnotationPendingRestartKey]
+	if len(owned) == 0 && !pending {
+		return nil
+	}
+
+	if len(owned) > 0 {
+		log.FromContext(ctx).Info("Workload is no longer an oidc-application-controller target, removing the proxies")
+
+		if !pending && hasOidcAppsPods(ctx, c, object) {
+			if err = markPendingRestart(ctx, c, object); err != nil {
+				return err
+			}
+
+			pending = true
+		}
+
+		if err = deleteOwnedResources(ctx, c, object, deletionPropagation()); err != nil {
+			return err
+		}
+	}
+
+	if pending {
+		if err = restartWorkload(ctx, c, object, map[string]string{constants.AnnotationPendingRestartKey: ""}); err != nil {
+			return err
+		}
+	}
+
+	forgetSuffix(object)
+	forgetOutcome(object)
+	forgetRetryBudget(object)
+
+	return nil
+}
+
+// markPendingRestart records on the workload, that its pods are to be restarted without the proxies.
+func markPendingRestart(ctx context.Context, c client.Client, object client.Object) error {
+	patch := client.MergeFrom(object.DeepCopyObject().(client.Object))
+	object.SetAnnotations(withAnnotation(object.GetAnnotations(), constants.AnnotationPendingRestartKey, "true"))
+
+	if err := c.Patch(ctx, object, patch); err != nil {
+		return fmt.Errorf("failed to mark the pending restart: %w", err)
+	}
+
+	return nil
+}
+
+// restartWorkload stamps the pod template of the workload with the restart annotation, rolling out new pods. The given
+// annotations are set on the workload in the same patch, an empty value removes the annotation.
+func restartWorkload(ctx context.Context, c client.Client, object client.Object, annotations map[string]string) error {
+	patch := client.MergeFrom(object.DeepCopyObject().(client.Object))
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	for k, v := range annotations {
+		if v == "" {
+			a := object.GetAnnotations()
+			delete(a, k)
+			object.SetAnnotations(a)
+
+			continue
+		}
+
+		object.SetAnnotations(withAnnotation(object.GetAnnotations(), k, v))
+	}
+
+	switch o := object.(type) {
+	case *appsv1.Deployment:
+		o.Spec.Template.SetAnnotations(withAnnotation(o.Spec.Template.GetAnnotations(), restartedAtAnnotation, now))
+	case *appsv1.StatefulSet:
+		o.Spec.Template.SetAnnotations(withAnnotation(o.Spec.Template.GetAnnotations(), restartedAtAnnotation, now))
+	case *unstructured.Unstructured:
+		if err := unstructured.SetNestedField(o.Object, now,
+			"spec", "template", "metadata", "annotations", restartedAtAnnotation); err != nil {
+			return fmt.Errorf("failed to set the restart annotation: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: %T", errUnsupportedKind, object)
+	}
+
+	if err := c.Patch(ctx, object, patch); err != nil {
+		return fmt.Errorf("failed to restart workload: %w", err)
+	}
+
+	return nil
+}
+
+func withAnnotation(annotations map[string]string, key, value string) map[string]string {
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+
+	annotations[key] = value
+
+	return annotations
+}
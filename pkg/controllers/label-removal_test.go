@@ -0,0 +1,202 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func TestLabelRemovalPredicate(t *testing.T) {
+	g := NewWithT(t)
+
+	p := LabelRemovalPredicate()
+
+	labelled := getTargetDeployment()
+	labelled.Labels[constants.LabelKey] = "nginx"
+
+	unlabelled := getTargetDeployment()
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: labelled, ObjectNew: unlabelled})).To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: unlabelled, ObjectNew: labelled})).To(BeFalse())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: labelled, ObjectNew: labelled})).To(BeFalse())
+
+	// A workload, which no longer matches a target
+	nonTarget := getTargetDeployment()
+	nonTarget.Labels = nil
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: unlabelled, ObjectNew: nonTarget})).To(BeTrue())
+
+	g.Expect(p.Create(event.CreateEvent{Object: labelled})).To(BeFalse())
+	g.Expect(p.Delete(event.DeleteEvent{Object: labelled})).To(BeFalse())
+}
+
+func TestRolloutReconcileLabelRemoval(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	rollout := getTargetRollout()
+	replicaSet, pod := getRolloutPod()
+	c := newFakeClient(g, rollout, replicaSet, pod)
+
+	r := &RolloutReconciler{Client: c}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(rollout)}
+	_, err := r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	owned, err := fetchOwnedResources(ctx, c, rollout)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(owned).NotTo(BeEmpty())
+
+	g.Expect(c.Get(ctx, request.NamespacedName, rollout)).To(Succeed())
+	retryBudgets.failure(rollout)
+
+	// The label is removed from the rollout
+	g.Expect(c.Get(ctx, request.NamespacedName, rollout)).To(Succeed())
+	rollout.SetLabels(nil)
+	g.Expect(c.Update(ctx, rollout)).To(Succeed())
+
+	// The generated resources are deleted before the rollout is restarted
+	var restarted, deletedAfterRestart bool
+
+	r.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+			opts ...client.PatchOption) error {
+			restarted = restarted || obj.GetUID() == rollout.GetUID() && isRestartPatch(obj, patch)
+
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deletedAfterRestart = deletedAfterRestart || restarted
+
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(restarted).To(BeTrue())
+	g.Expect(deletedAfterRestart).To(BeFalse())
+
+	for _, list := range []client.ObjectList{&corev1.SecretList{}, &corev1.ServiceList{}, &networkingv1.IngressList{}} {
+		g.Expect(c.List(ctx, list, client.InNamespace("default"))).To(Succeed())
+		g.Expect(list).To(HaveField("Items", BeEmpty()))
+	}
+
+	// The rollout is restarted to recreate the pods without the proxies
+	g.Expect(c.Get(ctx, request.NamespacedName, rollout)).To(Succeed())
+	_, found, err := unstructured.NestedString(rollout.Object,
+		"spec", "template", "metadata", "annotations", restartedAtAnnotation)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(BeTrue())
+	g.Expect(rollout.GetAnnotations()).NotTo(HaveKey(constants.AnnotationPendingRestartKey))
+
+	// The retry budget of the former target is dropped
+	g.Expect(retryBudgets.entries).NotTo(HaveKey(rollout.GetUID()))
+
+	// The rollout is restarted only once
+	resourceVersion := rollout.GetResourceVersion()
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(c.Get(ctx, request.NamespacedName, rollout)).To(Succeed())
+	g.Expect(rollout.GetResourceVersion()).To(Equal(resourceVersion))
+}
+
+func TestRolloutReconcileLabelRemovalRetriesRestart(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	rollout := getTargetRollout()
+	replicaSet, pod := getRolloutPod()
+	c := newFakeClient(g, rollout, replicaSet, pod)
+
+	r := &RolloutReconciler{Client: c}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(rollout)}
+	_, err := r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(c.Get(ctx, request.NamespacedName, rollout)).To(Succeed())
+	rollout.SetLabels(nil)
+	g.Expect(c.Update(ctx, rollout)).To(Succeed())
+
+	// The first restart of the rollout fails, after the generated resources are deleted
+	var failed bool
+
+	r.Client = interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+			opts ...client.PatchOption) error {
+			if !failed && isRestartPatch(obj, patch) {
+				failed = true
+
+				return errors.New("patch failed")
+			}
+
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	})
+
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).To(HaveOccurred())
+
+	owned, err := fetchOwnedResources(ctx, c, rollout)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(owned).To(BeEmpty())
+
+	// The requeue restarts the rollout, although its generated resources are already gone
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(c.Get(ctx, request.NamespacedName, rollout)).To(Succeed())
+	_, found, err := unstructured.NestedString(rollout.Object,
+		"spec", "template", "metadata", "annotations", restartedAtAnnotation)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found).To(BeTrue())
+	g.Expect(rollout.GetAnnotations()).NotTo(HaveKey(constants.AnnotationPendingRestartKey))
+}
+
+func TestDeploymentReconcileLabelRemovalWithoutOwnedResources(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	deployment.Labels = map[string]string{"app": "other"}
+	c := newFakeClient(g, deployment)
+
+	d := &DeploymentReconciler{Client: c}
+	_, err := d.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(deployment)})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// A workload, which has never been a target, is not restarted
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+	g.Expect(deployment.Spec.Template.GetAnnotations()).NotTo(HaveKey(restartedAtAnnotation))
+}
+
+func isRestartPatch(obj client.Object, patch client.Patch) bool {
+	data, err := patch.Data(obj)
+
+	return err == nil && strings.Contains(string(data), restartedAtAnnotation)
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// secretAgeDesc describes the age of the oauth2-proxy secrets, holding the client secret of the proxies
+var secretAgeDesc = prometheus.NewDesc(
+	"oidc_apps_controller_oauth2_secret_age_seconds",
+	"Seconds since the creation or the last rotation of the managed oauth2-proxy secret.",
+	[]string{"namespace", "name"}, nil,
+)
+
+// secretAgeListTimeout bounds the secret lookup of a metrics scrape
+const secretAgeListTimeout = 10 * time.Second
+
+var _ prometheus.Collector = &SecretAgeCollector{}
+
+// SecretAgeCollector exposes the age of the managed oauth2-proxy secrets, computed from their rotated-at annotation
+// at scrape time
+type SecretAgeCollector struct {
+	client client.Reader
+	now    func() time.Time
+}
+
+// NewSecretAgeCollector returns a collector reading the oauth2-proxy secrets through the given client
+func NewSecretAgeCollector(c client.Reader) *SecretAgeCollector {
+	return &SecretAgeCollector{client: c, now: time.Now}
+}
+
+// Describe implements prometheus.Collector
+func (s *SecretAgeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- secretAgeDesc
+}
+
+// Collect implements prometheus.Collector
+func (s *SecretAgeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretAgeListTimeout)
+	defer cancel()
+
+	secrets := &corev1.SecretList{}
+	if err := s.client.List(ctx, secrets, client.MatchingLabels{
+		constants.LabelKey:       constants.LabelValue,
+		constants.SecretLabelKey: constants.Oauth2LabelValue,
+	}); err != nil {
+		logf.Log.Error(err, "failed to list the oauth2-proxy secrets for the age metric")
+
+		return
+	}
+
+	for _, secret := range secrets.Items {
+		rotatedAt, err := time.Parse(time.RFC3339, secret.GetAnnotations()[constants.AnnotationRotatedAtKey])
+		if err != nil {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(secretAgeDesc, prometheus.GaugeValue,
+			s.now().Sub(rotatedAt).Seconds(), secret.GetNamespace(), secret.GetName())
+	}
+}
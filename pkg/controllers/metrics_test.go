@@ -0,0 +1,122 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func newOauth2Secret(name string, annotations map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: annotations,
+			Labels: map[string]string{
+				constants.LabelKey:       constants.LabelValue,
+				constants.SecretLabelKey: constants.Oauth2LabelValue,
+			},
+		},
+	}
+}
+
+// collectSecretAges returns the collected ages keyed by the secret name
+func collectSecretAges(g *WithT, collector prometheus.Collector) map[string]float64 {
+	ch := make(chan prometheus.Metric, 10)
+	collector.Collect(ch)
+	close(ch)
+
+	ages := map[string]float64{}
+
+	for m := range ch {
+		metric := &dto.Metric{}
+		g.Expect(m.Write(metric)).To(Succeed())
+
+		labels := map[string]string{}
+		for _, l := range metric.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+
+		g.Expect(labels).To(HaveKeyWithValue("namespace", "default"))
+		ages[labels["name"]] = metric.GetGauge().GetValue()
+	}
+
+	return ages
+}
+
+func TestSecretAgeCollector(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	rotated := newOauth2Secret("oauth2-proxy-rotated", map[string]string{
+		constants.AnnotationRotatedAtKey: now.Add(-90 * time.Minute).Format(time.RFC3339),
+	})
+	unstamped := newOauth2Secret("oauth2-proxy-unstamped", nil)
+	invalid := newOauth2Secret("oauth2-proxy-invalid", map[string]string{constants.AnnotationRotatedAtKey: "yesterday"})
+
+	// Only the oauth2-proxy secrets are measured
+	other := newOauth2Secret("resource-attributes", map[string]string{
+		constants.AnnotationRotatedAtKey: now.Format(time.RFC3339),
+	})
+	other.Labels[constants.SecretLabelKey] = constants.RbacLabelValue
+
+	collector := NewSecretAgeCollector(newFakeClient(g, rotated, unstamped, invalid, other))
+	collector.now = func() time.Time { return now }
+
+	g.Expect(collectSecretAges(g, collector)).To(Equal(map[string]float64{"oauth2-proxy-rotated": 5400}))
+}
+
+func TestCreateOrPatchSecretRotatedAt(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	c := newFakeClient(g)
+	patch := newOauth2Secret("oauth2-proxy", map[string]string{constants.AnnotationOauth2SecertCehcksumKey: "a"})
+
+	// The rotation time is stamped on creation
+	g.Expect(createOrPatchSecret(ctx, c, *patch)).To(Succeed())
+
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(patch), secret)).To(Succeed())
+	g.Expect(secret.GetAnnotations()).To(HaveKey(constants.AnnotationRotatedAtKey))
+	g.Expect(patch.GetAnnotations()).NotTo(HaveKey(constants.AnnotationRotatedAtKey))
+
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	secret.Annotations[constants.AnnotationRotatedAtKey] = past
+	g.Expect(c.Update(ctx, secret)).To(Succeed())
+
+	// The rotation time is kept while the content is unchanged
+	g.Expect(createOrPatchSecret(ctx, c, *patch)).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(patch), secret)).To(Succeed())
+	g.Expect(secret.GetAnnotations()).To(HaveKeyWithValue(constants.AnnotationRotatedAtKey, past))
+
+	// The rotation time is renewed with the content
+	patch.Annotations = map[string]string{constants.AnnotationOauth2SecertCehcksumKey: "b"}
+	g.Expect(createOrPatchSecret(ctx, c, *patch)).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(patch), secret)).To(Succeed())
+	g.Expect(secret.GetAnnotations()).To(HaveKey(constants.AnnotationRotatedAtKey))
+	g.Expect(secret.GetAnnotations()[constants.AnnotationRotatedAtKey]).NotTo(Equal(past))
+}
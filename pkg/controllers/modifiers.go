@@ -18,9 +18,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"strconv"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	gardencorev1beta1 "github.com/gardener/gardener/pkg/apis/core/v1beta1"
 	gardenextensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
@@ -28,17 +29,21 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 	autoscalerv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/component-base/version"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/gardener/oidc-apps-controller/pkg/configuration"
 	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/garden"
 )
 
 func fetchOidcAppsServices(ctx context.Context, c client.Client, object client.Object) (*corev1.ServiceList, error) {
@@ -119,22 +124,40 @@ func fetchOidcAppsSecrets(ctx context.Context, c client.Client, object client.Ob
 	return &corev1.SecretList{Items: ownedSecrets}, nil
 }
 
-func fetchResourceAttributesNamespace(ctx context.Context, c client.Client, object client.Object) string {
+// errClusterNotFound is returned when the Cluster resource of the target namespace does not exist (yet)
+var errClusterNotFound = errors.New("cluster resource not found")
+
+// fetchResourceAttributesNamespace returns the namespace of the kube-rbac-proxy resource attributes. On a gardener seed
+// cluster it is the project namespace of the shoot, an errClusterNotFound is returned if the Cluster resource of a
+// freshly created shoot is not yet present. The resource-attributes-namespace annotation of the target overrides it.
+func fetchResourceAttributesNamespace(ctx context.Context, c client.Client, object client.Object) (string, error) {
 	_log := log.FromContext(ctx)
+	// An explicit namespace takes precedence, an empty one means cluster scoped
+	if namespace, found := object.GetAnnotations()[constants.AnnotationResourceAttributesNamespaceKey]; found {
+		return namespace, nil
+	}
 	// In the case when we are not running on a gardener seed cluster, just return the target namespace
-	if os.Getenv(constants.GardenKubeconfig) == "" {
-		return object.GetNamespace()
+	if !garden.Enabled() {
+		return object.GetNamespace(), nil
 	}
-	// In the case the target is in the garden namespace, then we shall not set a namespace.
+	// In the case the target is in a garden namespace, then we shall not set a namespace.
 	// The goal is the kick in only the gardener operators access which should have cluster scoped access
-	if object.GetNamespace() == constants.GardenNamespace {
-		return ""
+	if garden.IsGardenNamespace(object.GetNamespace()) {
+		return "", nil
 	}
 	// In other cases, fetch the cluster resources and set the project namespace
 	clusters := &gardenextensionsv1alpha1.ClusterList{}
 
-	if err := c.List(ctx, clusters); err != nil {
-		_log.Error(err, "Failed to list Cluster resources")
+	var listOpts []client.ListOption
+	if namespace := garden.ClusterNamespace(); namespace != "" {
+		listOpts = append(listOpts, client.InNamespace(namespace))
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, garden.ClientTimeout())
+	defer cancel()
+
+	if err := c.List(listCtx, clusters, listOpts...); err != nil {
+		return "", fmt.Errorf("failed to list Cluster resources: %w", err)
 	}
 
 	for _, cluster := range clusters.Items {
@@ -148,263 +171,470 @@ func fetchResourceAttributesNamespace(ctx context.Context, c client.Client, obje
 		if err := json.Unmarshal(cluster.Spec.Shoot.Raw, &shoot); err != nil {
 			_log.Error(err, "Failed to parse the shoot raw extension", "cluster", cluster.Name)
 
-			return ""
+			return "", nil
 		}
 
 		_log.Info("Fetched resource_attribute", "namespace", shoot.GetNamespace(), "shoot", shoot.GetName())
 
-		return shoot.GetNamespace()
+		return shoot.GetNamespace(), nil
+	}
+
+	return "", fmt.Errorf("%w: %s", errClusterNotFound, object.GetNamespace())
+}
+
+// controllerVersion returns the build version of the controller, stamped on the generated resources
+var controllerVersion = func() string {
+	return version.Get().GitVersion
+}
+
+// errUnsupportedKind is returned when the dependencies of a workload kind cannot be reconciled
+var errUnsupportedKind = errors.New("unsupported kind")
+
+// reconcileDependencies reconciles the authentication & authorization dependencies of a target workload,
+// dispatching to the handler of its concrete kind. A failed reconcile is recorded in the status summary of the
+// workload, which would otherwise remain ready while the reconciles keep failing.
+func reconcileDependencies(ctx context.Context, c client.Client, object client.Object) error {
+	err := reconcileDependenciesOfKind(ctx, c, object)
+	if err == nil || errors.Is(err, errClusterNotFound) || errors.Is(err, errUnsupportedKind) ||
+		!object.GetDeletionTimestamp().IsZero() {
+		return err
+	}
+
+	if statusErr := recordStatusSummary(ctx, c, object, err); statusErr != nil {
+		log.FromContext(ctx).Error(statusErr, "failed to record the status summary of the failed reconcile")
 	}
 
-	return ""
+	return err
+}
+
+// reconcileDependenciesOfKind dispatches the reconcile of the dependencies to the handler of the concrete kind of the
+// target workload
+func reconcileDependenciesOfKind(ctx context.Context, c client.Client, object client.Object) error {
+	switch o := object.(type) {
+	case *appsv1.Deployment, *appsv1.StatefulSet:
+		// A statefulset exposed through a single ingress is reconciled the same as a deployment
+		if configuration.GetOIDCAppsControllerConfig().GetPerPodIngress(o) {
+			return reconcilePerPodDependencies(ctx, c, o)
+		}
+
+		return reconcileDeploymentDependencies(ctx, c, o)
+	case *unstructured.Unstructured:
+		if !IsRollout(o) {
+			return fmt.Errorf("%w: %s", errUnsupportedKind, o.GroupVersionKind())
+		}
+
+		// Rollouts manage their pods through replicasets, same as deployments
+		return reconcileDeploymentDependencies(ctx, c, o)
+	default:
+		return fmt.Errorf("%w: %T", errUnsupportedKind, object)
+	}
 }
 
 // reconcileDeploymentDependencies is the function responsible for managing authentication & authorization dependencies.
-// It reconciles the needed secrets, ingresses and services.
-func reconcileDeploymentDependencies(ctx context.Context, c client.Client, object *appsv1.Deployment) error {
-	var (
-		// Service for the oauth2-proxy sidecar
-		oauth2Service corev1.Service
-		// Ingress for the oauth2-proxy sidecar
-		oauth2Ingress networkingv1.Ingress
-		// Secret with oidc configuration for oauth2-proxy sidecar
-		oauth2Secret corev1.Secret
-		// Secret with resource attributes for the rbac-proxy sidecar
-		rbacSecret corev1.Secret
-		// Secret with oidc CA certificate for the rbac-proxy sidecar
-		oidcCABundleSecret corev1.Secret
-		// Optional secret with kubeconfig the rbac-proxy sidecar
-		kubeConfig corev1.Secret
-		// Error
-		err error
-	)
+// It reconciles the needed secrets, ingresses and services of a deployment or a rollout.
+func reconcileDeploymentDependencies(ctx context.Context, c client.Client, object client.Object) error {
+	if !object.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	// The resources are created also without a Cluster resource, and corrected once it appears
+	desired, desiredErr := desiredDeploymentResources(ctx, c, object)
+	if desiredErr != nil && !errors.Is(desiredErr, errClusterNotFound) {
+		return desiredErr
+	}
 
+	desiredHash, unchanged, err := isOutcomeUnchanged(ctx, c, object, desired, desiredErr)
+	if err != nil {
+		return err
+	}
+
+	if !unchanged {
+		if err := applyDesiredResources(ctx, c, object, desired); err != nil {
+			return err
+		}
+	}
+
+	// The resources, which are not generated for the target alone, are not covered by the outcome of the desired
+	// resources and reconciled every time
+	if err := reconcileSharedIngressPath(ctx, c, object); err != nil {
+		return err
+	}
+
+	if err := addOauth2PortToService(ctx, c, object); err != nil {
+		return err
+	}
+
+	if err := patchVpa(ctx, c, object); err != nil {
+		return err
+	}
+
+	if unchanged {
+		return nil
+	}
+
+	if err := recordStatusSummary(ctx, c, object, desiredErr); err != nil {
+		return err
+	}
+
+	if desiredErr != nil {
+		return desiredErr
+	}
+
+	return recordOutcome(ctx, c, object, desiredHash)
+}
+
+// reconcilePerPodDependencies reconciles the secrets of a statefulset or a deployment and the services and ingresses
+// of its pods.
+func reconcilePerPodDependencies(ctx context.Context, c client.Client, object client.Object) error {
 	if !object.GetDeletionTimestamp().IsZero() {
 		return nil
 	}
 
-	// Create or update the oauth2 secret setting the owner reference
-	if oauth2Secret, err = createOauth2Secret(object); err != nil {
-		return fmt.Errorf("failed to create oauth2 secret: %w", err)
+	// The resources are created also without a Cluster resource, and corrected once it appears
+	desired, desiredErr := desiredPerPodResources(ctx, c, object)
+	if desiredErr != nil && !errors.Is(desiredErr, errClusterNotFound) {
+		return desiredErr
+	}
+
+	desiredHash, unchanged, err := isOutcomeUnchanged(ctx, c, object, desired, desiredErr)
+	if err != nil {
+		return err
+	}
+
+	if !unchanged {
+		if err := applyDesiredResources(ctx, c, object, desired); err != nil {
+			return err
+		}
 	}
 
-	if err = controllerutil.SetOwnerReference(object, &oauth2Secret, c.Scheme()); err != nil {
-		return fmt.Errorf("failed to set owner reference to oauth secret: %w", err)
+	// The per-pod services replace a formerly reused target service
+	if err := removeOauth2PortFromServices(ctx, c, object.GetNamespace(),
+		ownerLabelValue(workloadKind(object), client.ObjectKeyFromObject(object)), ""); err != nil {
+		return err
 	}
 
-	if err = createOrPatchObject(ctx, c, &oauth2Secret); err != nil {
-		return fmt.Errorf("failed to create or update oauth2 secret: %w", err)
+	if err := patchVpa(ctx, c, object); err != nil {
+		return err
 	}
 
-	// Create or update the oauth2 service setting the owner reference
-	selectors := configuration.GetOIDCAppsControllerConfig().GetTargetLabelSelector(object)
-	if oauth2Service, err = createOauth2Service(selectors.MatchLabels, object); err != nil {
-		return fmt.Errorf("failed to create oauth2 service: %w", err)
+	if unchanged {
+		return nil
 	}
 
-	if err := controllerutil.SetOwnerReference(object, &oauth2Service, c.Scheme()); err != nil {
-		return fmt.Errorf("failed to set owner reference to oauth service: %w", err)
+	if err := recordStatusSummary(ctx, c, object, desiredErr); err != nil {
+		return err
 	}
 
-	if err = createOrPatchObject(ctx, c, &oauth2Service); err != nil {
-		return fmt.Errorf("failed to create or update oauth2 service: %w", err)
+	if desiredErr != nil {
+		return desiredErr
 	}
 
-	// Create or update the resource attributes secret setting the owner reference
-	ns := fetchResourceAttributesNamespace(ctx, c, object)
-	if rbacSecret, err = createResourceAttributesSecret(object, ns); err != nil {
-		return fmt.Errorf("failed to create resource attributes secret: %w", err)
+	return recordOutcome(ctx, c, object, desiredHash)
+}
+
+// applyDesiredResources creates or patches the desired resources of a target, removes its resources which are no
+// longer desired and restarts the target upon a changed configuration
+func applyDesiredResources(ctx context.Context, c client.Client, object client.Object, desired []client.Object) error {
+	// The resources of a renamed workload would otherwise conflict with the desired ones
+	if err := deleteResourcesOfRenamedWorkload(ctx, c, object, desired); err != nil {
+		return err
 	}
 
-	if err := controllerutil.SetOwnerReference(object, &rbacSecret, c.Scheme()); err != nil {
-		return fmt.Errorf("failed to set owner reference to resource attributes secret: %w", err)
+	if err := reconcileGeneratedNames(ctx, c, object, desired); err != nil {
+		return err
 	}
 
-	if err = createOrPatchObject(ctx, c, &rbacSecret); err != nil {
-		return fmt.Errorf("failed to create or update rbac secret: %w", err)
+	if err := verifySuffixIsUnique(ctx, c, object, desired); err != nil {
+		return err
 	}
 
-	// kubeconfig secret is optionally added to the kube-rbac-proxy
-	if kubeConfig, err = createKubeconfigSecret(object); err != nil && !errors.Is(err, errSecretDoesNotExist) {
-		return fmt.Errorf("failed to create kubeconfig secret: %w", err)
+	if err := createOrPatchObjects(ctx, c, desired); err != nil {
+		return err
+	}
+
+	if err := deleteUndesiredIngresses(ctx, c, object, desired); err != nil {
+		return err
 	}
 
-	if !errors.Is(err, errSecretDoesNotExist) {
-		if err = controllerutil.SetOwnerReference(object, &kubeConfig, c.Scheme()); err != nil {
-			return fmt.Errorf("failed to set owner reference to kubeconfig secret: %w", err)
+	if err := deleteUndesiredNetworkPolicies(ctx, c, object, desired); err != nil {
+		return err
+	}
+
+	// The per-pod services of a statefulset formerly exposed per pod, or the oauth2 service of a workload formerly
+	// exposed through a single ingress
+	if err := deleteUndesiredServices(ctx, c, object, desired); err != nil {
+		return err
+	}
+
+	return restartOnConfigChange(ctx, c, object, desired)
+}
+
+// desiredResources renders the authentication & authorization dependencies of a target workload, dispatching to
+// the renderer of its concrete kind.
+func desiredResources(ctx context.Context, c client.Client, object client.Object) ([]client.Object, error) {
+	switch o := object.(type) {
+	case *appsv1.Deployment, *appsv1.StatefulSet:
+		if configuration.GetOIDCAppsControllerConfig().GetPerPodIngress(o) {
+			return desiredPerPodResources(ctx, c, o)
 		}
 
-		if err = createOrPatchObject(ctx, c, &kubeConfig); err != nil {
-			return fmt.Errorf("failed to create or update kubecofig secret: %w", err)
+		return desiredDeploymentResources(ctx, c, o)
+	case *unstructured.Unstructured:
+		if !IsRollout(o) {
+			return nil, fmt.Errorf("%w: %s", errUnsupportedKind, o.GroupVersionKind())
 		}
+
+		return desiredDeploymentResources(ctx, c, o)
+	default:
+		return nil, fmt.Errorf("%w: %T", errUnsupportedKind, object)
 	}
+}
 
-	// oidc ca bundle secret is mandatory for the rbac-proxy
-	if oidcCABundleSecret, err = createOidcCaBundleSecret(object); err != nil && !errors.Is(err, errSecretDoesNotExist) {
-		return fmt.Errorf("failed to create oidc ca bundle secret: %w", err)
+// desiredDeploymentResources renders the secrets, service and ingress of a target deployment or rollout with their
+// owner set. The resources are rendered also when the Cluster resource is not yet present, alongside an
+// errClusterNotFound.
+func desiredDeploymentResources(ctx context.Context, c client.Client, object client.Object) ([]client.Object, error) {
+	// Secret with oidc configuration for oauth2-proxy sidecar
+	oauth2Secret, err := createOauth2Secret(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oauth2 secret: %w", err)
 	}
 
-	if !errors.Is(err, errSecretDoesNotExist) {
-		if err = controllerutil.SetOwnerReference(object, &oidcCABundleSecret, c.Scheme()); err != nil {
-			return fmt.Errorf("failed to set owner reference to oidc ca bundle secret: %w", err)
-		}
+	if err = withCookieSecret(ctx, c, object, &oauth2Secret); err != nil {
+		return nil, err
+	}
+
+	desired := []client.Object{&oauth2Secret}
 
-		if err = createOrPatchObject(ctx, c, &oidcCABundleSecret); err != nil {
-			return fmt.Errorf("failed to create or update oidc caBundle secret: %w", err)
+	// Service for the oauth2-proxy sidecar, unless the ingress routes to an existing target service
+	if configuration.GetOIDCAppsControllerConfig().GetIngressServiceName(object) == "" {
+		selectors := configuration.GetOIDCAppsControllerConfig().GetTargetLabelSelector(object)
+
+		oauth2Service, err := createOauth2Service(selectors.MatchLabels, object, object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create oauth2 service: %w", err)
 		}
+
+		desired = append(desired, &oauth2Service)
 	}
 
-	// Create or update the oauth2 ingress setting the owner reference
-	if oauth2Ingress, err = createIngressForDeployment(object); err != nil {
-		return fmt.Errorf("failed to create oauth2 ingress: %w", err)
+	// Secrets for the rbac-proxy sidecar
+	secrets, nsErr := desiredRbacProxySecrets(ctx, c, object)
+	if nsErr != nil && !errors.Is(nsErr, errClusterNotFound) {
+		return nil, nsErr
 	}
 
-	if err = controllerutil.SetOwnerReference(object, &oauth2Ingress, c.Scheme()); err != nil {
-		return fmt.Errorf("failed to set owner reference to oauth2 ingress: %w", err)
+	desired = append(desired, secrets...)
+
+	// Ingress for the oauth2-proxy sidecar, unless the oauth2 service exposes it directly or the target is registered on
+	// a shared ingress
+	if !configuration.GetOIDCAppsControllerConfig().GetSkipIngress(object) &&
+		!configuration.GetOIDCAppsControllerConfig().GetIngressShared(object) {
+		oauth2Ingress, err := createIngressForDeployment(object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create oauth2 ingress: %w", err)
+		}
+
+		desired = append(desired, &oauth2Ingress)
 	}
 
-	if err = createOrPatchObject(ctx, c, &oauth2Ingress); err != nil {
-		return fmt.Errorf("failed to create or update oauth2 ingress: %w", err)
+	// Network policy restricting the access to the oauth2-proxy metrics port
+	desired = append(desired, desiredMetricsNetworkPolicy(object)...)
+
+	for _, d := range desired {
+		if err = setOwner(object, object, d, c.Scheme()); err != nil {
+			return nil, fmt.Errorf("failed to set owner reference to %s: %w", d.GetName(), err)
+		}
 	}
 
-	return patchVpa(ctx, c, object)
+	return desired, nsErr
 }
 
-func reconcileStatefulSetDependencies(ctx context.Context, c client.Client, object *appsv1.StatefulSet) error {
-	var (
-		// Service for the oauth2-proxy sidecar
-		oauth2Service corev1.Service
-		// Ingress for the oauth2-proxy sidecar
-		oauth2Ingress networkingv1.Ingress
-		// Secret with oidc configuration for oauth2-proxy sidecar
-		oauth2Secret corev1.Secret
-		// Secret with resource attributes for the rbac-proxy sidecar
-		rbacSecret corev1.Secret
-		// Secret with oidc CA certificate for the rbac-proxy sidecar
-		oidcCABundleSecret corev1.Secret
-		// Optional secret with kubeconfig the rbac-proxy sidecar
-		kubeConfig corev1.Secret
-
-		err error
-	)
-
-	if !object.GetDeletionTimestamp().IsZero() {
-		return nil
+// desiredPerPodResources renders the secrets of a target statefulset or deployment and the services and ingresses of
+// its pods with their owner set. The resources are rendered also when the Cluster resource is not yet present,
+// alongside an errClusterNotFound.
+func desiredPerPodResources(ctx context.Context, c client.Client, object client.Object) ([]client.Object, error) {
+	// Secret with oidc configuration for oauth2-proxy sidecar
+	oauth2Secret, err := createOauth2Secret(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oauth2 secret: %w", err)
 	}
 
-	// Create or update the oauth2 secret setting the owner reference
-	if oauth2Secret, err = createOauth2Secret(object); err != nil {
-		return fmt.Errorf("failed to create oauth2 secret: %w", err)
+	if err = withCookieSecret(ctx, c, object, &oauth2Secret); err != nil {
+		return nil, err
 	}
 
-	if err = controllerutil.SetOwnerReference(object, &oauth2Secret, c.Scheme()); err != nil {
-		return fmt.Errorf("failed to set owner reference to oauth secret: %w", err)
+	if err = setOwner(object, object, &oauth2Secret, c.Scheme()); err != nil {
+		return nil, fmt.Errorf("failed to set owner reference to oauth secret: %w", err)
 	}
 
-	if err = createOrPatchObject(ctx, c, &oauth2Secret); err != nil {
-		return fmt.Errorf("failed to create or update oauth2 secret: %w", err)
+	desired := []client.Object{&oauth2Secret}
+
+	// Network policy restricting the access to the oauth2-proxy metrics port of all the pods
+	for _, networkPolicy := range desiredMetricsNetworkPolicy(object) {
+		if err = setOwner(object, object, networkPolicy, c.Scheme()); err != nil {
+			return nil, fmt.Errorf("failed to set owner reference to network policy: %w", err)
+		}
+
+		desired = append(desired, networkPolicy)
 	}
 
-	// For each pod in the statefulset
+	// For each pod selected by the workload
 	podList := &corev1.PodList{}
 
-	labelSelector := client.MatchingLabels(object.Spec.Selector.MatchLabels)
+	labelSelector := client.MatchingLabels(podSelector(object))
 	if err := c.List(ctx, podList, labelSelector, client.InNamespace(object.GetNamespace())); err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+		return nil, fmt.Errorf("failed to list pods: %w", err)
 	}
 
 	for _, pod := range podList.Items {
 		log.FromContext(ctx).V(9).Info("Reconciling pod", "pod", pod.GetName(), "annotations", pod.GetAnnotations())
 
+		// The pods admitted before the per-pod mode was enabled have no host index until they are recreated
 		_, found := pod.GetAnnotations()[constants.AnnotationHostKey]
-		if !found {
+		if !found || podHostIndex(&pod) == "" || isBeyondPerPodResourcesCap(&pod) {
 			continue
 		}
 
-		// Create or update the oauth2 service setting the owner reference
+		// Service for the oauth2-proxy sidecar of the pod
 		selectors := client.MatchingLabels{}
-		if configuration.GetOIDCAppsControllerConfig().GetTargetLabelSelector(&pod) != nil {
-			selectors = configuration.GetOIDCAppsControllerConfig().GetTargetLabelSelector(&pod).MatchLabels
+		if configuration.GetOIDCAppsControllerConfig().GetTargetLabelSelector(object) != nil {
+			selectors = configuration.GetOIDCAppsControllerConfig().GetTargetLabelSelector(object).MatchLabels
 		}
 
 		if statefulSetPodNameLabel, ok := pod.GetLabels()["statefulset.kubernetes.io/pod-name"]; ok {
 			selectors = map[string]string{"statefulset.kubernetes.io/pod-name": statefulSetPodNameLabel}
 		}
 
-		if oauth2Service, err = createOauth2Service(selectors, &pod); err != nil {
-			return fmt.Errorf("failed to create oauth2 service: %w", err)
+		if podNameHash, ok := pod.GetLabels()[constants.LabelPodNameHashKey]; ok {
+			selectors = map[string]string{constants.LabelPodNameHashKey: podNameHash}
 		}
 
-		if err := controllerutil.SetOwnerReference(&pod, &oauth2Service, c.Scheme()); err != nil {
-			return fmt.Errorf("failed to set owner reference to oauth service: %w", err)
+		// The service is named after the pod and configured by the annotations of the workload
+		oauth2Service, err := createOauth2Service(selectors, &pod, object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create oauth2 service: %w", err)
 		}
 
-		if err = createOrPatchObject(ctx, c, &oauth2Service); err != nil {
-			return fmt.Errorf("failed to create or update oauth2 service: %w", err)
+		if err := setOwner(object, &pod, &oauth2Service, c.Scheme()); err != nil {
+			return nil, fmt.Errorf("failed to set owner reference to oauth service: %w", err)
 		}
 
-		// Create or update the oauth2 ingress setting the owner reference
-		if oauth2Ingress, err = createIngressForStatefulSetPod(&pod, object); err != nil {
-			return fmt.Errorf("failed to create oauth2 ingress: %w", err)
+		desired = append(desired, &oauth2Service)
+
+		// Ingress for the oauth2-proxy sidecar of the pod, unless the oauth2 service exposes it directly
+		if configuration.GetOIDCAppsControllerConfig().GetSkipIngress(object) {
+			continue
 		}
 
-		if err = controllerutil.SetOwnerReference(&pod, &oauth2Ingress, c.Scheme()); err != nil {
-			return fmt.Errorf("failed to set owner reference to oauth2 ingress: %w", err)
+		oauth2Ingress, err := createIngressForPod(&pod, object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create oauth2 ingress: %w", err)
 		}
 
-		if err = createOrPatchObject(ctx, c, &oauth2Ingress); err != nil {
-			return fmt.Errorf("failed to create or update oauth2 ingress: %w", err)
+		if err = setOwner(object, &pod, &oauth2Ingress, c.Scheme()); err != nil {
+			return nil, fmt.Errorf("failed to set owner reference to oauth2 ingress: %w", err)
 		}
+
+		desired = append(desired, &oauth2Ingress)
 	}
 
-	// Create or update the resource attributes secret setting the owner reference
-	ns := fetchResourceAttributesNamespace(ctx, c, object)
-	if rbacSecret, err = createResourceAttributesSecret(object, ns); err != nil {
-		return fmt.Errorf("failed to create resource attributes secret: %w", err)
+	// Secrets for the rbac-proxy sidecar
+	secrets, nsErr := desiredRbacProxySecrets(ctx, c, object)
+	if nsErr != nil && !errors.Is(nsErr, errClusterNotFound) {
+		return nil, nsErr
 	}
 
-	if err = controllerutil.SetOwnerReference(object, &rbacSecret, c.Scheme()); err != nil {
-		return fmt.Errorf("failed to set owner reference to resource attributes secret: %w", err)
+	for _, s := range secrets {
+		if err = setOwner(object, object, s, c.Scheme()); err != nil {
+			return nil, fmt.Errorf("failed to set owner reference to %s: %w", s.GetName(), err)
+		}
 	}
 
-	if err = createOrPatchObject(ctx, c, &rbacSecret); err != nil {
-		return fmt.Errorf("failed to create or update rbac secret: %w", err)
+	return append(desired, secrets...), nsErr
+}
+
+// podSelector returns the labels selecting the pods of a statefulset or a deployment
+func podSelector(object client.Object) map[string]string {
+	switch o := object.(type) {
+	case *appsv1.StatefulSet:
+		return o.Spec.Selector.MatchLabels
+	case *appsv1.Deployment:
+		return o.Spec.Selector.MatchLabels
+	default:
+		return nil
 	}
+}
 
-	// kubeconfig secret is optionally added to the kube-rbac-proxy
-	if kubeConfig, err = createKubeconfigSecret(object); err != nil && !errors.Is(err, errSecretDoesNotExist) {
-		return fmt.Errorf("failed to create kubeconfig secret: %w", err)
+// desiredRbacProxySecrets renders the resource attributes secret and the optional kubeconfig and oidc ca bundle secrets
+// of the kube-rbac-proxy. The resource attributes secret is rendered also without a Cluster resource, alongside an
+// errClusterNotFound.
+func desiredRbacProxySecrets(ctx context.Context, c client.Client, object client.Object) ([]client.Object, error) {
+	ns, nsErr := fetchResourceAttributesNamespace(ctx, c, object)
+	if nsErr != nil && !errors.Is(nsErr, errClusterNotFound) {
+		return nil, nsErr
 	}
 
-	if !errors.Is(err, errSecretDoesNotExist) {
-		if err = controllerutil.SetOwnerReference(object, &kubeConfig, c.Scheme()); err != nil {
-			return fmt.Errorf("failed to set owner reference to kubeconfig secret: %w", err)
-		}
+	// Secret with resource attributes for the rbac-proxy sidecar
+	rbacSecret, err := createResourceAttributesSecret(object, ns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource attributes secret: %w", err)
+	}
 
-		if err = createOrPatchObject(ctx, c, &kubeConfig); err != nil {
-			return fmt.Errorf("failed to create or update kubeconfig secret: %w", err)
-		}
+	secrets := []client.Object{&rbacSecret}
+
+	// kubeconfig secret is optionally added to the kube-rbac-proxy
+	kubeConfig, err := createKubeconfigSecret(object)
+	if err != nil && !errors.Is(err, errSecretDoesNotExist) {
+		return nil, fmt.Errorf("failed to create kubeconfig secret: %w", err)
+	}
+
+	if err == nil {
+		secrets = append(secrets, &kubeConfig)
 	}
 
 	// oidc ca bundle secret is mandatory for the rbac-proxy
-	if oidcCABundleSecret, err = createOidcCaBundleSecret(object); err != nil && !errors.Is(err, errSecretDoesNotExist) {
-		return fmt.Errorf("failed to create oidc ca bundle secret: %w", err)
+	oidcCABundleSecret, err := createOidcCaBundleSecret(ctx, c, object)
+	if err != nil && !errors.Is(err, errSecretDoesNotExist) {
+		return nil, fmt.Errorf("failed to create oidc ca bundle secret: %w", err)
 	}
 
-	if !errors.Is(err, errSecretDoesNotExist) {
-		if err = controllerutil.SetOwnerReference(object, &oidcCABundleSecret, c.Scheme()); err != nil {
-			return fmt.Errorf("failed to set owner reference to oidc ca bundle secret: %w", err)
-		}
+	if err == nil {
+		secrets = append(secrets, &oidcCABundleSecret)
+	}
+
+	return secrets, nsErr
+}
 
-		if err = createOrPatchObject(ctx, c, &oidcCABundleSecret); err != nil {
-			return fmt.Errorf("failed to create or update oidc caBundle secret: %w", err)
+// createOrPatchObjects creates or patches the desired resources in order
+func createOrPatchObjects(ctx context.Context, c client.Client, desired []client.Object) error {
+	for _, d := range desired {
+		if err := createOrPatchObject(ctx, c, d); err != nil {
+			return fmt.Errorf("failed to create or update %s/%s: %w", d.GetNamespace(), d.GetName(), err)
 		}
 	}
 
-	return patchVpa(ctx, c, object)
+	return nil
 }
 
 func createOrPatchObject(ctx context.Context, c client.Client, patch client.Object) error {
+	// Stamp the controller version on every generated resource
+	annotations := patch.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+
+	annotations[constants.AnnotationManagedByVersionKey] = controllerVersion()
+
+	// Record the controller-owned keys, so that a later reconcile removes only these when they are no longer desired
+	annotations[constants.AnnotationManagedAnnotationsKey] = strings.Join(slices.Sorted(maps.Keys(annotations)), ",")
+	annotations[constants.AnnotationManagedLabelsKey] = strings.Join(slices.Sorted(maps.Keys(patch.GetLabels())), ",")
+	patch.SetAnnotations(annotations)
+
+	if err := verifyNotPendingDeletion(ctx, c, patch); err != nil {
+		return err
+	}
+
 	// Switch over type
 	switch p := patch.(type) {
 	case *corev1.Secret:
@@ -413,6 +643,8 @@ func createOrPatchObject(ctx context.Context, c client.Client, patch client.Obje
 		return createOrPatchService(ctx, c, *p)
 	case *networkingv1.Ingress:
 		return createOrPatchIngress(ctx, c, *p)
+	case *networkingv1.NetworkPolicy:
+		return createOrPatchNetworkPolicy(ctx, c, *p)
 	}
 
 	log.FromContext(ctx).Info("unknown object type", "object", patch)
@@ -420,25 +652,108 @@ func createOrPatchObject(ctx context.Context, c client.Client, patch client.Obje
 	return nil
 }
 
+// errPendingDeletion is returned when a resource to be created is still being deleted, e.g. with the Foreground
+// propagation policy or a finalizer of another controller
+var errPendingDeletion = errors.New("resource is being deleted")
+
+// verifyNotPendingDeletion returns an errPendingDeletion if the existing resource of the desired name is being deleted.
+// A patch would not survive its deletion, hence the reconcile is requeued until it is gone and recreated then.
+func verifyNotPendingDeletion(ctx context.Context, c client.Client, desired client.Object) error {
+	existing, ok := desired.DeepCopyObject().(client.Object)
+	if !ok {
+		return nil
+	}
+
+	if err := c.Get(ctx, client.ObjectKeyFromObject(desired), existing); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if existing.GetDeletionTimestamp().IsZero() {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s/%s", errPendingDeletion, existing.GetNamespace(), existing.GetName())
+}
+
+// mergeObjectMeta applies the desired labels, annotations and owner references onto the existing object. Keys and
+// references set by users or other controllers, e.g. cert-manager or external-dns, are preserved. Only the
+// controller-owned keys, recorded on the object upon the previous reconcile, are overwritten or removed.
+func mergeObjectMeta(existing, desired client.Object) {
+	previous := existing.GetAnnotations()
+
+	existing.SetLabels(mergeOwnedKeys(existing.GetLabels(), desired.GetLabels(),
+		previous[constants.AnnotationManagedLabelsKey]))
+	existing.SetAnnotations(mergeOwnedKeys(previous, desired.GetAnnotations(),
+		previous[constants.AnnotationManagedAnnotationsKey]))
+
+	refs := slices.Clone(existing.GetOwnerReferences())
+	for _, ref := range desired.GetOwnerReferences() {
+		// The references to a former incarnation of the owner are dangling, the resource is adopted by the current one
+		refs = slices.DeleteFunc(refs, func(r metav1.OwnerReference) bool { return isFormerOwnerReference(r, ref) })
+
+		// A reference to the same owner is refreshed, as it may carry an outdated api version after an API migration,
+		// e.g. apps/v1beta2 to apps/v1, which the garbage collector can no longer resolve
+		if i := slices.IndexFunc(refs, func(r metav1.OwnerReference) bool { return r.UID == ref.UID }); i >= 0 {
+			refs[i] = ref
+
+			continue
+		}
+
+		refs = append(refs, ref)
+	}
+
+	existing.SetOwnerReferences(refs)
+}
+
+// mergeOwnedKeys returns the existing keys updated with the desired ones, where the previously owned keys, which are
+// no longer desired, are removed
+func mergeOwnedKeys(existing, desired map[string]string, owned string) map[string]string {
+	merged := maps.Clone(existing)
+	if merged == nil {
+		merged = make(map[string]string, len(desired))
+	}
+
+	for _, k := range strings.Split(owned, ",") {
+		if _, found := desired[k]; !found {
+			delete(merged, k)
+		}
+	}
+
+	maps.Copy(merged, desired)
+
+	return merged
+}
+
 func createOrPatchSecret(ctx context.Context, c client.Client, patch corev1.Secret) error {
 	secret := &corev1.Secret{}
 	// Create a secret if it does not exist
 	if err := c.Get(ctx, client.ObjectKeyFromObject(&patch), secret); apierrors.IsNotFound(err) {
+		stampRotatedAt(nil, &patch)
+
 		if err = c.Create(ctx, &patch); err != nil {
-			return fmt.Errorf("failed to create ingress	: %w", err)
+			return fmt.Errorf("failed to create secret: %w", err)
 		}
 
 		return nil
 	}
 
 	// Patch the secret if it exists
-	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		_patch := client.MergeFrom(&patch)
-		err := c.Get(ctx, client.ObjectKeyFromObject(&patch), secret)
-		if err != nil {
+	if err := retry.RetryOnConflict(configuration.GetOIDCAppsControllerConfig().GetRetryBackoff(), func() error {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(&patch), secret); err != nil {
 			return fmt.Errorf("failed to get secret: %w", err)
 		}
 
+		_patch := client.MergeFrom(secret.DeepCopy())
+
+		stampRotatedAt(secret, &patch)
+		mergeObjectMeta(secret, &patch)
+
+		if patch.Data != nil {
+			secret.Data = patch.Data
+		}
+
+		secret.StringData = patch.StringData
+
 		return c.Patch(ctx, secret, _patch)
 	}); err != nil {
 		return fmt.Errorf("failed to patch secret: %w", err)
@@ -447,26 +762,58 @@ func createOrPatchSecret(ctx context.Context, c client.Client, patch corev1.Secr
 	return nil
 }
 
+// stampRotatedAt stamps the rotation time on the desired secret, which carries a content checksum. The time of the
+// existing secret is kept while the checksum is unchanged, the secrets created before the stamping report their
+// creation time.
+func stampRotatedAt(existing, desired *corev1.Secret) {
+	checksum, found := desired.GetAnnotations()[constants.AnnotationOauth2SecertCehcksumKey]
+	if !found {
+		return
+	}
+
+	rotatedAt := time.Now().UTC().Format(time.RFC3339)
+
+	if existing != nil && existing.GetAnnotations()[constants.AnnotationOauth2SecertCehcksumKey] == checksum {
+		if t, ok := existing.GetAnnotations()[constants.AnnotationRotatedAtKey]; ok {
+			rotatedAt = t
+		} else if created := existing.GetCreationTimestamp(); !created.IsZero() {
+			rotatedAt = created.UTC().Format(time.RFC3339)
+		}
+	}
+
+	annotations := maps.Clone(desired.GetAnnotations())
+	annotations[constants.AnnotationRotatedAtKey] = rotatedAt
+	desired.SetAnnotations(annotations)
+}
+
 func createOrPatchIngress(ctx context.Context, c client.Client, patch networkingv1.Ingress) error {
+	if err := verifyIngressHostIsUnique(ctx, c, patch); err != nil {
+		return err
+	}
+
 	ingress := &networkingv1.Ingress{}
 
 	// Create an ingress if it does not exist
 	if err := c.Get(ctx, client.ObjectKeyFromObject(&patch), ingress); apierrors.IsNotFound(err) {
 		if err = c.Create(ctx, &patch); err != nil {
-			return fmt.Errorf("failed to create ingress	: %w", err)
+			return fmt.Errorf("failed to create ingress: %w", err)
 		}
 
 		return nil
 	}
 
 	// Patch the ingress if it exists
-	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		_patch := client.MergeFrom(&patch)
-		err := c.Get(ctx, client.ObjectKeyFromObject(&patch), ingress)
-		if err != nil {
+	if err := retry.RetryOnConflict(configuration.GetOIDCAppsControllerConfig().GetRetryBackoff(), func() error {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(&patch), ingress); err != nil {
 			return fmt.Errorf("failed to get ingress: %w", err)
 		}
 
+		_patch := client.MergeFrom(ingress.DeepCopy())
+
+		mergeObjectMeta(ingress, &patch)
+		ingress.Spec = patch.Spec
+		setIngressClass(ingress, patch.Spec.IngressClassName, patch.Annotations)
+
 		return c.Patch(ctx, ingress, _patch)
 	}); err != nil {
 		return fmt.Errorf("failed to patch ingress: %w", err)
@@ -480,28 +827,91 @@ func createOrPatchService(ctx context.Context, c client.Client, patch corev1.Ser
 	// Create a service if it does not exist
 	if err := c.Get(ctx, client.ObjectKeyFromObject(&patch), service); apierrors.IsNotFound(err) {
 		if err = c.Create(ctx, &patch); err != nil {
-			return fmt.Errorf("failed to create service	: %w", err)
+			return fmt.Errorf("failed to create service: %w", err)
 		}
 
 		return nil
 	}
 
 	// Patch the service if it exists
-	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		_patch := client.MergeFrom(&patch)
-		err := c.Get(ctx, client.ObjectKeyFromObject(&patch), service)
-		if err != nil {
+	if err := retry.RetryOnConflict(configuration.GetOIDCAppsControllerConfig().GetRetryBackoff(), func() error {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(&patch), service); err != nil {
 			return fmt.Errorf("failed to get service: %w", err)
 		}
 
+		_patch := client.MergeFrom(service.DeepCopy())
+
+		mergeObjectMeta(service, &patch)
+		// The cluster IPs are allocated by the API server, hence only the selector, ports and type are reconciled
+		service.Spec.Selector = patch.Spec.Selector
+		service.Spec.Ports = patch.Spec.Ports
+		service.Spec.Type = patch.Spec.Type
+
 		return c.Patch(ctx, service, _patch)
 	}); err != nil {
+		if isImmutableFieldError(err) && configuration.GetOIDCAppsControllerConfig().GetRecreateServicesOnImmutableChange() {
+			return recreateService(ctx, c, service, patch)
+		}
+
 		return fmt.Errorf("failed to patch service: %w", err)
 	}
 
 	return nil
 }
 
+// recreateService replaces the existing service, which cannot be patched due to a change of an immutable field, with
+// the desired one. The labels, the annotations and the owner references of the existing service are preserved.
+func recreateService(ctx context.Context, c client.Client, existing *corev1.Service, desired corev1.Service) error {
+	mergeObjectMeta(existing, &desired)
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            desired.GetName(),
+			Namespace:       desired.GetNamespace(),
+			Labels:          existing.GetLabels(),
+			Annotations:     existing.GetAnnotations(),
+			OwnerReferences: existing.GetOwnerReferences(),
+		},
+		Spec: desired.Spec,
+	}
+
+	_log := log.FromContext(ctx)
+	_log.Info("Recreating service due to a change of an immutable field", "name", service.GetName(),
+		"namespace", service.GetNamespace())
+
+	if err := c.Delete(ctx, existing, client.Preconditions{UID: ptr.To(existing.GetUID())}); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to delete service: %w", err)
+	}
+
+	if err := c.Create(ctx, service); err != nil {
+		return fmt.Errorf("failed to recreate service: %w", err)
+	}
+
+	return nil
+}
+
+// isImmutableFieldError returns true if the update was rejected by the API server due to a change of an immutable field
+func isImmutableFieldError(err error) bool {
+	if !apierrors.IsInvalid(err) {
+		return false
+	}
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if isImmutableFieldMessage(cause.Message) {
+				return true
+			}
+		}
+	}
+
+	return isImmutableFieldMessage(err.Error())
+}
+
+func isImmutableFieldMessage(message string) bool {
+	return strings.Contains(message, "field is immutable") || strings.Contains(message, "may not change once set")
+}
+
 func patchVpa(ctx context.Context, c client.Client, object client.Object) error {
 	vpa := &autoscalerv1.VerticalPodAutoscalerList{}
 	targetLabels := configuration.GetOIDCAppsControllerConfig().GetTargetLabelSelector(object)
@@ -550,24 +960,6 @@ func patchVpa(ctx context.Context, c client.Client, object client.Object) error
 	return nil
 }
 
-func addOptionalIndex(idx string) string {
-	if idx == "-" {
-		return ""
-	}
-
-	idxStr, ok := strings.CutSuffix(idx, "-")
-	if !ok {
-		return ""
-	}
-
-	i, err := strconv.ParseInt(idxStr, 0, 32)
-	if err != nil {
-		return ""
-	}
-
-	return fmt.Sprintf("%d-", i)
-}
-
 func hasOidcAppsPods(ctx context.Context, c client.Client, object client.Object) bool {
 	_log := log.FromContext(ctx)
 
@@ -598,7 +990,7 @@ func hasOidcAppsPods(ctx context.Context, c client.Client, object client.Object)
 				}
 
 				for _, d := range rs.OwnerReferences {
-					if d.Kind == "Deployment" && d.UID == object.GetUID() {
+					if (d.Kind == "Deployment" || d.Kind == constants.RolloutKind) && d.UID == object.GetUID() {
 						return true
 					}
 				}
@@ -610,7 +1002,7 @@ func hasOidcAppsPods(ctx context.Context, c client.Client, object client.Object)
 }
 
 func isOidcAppPod(pod corev1.Pod) bool {
-	for _, c := range pod.Spec.Containers {
+	for _, c := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
 		if c.Name == constants.ContainerNameOauth2Proxy || c.Name == constants.ContainerNameKubeRbacProxy {
 			return true
 		}
@@ -0,0 +1,647 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gardenextensionsv1alpha1 "github.com/gardener/gardener/pkg/apis/extensions/v1alpha1"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	autoscalerv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func newFakeClient(g *WithT, objects ...client.Object) client.Client {
+	s := runtime.NewScheme()
+	g.Expect(scheme.AddToScheme(s)).To(Succeed())
+	g.Expect(autoscalerv1.AddToScheme(s)).To(Succeed())
+
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(objects...).Build()
+}
+
+func TestReconcileDependenciesDeployment(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	suffix := rand.GenerateSha256("nginx-default")
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: constants.SecretNameOauth2Proxy + "-" + suffix},
+		&corev1.Secret{})).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: constants.ServiceNameOauth2Service + "-" + suffix},
+		&corev1.Service{})).To(Succeed())
+}
+
+func TestReconcileDependenciesStatefulSet(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	statefulSet := getTargetStatefulSet()
+	c := newFakeClient(g, statefulSet)
+
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+
+	suffix := rand.GenerateSha256("nginx-default")
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: constants.SecretNameOauth2Proxy + "-" + suffix},
+		&corev1.Secret{})).To(Succeed())
+	// The services of a StatefulSet are created per pod
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: constants.ServiceNameOauth2Service + "-" + suffix},
+		&corev1.Service{})).NotTo(Succeed())
+}
+
+func TestReconcileDependenciesStatefulSetIngressModes(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	statefulSet := getTargetStatefulSet()
+	c := newFakeClient(g, statefulSet, getStatefulSetPod(0), getStatefulSetPod(1))
+
+	t.Cleanup(func() { forgetOutcome(statefulSet) })
+
+	suffix := rand.GenerateSha256("nginx-default")
+	singleIngress := client.ObjectKey{Namespace: "default", Name: constants.IngressName + "-" + suffix}
+	singleService := client.ObjectKey{Namespace: "default", Name: constants.ServiceNameOauth2Service + "-" + suffix}
+
+	// The pods of a StatefulSet are exposed per pod by default
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(c.List(ctx, ingresses, client.InNamespace("default"))).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(2))
+	g.Expect(c.Get(ctx, singleIngress, &networkingv1.Ingress{})).NotTo(Succeed())
+	g.Expect(c.Get(ctx, singleService, &corev1.Service{})).NotTo(Succeed())
+
+	// The single ingress mode exposes the StatefulSet the same as a deployment
+	statefulSet.SetAnnotations(map[string]string{constants.AnnotationIngressModeKey: constants.IngressModeSingle})
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+
+	g.Expect(c.List(ctx, ingresses, client.InNamespace("default"))).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+	g.Expect(ingresses.Items[0].GetName()).To(Equal(singleIngress.Name))
+	g.Expect(ingresses.Items[0].Spec.Rules[0].Host).To(Equal("nginx-default.domain.org"))
+
+	service := &corev1.Service{}
+	g.Expect(c.Get(ctx, singleService, service)).To(Succeed())
+	g.Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "nginx"}))
+
+	// The per-pod services are removed next to the per-pod ingresses
+	services := &corev1.ServiceList{}
+	g.Expect(c.List(ctx, services, client.InNamespace("default"))).To(Succeed())
+	g.Expect(services.Items).To(HaveLen(1))
+	g.Expect(services.Items[0].GetName()).To(Equal(singleService.Name))
+
+	// Switching back to per-pod ingresses removes the single ingress and service
+	statefulSet.SetAnnotations(map[string]string{constants.AnnotationIngressModeKey: constants.IngressModePerPod})
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+
+	g.Expect(c.List(ctx, ingresses, client.InNamespace("default"))).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(2))
+	g.Expect(c.Get(ctx, singleIngress, &networkingv1.Ingress{})).NotTo(Succeed())
+	g.Expect(c.Get(ctx, singleService, &corev1.Service{})).NotTo(Succeed())
+}
+
+func getDeploymentPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				"app":                         "nginx",
+				constants.LabelPodNameHashKey: rand.GenerateSha256(name),
+			},
+			Annotations: map[string]string{constants.AnnotationHostKey: "nginx-default.domain.org"},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       "nginx-5d8f7c",
+				UID:        "target-replicaset",
+			}},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: constants.ContainerNameOauth2Proxy}}},
+	}
+}
+
+func TestReconcileDependenciesDeploymentPerPod(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	deployment.SetAnnotations(map[string]string{constants.AnnotationIngressModeKey: constants.IngressModePerPod})
+
+	// A pod admitted before the per-pod mode was enabled has no name hash label
+	admittedBefore := getDeploymentPod("nginx-5d8f7c-z9x8w")
+	delete(admittedBefore.Labels, constants.LabelPodNameHashKey)
+
+	c := newFakeClient(g, deployment, getDeploymentPod("nginx-5d8f7c-abcde"), getDeploymentPod("nginx-5d8f7c-fghij"),
+		admittedBefore)
+
+	t.Cleanup(func() { forgetOutcome(deployment) })
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	suffix := rand.GenerateSha256("nginx-default")
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: constants.ServiceNameOauth2Service + "-" + suffix},
+		&corev1.Service{})).NotTo(Succeed())
+
+	// The pods are exposed on the hosts suffixed with their name hash
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(c.List(ctx, ingresses, client.InNamespace("default"))).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(2))
+
+	hosts := make([]string, 0, len(ingresses.Items))
+	for _, ingress := range ingresses.Items {
+		hosts = append(hosts, ingress.Spec.Rules[0].Host)
+	}
+
+	g.Expect(hosts).To(ConsistOf(
+		"nginx-default-"+rand.GenerateSha256("nginx-5d8f7c-abcde")+".domain.org",
+		"nginx-default-"+rand.GenerateSha256("nginx-5d8f7c-fghij")+".domain.org",
+	))
+
+	// Each service selects its pod by the name hash
+	services := &corev1.ServiceList{}
+	g.Expect(c.List(ctx, services, client.InNamespace("default"))).To(Succeed())
+	g.Expect(services.Items).To(HaveLen(2))
+
+	for _, service := range services.Items {
+		g.Expect(service.Spec.Selector).To(HaveLen(1))
+		g.Expect(service.Spec.Selector).To(HaveKey(constants.LabelPodNameHashKey))
+	}
+
+	// The single ingress mode is the default of the deployments
+	deployment.SetAnnotations(nil)
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	g.Expect(c.List(ctx, ingresses, client.InNamespace("default"))).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+	g.Expect(ingresses.Items[0].Spec.Rules[0].Host).To(Equal("nginx-default.domain.org"))
+}
+
+func TestReconcileDependenciesUnsupportedKind(t *testing.T) {
+	g := NewWithT(t)
+
+	daemonSet := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"}}
+
+	err := reconcileDependencies(context.TODO(), newFakeClient(g), daemonSet)
+	g.Expect(err).To(MatchError(errUnsupportedKind))
+	g.Expect(err.Error()).To(ContainSubstring("*v1.DaemonSet"))
+}
+
+func TestCreateOrPatchObjectManagedByVersion(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	version := "v1.0.0"
+	defaultControllerVersion := controllerVersion
+	controllerVersion = func() string { return version }
+
+	t.Cleanup(func() { controllerVersion = defaultControllerVersion })
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	suffix := rand.GenerateSha256("nginx-default")
+	objects := []client.Object{
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: constants.SecretNameOauth2Proxy + "-" + suffix}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: constants.SecretNameResourceAttributes + "-" + suffix}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: constants.ServiceNameOauth2Service + "-" + suffix}},
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: constants.IngressName + "-" + suffix}},
+	}
+
+	for _, o := range objects {
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: o.GetName()}, o)).To(Succeed())
+		g.Expect(o.GetAnnotations()).To(HaveKeyWithValue(constants.AnnotationManagedByVersionKey, "v1.0.0"))
+	}
+
+	// The annotation of the former controller releases is removed
+	const formerKey = "oidc-apps.extensions.gardener.cloud/managed-by-version"
+
+	for _, o := range objects {
+		annotations := o.GetAnnotations()
+		annotations[formerKey] = "v0.9.0"
+		annotations[constants.AnnotationManagedAnnotationsKey] += "," + formerKey
+		o.SetAnnotations(annotations)
+		g.Expect(c.Update(ctx, o)).To(Succeed())
+	}
+
+	// A reconcile by a new controller version updates the annotation
+	version = "v1.1.0"
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	for _, o := range objects {
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: o.GetName()}, o)).To(Succeed())
+		g.Expect(o.GetAnnotations()).To(HaveKeyWithValue(constants.AnnotationManagedByVersionKey, "v1.1.0"))
+		g.Expect(o.GetAnnotations()).NotTo(HaveKey(formerKey))
+	}
+}
+
+func TestCreateOrPatchSecretUpdatesData(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"},
+		Data:       map[string][]byte{"key": []byte("old")},
+	}
+	c := newFakeClient(g, existing)
+
+	g.Expect(createOrPatchObject(ctx, c, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"},
+		Data:       map[string][]byte{"key": []byte("new")},
+	})).To(Succeed())
+
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), existing)).To(Succeed())
+	g.Expect(existing.Data).To(HaveKeyWithValue("key", []byte("new")))
+}
+
+func TestCreateOrPatchObjectPreservesForeignKeys(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.Annotations = map[string]string{"nginx.ingress.kubernetes.io/proxy-body-size": "8m"}
+
+	t.Cleanup(func() { ingressConf.Annotations = nil })
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	// Other controllers add their annotations and labels to the generated ingress
+	ingress := &networkingv1.Ingress{}
+	key := client.ObjectKey{Namespace: "default", Name: constants.IngressName + "-" + rand.GenerateSha256("nginx-default")}
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+
+	ingress.Annotations["cert.gardener.cloud/purpose"] = "managed"
+	ingress.Annotations["external-dns.alpha.kubernetes.io/hostname"] = "nginx.example.org"
+	ingress.Labels["team"] = "frontend"
+	g.Expect(c.Update(ctx, ingress)).To(Succeed())
+
+	// A controller-owned annotation is removed from the configuration
+	ingressConf.Annotations = map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "300"}
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue("cert.gardener.cloud/purpose", "managed"))
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue("external-dns.alpha.kubernetes.io/hostname", "nginx.example.org"))
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/proxy-read-timeout", "300"))
+	g.Expect(ingress.Annotations).NotTo(HaveKey("nginx.ingress.kubernetes.io/proxy-body-size"))
+	g.Expect(ingress.Labels).To(HaveKeyWithValue("team", "frontend"))
+	g.Expect(ingress.Labels).To(HaveKeyWithValue(constants.LabelKey, constants.LabelValue))
+}
+
+func TestReconcileDependenciesLateCluster(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	// A garden kubeconfig is set, but the files are not mounted
+	t.Setenv(constants.GardenKubeconfig, filepath.Join(t.TempDir(), "kubeconfig"))
+
+	statefulSet := getTargetStatefulSet()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-0",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "nginx", UID: statefulSet.UID},
+			},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: constants.ContainerNameOauth2Proxy}}},
+	}
+
+	s := runtime.NewScheme()
+	g.Expect(scheme.AddToScheme(s)).To(Succeed())
+	g.Expect(autoscalerv1.AddToScheme(s)).To(Succeed())
+	g.Expect(gardenextensionsv1alpha1.AddToScheme(s)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(statefulSet, pod).Build()
+
+	r := &StatefulSetReconciler{Client: c}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(statefulSet)}
+
+	resourceAttributesNamespace := func() string {
+		secret := &corev1.Secret{}
+		key := client.ObjectKey{Namespace: "default", Name: constants.SecretNameResourceAttributes + "-" + rand.GenerateSha256("nginx-default")}
+		g.Expect(c.Get(ctx, key, secret)).To(Succeed())
+
+		attributes := struct {
+			Authorization struct {
+				ResourceAttributes struct {
+					Namespace string `json:"namespace"`
+				} `json:"resourceAttributes"`
+			} `json:"authorization"`
+		}{}
+		g.Expect(yaml.Unmarshal(secretData(secret, "config-file.yaml"), &attributes)).To(Succeed())
+
+		return attributes.Authorization.ResourceAttributes.Namespace
+	}
+
+	// The Cluster resource of the freshly created shoot is not yet present
+	result, err := r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.Requeue).To(BeTrue())
+	g.Expect(resourceAttributesNamespace()).To(BeEmpty())
+
+	// Once the Cluster appears, the resource attributes secret is corrected
+	cluster := &gardenextensionsv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "default"},
+		Spec: gardenextensionsv1alpha1.ClusterSpec{
+			Shoot: runtime.RawExtension{Raw: []byte(`{"metadata":{"name":"shoot","namespace":"garden-project"}}`)},
+		},
+	}
+	g.Expect(c.Create(ctx, cluster)).To(Succeed())
+
+	result, err = r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result.Requeue).To(BeFalse())
+	g.Expect(resourceAttributesNamespace()).To(Equal("garden-project"))
+}
+
+func secretData(secret *corev1.Secret, key string) []byte {
+	if v, found := secret.StringData[key]; found {
+		return []byte(v)
+	}
+
+	return secret.Data[key]
+}
+
+func TestFetchResourceAttributesNamespaceClusterNamespace(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	t.Setenv(constants.GardenKubeconfig, filepath.Join(t.TempDir(), "kubeconfig"))
+
+	s := runtime.NewScheme()
+	g.Expect(scheme.AddToScheme(s)).To(Succeed())
+	g.Expect(gardenextensionsv1alpha1.AddToScheme(s)).To(Succeed())
+
+	var namespaces []string
+
+	c := fake.NewClientBuilder().WithScheme(s).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			o := &client.ListOptions{}
+			o.ApplyOptions(opts)
+			namespaces = append(namespaces, o.Namespace)
+
+			return c.List(ctx, list, opts...)
+		},
+	}).Build()
+
+	// The Clusters are listed cluster-wide by default
+	_, err := fetchResourceAttributesNamespace(ctx, c, getTargetDeployment())
+	g.Expect(err).To(MatchError(errClusterNotFound))
+
+	// The list is scoped to the configured Cluster namespace
+	t.Setenv(constants.GardenClusterNamespace, "clusters")
+
+	_, err = fetchResourceAttributesNamespace(ctx, c, getTargetDeployment())
+	g.Expect(err).To(MatchError(errClusterNotFound))
+	g.Expect(namespaces).To(Equal([]string{"", "clusters"}))
+}
+
+func TestFetchResourceAttributesNamespaceClientTimeout(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	t.Setenv(constants.GardenKubeconfig, filepath.Join(t.TempDir(), "kubeconfig"))
+	t.Setenv(constants.GardenClientTimeout, "50ms")
+
+	// The API server never answers the Cluster lookups
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	s := runtime.NewScheme()
+	g.Expect(gardenextensionsv1alpha1.AddToScheme(s)).To(Succeed())
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(gardenextensionsv1alpha1.SchemeGroupVersion.WithKind("Cluster"), meta.RESTScopeRoot)
+
+	c, err := client.New(&rest.Config{Host: server.URL}, client.Options{Scheme: s, Mapper: mapper})
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	start := time.Now()
+	_, err = fetchResourceAttributesNamespace(ctx, c, getTargetDeployment())
+	g.Expect(err).To(MatchError(context.DeadlineExceeded))
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+}
+
+func TestCreateOrPatchSecretRetryBackoff(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	existing := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"}}
+
+	var attempts int
+
+	s := runtime.NewScheme()
+	g.Expect(scheme.AddToScheme(s)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(existing).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+			attempts++
+
+			return apierrors.NewConflict(corev1.Resource("secrets"), obj.GetName(), errors.New("conflict"))
+		},
+	}).Build()
+
+	patch := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"}}
+
+	// The conflicting patches are retried with the default backoff
+	g.Expect(createOrPatchSecret(ctx, c, patch)).To(MatchError(ContainSubstring("conflict")))
+	g.Expect(attempts).To(Equal(retry.DefaultRetry.Steps))
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.RetryBackoff = &configuration.RetryBackoffConfig{Steps: 2, Duration: "1ms"}
+
+	t.Cleanup(func() { cfg.Configuration.RetryBackoff = nil })
+
+	attempts = 0
+
+	g.Expect(createOrPatchSecret(ctx, c, patch)).To(MatchError(ContainSubstring("conflict")))
+	g.Expect(attempts).To(Equal(2))
+}
+
+func TestCreateOrPatchServiceImmutableField(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "nginx", UID: "target-deployment"}
+	existing := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "oauth2-service",
+			Namespace:       "default",
+			UID:             "existing-service",
+			Labels:          map[string]string{constants.LabelKey: constants.LabelValue, "team": "a"},
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Spec: corev1.ServiceSpec{IPFamilies: []corev1.IPFamily{corev1.IPv4Protocol}},
+	}
+
+	var deletes int
+
+	s := runtime.NewScheme()
+	g.Expect(scheme.AddToScheme(s)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(existing).WithInterceptorFuncs(interceptor.Funcs{
+		Patch: func(_ context.Context, _ client.WithWatch, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+			return apierrors.NewInvalid(schema.GroupKind{Kind: "Service"}, obj.GetName(), field.ErrorList{
+				field.Invalid(field.NewPath("spec", "ipFamilies").Index(0), corev1.IPv6Protocol, "may not change once set"),
+			})
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deletes++
+
+			return c.Delete(ctx, obj, opts...)
+		},
+	}).Build()
+
+	patch := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "oauth2-service",
+			Namespace:       "default",
+			Labels:          map[string]string{constants.LabelKey: constants.LabelValue},
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Spec: corev1.ServiceSpec{IPFamilies: []corev1.IPFamily{corev1.IPv6Protocol}},
+	}
+
+	// The immutable field change is reported while the recreation is disabled
+	g.Expect(createOrPatchService(ctx, c, patch)).To(MatchError(ContainSubstring("may not change once set")))
+	g.Expect(deletes).To(BeZero())
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.RecreateServicesOnImmutableChange = ptr.To(true)
+
+	t.Cleanup(func() { cfg.Configuration.RecreateServicesOnImmutableChange = nil })
+
+	g.Expect(createOrPatchService(ctx, c, patch)).To(Succeed())
+	g.Expect(deletes).To(Equal(1))
+
+	service := &corev1.Service{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), service)).To(Succeed())
+	g.Expect(service.Spec.IPFamilies).To(Equal([]corev1.IPFamily{corev1.IPv6Protocol}))
+	g.Expect(service.GetLabels()).To(HaveKeyWithValue(constants.LabelKey, constants.LabelValue))
+	g.Expect(service.GetLabels()).To(HaveKeyWithValue("team", "a"))
+	g.Expect(service.GetOwnerReferences()).To(ConsistOf(owner))
+}
+
+func TestIsImmutableFieldError(t *testing.T) {
+	g := NewWithT(t)
+
+	gk := schema.GroupKind{Kind: "Service"}
+	g.Expect(isImmutableFieldError(apierrors.NewInvalid(gk, "svc", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "clusterIPs").Index(0), "10.0.0.1", "may not change once set"),
+	}))).To(BeTrue())
+	g.Expect(isImmutableFieldError(apierrors.NewInvalid(gk, "svc", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "selector"), "", "field is immutable"),
+	}))).To(BeTrue())
+	g.Expect(isImmutableFieldError(apierrors.NewInvalid(gk, "svc", field.ErrorList{
+		field.Required(field.NewPath("spec", "ports"), ""),
+	}))).To(BeFalse())
+	g.Expect(isImmutableFieldError(apierrors.NewConflict(corev1.Resource("services"), "svc",
+		errors.New("field is immutable")))).To(BeFalse())
+}
+
+func TestFetchResourceAttributesNamespaceAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	// The target namespace is computed without the annotation
+	namespace, err := fetchResourceAttributesNamespace(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(namespace).To(Equal("default"))
+
+	deployment.SetAnnotations(map[string]string{constants.AnnotationResourceAttributesNamespaceKey: "shared"})
+
+	namespace, err = fetchResourceAttributesNamespace(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(namespace).To(Equal("shared"))
+
+	// An empty override means cluster scoped, also on a gardener seed without a Cluster resource
+	t.Setenv(constants.GardenKubeconfig, filepath.Join(t.TempDir(), "kubeconfig"))
+	deployment.SetAnnotations(map[string]string{constants.AnnotationResourceAttributesNamespaceKey: ""})
+
+	namespace, err = fetchResourceAttributesNamespace(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(namespace).To(BeEmpty())
+}
+
+func TestFetchResourceAttributesNamespaceGardenNamespaces(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	t.Setenv(constants.GardenKubeconfig, filepath.Join(t.TempDir(), "kubeconfig"))
+	t.Setenv(constants.GardenNamespaces, "garden,garden-*")
+
+	s := runtime.NewScheme()
+	g.Expect(scheme.AddToScheme(s)).To(Succeed())
+	g.Expect(gardenextensionsv1alpha1.AddToScheme(s)).To(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(s).Build()
+
+	// The seed workloads of all the garden namespaces are cluster scoped
+	for _, namespace := range []string{"garden", "garden-runtime", "garden-monitoring"} {
+		deployment := getTargetDeployment()
+		deployment.SetNamespace(namespace)
+
+		resourceNamespace, err := fetchResourceAttributesNamespace(ctx, c, deployment)
+		g.Expect(err).NotTo(HaveOccurred(), namespace)
+		g.Expect(resourceNamespace).To(BeEmpty(), namespace)
+	}
+
+	// The other namespaces are resolved through their Cluster resource
+	deployment := getTargetDeployment()
+	deployment.SetNamespace("shoot--foo--bar")
+
+	_, err := fetchResourceAttributesNamespace(ctx, c, deployment)
+	g.Expect(err).To(MatchError(errClusterNotFound))
+}
@@ -15,7 +15,10 @@
 package controllers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -26,23 +29,24 @@ import (
 
 	"github.com/gardener/oidc-apps-controller/pkg/configuration"
 	"github.com/gardener/oidc-apps-controller/pkg/constants"
-	"github.com/gardener/oidc-apps-controller/pkg/rand"
 )
 
+// errIngressHostConflict is returned when the ingress host is already claimed by another oidc-apps ingress
+var errIngressHostConflict = errors.New("ingress host is already in use")
+
 func createIngressForDeployment(object client.Object) (networkingv1.Ingress, error) {
-	suffix := rand.GenerateSha256(object.GetName() + "-" + object.GetNamespace())
 	ingressClassName := configuration.GetOIDCAppsControllerConfig().GetIngressClassName(object)
 	ingressTLSSecretName := configuration.GetOIDCAppsControllerConfig().GetIngressTLSSecretName(object)
 	host := configuration.GetOIDCAppsControllerConfig().GetHost(object)
 
 	ingress := networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      constants.IngressName + "-" + suffix,
+			Name:      resourceName(constants.IngressName, object),
 			Namespace: object.GetNamespace(),
 			Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
 		},
 		Spec: networkingv1.IngressSpec{
-			IngressClassName: ptr.To(ingressClassName),
+			IngressClassName: ingressClassNameOrNil(ingressClassName),
 			TLS: []networkingv1.IngressTLS{
 				{
 					Hosts:      []string{host},
@@ -56,11 +60,11 @@ func createIngressForDeployment(object client.Object) (networkingv1.Ingress, err
 						HTTP: &networkingv1.HTTPIngressRuleValue{
 							Paths: []networkingv1.HTTPIngressPath{
 								{
-									Path:     "/",
-									PathType: ptr.To(networkingv1.PathTypePrefix),
+									Path:     configuration.GetOIDCAppsControllerConfig().GetIngressPath(object),
+									PathType: ptr.To(configuration.GetOIDCAppsControllerConfig().GetIngressPathType(object)),
 									Backend: networkingv1.IngressBackend{
 										Service: &networkingv1.IngressServiceBackend{
-											Name: constants.ServiceNameOauth2Service + "-" + suffix,
+											Name: resourceName(constants.ServiceNameOauth2Service, object),
 											Port: networkingv1.ServiceBackendPort{
 												Name: "http",
 											},
@@ -75,16 +79,48 @@ func createIngressForDeployment(object client.Object) (networkingv1.Ingress, err
 		},
 	}
 
-	if annotations := configuration.GetOIDCAppsControllerConfig().GetIngressAnnotations(object); len(annotations) > 0 {
-		ingress.Annotations = annotations
+	// Route to the oauth2-proxy port of the existing target service, when it is reused
+	if name := configuration.GetOIDCAppsControllerConfig().GetIngressServiceName(object); name != "" {
+		ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service = &networkingv1.IngressServiceBackend{
+			Name: name,
+			Port: networkingv1.ServiceBackendPort{Name: constants.ServicePortNameOauth2Proxy},
+		}
 	}
 
+	ingress.Annotations = ingressAnnotations(object, host, ingressClassName)
+
 	return ingress, nil
 }
 
-func createIngressForStatefulSetPod(pod *corev1.Pod, object client.Object) (networkingv1.Ingress, error) {
-	suffix := rand.GenerateSha256(pod.GetName() + "-" + pod.GetNamespace())
-	ingressClassName := configuration.GetOIDCAppsControllerConfig().GetIngressClassName(object)
+// deleteUndesiredIngresses deletes the oidc-apps ingresses of the target, which are no longer desired, e.g. once its
+// oauth2 service exposes the oauth2-proxy directly
+func deleteUndesiredIngresses(ctx context.Context, c client.Client, object client.Object, desired []client.Object) error {
+	ingresses, err := fetchOidcAppsIngress(ctx, c, object)
+	if err != nil {
+		return err
+	}
+
+	for _, ingress := range ingresses.Items {
+		if slices.ContainsFunc(desired, func(d client.Object) bool {
+			_, isIngress := d.(*networkingv1.Ingress)
+
+			return isIngress && d.GetName() == ingress.GetName()
+		}) {
+			continue
+		}
+
+		if err := c.Delete(ctx, &ingress, deletionPropagation()); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete ingress %s: %w", ingress.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// createIngressForPod renders the ingress of a statefulset or a deployment pod. The pod host is derived from the target
+// host, suffixed with the ordinal of a statefulset pod or the name hash of a deployment pod.
+func createIngressForPod(pod *corev1.Pod, object client.Object) (networkingv1.Ingress, error) {
+	ingressClassName := configuration.GetOIDCAppsControllerConfig().GetPodIngressClassName(object, pod)
 	ingressTLSSecretName := configuration.GetOIDCAppsControllerConfig().GetIngressTLSSecretName(object)
 
 	hostPrefix, ok := pod.GetAnnotations()[constants.AnnotationHostKey]
@@ -93,35 +129,34 @@ func createIngressForStatefulSetPod(pod *corev1.Pod, object client.Object) (netw
 	}
 
 	host, domain, _ := strings.Cut(hostPrefix, ".")
-	index := fetchStrIndexIfPresent(pod)
+	podHost := fmt.Sprintf("%s-%s.%s", host, podHostIndex(pod), domain)
 
 	ingress := networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      constants.IngressName + "-" + addOptionalIndex(index+"-") + suffix,
+			Name:      resourceName(constants.IngressName, pod),
 			Namespace: object.GetNamespace(),
 			Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
 		},
 		Spec: networkingv1.IngressSpec{
-			IngressClassName: ptr.To(ingressClassName),
+			IngressClassName: ingressClassNameOrNil(ingressClassName),
 			TLS: []networkingv1.IngressTLS{
 				{
-					Hosts:      []string{fmt.Sprintf("%s-%s.%s", host, fetchStrIndexIfPresent(pod), domain)},
+					Hosts:      []string{podHost},
 					SecretName: ingressTLSSecretName,
 				},
 			},
 			Rules: []networkingv1.IngressRule{
 				{
-					Host: fmt.Sprintf("%s-%s.%s", host, fetchStrIndexIfPresent(pod), domain),
+					Host: podHost,
 					IngressRuleValue: networkingv1.IngressRuleValue{
 						HTTP: &networkingv1.HTTPIngressRuleValue{
 							Paths: []networkingv1.HTTPIngressPath{
 								{
-									Path:     "/",
-									PathType: ptr.To(networkingv1.PathTypePrefix),
+									Path:     configuration.GetOIDCAppsControllerConfig().GetIngressPath(object),
+									PathType: ptr.To(configuration.GetOIDCAppsControllerConfig().GetIngressPathType(object)),
 									Backend: networkingv1.IngressBackend{
 										Service: &networkingv1.IngressServiceBackend{
-											Name: constants.ServiceNameOauth2Service + "-" + addOptionalIndex(
-												index+"-") + suffix,
+											Name: resourceName(constants.ServiceNameOauth2Service, pod),
 											Port: networkingv1.ServiceBackendPort{
 												Name: "http",
 											},
@@ -135,9 +170,113 @@ func createIngressForStatefulSetPod(pod *corev1.Pod, object client.Object) (netw
 			},
 		},
 	}
-	if annotations := configuration.GetOIDCAppsControllerConfig().GetIngressAnnotations(object); len(annotations) > 0 {
-		ingress.Annotations = annotations
-	}
+	ingress.Annotations = ingressAnnotations(object, podHost, ingressClassName)
 
 	return ingress, nil
 }
+
+// ingressClassNameOrNil returns the ingress class name of a generated ingress, nil for the default ingress class of
+// the cluster
+func ingressClassNameOrNil(ingressClassName string) *string {
+	if ingressClassName == "" {
+		return nil
+	}
+
+	return ptr.To(ingressClassName)
+}
+
+// setIngressClass reasserts the desired ingress class on an existing ingress, so that a changed class converges. The
+// deprecated class annotation of an ingress created by others would otherwise shadow a set class name, it is kept only
+// when it is one of the configured ingress annotations.
+func setIngressClass(ingress *networkingv1.Ingress, ingressClassName *string, annotations map[string]string) {
+	ingress.Spec.IngressClassName = ingressClassName
+
+	if _, configured := annotations[constants.IngressClassAnnotation]; ingressClassName != nil && !configured {
+		delete(ingress.Annotations, constants.IngressClassAnnotation)
+	}
+}
+
+// ingressAnnotations returns the configured ingress annotations, together with the assigned ingress class and the
+// external-dns hostname annotation pointing to the ingress host when external-dns integration is enabled for the target
+func ingressAnnotations(object client.Object, host, ingressClassName string) map[string]string {
+	annotations := configuration.GetOIDCAppsControllerConfig().GetIngressAnnotations(object)
+
+	// The default ingress class of the cluster is not recorded
+	if ingressClassName != "" {
+		annotations = withAnnotation(annotations, constants.AnnotationIngressClassKey, ingressClassName)
+	}
+
+	if configuration.GetOIDCAppsControllerConfig().GetIngressExternalDNS(object) {
+		annotations = withAnnotation(annotations, constants.ExternalDNSHostnameAnnotation, host)
+	}
+
+	return annotations
+}
+
+// verifyIngressHostIsUnique returns an error if any of the ingress hosts is already claimed by another oidc-apps
+// ingress in the namespace. Otherwise, the ingress controller would silently shadow one of the ingresses.
+func verifyIngressHostIsUnique(ctx context.Context, c client.Client, ingress networkingv1.Ingress) error {
+	ingresses := &networkingv1.IngressList{}
+	if err := c.List(ctx, ingresses,
+		client.InNamespace(ingress.GetNamespace()),
+		client.MatchingLabels{constants.LabelKey: constants.LabelValue},
+	); err != nil {
+		return fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	for _, existing := range ingresses.Items {
+		// Ingresses of the same owner, e.g. named with a previous suffix, are not conflicting
+		if existing.GetName() == ingress.GetName() || haveCommonOwner(&existing, &ingress) {
+			continue
+		}
+
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host == "" || !ingressHasHost(existing, rule.Host) {
+				continue
+			}
+
+			owner := existing.GetName()
+			if refs := existing.GetOwnerReferences(); len(refs) > 0 {
+				owner = strings.ToLower(refs[0].Kind) + "/" + refs[0].Name
+			}
+
+			return fmt.Errorf("%w: host %s is claimed by ingress %s of %s", errIngressHostConflict,
+				rule.Host, existing.GetName(), owner)
+		}
+	}
+
+	return nil
+}
+
+func ingressHasHost(ingress networkingv1.Ingress, host string) bool {
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host == host {
+			return true
+		}
+	}
+
+	return false
+}
+
+func haveCommonOwner(a, b client.Object) bool {
+	if owner, found := a.GetLabels()[constants.LabelOwnerKey]; found && owner == b.GetLabels()[constants.LabelOwnerKey] {
+		return true
+	}
+
+	// The path rule of a workload on a shared ingress is replaced by its dedicated ingress
+	if owner, found := b.GetLabels()[constants.LabelOwnerKey]; found {
+		if _, registered := sharedIngressOwners(a)[owner]; registered {
+			return true
+		}
+	}
+
+	for _, ref := range a.GetOwnerReferences() {
+		for _, other := range b.GetOwnerReferences() {
+			if ref.UID == other.UID {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,421 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func TestIngressForDeploymentDefaultPath(t *testing.T) {
+	g := NewWithT(t)
+
+	ingress, err := createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	g.Expect(paths).To(HaveLen(1))
+	g.Expect(paths[0].Path).To(Equal("/"))
+	g.Expect(*paths[0].PathType).To(Equal(networkingv1.PathTypePrefix))
+}
+
+func TestIngressForDeploymentBasePath(t *testing.T) {
+	g := NewWithT(t)
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.Path = "/app"
+	ingressConf.PathType = string(networkingv1.PathTypeImplementationSpecific)
+
+	t.Cleanup(func() {
+		ingressConf.Path = ""
+		ingressConf.PathType = ""
+	})
+
+	ingress, err := createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	paths := ingress.Spec.Rules[0].HTTP.Paths
+	g.Expect(paths[0].Path).To(Equal("/app"))
+	g.Expect(*paths[0].PathType).To(Equal(networkingv1.PathTypeImplementationSpecific))
+
+	// The oauth2-proxy endpoints shall be served under the same base path
+	secret, err := createOauth2Secret(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(string(secret.Data["oauth2-proxy.cfg"])).To(ContainSubstring(`proxy_prefix="/app/oauth2"`))
+	g.Expect(string(secret.Data["oauth2-proxy.cfg"])).To(
+		ContainSubstring(`redirect_url="https://nginx-default.domain.org/app/oauth2/callback"`))
+}
+
+func TestIngressHostIsUnique(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	ingress, err := createIngressForDeployment(deployment)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	other := ingress.DeepCopy()
+	other.Name = constants.IngressName + "-other"
+	other.Spec.Rules[0].Host = "other.domain.org"
+
+	c := newFakeClient(g, deployment, other)
+	g.Expect(createOrPatchIngress(ctx, c, ingress)).To(Succeed())
+
+	// Patching the own ingress is not a conflict
+	g.Expect(createOrPatchIngress(ctx, c, ingress)).To(Succeed())
+
+	// Neither is an ingress of the same owner with a different name
+	ownerRefs := []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "nginx", UID: deployment.GetUID()}}
+	ingress.OwnerReferences = ownerRefs
+	renamed := ingress.DeepCopy()
+	renamed.Name = constants.IngressName + "-renamed"
+
+	c = newFakeClient(g, deployment, &ingress)
+	g.Expect(createOrPatchIngress(ctx, c, *renamed)).To(Succeed())
+}
+
+func TestIngressHostConflict(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	ingress, err := createIngressForDeployment(deployment)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	other := ingress.DeepCopy()
+	other.Name = constants.IngressName + "-other"
+	other.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "other", UID: "other"}}
+
+	c := newFakeClient(g, deployment, other)
+	err = createOrPatchIngress(ctx, c, ingress)
+	g.Expect(err).To(MatchError(errIngressHostConflict))
+	g.Expect(err.Error()).To(ContainSubstring("deployment/other"))
+
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(&ingress), &networkingv1.Ingress{})).NotTo(Succeed())
+}
+
+func TestIngressExternalDNS(t *testing.T) {
+	g := NewWithT(t)
+
+	ingress, err := createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).NotTo(HaveKey(constants.ExternalDNSHostnameAnnotation))
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.ExternalDNS = true
+
+	t.Cleanup(func() { ingressConf.ExternalDNS = false })
+
+	ingress, err = createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.ExternalDNSHostnameAnnotation, ingress.Spec.Rules[0].Host))
+	g.Expect(ingress.Annotations[constants.ExternalDNSHostnameAnnotation]).To(Equal("nginx-default.domain.org"))
+	g.Expect(ingressConf.Annotations).NotTo(HaveKey(constants.ExternalDNSHostnameAnnotation))
+
+	// Each StatefulSet pod ingress points to its own per-pod host
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nginx-1",
+			Namespace:   "default",
+			UID:         "target-statefulset-pod-1",
+			Labels:      map[string]string{"app": "nginx", "statefulset.kubernetes.io/pod-name": "nginx-1"},
+			Annotations: map[string]string{constants.AnnotationHostKey: "nginx-default.domain.org"},
+		},
+	}
+
+	ingress, err = createIngressForPod(pod, getTargetStatefulSet())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Spec.Rules[0].Host).To(Equal("nginx-default-1.domain.org"))
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.ExternalDNSHostnameAnnotation, "nginx-default-1.domain.org"))
+}
+
+func TestIngressSessionAffinity(t *testing.T) {
+	g := NewWithT(t)
+
+	ingress, err := createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).NotTo(HaveKey(constants.NginxAffinityAnnotation))
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.SessionAffinity = true
+	ingressConf.Annotations = map[string]string{constants.NginxSessionCookieNameAnnotation: "custom"}
+
+	t.Cleanup(func() {
+		ingressConf.SessionAffinity = false
+		ingressConf.Annotations = nil
+	})
+
+	ingress, err = createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.NginxAffinityAnnotation, "cookie"))
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.NginxAffinityModeAnnotation, "persistent"))
+	// Explicitly configured annotations take precedence
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.NginxSessionCookieNameAnnotation, "custom"))
+
+	// The per-pod ingresses of a StatefulSet also stick to the replica
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nginx-0",
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "nginx", "statefulset.kubernetes.io/pod-name": "nginx-0"},
+			Annotations: map[string]string{constants.AnnotationHostKey: "nginx-default.domain.org"},
+		},
+	}
+
+	ingress, err = createIngressForPod(pod, getTargetStatefulSet())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.NginxAffinityAnnotation, "cookie"))
+}
+
+func TestIngressProxyBodySize(t *testing.T) {
+	g := NewWithT(t)
+
+	ingress, err := createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).NotTo(HaveKey(constants.NginxProxyBodySizeAnnotation))
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.ProxyBodySize = "100m"
+
+	t.Cleanup(func() {
+		ingressConf.ProxyBodySize = ""
+		ingressConf.Annotations = nil
+	})
+
+	ingress, err = createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.NginxProxyBodySizeAnnotation, "100m"))
+
+	// The annotation of the workload takes precedence, an invalid one is ignored
+	deployment := getTargetDeployment()
+	deployment.SetAnnotations(map[string]string{constants.AnnotationProxyBodySizeKey: "0"})
+
+	ingress, err = createIngressForDeployment(deployment)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.NginxProxyBodySizeAnnotation, "0"))
+
+	deployment.SetAnnotations(map[string]string{constants.AnnotationProxyBodySizeKey: "unlimited"})
+
+	ingress, err = createIngressForDeployment(deployment)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.NginxProxyBodySizeAnnotation, "100m"))
+
+	// The per-pod ingresses of a StatefulSet accept the same bodies
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nginx-0",
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "nginx", "statefulset.kubernetes.io/pod-name": "nginx-0"},
+			Annotations: map[string]string{constants.AnnotationHostKey: "nginx-default.domain.org"},
+		},
+	}
+
+	ingress, err = createIngressForPod(pod, getTargetStatefulSet())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.NginxProxyBodySizeAnnotation, "100m"))
+
+	// Explicitly configured annotations take precedence
+	ingressConf.Annotations = map[string]string{constants.NginxProxyBodySizeAnnotation: "8m"}
+
+	ingress, err = createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.NginxProxyBodySizeAnnotation, "8m"))
+}
+
+func TestIngressClassNamesStatefulSetPods(t *testing.T) {
+	g := NewWithT(t)
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.IngressClassNames = []string{"nginx-a", "nginx-b", "nginx-c"}
+
+	t.Cleanup(func() {
+		ingressConf.IngressClassNames = nil
+		ingressConf.IngressClassAssignment = ""
+	})
+
+	statefulSetPodIngressClasses := func(replicas int) map[string]int {
+		classes := make(map[string]int)
+
+		for i := range replicas {
+			name := fmt.Sprintf("nginx-%d", i)
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        name,
+					Namespace:   "default",
+					Labels:      map[string]string{"app": "nginx", "statefulset.kubernetes.io/pod-name": name},
+					Annotations: map[string]string{constants.AnnotationHostKey: "nginx-default.domain.org"},
+				},
+			}
+
+			ingress, err := createIngressForPod(pod, getTargetStatefulSet())
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(ingress.Spec.IngressClassName).NotTo(BeNil())
+			g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.AnnotationIngressClassKey,
+				*ingress.Spec.IngressClassName))
+
+			classes[*ingress.Spec.IngressClassName]++
+		}
+
+		return classes
+	}
+
+	// The pods are spread evenly by their ordinal
+	g.Expect(statefulSetPodIngressClasses(9)).To(Equal(map[string]int{"nginx-a": 3, "nginx-b": 3, "nginx-c": 3}))
+
+	// The hashed pod names are spread across all classes, and each pod keeps its class
+	ingressConf.IngressClassAssignment = configuration.IngressClassAssignmentHash
+
+	hashed := statefulSetPodIngressClasses(300)
+	g.Expect(hashed).To(HaveLen(3))
+
+	for class, pods := range hashed {
+		g.Expect(pods).To(BeNumerically("~", 100, 25), class)
+	}
+
+	g.Expect(statefulSetPodIngressClasses(300)).To(Equal(hashed))
+
+	// The deployment ingress keeps the configured ingress class name
+	ingress, err := createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ingress.Spec.IngressClassName).To(Equal(ptr.To("nginx")))
+}
+
+func TestIngressClassAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	t.Cleanup(func() { forgetOutcome(deployment) })
+
+	c := newFakeClient(g, deployment)
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	// The generated ingress records the resolved ingress class
+	ingress := &networkingv1.Ingress{}
+	key := client.ObjectKey{Namespace: "default", Name: constants.IngressName + "-" + rand.GenerateSha256("nginx-default")}
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+	g.Expect(ingress.Spec.IngressClassName).To(Equal(ptr.To("nginx")))
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.AnnotationIngressClassKey, "nginx"))
+
+	// The default ingress class of the cluster is not recorded
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.IngressClassName = ""
+
+	t.Cleanup(func() { ingressConf.IngressClassName = "nginx" })
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+	g.Expect(ingress.Annotations).NotTo(HaveKey(constants.AnnotationIngressClassKey))
+}
+
+func TestIngressClassChange(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("ingress-class-change-deployment")
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+
+	t.Cleanup(func() {
+		ingressConf.IngressClassName = "nginx"
+		forgetSuffix(deployment)
+		forgetOutcome(deployment)
+	})
+
+	c := newFakeClient(g, deployment)
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	ingress := &networkingv1.Ingress{}
+	key := client.ObjectKey{Namespace: "default", Name: constants.IngressName + "-" + rand.GenerateSha256("nginx-default")}
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+	g.Expect(ingress.Spec.IngressClassName).To(Equal(ptr.To("nginx")))
+
+	// The deprecated class annotation, e.g. set by an earlier tooling, would shadow the class name
+	ingress.Annotations[constants.IngressClassAnnotation] = "nginx"
+	g.Expect(c.Update(ctx, ingress)).To(Succeed())
+
+	// A changed class converges the existing ingress
+	ingressConf.IngressClassName = "nginx-internal"
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+	g.Expect(ingress.Spec.IngressClassName).To(Equal(ptr.To("nginx-internal")))
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.AnnotationIngressClassKey, "nginx-internal"))
+	g.Expect(ingress.Annotations).NotTo(HaveKey(constants.IngressClassAnnotation))
+
+	// The class annotation is kept, when it is a configured ingress annotation
+	annotations := ingressConf.Annotations
+	ingressConf.Annotations = map[string]string{constants.IngressClassAnnotation: "nginx-internal"}
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+	g.Expect(ingress.Annotations).To(HaveKeyWithValue(constants.IngressClassAnnotation, "nginx-internal"))
+
+	ingressConf.Annotations = annotations
+
+	// The default ingress class of the cluster unsets the class name
+	ingressConf.IngressClassName = ""
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+	g.Expect(ingress.Spec.IngressClassName).To(BeNil())
+}
+
+func TestSkipIngressForExposedService(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	suffix := rand.GenerateSha256("nginx-default")
+	ingressKey := client.ObjectKey{Namespace: "default", Name: constants.IngressName + "-" + suffix}
+	serviceKey := client.ObjectKey{Namespace: "default", Name: constants.ServiceNameOauth2Service + "-" + suffix}
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(c.Get(ctx, ingressKey, &networkingv1.Ingress{})).To(Succeed())
+
+	target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+	target.Service = &configuration.ServiceConf{Type: corev1.ServiceTypeLoadBalancer, SkipIngress: true}
+
+	t.Cleanup(func() { target.Service = nil })
+
+	// The ingress is removed once the oauth2 service exposes the oauth2-proxy directly
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(apierrors.IsNotFound(c.Get(ctx, ingressKey, &networkingv1.Ingress{}))).To(BeTrue())
+
+	service := &corev1.Service{}
+	g.Expect(c.Get(ctx, serviceKey, service)).To(Succeed())
+	g.Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+
+	// The ingress is kept for a ClusterIP service
+	target.Service.Type = corev1.ServiceTypeClusterIP
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(c.Get(ctx, ingressKey, &networkingv1.Ingress{})).To(Succeed())
+}
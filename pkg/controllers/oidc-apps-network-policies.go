@@ -0,0 +1,188 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// createMetricsNetworkPolicy renders the network policy of the target pods, which allows the access to the oauth2-proxy
+// metrics port only from the configured scrapers. As the policy isolates the selected pods, all the other ports stay
+// open to any peer.
+func createMetricsNetworkPolicy(selectors client.MatchingLabels, object client.Object) networkingv1.NetworkPolicy {
+	metricsPort := configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyMetricsPort(object)
+
+	return networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(constants.NetworkPolicyNameOauth2Metrics, object),
+			Namespace: object.GetNamespace(),
+			Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: selectors},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{Ports: portsExcept(metricsPort)},
+				{
+					Ports: []networkingv1.NetworkPolicyPort{{
+						Protocol: ptr.To(corev1.ProtocolTCP),
+						Port:     ptr.To(intstr.FromInt32(metricsPort)),
+					}},
+					From: configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyMetricsScrapers(object),
+				},
+			},
+		},
+	}
+}
+
+// portsExcept returns the port ranges of all the protocols, which leave out the given TCP port
+func portsExcept(port int32) []networkingv1.NetworkPolicyPort {
+	portRange := func(protocol corev1.Protocol, from, to int32) networkingv1.NetworkPolicyPort {
+		return networkingv1.NetworkPolicyPort{
+			Protocol: ptr.To(protocol),
+			Port:     ptr.To(intstr.FromInt32(from)),
+			EndPort:  ptr.To(to),
+		}
+	}
+
+	var ports []networkingv1.NetworkPolicyPort
+
+	if port > 1 {
+		ports = append(ports, portRange(corev1.ProtocolTCP, 1, port-1))
+	}
+
+	if port < math.MaxUint16 {
+		ports = append(ports, portRange(corev1.ProtocolTCP, port+1, math.MaxUint16))
+	}
+
+	return append(ports,
+		portRange(corev1.ProtocolUDP, 1, math.MaxUint16),
+		portRange(corev1.ProtocolSCTP, 1, math.MaxUint16),
+	)
+}
+
+// desiredMetricsNetworkPolicy renders the metrics network policy of the target pods, if the metrics scrapers are
+// configured for the target. The policy is not rendered without a pod selector, as it would select all the pods in the
+// namespace.
+func desiredMetricsNetworkPolicy(object client.Object) []client.Object {
+	if len(configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyMetricsScrapers(object)) == 0 {
+		return nil
+	}
+
+	selectors := podSelector(object)
+	if len(selectors) == 0 {
+		// The pods of a rollout are selected by the target label selector
+		if labelSelector := configuration.GetOIDCAppsControllerConfig().GetTargetLabelSelector(object); labelSelector != nil {
+			selectors = labelSelector.MatchLabels
+		}
+	}
+
+	if len(selectors) == 0 {
+		return nil
+	}
+
+	networkPolicy := createMetricsNetworkPolicy(selectors, object)
+
+	return []client.Object{&networkPolicy}
+}
+
+func fetchOidcAppsNetworkPolicies(ctx context.Context, c client.Client, object client.Object) (
+	*networkingv1.NetworkPolicyList, error) {
+	oidcNetworkPolicies := &networkingv1.NetworkPolicyList{}
+
+	if err := c.List(ctx, oidcNetworkPolicies,
+		client.InNamespace(object.GetNamespace()),
+		client.MatchingLabels{constants.LabelKey: constants.LabelValue},
+	); err != nil {
+		return oidcNetworkPolicies, client.IgnoreNotFound(err)
+	}
+
+	owned := make([]networkingv1.NetworkPolicy, 0, len(oidcNetworkPolicies.Items))
+
+	for _, networkPolicy := range oidcNetworkPolicies.Items {
+		if isAnOwnedResource(object, &networkPolicy) {
+			owned = append(owned, networkPolicy)
+		}
+	}
+
+	return &networkingv1.NetworkPolicyList{Items: owned}, nil
+}
+
+// deleteUndesiredNetworkPolicies deletes the oidc-apps network policies of the target, which are no longer desired,
+// e.g. once the metrics scrapers are removed from the configuration
+func deleteUndesiredNetworkPolicies(ctx context.Context, c client.Client, object client.Object,
+	desired []client.Object) error {
+	networkPolicies, err := fetchOidcAppsNetworkPolicies(ctx, c, object)
+	if err != nil {
+		return err
+	}
+
+	for _, networkPolicy := range networkPolicies.Items {
+		if containsResource(desired, &networkPolicy) {
+			continue
+		}
+
+		if err := c.Delete(ctx, &networkPolicy, deletionPropagation()); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete network policy %s: %w", networkPolicy.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+func createOrPatchNetworkPolicy(ctx context.Context, c client.Client, patch networkingv1.NetworkPolicy) error {
+	networkPolicy := &networkingv1.NetworkPolicy{}
+
+	// Create a network policy if it does not exist
+	if err := c.Get(ctx, client.ObjectKeyFromObject(&patch), networkPolicy); apierrors.IsNotFound(err) {
+		if err = c.Create(ctx, &patch); err != nil {
+			return fmt.Errorf("failed to create network policy: %w", err)
+		}
+
+		return nil
+	}
+
+	// Patch the network policy if it exists
+	if err := retry.RetryOnConflict(configuration.GetOIDCAppsControllerConfig().GetRetryBackoff(), func() error {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(&patch), networkPolicy); err != nil {
+			return fmt.Errorf("failed to get network policy: %w", err)
+		}
+
+		_patch := client.MergeFrom(networkPolicy.DeepCopy())
+
+		mergeObjectMeta(networkPolicy, &patch)
+		networkPolicy.Spec = patch.Spec
+
+		return c.Patch(ctx, networkPolicy, _patch)
+	}); err != nil {
+		return fmt.Errorf("failed to patch network policy: %w", err)
+	}
+
+	return nil
+}
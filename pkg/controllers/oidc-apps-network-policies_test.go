@@ -0,0 +1,108 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func TestMetricsNetworkPolicy(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	scrapers := []networkingv1.NetworkPolicyPeer{{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "monitoring"}},
+	}}
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.Oauth2Proxy.MetricsScrapers = scrapers
+
+	t.Cleanup(func() {
+		cfg.Configuration.Oauth2Proxy.MetricsPort = 0
+		cfg.Configuration.Oauth2Proxy.MetricsScrapers = nil
+	})
+
+	deployment := getTargetDeployment()
+	t.Cleanup(func() { forgetOutcome(deployment) })
+
+	c := newFakeClient(g, deployment)
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+
+	// The metrics port is not restricted without being exposed
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	networkPolicies, err := fetchOidcAppsNetworkPolicies(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(networkPolicies.Items).To(BeEmpty())
+
+	cfg.Configuration.Oauth2Proxy.MetricsPort = 9090
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	networkPolicy := &networkingv1.NetworkPolicy{}
+	key := client.ObjectKey{
+		Namespace: "default",
+		Name:      constants.NetworkPolicyNameOauth2Metrics + "-" + rand.GenerateSha256("nginx-default"),
+	}
+	g.Expect(c.Get(ctx, key, networkPolicy)).To(Succeed())
+	g.Expect(isAnOwnedResource(deployment, networkPolicy)).To(BeTrue())
+	g.Expect(networkPolicy.Spec.PodSelector.MatchLabels).To(Equal(deployment.Spec.Selector.MatchLabels))
+	g.Expect(networkPolicy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress))
+
+	// The metrics port is open to the scrapers only, all the other ports to any peer
+	tcp, metricsPort := ptr.To(corev1.ProtocolTCP), ptr.To(intstr.FromInt32(9090))
+	g.Expect(networkPolicy.Spec.Ingress).To(HaveLen(2))
+	g.Expect(networkPolicy.Spec.Ingress[0].From).To(BeEmpty())
+	g.Expect(networkPolicy.Spec.Ingress[0].Ports).To(ContainElements(
+		networkingv1.NetworkPolicyPort{Protocol: tcp, Port: ptr.To(intstr.FromInt32(1)), EndPort: ptr.To[int32](9089)},
+		networkingv1.NetworkPolicyPort{Protocol: tcp, Port: ptr.To(intstr.FromInt32(9091)), EndPort: ptr.To[int32](65535)},
+	))
+	g.Expect(networkPolicy.Spec.Ingress[1]).To(Equal(networkingv1.NetworkPolicyIngressRule{
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: tcp, Port: metricsPort}},
+		From:  scrapers,
+	}))
+
+	// The network policy is removed together with the scrapers
+	cfg.Configuration.Oauth2Proxy.MetricsScrapers = nil
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(c.Get(ctx, key, &networkingv1.NetworkPolicy{})).NotTo(Succeed())
+}
+
+func TestPortsExcept(t *testing.T) {
+	g := NewWithT(t)
+
+	for _, port := range []int32{1, 9090, 65535} {
+		for _, p := range portsExcept(port) {
+			if *p.Protocol != corev1.ProtocolTCP {
+				continue
+			}
+
+			g.Expect(p.Port.IntVal > port || *p.EndPort < port).To(BeTrue(), "port %d", port)
+			g.Expect(p.Port.IntVal).To(BeNumerically("<=", *p.EndPort))
+		}
+	}
+}
@@ -15,60 +15,50 @@
 package controllers
 
 import (
+	"bytes"
+	"context"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
-	"path/filepath"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/yaml"
 
 	"github.com/gardener/oidc-apps-controller/pkg/configuration"
 	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/garden"
 	"github.com/gardener/oidc-apps-controller/pkg/rand"
 )
 
 var errSecretDoesNotExist = errors.New("secret does not exist")
 
 func createOauth2Secret(object client.Object) (corev1.Secret, error) {
-	var cfg string
-
-	suffix := rand.GenerateSha256(object.GetName() + "-" + object.GetNamespace())
 	extConfig := configuration.GetOIDCAppsControllerConfig()
 
-	switch extConfig.GetClientSecret(object) {
-	case "":
-		cfg = configuration.NewOAuth2Config(
-			configuration.WithClientID(extConfig.GetClientID(object)),
-			configuration.WithClientSecretFile("/dev/null"),
-			configuration.WithScope(extConfig.GetScope(object)),
-			configuration.WithRedirectURL(extConfig.GetRedirectURL(object)),
-			configuration.WithOidcIssuerURL(extConfig.GetOidcIssuerURL(object)),
-			configuration.EnableSslInsecureSkipVerify(extConfig.GetSslInsecureSkipVerify(object)),
-			configuration.EnableInsecureOidcSkipIssuerVerification(extConfig.GetInsecureOidcSkipIssuerVerification(object)),
-			configuration.EnableInsecureOidcSkipNonce(extConfig.GetInsecureOidcSkipNonce(object))).Parse()
-
-	default:
-		cfg = configuration.NewOAuth2Config(
-			configuration.WithClientID(extConfig.GetClientID(object)),
-			configuration.WithClientSecret(extConfig.GetClientSecret(object)),
-			configuration.WithScope(extConfig.GetScope(object)),
-			configuration.WithRedirectURL(extConfig.GetRedirectURL(object)),
-			configuration.WithOidcIssuerURL(extConfig.GetOidcIssuerURL(object)),
-			configuration.EnableSslInsecureSkipVerify(extConfig.GetSslInsecureSkipVerify(object)),
-			configuration.EnableInsecureOidcSkipIssuerVerification(extConfig.GetInsecureOidcSkipIssuerVerification(object)),
-			configuration.EnableInsecureOidcSkipNonce(extConfig.GetInsecureOidcSkipNonce(object))).Parse()
-	}
-
-	checksum := rand.GenerateFullSha256(cfg)
+	cfg := configuration.NewOAuth2Config(extConfig.GetOauth2ProxyOptions(object)...).Parse()
+	alphaCfg := configuration.NewOAuth2AlphaConfig(extConfig.GetOauth2ProxyOptions(object)...).Parse()
+
+	// The alpha configuration is empty unless the upstream paths are rewritten, hence the checksums of the other
+	// targets are unchanged
+	checksum := rand.GenerateFullSha256(cfg + alphaCfg)
+
+	data := map[string][]byte{"oauth2-proxy.cfg": []byte(cfg)}
+	if alphaCfg != "" {
+		data[constants.Oauth2AlphaConfigKey] = []byte(alphaCfg)
+	}
 
 	return corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:        constants.SecretNameOauth2Proxy + "-" + suffix,
+			Name:        resourceName(constants.SecretNameOauth2Proxy, object),
 			Namespace:   object.GetNamespace(),
 			Annotations: map[string]string{constants.AnnotationOauth2SecertCehcksumKey: checksum},
 			Labels: map[string]string{
@@ -76,13 +66,106 @@ func createOauth2Secret(object client.Object) (corev1.Secret, error) {
 				constants.SecretLabelKey: constants.Oauth2LabelValue,
 			},
 		},
-		Data: map[string][]byte{"oauth2-proxy.cfg": []byte(cfg)},
+		Data: data,
 	}, nil
 }
 
-func createResourceAttributesSecret(object client.Object, targetNamespace string) (corev1.Secret, error) {
-	suffix := rand.GenerateSha256(object.GetName() + "-" + object.GetNamespace())
+// withCookieSecret sets the oauth2-proxy cookie secret on the oauth2 secret. The cookie secret of an existing oauth2
+// secret of the target is preserved, also when the secret is recreated under a new name, so that the user sessions
+// remain valid. A new cookie secret is generated only when none is found, or the found one exceeds the configured
+// maximum age.
+func withCookieSecret(ctx context.Context, c client.Client, object client.Object, oauth2Secret *corev1.Secret) error {
+	existing := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(oauth2Secret), existing); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("failed to get oauth2 secret: %w", err)
+	}
+
+	cookieSecret, rotatedAt := existing.Data[constants.Oauth2CookieSecretKey], cookieSecretRotatedAt(existing)
+
+	if len(cookieSecret) == 0 {
+		secrets, err := fetchOidcAppsSecrets(ctx, c, object)
+		if err != nil {
+			return fmt.Errorf("failed to list oauth2 secrets: %w", err)
+		}
+
+		for _, s := range secrets.Items {
+			if v := s.Data[constants.Oauth2CookieSecretKey]; len(v) > 0 {
+				cookieSecret, rotatedAt = v, cookieSecretRotatedAt(&s)
+
+				break
+			}
+		}
+	}
+
+	maxAge := configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyCookieSecretMaxAge(object)
+	if len(cookieSecret) > 0 && maxAge > 0 && !rotatedAt.IsZero() && time.Since(rotatedAt) >= maxAge {
+		log.FromContext(ctx).Info("Rotating the cookie secret exceeding its maximum age", "rotatedAt", rotatedAt,
+			"maxAge", maxAge)
+
+		cookieSecret = nil
+	}
+
+	// oauth2-proxy expects a cookie secret of 16, 24 or 32 bytes
+	if len(cookieSecret) == 0 {
+		cookieSecret, rotatedAt = []byte(rand.GenerateRandomString(32)), time.Now()
+	}
+
+	oauth2Secret.Data[constants.Oauth2CookieSecretKey] = cookieSecret
+
+	if maxAge == 0 {
+		return nil
+	}
+
+	// The cookie secrets found without a generation time are aged from now on
+	if rotatedAt.IsZero() {
+		rotatedAt = time.Now()
+	}
+
+	// The cookie secret is part of the checksum, so that the workload is restarted with the rotated cookie secret
+	annotations := withAnnotation(maps.Clone(oauth2Secret.GetAnnotations()), constants.AnnotationCookieSecretRotatedAtKey,
+		rotatedAt.UTC().Format(time.RFC3339))
+	annotations[constants.AnnotationOauth2SecertCehcksumKey] = rand.GenerateFullSha256(
+		annotations[constants.AnnotationOauth2SecertCehcksumKey] + string(cookieSecret))
+	oauth2Secret.SetAnnotations(annotations)
+
+	return nil
+}
+
+// cookieSecretRotationDelay returns the time until the cookie secret of the target exceeds its maximum age, zero when
+// it is never rotated. The target is requeued after it, as an idle workload is otherwise not reconciled again.
+func cookieSecretRotationDelay(ctx context.Context, c client.Client, object client.Object) (time.Duration, error) {
+	maxAge := configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyCookieSecretMaxAge(object)
+	if maxAge == 0 {
+		return 0, nil
+	}
 
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: object.GetNamespace(), Name: resourceName(constants.SecretNameOauth2Proxy, object)}
+
+	if err := c.Get(ctx, key, secret); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+
+	rotatedAt := cookieSecretRotatedAt(secret)
+	if rotatedAt.IsZero() {
+		return maxAge, nil
+	}
+
+	// The cookie secret exceeding its maximum age is rotated right away
+	return max(maxAge-time.Since(rotatedAt), time.Second), nil
+}
+
+// cookieSecretRotatedAt returns the generation time of the cookie secret in the oauth2 secret, its creation time when
+// the secret was created before the cookie secret rotation was enabled
+func cookieSecretRotatedAt(secret *corev1.Secret) time.Time {
+	if t, err := time.Parse(time.RFC3339, secret.GetAnnotations()[constants.AnnotationCookieSecretRotatedAtKey]); err == nil {
+		return t
+	}
+
+	return secret.GetCreationTimestamp().Time
+}
+
+func createResourceAttributesSecret(object client.Object, targetNamespace string) (corev1.Secret, error) {
 	// TODO: add configurable resource, subresource
 	cfg := configuration.NewResourceAttributes(
 		configuration.WithNamespace(targetNamespace),
@@ -91,7 +174,7 @@ func createResourceAttributesSecret(object client.Object, targetNamespace string
 
 	return corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      constants.SecretNameResourceAttributes + "-" + suffix,
+			Name:      resourceName(constants.SecretNameResourceAttributes, object),
 			Namespace: object.GetNamespace(),
 			Labels: map[string]string{
 				constants.LabelKey:       constants.LabelValue,
@@ -103,8 +186,6 @@ func createResourceAttributesSecret(object client.Object, targetNamespace string
 }
 
 func createKubeconfigSecret(object client.Object) (corev1.Secret, error) {
-	suffix := rand.GenerateSha256(object.GetName() + "-" + object.GetNamespace())
-
 	kubeConfigStr := configuration.GetOIDCAppsControllerConfig().GetKubeConfigStr(object)
 	if len(kubeConfigStr) > 0 {
 		decodestr, err := base64.StdEncoding.DecodeString(kubeConfigStr)
@@ -121,7 +202,7 @@ func createKubeconfigSecret(object client.Object) (corev1.Secret, error) {
 
 		secret := corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      constants.SecretNameKubeconfig + "-" + suffix,
+				Name:      resourceName(constants.SecretNameKubeconfig, object),
 				Namespace: object.GetNamespace(),
 				Labels: map[string]string{
 					constants.LabelKey:       constants.LabelValue,
@@ -134,16 +215,8 @@ func createKubeconfigSecret(object client.Object) (corev1.Secret, error) {
 		return secret, nil
 	}
 
-	var (
-		path        string
-		kcfg, token []byte
-		err         error
-	)
-
-	path = filepath.Dir(os.Getenv("GARDEN_KUBECONFIG"))
-	kcfg, err = os.ReadFile(filepath.Join(filepath.Clean(path), "kubeconfig"))
-
-	if err != nil && os.IsNotExist(err) {
+	kubeConfig, err := garden.NewKubeconfig()
+	if errors.Is(err, garden.ErrNotConfigured) || errors.Is(err, os.ErrNotExist) {
 		return corev1.Secret{}, errSecretDoesNotExist
 	}
 
@@ -151,39 +224,6 @@ func createKubeconfigSecret(object client.Object) (corev1.Secret, error) {
 		return corev1.Secret{}, fmt.Errorf("error creating kubeconfig secret: %w", err)
 	}
 
-	// Token is fetched from either the GARDEN_ACCESS_TOKEN environment variable if present, or from the GARDEN_KUBECONFIG
-
-	if os.Getenv("GARDEN_ACCESS_TOKEN") != "" {
-		path = os.Getenv("GARDEN_ACCESS_TOKEN")
-	}
-
-	token, err = os.ReadFile(filepath.Join(filepath.Clean(path), "token"))
-	if err != nil && os.IsNotExist(err) {
-		return corev1.Secret{}, nil
-	}
-
-	if err != nil {
-		return corev1.Secret{}, errSecretDoesNotExist
-	}
-
-	kubeConfig := clientcmdv1.Config{}
-	if err = yaml.Unmarshal(kcfg, &kubeConfig); err != nil {
-		return corev1.Secret{}, fmt.Errorf("error unmarshalling kubeconfig: %v", err)
-	}
-
-	if err != nil {
-		return corev1.Secret{}, fmt.Errorf("rror creating kubeconfig secret: %w", err)
-	}
-
-	for i, n := range kubeConfig.AuthInfos {
-		if n.Name != "extension" {
-			continue
-		}
-
-		kubeConfig.AuthInfos[i].AuthInfo.TokenFile = ""
-		kubeConfig.AuthInfos[i].AuthInfo.Token = string(token)
-	}
-
 	k, err := yaml.Marshal(kubeConfig)
 	if err != nil {
 		return corev1.Secret{}, fmt.Errorf("error marshaling kubeconfig: %v", err)
@@ -191,7 +231,7 @@ func createKubeconfigSecret(object client.Object) (corev1.Secret, error) {
 
 	return corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "kubeconfig-" + suffix,
+			Name:      resourceName(constants.SecretNameKubeconfig, object),
 			Namespace: object.GetNamespace(),
 			Labels: map[string]string{
 				constants.LabelKey:       constants.LabelValue,
@@ -202,15 +242,25 @@ func createKubeconfigSecret(object client.Object) (corev1.Secret, error) {
 	}, nil
 }
 
-func createOidcCaBundleSecret(object client.Object) (corev1.Secret, error) {
-	suffix := rand.GenerateSha256(object.GetName() + "-" + object.GetNamespace())
-
+// createOidcCaBundleSecret renders the secret with the trusted CA bundle of the OIDC Provider. The CA bundle
+// referenced by the oidc-ca-secret annotation of the workload overrides the configured one.
+func createOidcCaBundleSecret(ctx context.Context, c client.Client, object client.Object) (corev1.Secret, error) {
 	oidcCABundle := configuration.GetOIDCAppsControllerConfig().GetOidcCABundle(object)
+
+	if ref := configuration.GetOIDCAppsControllerConfig().GetOidcCASecretKeyRef(object); ref != nil {
+		bundle, err := fetchOidcCABundle(ctx, c, object.GetNamespace(), ref)
+		if err != nil {
+			return corev1.Secret{}, err
+		}
+
+		oidcCABundle = bundle
+	}
+
 	if len(oidcCABundle) > 0 {
 		// TODO: verify the oidcCABundle str, it shall be CA certificates in PEM format
 		secret := corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
-				Name:      constants.SecretNameOidcCa + "-" + suffix,
+				Name:      resourceName(constants.SecretNameOidcCa, object),
 				Namespace: object.GetNamespace(),
 				Labels: map[string]string{
 					constants.LabelKey:       constants.LabelValue,
@@ -225,3 +275,54 @@ func createOidcCaBundleSecret(object client.Object) (corev1.Secret, error) {
 
 	return corev1.Secret{}, errSecretDoesNotExist
 }
+
+// fetchOidcCABundle returns the PEM encoded CA bundle held by the referenced key of a secret in the namespace. The
+// secret has to carry the oidc-application-controller/component: oidc-apps label to be visible to the controller.
+func fetchOidcCABundle(ctx context.Context, c client.Client, namespace string, ref *corev1.SecretKeySelector) (string,
+	error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return "", fmt.Errorf("failed to get oidc ca secret %s/%s: %w", namespace, ref.Name, err)
+	}
+
+	bundle, found := secret.Data[ref.Key]
+	if !found {
+		return "", fmt.Errorf("oidc ca secret %s/%s has no %s key", namespace, ref.Name, ref.Key)
+	}
+
+	if err := verifyPEMCertificates(bundle); err != nil {
+		return "", fmt.Errorf("invalid oidc ca bundle in secret %s/%s: %w", namespace, ref.Name, err)
+	}
+
+	return string(bundle), nil
+}
+
+// verifyPEMCertificates verifies that the bundle consists of PEM encoded x509 certificates only
+func verifyPEMCertificates(bundle []byte) error {
+	var certificates int
+
+	for rest := bytes.TrimSpace(bundle); len(rest) > 0; rest = bytes.TrimSpace(rest) {
+		var block *pem.Block
+
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return errors.New("no PEM data found")
+		}
+
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected PEM block type %s", block.Type)
+		}
+
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("failed to parse certificate: %w", err)
+		}
+
+		certificates++
+	}
+
+	if certificates == 0 {
+		return errors.New("no certificates found")
+	}
+
+	return nil
+}
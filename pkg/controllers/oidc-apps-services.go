@@ -15,6 +15,10 @@
 package controllers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -22,17 +26,16 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
 	"github.com/gardener/oidc-apps-controller/pkg/constants"
-	"github.com/gardener/oidc-apps-controller/pkg/rand"
 )
 
-func createOauth2Service(selectors client.MatchingLabels, object client.Object) (corev1.Service, error) {
-	suffix := rand.GenerateSha256(object.GetName() + "-" + object.GetNamespace())
-	index := fetchStrIndexIfPresent(object)
-
-	return corev1.Service{
+// createOauth2Service renders the oauth2 service of the object, the target workload or one of its pods exposed per pod,
+// configured according to the owning target workload
+func createOauth2Service(selectors client.MatchingLabels, object, owner client.Object) (corev1.Service, error) {
+	service := corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      constants.ServiceNameOauth2Service + "-" + addOptionalIndex(index+"-") + suffix,
+			Name:      resourceName(constants.ServiceNameOauth2Service, object),
 			Namespace: object.GetNamespace(),
 			Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
 		},
@@ -46,8 +49,149 @@ func createOauth2Service(selectors client.MatchingLabels, object client.Object)
 				},
 			},
 			Selector: selectors,
+			Type:     configuration.GetOIDCAppsControllerConfig().GetServiceType(owner),
 		},
-	}, nil
+	}
+
+	// The metrics port is exposed on the service, bypassing the proxy authentication, so it can be scraped. A NodePort
+	// or LoadBalancer service exposes the oauth2 port only, the metrics would otherwise be reachable from outside.
+	if service.Spec.Type != corev1.ServiceTypeClusterIP {
+		return service, nil
+	}
+
+	if metricsPort := configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyMetricsPort(owner); metricsPort > 0 {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       "metrics",
+			Port:       metricsPort,
+			TargetPort: intstr.FromString("metrics"),
+		})
+	}
+
+	return service, nil
+}
+
+// deleteUndesiredServices deletes the oidc-apps services of the target, which are no longer desired, e.g. the oauth2
+// service of a statefulset once its pods are exposed through per-pod services
+func deleteUndesiredServices(ctx context.Context, c client.Client, object client.Object, desired []client.Object) error {
+	services, err := fetchOidcAppsServices(ctx, c, object)
+	if err != nil {
+		return err
+	}
+
+	for _, service := range services.Items {
+		if slices.ContainsFunc(desired, func(d client.Object) bool {
+			_, isService := d.(*corev1.Service)
+
+			return isService && d.GetName() == service.GetName()
+		}) {
+			continue
+		}
+
+		if err := c.Delete(ctx, &service, deletionPropagation()); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to delete service %s: %w", service.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// errServicePortConflict is returned when the port of the oauth2-proxy is already used by the reused target service
+var errServicePortConflict = errors.New("service port is already in use")
+
+// oauth2ServicePort returns the oauth2-proxy port added to a reused target service
+func oauth2ServicePort(object client.Object) corev1.ServicePort {
+	return corev1.ServicePort{
+		Name:       constants.ServicePortNameOauth2Proxy,
+		Port:       configuration.GetOIDCAppsControllerConfig().GetIngressServicePort(object),
+		TargetPort: intstr.FromString("oauth2"),
+	}
+}
+
+// addOauth2PortToService adds the oauth2-proxy port to the existing target service, which the ingress routes to
+// instead of a generated oauth2 service. The service is annotated with the owner of the port, so that the port is
+// removed also once the service is no longer reused or the workload is gone.
+func addOauth2PortToService(ctx context.Context, c client.Client, object client.Object) error {
+	owner := ownerLabelValue(workloadKind(object), client.ObjectKeyFromObject(object))
+	name := configuration.GetOIDCAppsControllerConfig().GetIngressServiceName(object)
+
+	// The port is removed from a formerly reused service
+	if err := removeOauth2PortFromServices(ctx, c, object.GetNamespace(), owner, name); err != nil {
+		return err
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	service := &corev1.Service{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: object.GetNamespace(), Name: name}, service); err != nil {
+		return fmt.Errorf("failed to get service %s: %w", name, err)
+	}
+
+	port := oauth2ServicePort(object)
+	for _, p := range service.Spec.Ports {
+		if p.Name != port.Name && p.Port == port.Port {
+			return fmt.Errorf("%w: port %d of service %s", errServicePortConflict, port.Port, name)
+		}
+	}
+
+	isOauth2Port := func(p corev1.ServicePort) bool {
+		return p.Name == port.Name && p.Port == port.Port && p.TargetPort == port.TargetPort
+	}
+	if slices.ContainsFunc(service.Spec.Ports, isOauth2Port) &&
+		service.GetAnnotations()[constants.AnnotationOauth2PortOwnerKey] == owner {
+		return nil
+	}
+
+	patch := client.MergeFrom(service.DeepCopy())
+	service.Spec.Ports = append(slices.DeleteFunc(service.Spec.Ports, func(p corev1.ServicePort) bool {
+		return p.Name == port.Name
+	}), port)
+	metav1.SetMetaDataAnnotation(&service.ObjectMeta, constants.AnnotationOauth2PortOwnerKey, owner)
+
+	if err := c.Patch(ctx, service, patch); err != nil {
+		return fmt.Errorf("failed to add the oauth2-proxy port to service %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// removeOauth2PortFromServices removes the oauth2-proxy port from the existing target services, which carry it on
+// behalf of the given owner, apart from the one still reused
+func removeOauth2PortFromServices(ctx context.Context, c client.Client, namespace, owner, reused string) error {
+	services := &corev1.ServiceList{}
+	if err := c.List(ctx, services, client.InNamespace(namespace),
+		client.MatchingLabels{constants.LabelKey: constants.LabelValue}); err != nil {
+		return fmt.Errorf("failed to list services: %w", err)
+	}
+
+	for _, service := range services.Items {
+		if service.GetName() == reused || service.GetAnnotations()[constants.AnnotationOauth2PortOwnerKey] != owner {
+			continue
+		}
+
+		patch := client.MergeFrom(service.DeepCopy())
+		service.Spec.Ports = slices.DeleteFunc(service.Spec.Ports, func(p corev1.ServicePort) bool {
+			return p.Name == constants.ServicePortNameOauth2Proxy
+		})
+		delete(service.Annotations, constants.AnnotationOauth2PortOwnerKey)
+
+		if err := c.Patch(ctx, &service, patch); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to remove the oauth2-proxy port from service %s: %w", service.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// podHostIndex returns the index distinguishing the host of a pod exposed through a dedicated ingress, the ordinal of a
+// statefulset pod or the name hash of a deployment pod, empty for other pods
+func podHostIndex(object client.Object) string {
+	if index := fetchStrIndexIfPresent(object); index != "" {
+		return index
+	}
+
+	return object.GetLabels()[constants.LabelPodNameHashKey]
 }
 
 func fetchStrIndexIfPresent(object client.Object) string {
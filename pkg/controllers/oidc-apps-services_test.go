@@ -0,0 +1,232 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func TestOauth2ServiceWithoutMetricsPort(t *testing.T) {
+	g := NewWithT(t)
+
+	service, err := createOauth2Service(map[string]string{"app": "nginx"}, getTargetDeployment(), getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(service.Spec.Ports).To(HaveLen(1))
+	g.Expect(service.Spec.Ports[0].Name).To(Equal("http"))
+}
+
+func TestOauth2ServiceWithMetricsPort(t *testing.T) {
+	g := NewWithT(t)
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.Oauth2Proxy.MetricsPort = 9090
+
+	t.Cleanup(func() { cfg.Configuration.Oauth2Proxy.MetricsPort = 0 })
+
+	service, err := createOauth2Service(map[string]string{"app": "nginx"}, getTargetDeployment(), getTargetDeployment())
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(service.Spec.Ports).To(ContainElement(corev1.ServicePort{
+		Name:       "metrics",
+		Port:       9090,
+		TargetPort: intstr.FromString("metrics"),
+	}))
+}
+
+func TestReuseExistingService(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.ServiceName = "nginx"
+
+	t.Cleanup(func() { ingressConf.ServiceName = "" })
+
+	deployment := getTargetDeployment()
+	existing := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx",
+			Namespace: "default",
+			Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromInt32(8080)}},
+			Selector: map[string]string{"app": "nginx"},
+		},
+	}
+	c := newFakeClient(g, deployment, existing)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	// No dedicated oauth2 service is created
+	services := &corev1.ServiceList{}
+	g.Expect(c.List(ctx, services)).To(Succeed())
+	g.Expect(services.Items).To(HaveLen(1))
+
+	// The oauth2-proxy port is added to the existing service
+	service := services.Items[0]
+	g.Expect(service.Spec.Ports).To(ConsistOf(existing.Spec.Ports[0], oauth2ServicePort(deployment)))
+	g.Expect(service.GetOwnerReferences()).To(BeEmpty())
+	g.Expect(service.GetAnnotations()).To(HaveKeyWithValue(constants.AnnotationOauth2PortOwnerKey,
+		ownerLabelValue("Deployment", client.ObjectKeyFromObject(deployment))))
+
+	// The ingress routes to the oauth2-proxy port of the existing service
+	suffix := rand.GenerateSha256("nginx-default")
+	ingress := &networkingv1.Ingress{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: constants.IngressName + "-" + suffix},
+		ingress)).To(Succeed())
+	g.Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service).To(Equal(&networkingv1.IngressServiceBackend{
+		Name: "nginx",
+		Port: networkingv1.ServiceBackendPort{Name: constants.ServicePortNameOauth2Proxy},
+	}))
+
+	// A repeated reconcile does not add the port twice
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), &service)).To(Succeed())
+	g.Expect(service.Spec.Ports).To(HaveLen(2))
+
+	// The port is removed together with the owned resources
+	g.Expect(deleteOwnedResources(ctx, c, deployment, deletionPropagation())).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), &service)).To(Succeed())
+	g.Expect(service.Spec.Ports).To(ConsistOf(existing.Spec.Ports[0]))
+	g.Expect(service.GetAnnotations()).NotTo(HaveKey(constants.AnnotationOauth2PortOwnerKey))
+}
+
+func TestReuseExistingServiceRemovesPort(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.ServiceName = "nginx"
+	ingressConf.ServicePort = 8443
+
+	t.Cleanup(func() {
+		ingressConf.ServiceName = ""
+		ingressConf.ServicePort = 0
+	})
+
+	deployment := getTargetDeployment()
+	existing := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx",
+			Namespace: "default",
+			Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "http", Port: 8080}},
+		},
+	}
+	c := newFakeClient(g, deployment, existing)
+
+	// The configured port does not conflict with the one of the application
+	g.Expect(addOauth2PortToService(ctx, c, deployment)).To(Succeed())
+
+	service := &corev1.Service{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), service)).To(Succeed())
+	g.Expect(service.Spec.Ports).To(ContainElement(corev1.ServicePort{
+		Name:       constants.ServicePortNameOauth2Proxy,
+		Port:       8443,
+		TargetPort: intstr.FromString("oauth2"),
+	}))
+
+	// The port is removed once the service is no longer reused
+	ingressConf.ServiceName = ""
+	g.Expect(addOauth2PortToService(ctx, c, deployment)).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), service)).To(Succeed())
+	g.Expect(service.Spec.Ports).To(ConsistOf(existing.Spec.Ports[0]))
+
+	// The port is removed as well once the workload is gone
+	ingressConf.ServiceName = "nginx"
+	g.Expect(addOauth2PortToService(ctx, c, deployment)).To(Succeed())
+	g.Expect(deleteOrphanedResources(ctx, c, "default",
+		ownerLabelValue("Deployment", client.ObjectKeyFromObject(deployment)), deletionPropagation())).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), service)).To(Succeed())
+	g.Expect(service.Spec.Ports).To(ConsistOf(existing.Spec.Ports[0]))
+}
+
+func TestReuseExistingServicePortConflict(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.ServiceName = "nginx"
+
+	t.Cleanup(func() { ingressConf.ServiceName = "" })
+
+	deployment := getTargetDeployment()
+	existing := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "http", Port: 8080}},
+		},
+	}
+	c := newFakeClient(g, deployment, existing)
+
+	g.Expect(addOauth2PortToService(ctx, c, deployment)).To(MatchError(errServicePortConflict))
+
+	// A missing service is reported
+	g.Expect(c.Delete(ctx, existing)).To(Succeed())
+	g.Expect(addOauth2PortToService(ctx, c, deployment)).To(MatchError(ContainSubstring("failed to get service nginx")))
+}
+
+func TestOauth2ServiceType(t *testing.T) {
+	target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+	t.Cleanup(func() { target.Service = nil })
+
+	for _, tc := range []struct {
+		name       string
+		conf       *configuration.ServiceConf
+		annotation string
+		expected   corev1.ServiceType
+	}{
+		{name: "default", expected: corev1.ServiceTypeClusterIP},
+		{name: "cluster ip", conf: &configuration.ServiceConf{Type: corev1.ServiceTypeClusterIP},
+			expected: corev1.ServiceTypeClusterIP},
+		{name: "node port", conf: &configuration.ServiceConf{Type: corev1.ServiceTypeNodePort},
+			expected: corev1.ServiceTypeNodePort},
+		{name: "load balancer", conf: &configuration.ServiceConf{Type: corev1.ServiceTypeLoadBalancer},
+			expected: corev1.ServiceTypeLoadBalancer},
+		{name: "annotation", conf: &configuration.ServiceConf{Type: corev1.ServiceTypeNodePort},
+			annotation: string(corev1.ServiceTypeLoadBalancer), expected: corev1.ServiceTypeLoadBalancer},
+		{name: "unsupported annotation", annotation: string(corev1.ServiceTypeExternalName),
+			expected: corev1.ServiceTypeClusterIP},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			target.Service = tc.conf
+
+			deployment := getTargetDeployment()
+			if tc.annotation != "" {
+				deployment.Annotations = map[string]string{constants.AnnotationServiceTypeKey: tc.annotation}
+			}
+
+			service, err := createOauth2Service(map[string]string{"app": "nginx"}, deployment, deployment)
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(service.Spec.Type).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// reasonUpstreamPortNotExposed is the reason of the warning event emitted for a target not exposing its upstream port
+const reasonUpstreamPortNotExposed = "UpstreamPortNotExposed"
+
+// errUpstreamPortNotExposed is returned when no container of the target exposes the configured upstream port
+var errUpstreamPortNotExposed = errors.New("upstream port is not exposed")
+
+// warnOnUnexposedUpstreamPort emits a warning event when the target does not expose the configured upstream port, as
+// the injected proxies cannot reach the upstream then
+func warnOnUnexposedUpstreamPort(recorder record.EventRecorder, object client.Object) {
+	if recorder == nil {
+		return
+	}
+
+	if err := verifyUpstreamPort(object); err != nil {
+		recorder.Event(object, corev1.EventTypeWarning, reasonUpstreamPortNotExposed, err.Error())
+	}
+}
+
+// verifyUpstreamPort verifies that a container of the target exposes the configured upstream port. A named port has
+// to be declared by a container. A numeric port has to be declared as well, unless the containers declare no ports at
+// all, in which case any valid port number is plausible.
+func verifyUpstreamPort(object client.Object) error {
+	port := configuration.GetOIDCAppsControllerConfig().GetTargetPort(object)
+	if port.String() == "" || port.String() == "0" {
+		return nil
+	}
+
+	spec, ok := workloadPodSpec(object)
+	if !ok {
+		return nil
+	}
+
+	var declared []corev1.ContainerPort
+
+	for _, c := range spec.Containers {
+		if c.Name == constants.ContainerNameOauth2Proxy || c.Name == constants.ContainerNameKubeRbacProxy {
+			continue
+		}
+
+		declared = append(declared, c.Ports...)
+	}
+
+	for _, p := range declared {
+		if (port.Type == intstr.String && p.Name == port.StrVal) ||
+			(port.Type == intstr.Int && p.ContainerPort == port.IntVal) {
+			return nil
+		}
+	}
+
+	if port.Type == intstr.Int && len(declared) == 0 && port.IntVal > 0 && port.IntVal <= 65535 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: no container of %s/%s exposes the upstream port %s", errUpstreamPortNotExposed,
+		object.GetNamespace(), object.GetName(), port.String())
+}
+
+// workloadPodSpec returns the pod template spec of the target workload
+func workloadPodSpec(object client.Object) (corev1.PodSpec, bool) {
+	switch o := object.(type) {
+	case *appsv1.Deployment:
+		return o.Spec.Template.Spec, true
+	case *appsv1.StatefulSet:
+		return o.Spec.Template.Spec, true
+	case *unstructured.Unstructured:
+		template, found, err := unstructured.NestedMap(o.Object, "spec", "template", "spec")
+		if err != nil || !found {
+			return corev1.PodSpec{}, false
+		}
+
+		spec := corev1.PodSpec{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(template, &spec); err != nil {
+			return corev1.PodSpec{}, false
+		}
+
+		return spec, true
+	default:
+		return corev1.PodSpec{}, false
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func TestVerifyUpstreamPort(t *testing.T) {
+	g := NewWithT(t)
+
+	target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+	defaultPort := target.TargetPort
+
+	t.Cleanup(func() { target.TargetPort = defaultPort })
+
+	deployment := getTargetDeployment()
+
+	// Any valid port number is plausible when the containers declare no ports
+	g.Expect(verifyUpstreamPort(deployment)).To(Succeed())
+
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name:  "nginx",
+		Ports: []corev1.ContainerPort{{Name: "web", ContainerPort: 8080}},
+	}}
+	g.Expect(verifyUpstreamPort(deployment)).To(Succeed())
+
+	target.TargetPort = intstr.FromInt32(8081)
+	g.Expect(verifyUpstreamPort(deployment)).To(MatchError(errUpstreamPortNotExposed))
+
+	target.TargetPort = intstr.FromString("web")
+	g.Expect(verifyUpstreamPort(deployment)).To(Succeed())
+
+	target.TargetPort = intstr.FromString("wbe")
+	g.Expect(verifyUpstreamPort(deployment)).To(MatchError(ContainSubstring("upstream port wbe")))
+
+	// The ports of the injected proxies do not count
+	deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, corev1.Container{
+		Name:  constants.ContainerNameOauth2Proxy,
+		Ports: []corev1.ContainerPort{{Name: "wbe", ContainerPort: 8000}},
+	})
+	g.Expect(verifyUpstreamPort(deployment)).To(MatchError(errUpstreamPortNotExposed))
+
+	// The pod template of a rollout is verified as well
+	rollout := getTargetRollout()
+	g.Expect(setRolloutContainerPort(rollout, "web")).To(Succeed())
+	g.Expect(verifyUpstreamPort(rollout)).To(MatchError(errUpstreamPortNotExposed))
+	g.Expect(setRolloutContainerPort(rollout, "wbe")).To(Succeed())
+	g.Expect(verifyUpstreamPort(rollout)).To(Succeed())
+}
+
+func setRolloutContainerPort(rollout *unstructured.Unstructured, name string) error {
+	return unstructured.SetNestedSlice(rollout.Object, []interface{}{map[string]interface{}{
+		"name":  "nginx",
+		"ports": []interface{}{map[string]interface{}{"name": name, "containerPort": int64(8080)}},
+	}}, "spec", "template", "spec", "containers")
+}
+
+func TestReconcileWarnsOnUnexposedUpstreamPort(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+	defaultPort := target.TargetPort
+
+	t.Cleanup(func() { target.TargetPort = defaultPort })
+
+	rollout := getTargetRollout()
+	g.Expect(setRolloutContainerPort(rollout, "web")).To(Succeed())
+
+	replicaSet, pod := getRolloutPod()
+	c := newFakeClient(g, rollout, replicaSet, pod)
+	recorder := record.NewFakeRecorder(10)
+	r := &RolloutReconciler{Client: c, Recorder: recorder}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(rollout)}
+
+	// The matching port does not emit an event
+	_, err := r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	// The mismatching port emits a warning event, the dependencies are reconciled nevertheless
+	target.TargetPort = intstr.FromString("http")
+
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(recorder.Events).To(Receive(And(
+		HavePrefix(corev1.EventTypeWarning+" "+reasonUpstreamPortNotExposed),
+		ContainSubstring("upstream port http"),
+	)))
+}
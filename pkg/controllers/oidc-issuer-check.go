@@ -0,0 +1,151 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+)
+
+// oidcIssuerCheckTimeout bounds the fetch of the discovery documents of all the configured issuers
+const oidcIssuerCheckTimeout = 5 * time.Second
+
+// maxDiscoveryDocumentBytes is the maximal length of a read discovery document
+const maxDiscoveryDocumentBytes = 1 << 20
+
+// errInvalidDiscoveryDocument is returned when the issuer does not serve a valid discovery document
+var errInvalidDiscoveryDocument = errors.New("invalid discovery document")
+
+// OidcIssuerCheck is a readiness check verifying that the discovery documents of the configured OIDC issuers are
+// reachable, so that a misconfigured issuer fails fast instead of breaking the logins of the targets
+type OidcIssuerCheck struct {
+	// NewHTTPClient returns the client fetching the discovery documents, trusting the given PEM CA bundle in addition
+	// to the system roots. Defaults to a client with the oidcIssuerCheckTimeout.
+	NewHTTPClient func(caBundle string) (*http.Client, error)
+
+	mu sync.Mutex
+	// clients are the clients of the probed issuers keyed by their CA bundle, so that the connections are reused
+	// across the probes
+	clients map[string]*http.Client
+}
+
+// Check implements the healthz.Checker of the controller-runtime manager
+func (o *OidcIssuerCheck) Check(req *http.Request) error {
+	ctx, cancel := context.WithTimeout(req.Context(), oidcIssuerCheckTimeout)
+	defer cancel()
+
+	for _, issuer := range configuration.GetOIDCAppsControllerConfig().GetOidcIssuers() {
+		c, err := o.httpClient(issuer.CABundle)
+		if err != nil {
+			return fmt.Errorf("oidc issuer %s: %w", issuer.URL, err)
+		}
+
+		if err := fetchDiscoveryDocument(ctx, c, issuer.URL); err != nil {
+			return fmt.Errorf("oidc issuer %s: %w", issuer.URL, err)
+		}
+	}
+
+	return nil
+}
+
+// httpClient returns the cached client trusting the CA bundle, it is created upon the first probe of the bundle
+func (o *OidcIssuerCheck) httpClient(caBundle string) (*http.Client, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if c, ok := o.clients[caBundle]; ok {
+		return c, nil
+	}
+
+	newHTTPClient := o.NewHTTPClient
+	if newHTTPClient == nil {
+		newHTTPClient = newIssuerHTTPClient
+	}
+
+	c, err := newHTTPClient(caBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.clients == nil {
+		o.clients = make(map[string]*http.Client)
+	}
+
+	o.clients[caBundle] = c
+
+	return c, nil
+}
+
+// fetchDiscoveryDocument fetches the .well-known/openid-configuration of the issuer and verifies that it is a valid
+// discovery document
+func fetchDiscoveryDocument(ctx context.Context, c *http.Client, issuer string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: unexpected status %s", errInvalidDiscoveryDocument, resp.Status)
+	}
+
+	document := struct {
+		Issuer string `json:"issuer"`
+	}{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDiscoveryDocumentBytes)).Decode(&document); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidDiscoveryDocument, err)
+	}
+
+	if document.Issuer == "" {
+		return fmt.Errorf("%w: missing issuer", errInvalidDiscoveryDocument)
+	}
+
+	return nil
+}
+
+// newIssuerHTTPClient returns a client trusting the PEM CA bundle in addition to the system roots
+func newIssuerHTTPClient(caBundle string) (*http.Client, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if caBundle != "" && !pool.AppendCertsFromPEM([]byte(caBundle)) {
+		return nil, errors.New("no valid certificate in the oidc ca bundle")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+	return &http.Client{Transport: transport, Timeout: oidcIssuerCheckTimeout}, nil
+}
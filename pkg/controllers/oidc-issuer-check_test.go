@@ -0,0 +1,118 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+)
+
+func newIssuerServer(t *testing.T) *httptest.Server {
+	server := httptest.NewTLSServer(nil)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{"issuer": %q}`, server.URL)
+	})
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func useOidcIssuer(t *testing.T, issuer, caBundle string) {
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	previousIssuer, previousCABundle := cfg.Configuration.Oauth2Proxy.OidcIssuerURL, cfg.Configuration.OidcCABundle
+	cfg.Configuration.Oauth2Proxy.OidcIssuerURL, cfg.Configuration.OidcCABundle = issuer, caBundle
+
+	t.Cleanup(func() {
+		cfg.Configuration.Oauth2Proxy.OidcIssuerURL, cfg.Configuration.OidcCABundle = previousIssuer, previousCABundle
+	})
+}
+
+func TestOidcIssuerCheck(t *testing.T) {
+	g := NewWithT(t)
+	server := newIssuerServer(t)
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	check := &OidcIssuerCheck{NewHTTPClient: func(string) (*http.Client, error) { return server.Client(), nil }}
+
+	// A reachable issuer
+	useOidcIssuer(t, server.URL, "")
+	g.Expect(check.Check(req)).To(Succeed())
+
+	// An issuer without a discovery document
+	useOidcIssuer(t, server.URL+"/realms/missing", "")
+	g.Expect(check.Check(req)).To(MatchError(errInvalidDiscoveryDocument))
+
+	// An unreachable issuer
+	unreachable := newIssuerServer(t)
+	unreachable.Close()
+
+	useOidcIssuer(t, unreachable.URL, "")
+	g.Expect(check.Check(req)).To(MatchError(ContainSubstring(unreachable.URL)))
+}
+
+func TestOidcIssuerCheckCABundle(t *testing.T) {
+	g := NewWithT(t)
+	server := newIssuerServer(t)
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	// The self-signed certificate of the issuer is not trusted by default
+	useOidcIssuer(t, server.URL, "")
+	g.Expect((&OidcIssuerCheck{}).Check(req)).NotTo(Succeed())
+
+	// The configured CA bundle is trusted
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	useOidcIssuer(t, server.URL, base64.StdEncoding.EncodeToString(caBundle))
+	g.Expect((&OidcIssuerCheck{}).Check(req)).To(Succeed())
+}
+
+func TestOidcIssuerCheckReusesClients(t *testing.T) {
+	g := NewWithT(t)
+	server := newIssuerServer(t)
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	var created int
+
+	check := &OidcIssuerCheck{NewHTTPClient: func(string) (*http.Client, error) {
+		created++
+
+		return server.Client(), nil
+	}}
+
+	useOidcIssuer(t, server.URL, "")
+	g.Expect(check.Check(req)).To(Succeed())
+	g.Expect(check.Check(req)).To(Succeed())
+	g.Expect(created).To(Equal(1))
+
+	// Another CA bundle gets its own client
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	useOidcIssuer(t, server.URL, base64.StdEncoding.EncodeToString(caBundle))
+	g.Expect(check.Check(req)).To(Succeed())
+	g.Expect(created).To(Equal(2))
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// outcomeCacheEntry holds the hash of the reconcile inputs of a target as of its last successful reconcile
+type outcomeCacheEntry struct {
+	key        types.NamespacedName
+	generation int64
+	hash       string
+}
+
+// outcomeCache keeps the outcome of the last successful reconcile keyed by the target object UID, so that a
+// reconcile with unchanged inputs skips the create and update calls of the dependent resources
+type outcomeCache struct {
+	mu      sync.RWMutex
+	entries map[types.UID]outcomeCacheEntry
+}
+
+var outcomes = &outcomeCache{entries: make(map[types.UID]outcomeCacheEntry)}
+
+// forgetOutcome drops the cached reconcile outcome of an object, typically upon its deletion
+func forgetOutcome(object client.Object) {
+	outcomes.mu.Lock()
+	defer outcomes.mu.Unlock()
+
+	delete(outcomes.entries, object.GetUID())
+}
+
+// unchanged returns true if the object was successfully reconciled with the same inputs at its current generation
+func (o *outcomeCache) unchanged(object client.Object, hash string) bool {
+	if object.GetUID() == "" {
+		return false
+	}
+
+	o.mu.RLock()
+	entry, ok := o.entries[object.GetUID()]
+	o.mu.RUnlock()
+
+	return ok && entry.generation == object.GetGeneration() && entry.hash == hash
+}
+
+// store records the inputs of a successful reconcile of the object
+func (o *outcomeCache) store(object client.Object, hash string) {
+	if object.GetUID() == "" {
+		return
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.entries[object.GetUID()] = outcomeCacheEntry{
+		key:        client.ObjectKeyFromObject(object),
+		generation: object.GetGeneration(),
+		hash:       hash,
+	}
+}
+
+// deleteKey drops the cached outcomes of the objects with the given name
+func (o *outcomeCache) deleteKey(key types.NamespacedName) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	maps.DeleteFunc(o.entries, func(_ types.UID, e outcomeCacheEntry) bool { return e.key == key })
+}
+
+// getHash returns the sha256 hash of the JSON representation of the given values
+func getHash(values ...any) (string, error) {
+	h := sha256.New()
+
+	for _, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %T: %w", v, err)
+		}
+
+		_, _ = h.Write(b)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// reconcileInputsHash returns the hash of the desired state hash together with the resource versions of the
+// generated resources of the target. Thus, the resources modified or deleted by others are reconciled again.
+func reconcileInputsHash(ctx context.Context, c client.Client, object client.Object, desiredHash string) (string,
+	error) {
+	generated, err := fetchOwnedResources(ctx, c, object)
+	if err != nil {
+		return "", err
+	}
+
+	versions := make(map[string]string, len(generated))
+	for _, g := range generated {
+		versions[fmt.Sprintf("%T/%s", g, g.GetName())] = g.GetResourceVersion()
+	}
+
+	return getHash(desiredHash, versions)
+}
+
+// isOutcomeUnchanged returns the hash of the desired resources, stamped with the controller version, and whether the
+// target was already reconciled with the same inputs. The resources rendered without a Cluster resource are never
+// considered unchanged.
+func isOutcomeUnchanged(ctx context.Context, c client.Client, object client.Object, desired []client.Object,
+	desiredErr error) (string, bool, error) {
+	desiredHash, err := getHash(controllerVersion(), desired)
+	if err != nil {
+		return "", false, err
+	}
+
+	if desiredErr != nil {
+		return desiredHash, false, nil
+	}
+
+	hash, err := reconcileInputsHash(ctx, c, object, desiredHash)
+	if err != nil {
+		return "", false, err
+	}
+
+	if outcomes.unchanged(object, hash) {
+		log.FromContext(ctx).V(9).Info("Reconcile inputs are unchanged, skipping", "name", object.GetName())
+
+		return desiredHash, true, nil
+	}
+
+	return desiredHash, false, nil
+}
+
+// recordOutcome records the inputs of a successful reconcile, including the resource versions of the just written
+// resources
+func recordOutcome(ctx context.Context, c client.Client, object client.Object, desiredHash string) error {
+	hash, err := reconcileInputsHash(ctx, c, object, desiredHash)
+	if err != nil {
+		return err
+	}
+
+	outcomes.store(object, hash)
+
+	return nil
+}
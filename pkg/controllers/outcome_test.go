@@ -0,0 +1,202 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// newWriteCountingClient returns a client counting the create, update, patch and delete calls
+func newWriteCountingClient(c client.Client, writes *int) client.Client {
+	return interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			*writes++
+
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			*writes++
+
+			return c.Update(ctx, obj, opts...)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+			opts ...client.PatchOption) error {
+			*writes++
+
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			*writes++
+
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+}
+
+func TestReconcileDependenciesSkipsUnchangedDeployment(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("outcome-deployment")
+	deployment.SetGeneration(1)
+
+	t.Cleanup(func() { forgetOutcome(deployment) })
+
+	var writes int
+
+	fakeClient := newFakeClient(g, deployment)
+	c := newWriteCountingClient(fakeClient, &writes)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(writes).To(BeNumerically(">", 0))
+
+	// The second reconcile with unchanged inputs performs no writes
+	writes = 0
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(writes).To(BeZero())
+
+	// A generation change invalidates the cached outcome
+	deployment.SetGeneration(2)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(writes).To(BeNumerically(">", 0))
+
+	writes = 0
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(writes).To(BeZero())
+
+	// A generated resource deleted by others is recreated
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(fakeClient.List(ctx, ingresses, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+	g.Expect(fakeClient.Delete(ctx, &ingresses.Items[0])).To(Succeed())
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(writes).To(BeNumerically(">", 0))
+	g.Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(&ingresses.Items[0]), &networkingv1.Ingress{})).To(Succeed())
+}
+
+func TestReconcileDependenciesSkipsUnchangedStatefulSet(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	statefulSet := getTargetStatefulSet()
+	statefulSet.SetUID("outcome-statefulset")
+
+	t.Cleanup(func() { forgetOutcome(statefulSet) })
+
+	var writes int
+
+	c := newWriteCountingClient(newFakeClient(g, statefulSet), &writes)
+
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+	g.Expect(writes).To(BeNumerically(">", 0))
+
+	writes = 0
+
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+	g.Expect(writes).To(BeZero())
+}
+
+func TestReconcileDependenciesRestoresReusedServicePort(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.ServiceName = "nginx"
+
+	t.Cleanup(func() { ingressConf.ServiceName = "" })
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("outcome-reused-service")
+	deployment.SetGeneration(1)
+
+	t.Cleanup(func() { forgetOutcome(deployment) })
+
+	existing := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx",
+			Namespace: "default",
+			Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}
+	c := newFakeClient(g, deployment, existing)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	service := &corev1.Service{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), service)).To(Succeed())
+	g.Expect(service.Spec.Ports).To(HaveLen(2))
+
+	// The port removed by others is restored, although the outcome of the target is unchanged
+	service.Spec.Ports = existing.Spec.Ports
+	g.Expect(c.Update(ctx, service)).To(Succeed())
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(existing), service)).To(Succeed())
+	g.Expect(service.Spec.Ports).To(ContainElement(HaveField("Name", constants.ServicePortNameOauth2Proxy)))
+}
+
+func TestForgetDeletedTarget(t *testing.T) {
+	g := NewWithT(t)
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("forget-deleted-target")
+	deployment.SetGeneration(1)
+
+	other := getTargetDeployment()
+	other.SetName("other")
+	other.SetUID("forget-other-target")
+
+	t.Cleanup(func() {
+		forgetOutcome(other)
+		forgetSuffix(other)
+		forgetRetryBudget(other)
+	})
+
+	for _, object := range []client.Object{deployment, other} {
+		outcomes.store(object, "hash")
+		suffixes.get(object)
+		retryBudgets.failure(object)
+	}
+
+	// Only the name of a deleted target is known
+	forgetDeletedTarget(client.ObjectKeyFromObject(deployment))
+
+	g.Expect(outcomes.unchanged(deployment, "hash")).To(BeFalse())
+	g.Expect(suffixes.entries).NotTo(HaveKey(deployment.GetUID()))
+	g.Expect(retryBudgets.entries).NotTo(HaveKey(deployment.GetUID()))
+
+	g.Expect(outcomes.unchanged(other, "hash")).To(BeTrue())
+	g.Expect(suffixes.entries).To(HaveKey(other.GetUID()))
+	g.Expect(retryBudgets.entries).To(HaveKey(other.GetUID()))
+}
@@ -16,10 +16,24 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
-
+	"maps"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
 )
 
 func isAnOwnedResource(owner, owned client.Object) bool {
@@ -27,58 +41,193 @@ func isAnOwnedResource(owner, owned client.Object) bool {
 		return false
 	}
 
+	// The owner references are matched by the UID only, so that references with an outdated api version, e.g. from
+	// before an apps/v1beta2 to apps/v1 migration, are still recognized
 	for _, ref := range owned.GetOwnerReferences() {
 		if ref.UID == owner.GetUID() {
 			return true
 		}
 	}
 
+	// Resources without owner references are identified by the owner label
+	if v, found := owned.GetLabels()[constants.LabelOwnerKey]; found {
+		return v == ownerLabelValue(workloadKind(owner), client.ObjectKeyFromObject(owner))
+	}
+
 	return false
 }
 
-func deleteOwnedResources(ctx context.Context, c client.Client, object client.Object) error {
+// isFormerOwnerReference returns true if the reference points to a former incarnation of the desired owner, i.e. an
+// object of the same kind and name with another UID. A statefulset pod is recreated under its name, as is a workload
+// deleted without its dependents, and the resources of the former owner are adopted instead of being duplicated.
+func isFormerOwnerReference(ref, desired metav1.OwnerReference) bool {
+	group := func(apiVersion string) string {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return apiVersion
+		}
+
+		return gv.Group
+	}
+
+	return ref.UID != desired.UID && ref.Kind == desired.Kind && ref.Name == desired.Name &&
+		group(ref.APIVersion) == group(desired.APIVersion)
+}
+
+// setOwner labels the dependent resource with the owning target workload and sets the owner reference to the owner,
+// which is either the workload itself or one of its pods. The owner reference is omitted when disabled for the target.
+// The configured resource labels are added as well, without overriding the labels set by the controller.
+func setOwner(workload, owner, dependent client.Object, s *runtime.Scheme) error {
+	labels := configuration.GetOIDCAppsControllerConfig().GetResourceLabels(workload)
+	maps.Copy(labels, dependent.GetLabels())
+
+	labels[constants.LabelOwnerKey] = ownerLabelValue(workloadKind(workload), client.ObjectKeyFromObject(workload))
+	dependent.SetLabels(labels)
+
+	if configuration.GetOIDCAppsControllerConfig().GetDisableOwnerReferences(workload) {
+		return nil
+	}
+
+	return controllerutil.SetOwnerReference(owner, dependent, s)
+}
+
+// ownerLabelValue returns the owner label value of the target workload. It is derived from the workload kind and key,
+// so that the dependent resources can be found after the workload is deleted.
+func ownerLabelValue(kind string, key client.ObjectKey) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(kind+"/"+key.Namespace+"/"+key.Name)))[:32]
+}
+
+func workloadKind(object client.Object) string {
+	switch object.(type) {
+	case *appsv1.Deployment:
+		return "Deployment"
+	case *appsv1.StatefulSet:
+		return "StatefulSet"
+	default:
+		return object.GetObjectKind().GroupVersionKind().Kind
+	}
+}
+
+// forgetDeletedTarget drops the cached suffix, reconcile outcome and retry budget of a deleted target, of which only
+// the name is known. The entries of a workload of another kind with the same name are dropped as well, they are
+// rebuilt by its next reconcile.
+func forgetDeletedTarget(key types.NamespacedName) {
+	suffixes.deleteKey(key)
+	outcomes.deleteKey(key)
+	retryBudgets.deleteKey(key)
+}
+
+// deleteOrphanedResources deletes the oidc-apps ingresses, services, secrets and network policies labelled with the given owner and removes
+// its path rules from the shared ingresses. It cleans up after deleted workloads, whose dependent resources are not
+// garbage collected due to missing owner references.
+func deleteOrphanedResources(ctx context.Context, c client.Client, namespace, owner string,
+	policy client.PropagationPolicy) error {
+	// The shared ingresses are not owned by a single workload, only the path rules of the workload are removed
+	if err := deregisterSharedIngressPaths(ctx, c, namespace, owner); err != nil {
+		return err
+	}
+
+	// The reused target services are not owned by the workload either, only the oauth2-proxy port is removed
+	if err := removeOauth2PortFromServices(ctx, c, namespace, owner, ""); err != nil {
+		return err
+	}
+
+	for _, list := range cleanupOrder() {
+		if err := c.List(ctx, list,
+			client.InNamespace(namespace),
+			client.MatchingLabels{constants.LabelKey: constants.LabelValue, constants.LabelOwnerKey: owner},
+		); err != nil {
+			return fmt.Errorf("failed to list resources: %w", err)
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			o, ok := item.(client.Object)
+			if !ok {
+				continue
+			}
+
+			if err := c.Delete(ctx, o, policy); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete %s/%s: %w", o.GetNamespace(), o.GetName(), err)
+			}
+
+			log.FromContext(ctx).V(9).Info("Deleted", "name", o.GetName(), "namespace", o.GetNamespace())
+		}
+	}
+
+	return nil
+}
+
+// deleteOwnedResources deletes the oidc-apps ingresses, services and secrets of the target workload, in that order
+func deleteOwnedResources(ctx context.Context, c client.Client, object client.Object,
+	policy client.PropagationPolicy) error {
 	var err error
 
 	_log := log.FromContext(ctx).WithValues("uid", object.GetUID())
 
-	secrets, err := fetchOidcAppsSecrets(ctx, c, object)
+	ingresses, err := fetchOidcAppsIngress(ctx, c, object)
 	if err != nil {
 		return err
 	}
 
-	for _, s := range secrets.Items {
-		if err = c.Delete(ctx, &s); err != nil {
+	for _, s := range ingresses.Items {
+		if err = c.Delete(ctx, &s, policy); err != nil {
 			return fmt.Errorf("failed to delete")
 		}
 
 		_log.V(9).Info("Deleted", "name", s.Name, "namespace", s.Namespace)
 	}
 
-	ingresses, err := fetchOidcAppsIngress(ctx, c, object)
+	owner := ownerLabelValue(workloadKind(object), client.ObjectKeyFromObject(object))
+	if err = removeOauth2PortFromServices(ctx, c, object.GetNamespace(), owner, ""); err != nil {
+		return err
+	}
+
+	services, err := fetchOidcAppsServices(ctx, c, object)
 	if err != nil {
 		return err
 	}
 
-	for _, s := range ingresses.Items {
-		if err = c.Delete(ctx, &s); err != nil {
+	for _, s := range services.Items {
+		if err = c.Delete(ctx, &s, policy); err != nil {
 			return fmt.Errorf("failed to delete")
 		}
 
 		_log.V(9).Info("Deleted", "name", s.Name, "namespace", s.Namespace)
 	}
 
-	services, err := fetchOidcAppsServices(ctx, c, object)
+	secrets, err := fetchOidcAppsSecrets(ctx, c, object)
 	if err != nil {
 		return err
 	}
 
-	for _, s := range services.Items {
-		if err = c.Delete(ctx, &s); err != nil {
+	for _, s := range secrets.Items {
+		if err = c.Delete(ctx, &s, policy); err != nil {
 			return fmt.Errorf("failed to delete")
 		}
 
 		_log.V(9).Info("Deleted", "name", s.Name, "namespace", s.Namespace)
 	}
 
-	return nil
+	// The remaining dependent resources without owner references are not garbage collected
+	return deleteOrphanedResources(ctx, c, object.GetNamespace(),
+		ownerLabelValue(workloadKind(object), client.ObjectKeyFromObject(object)), policy)
+}
+
+// cleanupOrder returns the lists of the generated resources in their deletion order. The ingresses are removed before
+// the services they route to, and the services before the secrets. The network policies are removed last, so that the
+// metrics port stays restricted while the pods are running.
+func cleanupOrder() []client.ObjectList {
+	return []client.ObjectList{
+		&networkingv1.IngressList{}, &corev1.ServiceList{}, &corev1.SecretList{}, &networkingv1.NetworkPolicyList{},
+	}
+}
+
+// deletionPropagation returns the configured propagation policy of the cleanup deletions
+func deletionPropagation() client.PropagationPolicy {
+	return client.PropagationPolicy(configuration.GetOIDCAppsControllerConfig().GetDeletionPropagationPolicy())
 }
@@ -0,0 +1,393 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func TestReconcileDependenciesWithOwnerReferences(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: "default", Name: constants.SecretNameOauth2Proxy + "-" + rand.GenerateSha256("nginx-default")}
+	g.Expect(c.Get(ctx, key, secret)).To(Succeed())
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+	g.Expect(secret.OwnerReferences[0].UID).To(Equal(deployment.UID))
+	g.Expect(secret.Labels).To(HaveKeyWithValue(constants.LabelOwnerKey,
+		ownerLabelValue("Deployment", client.ObjectKeyFromObject(deployment))))
+}
+
+func TestReconcileDependenciesWithoutOwnerReferences(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	configuration.GetOIDCAppsControllerConfig().Configuration.DisableOwnerReferences = ptr.To(true)
+
+	t.Cleanup(func() { configuration.GetOIDCAppsControllerConfig().Configuration.DisableOwnerReferences = nil })
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	secrets := &corev1.SecretList{}
+	g.Expect(c.List(ctx, secrets, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+	services := &corev1.ServiceList{}
+	g.Expect(c.List(ctx, services, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(c.List(ctx, ingresses, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+
+	g.Expect(secrets.Items).NotTo(BeEmpty())
+	g.Expect(services.Items).To(HaveLen(1))
+	g.Expect(ingresses.Items).To(HaveLen(1))
+
+	owned := []client.Object{&services.Items[0], &ingresses.Items[0]}
+	for i := range secrets.Items {
+		owned = append(owned, &secrets.Items[i])
+	}
+
+	for _, o := range owned {
+		g.Expect(o.GetOwnerReferences()).To(BeEmpty(), o.GetName())
+		g.Expect(o.GetLabels()).To(HaveKeyWithValue(constants.LabelOwnerKey,
+			ownerLabelValue("Deployment", client.ObjectKeyFromObject(deployment))), o.GetName())
+		g.Expect(o.GetAnnotations()).To(HaveKey(constants.AnnotationManagedByVersionKey), o.GetName())
+		g.Expect(isAnOwnedResource(deployment, o)).To(BeTrue(), o.GetName())
+	}
+
+	// The resources of a deleted deployment are cleaned up by their owner label
+	g.Expect(c.Delete(ctx, deployment)).To(Succeed())
+
+	r := &DeploymentReconciler{Client: c}
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(deployment)})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(c.List(ctx, secrets, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+	g.Expect(secrets.Items).To(BeEmpty())
+	g.Expect(c.List(ctx, services, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+	g.Expect(services.Items).To(BeEmpty())
+	g.Expect(c.List(ctx, ingresses, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+	g.Expect(ingresses.Items).To(BeEmpty())
+}
+
+func TestDisableOwnerReferencesAnnotation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	statefulSet := getTargetStatefulSet()
+	statefulSet.SetAnnotations(map[string]string{constants.AnnotationDisableOwnerReferencesKey: "true"})
+	c := newFakeClient(g, statefulSet)
+
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: "default", Name: constants.SecretNameOauth2Proxy + "-" + rand.GenerateSha256("nginx-default")}
+	g.Expect(c.Get(ctx, key, secret)).To(Succeed())
+	g.Expect(secret.OwnerReferences).To(BeEmpty())
+
+	// A deployment with the same name does not own the resources of the statefulset
+	deployment := getTargetDeployment()
+	g.Expect(isAnOwnedResource(deployment, secret)).To(BeFalse())
+	g.Expect(isAnOwnedResource(statefulSet, secret)).To(BeTrue())
+	g.Expect(isAnOwnedResource(&appsv1.StatefulSet{}, secret)).To(BeFalse())
+}
+
+func TestDeleteOwnedResourcesOrderAndPropagation(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	var (
+		deleted  []string
+		policies []metav1.DeletionPropagation
+	)
+
+	recorder := interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			o := &client.DeleteOptions{}
+			o.ApplyOptions(opts)
+
+			deleted = append(deleted, fmt.Sprintf("%T", obj))
+			policies = append(policies, ptr.Deref(o.PropagationPolicy, ""))
+
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+
+	g.Expect(deleteOwnedResources(ctx, recorder, deployment,
+		client.PropagationPolicy(metav1.DeletePropagationForeground))).To(Succeed())
+
+	// The ingress is deleted before the service, and the service before the secrets
+	g.Expect(deleted).To(HaveLen(4))
+	g.Expect(deleted[0]).To(Equal("*v1.Ingress"))
+	g.Expect(deleted[1]).To(Equal("*v1.Service"))
+	g.Expect(deleted[2:]).To(HaveEach("*v1.Secret"))
+	g.Expect(policies).To(HaveEach(metav1.DeletePropagationForeground))
+}
+
+func TestDeleteOrphanedResourcesOrder(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	deployment.SetAnnotations(map[string]string{constants.AnnotationDisableOwnerReferencesKey: "true"})
+	c := newFakeClient(g, deployment)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	var deleted []string
+
+	recorder := interceptor.NewClient(c.(client.WithWatch), interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deleted = append(deleted, fmt.Sprintf("%T", obj))
+
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+
+	g.Expect(deleteOrphanedResources(ctx, recorder, "default",
+		ownerLabelValue("Deployment", client.ObjectKeyFromObject(deployment)),
+		client.PropagationPolicy(metav1.DeletePropagationBackground))).To(Succeed())
+
+	g.Expect(deleted).To(HaveLen(4))
+	g.Expect(deleted[0]).To(Equal("*v1.Ingress"))
+	g.Expect(deleted[1]).To(Equal("*v1.Service"))
+	g.Expect(deleted[2:]).To(HaveEach("*v1.Secret"))
+}
+
+func TestReconcileDependenciesResourceLabels(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.ResourceLabels = map[string]string{
+		"app.kubernetes.io/part-of": "observability",
+		constants.LabelKey:          "foreign",
+	}
+
+	t.Cleanup(func() { cfg.Configuration.ResourceLabels = nil })
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	secrets := &corev1.SecretList{}
+	g.Expect(c.List(ctx, secrets, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+	services := &corev1.ServiceList{}
+	g.Expect(c.List(ctx, services, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(c.List(ctx, ingresses, client.MatchingLabels{constants.LabelKey: constants.LabelValue})).To(Succeed())
+
+	g.Expect(secrets.Items).NotTo(BeEmpty())
+	g.Expect(services.Items).To(HaveLen(1))
+	g.Expect(ingresses.Items).To(HaveLen(1))
+
+	generated := []client.Object{&services.Items[0], &ingresses.Items[0]}
+	for i := range secrets.Items {
+		generated = append(generated, &secrets.Items[i])
+	}
+
+	// The configured labels are added, the labels managed by the controller are preserved
+	for _, o := range generated {
+		g.Expect(o.GetLabels()).To(HaveKeyWithValue("app.kubernetes.io/part-of", "observability"), o.GetName())
+		g.Expect(o.GetLabels()).To(HaveKeyWithValue(constants.LabelKey, constants.LabelValue), o.GetName())
+		g.Expect(o.GetLabels()).To(HaveKeyWithValue(constants.LabelOwnerKey,
+			ownerLabelValue("Deployment", client.ObjectKeyFromObject(deployment))), o.GetName())
+	}
+
+	// The removed labels are dropped from the generated resources
+	cfg.Configuration.ResourceLabels = nil
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(&ingresses.Items[0]), &ingresses.Items[0])).To(Succeed())
+	g.Expect(ingresses.Items[0].GetLabels()).NotTo(HaveKey("app.kubernetes.io/part-of"))
+	g.Expect(ingresses.Items[0].GetLabels()).To(HaveKeyWithValue(constants.LabelKey, constants.LabelValue))
+}
+
+func TestOutdatedOwnerReferenceAPIVersion(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	c := newFakeClient(g, deployment)
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	// Simulate an owner reference created before the apps/v1beta2 to apps/v1 migration
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: "default", Name: constants.SecretNameOauth2Proxy + "-" + rand.GenerateSha256("nginx-default")}
+	g.Expect(c.Get(ctx, key, secret)).To(Succeed())
+
+	secret.OwnerReferences[0].APIVersion = "apps/v1beta2"
+	g.Expect(c.Update(ctx, secret)).To(Succeed())
+
+	// The resource is still recognized as owned by the deployment
+	g.Expect(isAnOwnedResource(deployment, secret)).To(BeTrue())
+
+	secrets, err := fetchOidcAppsSecrets(ctx, c, deployment)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(secrets.Items).To(ContainElement(HaveField("Name", key.Name)))
+
+	// The outdated owner reference counts as a drift
+	diff, err := diffManagedResources(ctx, c, deployment)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(resourceNames(diff.Updated)).To(ContainElement(key.Name))
+
+	// The outdated owner reference is refreshed on patching, without adding a second reference to the same owner
+	desired, err := desiredResources(ctx, c, deployment)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(createOrPatchObjects(ctx, c, desired)).To(Succeed())
+
+	g.Expect(c.Get(ctx, key, secret)).To(Succeed())
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+	g.Expect(secret.OwnerReferences[0].APIVersion).To(Equal("apps/v1"))
+	g.Expect(secret.OwnerReferences[0].UID).To(Equal(deployment.UID))
+}
+
+func TestReconcileDependenciesAdoptsExistingResources(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("adopting-deployment")
+
+	t.Cleanup(func() {
+		forgetSuffix(deployment)
+		forgetOutcome(deployment)
+	})
+
+	c := newFakeClient(g, deployment)
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	existing, err := fetchOwnedResources(ctx, c, deployment)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(existing).NotTo(BeEmpty())
+
+	// A controller restart starts with empty caches, the existing resources are reconciled under their names
+	forgetSuffix(deployment)
+	forgetOutcome(deployment)
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	adopted, err := fetchOwnedResources(ctx, c, deployment)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(resourceNames(adopted)).To(ConsistOf(resourceNames(existing)))
+
+	// The workload recreated after an orphaning deletion adopts the resources of its former incarnation, whose owner
+	// references are replaced
+	g.Expect(c.Delete(ctx, deployment)).To(Succeed())
+
+	recreated := getTargetDeployment()
+	recreated.SetUID("adopting-deployment-recreated")
+	g.Expect(c.Create(ctx, recreated)).To(Succeed())
+
+	t.Cleanup(func() {
+		forgetSuffix(recreated)
+		forgetOutcome(recreated)
+	})
+
+	g.Expect(reconcileDependencies(ctx, c, recreated)).To(Succeed())
+
+	adopted, err = fetchOwnedResources(ctx, c, recreated)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(resourceNames(adopted)).To(ConsistOf(resourceNames(existing)))
+
+	for _, a := range adopted {
+		g.Expect(a.GetOwnerReferences()).To(HaveLen(1), a.GetName())
+		g.Expect(a.GetOwnerReferences()[0].UID).To(Equal(recreated.GetUID()), a.GetName())
+	}
+
+	// Nothing is left to update
+	diff, err := diffManagedResources(ctx, c, recreated)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(diff.Created).To(BeEmpty())
+	g.Expect(diff.Updated).To(BeEmpty())
+}
+
+func TestIsFormerOwnerReference(t *testing.T) {
+	g := NewWithT(t)
+
+	desired := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "nginx", UID: "current"}
+
+	g.Expect(isFormerOwnerReference(desired, desired)).To(BeFalse())
+	g.Expect(isFormerOwnerReference(metav1.OwnerReference{APIVersion: "apps/v1beta2", Kind: "Deployment",
+		Name: "nginx", UID: "former"}, desired)).To(BeTrue())
+	g.Expect(isFormerOwnerReference(metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment",
+		Name: "other", UID: "former"}, desired)).To(BeFalse())
+	g.Expect(isFormerOwnerReference(metav1.OwnerReference{APIVersion: "argoproj.io/v1alpha1", Kind: "Deployment",
+		Name: "nginx", UID: "former"}, desired)).To(BeFalse())
+}
+
+func TestReconcileDependenciesAwaitsPendingDeletion(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	t.Cleanup(func() { forgetOutcome(deployment) })
+
+	// The ingress of a former incarnation of the target is still held by a finalizer
+	key := client.ObjectKey{Namespace: "default", Name: constants.IngressName + "-" + rand.GenerateSha256("nginx-default")}
+	deleting := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              key.Name,
+			Namespace:         key.Namespace,
+			Finalizers:        []string{"example.com/finalizer"},
+			DeletionTimestamp: ptr.To(metav1.Now()),
+		},
+	}
+	c := newFakeClient(g, deployment, deleting)
+
+	err := reconcileDependencies(ctx, c, deployment)
+	g.Expect(err).To(MatchError(errPendingDeletion))
+	g.Expect(isTransientError(err)).To(BeTrue())
+
+	// The ingress is recreated once it is gone
+	ingress := &networkingv1.Ingress{}
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+	ingress.Finalizers = nil
+	g.Expect(c.Update(ctx, ingress)).To(Succeed())
+
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(c.Get(ctx, key, ingress)).To(Succeed())
+	g.Expect(ingress.GetDeletionTimestamp().IsZero()).To(BeTrue())
+	g.Expect(ingress.Spec.Rules).NotTo(BeEmpty())
+}
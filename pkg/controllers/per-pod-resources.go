@@ -0,0 +1,60 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+)
+
+// reasonPerPodResourcesCapExceeded is the reason of the warning event emitted for a statefulset with more replicas
+// than the per-pod resources cap
+const reasonPerPodResourcesCapExceeded = "PerPodResourcesCapExceeded"
+
+// isBeyondPerPodResourcesCap returns true if the ordinal of the statefulset pod is beyond the configured cap, hence
+// no dedicated ingress and service are created for it
+func isBeyondPerPodResourcesCap(pod *corev1.Pod) bool {
+	limit := configuration.GetOIDCAppsControllerConfig().GetMaxPerPodResources()
+	if limit == 0 {
+		return false
+	}
+
+	ordinal, err := strconv.Atoi(fetchStrIndexIfPresent(pod))
+
+	return err == nil && ordinal >= int(limit)
+}
+
+// warnOnExceededPerPodResourcesCap emits a warning event when the statefulset has more replicas than the per-pod
+// resources cap, as its pods beyond the cap are not exposed
+func warnOnExceededPerPodResourcesCap(recorder record.EventRecorder, object *appsv1.StatefulSet) {
+	limit := configuration.GetOIDCAppsControllerConfig().GetMaxPerPodResources()
+	if recorder == nil || limit == 0 || !configuration.GetOIDCAppsControllerConfig().GetPerPodIngress(object) {
+		return
+	}
+
+	if replicas := ptr.Deref(object.Spec.Replicas, 1); replicas > limit {
+		recorder.Event(object, corev1.EventTypeWarning, reasonPerPodResourcesCapExceeded, fmt.Sprintf(
+			"%d replicas exceed the maximum of %d pods with a dedicated ingress and service, the pods from ordinal %d on "+
+				"are not exposed. Reduce the replicas or raise the maxPerPodResources configuration",
+			replicas, limit, limit))
+	}
+}
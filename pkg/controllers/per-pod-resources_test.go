@@ -0,0 +1,140 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func getStatefulSetPod(ordinal int) *corev1.Pod {
+	name := fmt.Sprintf("nginx-%d", ordinal)
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "nginx", "statefulset.kubernetes.io/pod-name": name},
+			Annotations: map[string]string{constants.AnnotationHostKey: "nginx-default.domain.org"},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "StatefulSet",
+				Name:       "nginx",
+				UID:        "target-statefulset",
+			}},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: constants.ContainerNameOauth2Proxy}}},
+	}
+}
+
+func TestPerPodResourcesCap(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.MaxPerPodResources = 2
+
+	t.Cleanup(func() { cfg.Configuration.MaxPerPodResources = 0 })
+
+	statefulSet := getTargetStatefulSet()
+	statefulSet.Spec.Replicas = ptr.To[int32](3)
+	c := newFakeClient(g, statefulSet, getStatefulSetPod(0), getStatefulSetPod(1), getStatefulSetPod(2))
+
+	recorder := record.NewFakeRecorder(10)
+	r := &StatefulSetReconciler{Client: c, Recorder: recorder}
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(statefulSet)})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Only the pods below the cap get a dedicated ingress and service
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(c.List(ctx, ingresses, client.InNamespace("default"))).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(2))
+
+	services := &corev1.ServiceList{}
+	g.Expect(c.List(ctx, services, client.InNamespace("default"))).To(Succeed())
+	g.Expect(services.Items).To(HaveLen(2))
+
+	for _, i := range ingresses.Items {
+		g.Expect(i.Spec.Rules[0].Host).NotTo(HavePrefix("nginx-default-2."))
+	}
+
+	g.Expect(recorder.Events).To(Receive(
+		HavePrefix(corev1.EventTypeWarning + " " + reasonPerPodResourcesCapExceeded)))
+}
+
+func TestPerPodResourcesWithoutCap(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	statefulSet := getTargetStatefulSet()
+	statefulSet.Spec.Replicas = ptr.To[int32](3)
+	c := newFakeClient(g, statefulSet, getStatefulSetPod(0), getStatefulSetPod(1), getStatefulSetPod(2))
+
+	recorder := record.NewFakeRecorder(10)
+	r := &StatefulSetReconciler{Client: c, Recorder: recorder}
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(statefulSet)})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(c.List(ctx, ingresses, client.InNamespace("default"))).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(3))
+	g.Expect(recorder.Events).NotTo(Receive())
+}
+
+func TestPerPodServicesConfiguredByWorkload(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.Oauth2Proxy.MetricsPort = 9090
+
+	statefulSet := getTargetStatefulSet()
+	statefulSet.SetUID("per-pod-services-statefulset")
+	statefulSet.SetAnnotations(map[string]string{constants.AnnotationServiceTypeKey: string(corev1.ServiceTypeLoadBalancer)})
+
+	t.Cleanup(func() {
+		cfg.Configuration.Oauth2Proxy.MetricsPort = 0
+		forgetSuffix(statefulSet)
+		forgetOutcome(statefulSet)
+	})
+
+	c := newFakeClient(g, statefulSet, getStatefulSetPod(0), getStatefulSetPod(1))
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+
+	// The service type annotation of the StatefulSet applies to the services of its pods, which expose the oauth2 port
+	// only
+	services := &corev1.ServiceList{}
+	g.Expect(c.List(ctx, services, client.InNamespace("default"))).To(Succeed())
+	g.Expect(services.Items).To(HaveLen(2))
+
+	for _, s := range services.Items {
+		g.Expect(s.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+		g.Expect(s.Spec.Ports).To(HaveLen(1))
+		g.Expect(s.Spec.Ports[0].Name).To(Equal("http"))
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// ReferencedSecretPredicate passes the creations and the updates of the user secrets, which may be referenced by the
+// targets, e.g. the oidc CA bundle secrets. The generated secrets, labelled with their secret kind, are handled by the
+// owner watches. The referenced secrets have to carry the oidc-application-controller/component: oidc-apps label to be
+// visible to the controller.
+func ReferencedSecretPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return isUserSecret(e.Object) },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return isUserSecret(e.ObjectNew) && e.ObjectOld.GetResourceVersion() != e.ObjectNew.GetResourceVersion()
+		},
+	}
+}
+
+func isUserSecret(object client.Object) bool {
+	if _, ok := object.(*corev1.Secret); !ok {
+		return false
+	}
+
+	_, generated := object.GetLabels()[constants.SecretLabelKey]
+
+	return !generated
+}
+
+// EnqueueWorkloadsReferencingSecret returns an event handler enqueuing the targets in the namespace of a secret, which
+// reference it. The targets of the handled kind are listed with the given list.
+func EnqueueWorkloadsReferencingSecret(c client.Reader, newList func() client.ObjectList) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, secret client.Object) []reconcile.Request {
+		if !isUserSecret(secret) {
+			return nil
+		}
+
+		list := newList()
+		if err := c.List(ctx, list, client.InNamespace(secret.GetNamespace())); err != nil {
+			log.FromContext(ctx).Error(err, "could not list the workloads referencing a secret",
+				"namespace", secret.GetNamespace(), "name", secret.GetName())
+
+			return nil
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil
+		}
+
+		var requests []reconcile.Request
+
+		for _, item := range items {
+			o, ok := item.(client.Object)
+			if !ok || !configuration.GetOIDCAppsControllerConfig().Match(o) {
+				continue
+			}
+
+			if slices.Contains(referencedSecretNames(o), secret.GetName()) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			}
+		}
+
+		return requests
+	})
+}
+
+// referencedSecretNames returns the names of the user secrets referenced by the target
+func referencedSecretNames(object client.Object) []string {
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	names := []string{
+		cfg.GetOidcCASecretName(object),
+		cfg.GetKubeSecretName(object),
+		cfg.GetKubeRbacProxyAPIServerCASecretName(object),
+	}
+
+	if ref := cfg.GetOidcCASecretKeyRef(object); ref != nil {
+		names = append(names, ref.Name)
+	}
+
+	return slices.DeleteFunc(names, func(name string) bool { return name == "" })
+}
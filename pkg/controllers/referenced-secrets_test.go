@@ -0,0 +1,119 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func newReferencedSecret(resourceVersion string) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:            "ca-bundle",
+		Namespace:       "default",
+		ResourceVersion: resourceVersion,
+		Labels:          map[string]string{constants.LabelKey: constants.LabelValue},
+	}}
+}
+
+func TestReferencedSecretPredicate(t *testing.T) {
+	g := NewWithT(t)
+
+	p := ReferencedSecretPredicate()
+
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: newReferencedSecret("1"), ObjectNew: newReferencedSecret("2")})).
+		To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: newReferencedSecret("1"), ObjectNew: newReferencedSecret("1")})).
+		To(BeFalse())
+	g.Expect(p.Create(event.CreateEvent{Object: newReferencedSecret("1")})).To(BeTrue())
+
+	// The generated secrets are handled by the owner watches
+	generated := newReferencedSecret("2")
+	generated.Labels[constants.SecretLabelKey] = constants.Oauth2LabelValue
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: newReferencedSecret("1"), ObjectNew: generated})).To(BeFalse())
+
+	// Other kinds are not passed
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: getTargetDeployment(), ObjectNew: getTargetDeployment()})).
+		To(BeFalse())
+}
+
+func TestEnqueueWorkloadsReferencingSecret(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	referencing := getTargetDeployment()
+	referencing.Annotations = map[string]string{constants.AnnotationOidcCASecretKey: "ca-bundle/bundle.pem"}
+
+	other := getTargetDeployment()
+	other.Name, other.UID = "other", "other-deployment"
+
+	// A workload, which is not a target, is not enqueued
+	nonTarget := getTargetDeployment()
+	nonTarget.Name, nonTarget.UID = "non-target", "non-target-deployment"
+	nonTarget.Labels = map[string]string{"app": "other"}
+	nonTarget.Annotations = referencing.Annotations
+
+	c := newFakeClient(g, referencing, other, nonTarget)
+
+	q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	t.Cleanup(q.ShutDown)
+
+	h := EnqueueWorkloadsReferencingSecret(c, func() client.ObjectList { return &appsv1.DeploymentList{} })
+	h.Update(ctx, event.UpdateEvent{ObjectOld: newReferencedSecret("1"), ObjectNew: newReferencedSecret("2")}, q)
+
+	g.Expect(q.Len()).To(Equal(1))
+
+	item, _ := q.Get()
+	g.Expect(item).To(Equal(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(referencing)}))
+	q.Done(item)
+
+	// A secret, which is not referenced, enqueues nothing
+	unreferenced := newReferencedSecret("2")
+	unreferenced.Name = "unreferenced"
+	h.Update(ctx, event.UpdateEvent{ObjectOld: unreferenced, ObjectNew: unreferenced}, q)
+	g.Expect(q.Len()).To(BeZero())
+}
+
+func TestEnqueueRolloutsReferencingSecret(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	rollout := getTargetRollout()
+	rollout.SetAnnotations(map[string]string{constants.AnnotationOidcCASecretKey: "ca-bundle"})
+	c := newFakeClient(g, rollout)
+
+	q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	t.Cleanup(q.ShutDown)
+
+	h := EnqueueWorkloadsReferencingSecret(c, func() client.ObjectList { return NewRolloutList() })
+	h.Create(ctx, event.CreateEvent{Object: newReferencedSecret("1")}, q)
+
+	g.Expect(q.Len()).To(Equal(1))
+
+	item, _ := q.Get()
+	g.Expect(item).To(Equal(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(rollout)}))
+	q.Done(item)
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// deleteResourcesOfRenamedWorkload deletes the oidc-apps resources of a former workload, which is gone and reappears
+// as the given object under a new name, e.g. when recreated by a GitOps tool. The former workload is recognized by the
+// owner references of its ingresses and services, which claim the same hosts or select the same pods as the desired
+// resources. Its remaining resources are then found by the owner label. The resources without owner references are
+// cleaned up only upon the deletion of their workload.
+func deleteResourcesOfRenamedWorkload(ctx context.Context, c client.Client, object client.Object,
+	desired []client.Object) error {
+	formerOwners := make(map[string]string)
+
+	for _, list := range []client.ObjectList{&networkingv1.IngressList{}, &corev1.ServiceList{}} {
+		if err := c.List(ctx, list,
+			client.InNamespace(object.GetNamespace()),
+			client.MatchingLabels{constants.LabelKey: constants.LabelValue},
+		); err != nil {
+			return fmt.Errorf("failed to list resources: %w", err)
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			o, ok := item.(client.Object)
+			if !ok || isAnOwnedResource(object, o) || !isReplacedByDesired(o, desired) {
+				continue
+			}
+
+			owner, found := o.GetLabels()[constants.LabelOwnerKey]
+			if !found {
+				continue
+			}
+
+			name, gone, err := isFormerWorkloadGone(ctx, c, object, o)
+			if err != nil {
+				return err
+			}
+
+			if gone {
+				formerOwners[owner] = name
+			}
+		}
+	}
+
+	for owner, name := range formerOwners {
+		log.FromContext(ctx).Info("Workload is renamed, removing the resources of the former workload",
+			"former", name)
+
+		if err := deleteOrphanedResources(ctx, c, object.GetNamespace(), owner, deletionPropagation()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isFormerWorkloadGone returns the name of the workload of the same kind as the object, which owns the given resource,
+// and whether that workload no longer exists
+func isFormerWorkloadGone(ctx context.Context, c client.Client, object, owned client.Object) (string, bool, error) {
+	for _, ref := range owned.GetOwnerReferences() {
+		if ref.Kind != workloadKind(object) {
+			continue
+		}
+
+		former, ok := object.DeepCopyObject().(client.Object)
+		if !ok {
+			return "", false, nil
+		}
+
+		err := c.Get(ctx, client.ObjectKey{Namespace: object.GetNamespace(), Name: ref.Name}, former)
+		if apierrors.IsNotFound(err) {
+			return ref.Name, true, nil
+		}
+
+		if err != nil {
+			return "", false, fmt.Errorf("failed to get %s %s: %w", ref.Kind, ref.Name, err)
+		}
+	}
+
+	return "", false, nil
+}
+
+// isReplacedByDesired returns true if the existing ingress claims a host of a desired ingress, or the existing service
+// selects the same pods as a desired service
+func isReplacedByDesired(existing client.Object, desired []client.Object) bool {
+	for _, d := range desired {
+		switch d := d.(type) {
+		case *networkingv1.Ingress:
+			ingress, ok := existing.(*networkingv1.Ingress)
+			if !ok {
+				continue
+			}
+
+			for _, rule := range d.Spec.Rules {
+				if rule.Host != "" && ingressHasHost(*ingress, rule.Host) {
+					return true
+				}
+			}
+		case *corev1.Service:
+			service, ok := existing.(*corev1.Service)
+			if ok && len(d.Spec.Selector) > 0 && maps.Equal(service.Spec.Selector, d.Spec.Selector) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func TestDeleteResourcesOfRenamedWorkload(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	t.Cleanup(func() { forgetOutcome(deployment) })
+
+	desiredIngress, err := createIngressForDeployment(deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// The resources of the deployment formerly named nginx-old
+	oldOwnerRef := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "nginx-old", UID: "old"}
+	meta := func(name, owner string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				constants.LabelKey:      constants.LabelValue,
+				constants.LabelOwnerKey: ownerLabelValue("Deployment", client.ObjectKey{Namespace: "default", Name: owner}),
+			},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "Deployment", Name: owner, UID: types.UID("uid-" + owner),
+			}},
+		}
+	}
+	oldMeta := func(name string) metav1.ObjectMeta {
+		m := meta(name, "nginx-old")
+		m.OwnerReferences = []metav1.OwnerReference{oldOwnerRef}
+
+		return m
+	}
+
+	oldIngress := &networkingv1.Ingress{ObjectMeta: oldMeta(constants.IngressName + "-old"), Spec: desiredIngress.Spec}
+	oldService := &corev1.Service{
+		ObjectMeta: oldMeta(constants.ServiceNameOauth2Service + "-old"),
+		Spec: corev1.ServiceSpec{
+			Selector: configuration.GetOIDCAppsControllerConfig().GetTargetLabelSelector(deployment).MatchLabels,
+		},
+	}
+	oldSecret := &corev1.Secret{ObjectMeta: oldMeta(constants.SecretNameOauth2Proxy + "-old")}
+
+	// The resources of another existing deployment selecting the same pods are kept
+	other := getTargetDeployment()
+	other.SetName("nginx-other")
+	other.SetUID("uid-nginx-other")
+	otherService := &corev1.Service{
+		ObjectMeta: meta(constants.ServiceNameOauth2Service+"-other", "nginx-other"),
+		Spec:       oldService.Spec,
+	}
+
+	c := newFakeClient(g, deployment, other, oldIngress, oldService, oldSecret, otherService)
+
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	for _, o := range []client.Object{oldIngress, oldService, oldSecret} {
+		g.Expect(c.Get(ctx, client.ObjectKeyFromObject(o), o)).NotTo(Succeed(), o.GetName())
+	}
+
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(otherService), otherService)).To(Succeed())
+
+	ingresses, err := fetchOidcAppsIngress(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+	g.Expect(ingresses.Items[0].Spec.Rules).To(Equal(desiredIngress.Spec.Rules))
+}
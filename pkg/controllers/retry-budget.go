@@ -0,0 +1,135 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// reasonRetryBudgetExhausted is the reason of the warning event emitted for a target, which is no longer requeued
+// after its reconcile failed permanently for the retry budget
+const reasonRetryBudgetExhausted = "RetryBudgetExhausted"
+
+// retryBudgetEntry holds the number of consecutive permanent reconcile failures of a target at its generation
+type retryBudgetEntry struct {
+	key        types.NamespacedName
+	generation int64
+	failures   int
+}
+
+// retryBudgetCache keeps the consecutive permanent reconcile failures keyed by the target object UID
+type retryBudgetCache struct {
+	mu      sync.Mutex
+	entries map[types.UID]retryBudgetEntry
+}
+
+var retryBudgets = &retryBudgetCache{entries: make(map[types.UID]retryBudgetEntry)}
+
+// forgetRetryBudget drops the recorded reconcile failures of an object, upon a successful reconcile or its deletion
+func forgetRetryBudget(object client.Object) {
+	retryBudgets.mu.Lock()
+	defer retryBudgets.mu.Unlock()
+
+	delete(retryBudgets.entries, object.GetUID())
+}
+
+// failure records a permanent reconcile failure of the object and returns the number of its consecutive failures. The
+// failures of a former generation are not counted, so that the budget is renewed by a spec change.
+func (r *retryBudgetCache) failure(object client.Object) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := r.entries[object.GetUID()]
+	if entry.generation != object.GetGeneration() {
+		entry = retryBudgetEntry{key: client.ObjectKeyFromObject(object), generation: object.GetGeneration()}
+	}
+
+	entry.failures++
+	r.entries[object.GetUID()] = entry
+
+	return entry.failures
+}
+
+// deleteKey drops the recorded reconcile failures of the objects with the given name
+func (r *retryBudgetCache) deleteKey(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	maps.DeleteFunc(r.entries, func(_ types.UID, e retryBudgetEntry) bool { return e.key == key })
+}
+
+// isTransientError returns true if the reconcile error is expected to disappear by itself, e.g. an optimistic lock
+// conflict, an unavailable API server, a network failure of the oidc issuer probe or a pending deletion
+func isTransientError(err error) bool {
+	var netErr net.Error
+
+	return errors.Is(err, errPendingDeletion) ||
+		apierrors.IsConflict(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsUnexpectedServerError(err) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsConnectionReset(err) ||
+		errors.As(err, &netErr)
+}
+
+// spendRetryBudget counts a permanent reconcile failure of the target against the retry budget. Once the budget is
+// spent, the error is returned as terminal, hence the target is not requeued until a spec change or another event, and
+// a warning event is emitted. The transient errors and a zero budget always requeue.
+func spendRetryBudget(ctx context.Context, recorder record.EventRecorder, budget int, object client.Object,
+	err error) error {
+	if budget <= 0 || object.GetUID() == "" || isTransientError(err) {
+		return err
+	}
+
+	failures := retryBudgets.failure(object)
+	if failures < budget {
+		return err
+	}
+
+	if failures == budget {
+		log.FromContext(ctx).Info("Retry budget exhausted, stop requeueing until the spec changes",
+			"failures", failures, "error", err.Error())
+
+		if recorder != nil {
+			recorder.Event(object, corev1.EventTypeWarning, reasonRetryBudgetExhausted, fmt.Sprintf(
+				"The reconcile failed %d consecutive times and is not retried until the spec changes: %v", failures, err))
+		}
+	}
+
+	return reconcile.TerminalError(err)
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRetryBudget(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	statefulSet := getTargetStatefulSet()
+	statefulSet.SetGeneration(1)
+
+	t.Cleanup(func() { forgetRetryBudget(statefulSet) })
+
+	createErr := errors.New("secret creation failed")
+	fakeClient := newFakeClient(g, statefulSet, getStatefulSetPod(0))
+	c := interceptor.NewClient(fakeClient.(client.WithWatch), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*corev1.Secret); ok {
+				return createErr
+			}
+
+			return c.Create(ctx, obj, opts...)
+		},
+	})
+
+	recorder := record.NewFakeRecorder(10)
+	r := &StatefulSetReconciler{Client: c, Recorder: recorder, RetryBudget: 3}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(statefulSet)}
+
+	// The failures within the budget are requeued
+	for range 2 {
+		_, err := r.Reconcile(ctx, request)
+		g.Expect(err).To(MatchError(createErr))
+		g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeFalse())
+	}
+
+	// The requeue stops once the budget is spent, with a single warning event
+	for range 2 {
+		_, err := r.Reconcile(ctx, request)
+		g.Expect(err).To(MatchError(createErr))
+		g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeTrue())
+	}
+
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(<-recorder.Events).To(ContainSubstring(reasonRetryBudgetExhausted))
+
+	// A spec change renews the budget
+	g.Expect(fakeClient.Get(ctx, request.NamespacedName, statefulSet)).To(Succeed())
+	statefulSet.SetGeneration(2)
+	g.Expect(fakeClient.Update(ctx, statefulSet)).To(Succeed())
+
+	_, err := r.Reconcile(ctx, request)
+	g.Expect(err).To(MatchError(createErr))
+	g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeFalse())
+}
+
+func TestRetryBudgetTransientErrors(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	statefulSet := getTargetStatefulSet()
+
+	t.Cleanup(func() { forgetRetryBudget(statefulSet) })
+
+	transient := apierrors.NewConflict(schema.GroupResource{Resource: "secrets"}, "oauth2-proxy", errors.New("conflict"))
+
+	// Transient errors are always requeued
+	for range 3 {
+		err := spendRetryBudget(ctx, nil, 1, statefulSet, transient)
+		g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeFalse())
+	}
+
+	// Network failures, e.g. of the oidc issuer probe, are always requeued
+	for _, err := range []error{
+		fmt.Errorf("oidc issuer %s: %w", "https://issuer.local", &url.Error{Op: "Get", URL: "https://issuer.local",
+			Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}),
+		fmt.Errorf("oidc issuer %s: %w", "https://issuer.local", io.ErrUnexpectedEOF),
+		&net.DNSError{Err: "i/o timeout", Name: "issuer.local", IsTimeout: true},
+	} {
+		g.Expect(isTransientError(err)).To(BeTrue(), err.Error())
+		g.Expect(errors.Is(spendRetryBudget(ctx, nil, 1, statefulSet, err), reconcile.TerminalError(nil))).To(BeFalse())
+	}
+
+	// A zero budget requeues forever
+	for range 3 {
+		err := spendRetryBudget(ctx, nil, 0, statefulSet, errors.New("permanent"))
+		g.Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeFalse())
+	}
+
+	// A successful reconcile renews the budget
+	g.Expect(errors.Is(spendRetryBudget(ctx, nil, 2, statefulSet, errors.New("permanent")),
+		reconcile.TerminalError(nil))).To(BeFalse())
+	forgetRetryBudget(statefulSet)
+	g.Expect(errors.Is(spendRetryBudget(ctx, nil, 2, statefulSet, errors.New("permanent")),
+		reconcile.TerminalError(nil))).To(BeFalse())
+	g.Expect(errors.Is(spendRetryBudget(ctx, nil, 2, statefulSet, errors.New("permanent")),
+		reconcile.TerminalError(nil))).To(BeTrue())
+}
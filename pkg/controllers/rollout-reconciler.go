@@ -0,0 +1,165 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// RolloutGroupVersionKind is the group, version and kind of the Argo Rollouts custom workload
+var RolloutGroupVersionKind = schema.FromAPIVersionAndKind(constants.RolloutAPIVersion, constants.RolloutKind)
+
+// NewRollout returns an empty Argo Rollout. The controller does not depend on the Argo Rollouts api types, hence the
+// rollouts are handled as unstructured objects.
+func NewRollout() *unstructured.Unstructured {
+	rollout := &unstructured.Unstructured{}
+	rollout.SetGroupVersionKind(RolloutGroupVersionKind)
+
+	return rollout
+}
+
+// NewRolloutList returns an empty list of Argo Rollouts
+func NewRolloutList() *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(RolloutGroupVersionKind.GroupVersion().WithKind(constants.RolloutKind + "List"))
+
+	return list
+}
+
+// IsRollout returns true if the object is an Argo Rollout
+func IsRollout(object client.Object) bool {
+	return object.GetObjectKind().GroupVersionKind().GroupKind() == RolloutGroupVersionKind.GroupKind()
+}
+
+// RolloutReconciler holds configuration for the reconciler
+type RolloutReconciler struct {
+	Client client.Client
+	// Recorder emits the events of the reconciled targets
+	Recorder record.EventRecorder
+	// RetryBudget is the number of consecutive permanent failures, after which a target is no longer requeued until its
+	// spec changes. Zero requeues forever.
+	RetryBudget int
+	// Shard selects the workloads reconciled by this controller instance
+	Shard Shard
+	// VerifyIngressDNS enables the warning events for the ingress hosts, which do not resolve to the ingress load
+	// balancer. It is disabled by default, as it performs DNS lookups.
+	VerifyIngressDNS bool
+}
+
+// Reconcile creates the auth & zutz secrets mounted to the target rollout
+func (r *RolloutReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// The workloads of the other shards are reconciled by the other controller instances
+	if !r.Shard.Contains(request.NamespacedName) {
+		return reconcile.Result{}, nil
+	}
+
+	reconciledRollout := NewRollout()
+	if err := r.Client.Get(ctx, request.NamespacedName, reconciledRollout); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+
+		forgetDeletedTarget(request.NamespacedName)
+
+		// Clean up the dependent resources, which are not garbage collected without owner references
+		return reconcile.Result{}, deleteOrphanedResources(ctx, r.Client, request.Namespace,
+			ownerLabelValue(constants.RolloutKind, request.NamespacedName), deletionPropagation())
+	}
+
+	_log := log.FromContext(ctx).WithValues("resourceVersion", reconciledRollout.GetResourceVersion())
+
+	// Skip resource without an identity
+	if reconciledRollout.GetName() == "" && reconciledRollout.GetNamespace() == "" {
+		_log.V(9).Info("reconciled rollout is empty, returning ...")
+
+		return reconcile.Result{}, nil
+	}
+
+	_log.V(9).Info("handling rollout reconcile request")
+
+	if !configuration.GetOIDCAppsControllerConfig().Match(reconciledRollout) {
+		_log.V(9).Info("reconciled rollout is not an oidc-application-controller target, returning ...")
+
+		// Remove the proxies of a former target, e.g. after the oidc-apps label is removed
+		return reconcile.Result{}, removeAuthStack(ctx, r.Client, reconciledRollout)
+	}
+
+	if !hasOidcAppsPods(ctx, r.Client, reconciledRollout) {
+		return reconcile.Result{}, nil
+	}
+
+	// Check for deletion & handle cleanup of the dependencies
+	if !reconciledRollout.GetDeletionTimestamp().IsZero() {
+		_log.V(9).Info("Remove owned resources")
+
+		if err := deleteOwnedResources(ctx, r.Client, reconciledRollout, deletionPropagation()); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		forgetSuffix(reconciledRollout)
+		forgetOutcome(reconciledRollout)
+		forgetRetryBudget(reconciledRollout)
+
+		return reconcile.Result{}, nil
+	}
+
+	warnOnUnexposedUpstreamPort(r.Recorder, reconciledRollout)
+	warnOnInsecureSkipVerify(ctx, r.Recorder, reconciledRollout)
+
+	if err := reconcileDependencies(ctx, r.Client, reconciledRollout); err != nil {
+		if !errors.Is(err, errClusterNotFound) {
+			logRedactedOauth2Config(ctx, reconciledRollout)
+			warnOnSuffixConflict(r.Recorder, reconciledRollout, err)
+
+			return reconcile.Result{}, spendRetryBudget(ctx, r.Recorder, r.RetryBudget, reconciledRollout, err)
+		}
+
+		// Requeue with backoff until the Cluster resource of a freshly created shoot appears
+		_log.Info("Cluster resource not found, requeue", "namespace", reconciledRollout.GetNamespace())
+
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	forgetRetryBudget(reconciledRollout)
+
+	if r.VerifyIngressDNS {
+		warnOnUnresolvedIngressHosts(ctx, r.Client, r.Recorder, reconciledRollout)
+	}
+
+	if err := migrateSuffix(ctx, r.Client, reconciledRollout); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	requeueAfter, err := cookieSecretRotationDelay(ctx, r.Client, reconciledRollout)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	_log.Info("reconciled rollout successfully")
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func getTargetRollout() *unstructured.Unstructured {
+	rollout := NewRollout()
+	rollout.SetName("nginx")
+	rollout.SetNamespace("default")
+	rollout.SetUID("target-rollout")
+	rollout.SetLabels(map[string]string{"app": "nginx"})
+
+	return rollout
+}
+
+func getRolloutPod() (*appsv1.ReplicaSet, *corev1.Pod) {
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-rs",
+			Namespace: "default",
+			UID:       "rollout-replicaset",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: constants.RolloutAPIVersion,
+				Kind:       constants.RolloutKind,
+				Name:       "nginx",
+				UID:        "target-rollout",
+			}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx-rs-0",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "nginx"},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       "nginx-rs",
+				UID:        "rollout-replicaset",
+			}},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "nginx"}, {Name: constants.ContainerNameOauth2Proxy}},
+		},
+	}
+
+	return replicaSet, pod
+}
+
+func TestRolloutReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	rollout := getTargetRollout()
+	replicaSet, pod := getRolloutPod()
+	c := newFakeClient(g, rollout, replicaSet, pod)
+
+	r := &RolloutReconciler{Client: c}
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(rollout)})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	suffix := rand.GenerateSha256("nginx-default")
+	objects := []client.Object{
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: constants.SecretNameOauth2Proxy + "-" + suffix}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: constants.ServiceNameOauth2Service + "-" + suffix}},
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: constants.IngressName + "-" + suffix}},
+	}
+
+	for _, o := range objects {
+		g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: o.GetName()}, o)).To(Succeed())
+		g.Expect(o.GetOwnerReferences()).To(ConsistOf(And(
+			HaveField("APIVersion", constants.RolloutAPIVersion),
+			HaveField("Kind", constants.RolloutKind),
+			HaveField("Name", "nginx"),
+			HaveField("UID", rollout.GetUID()),
+		)))
+	}
+
+	// The applied suffix is recorded on the rollout
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(rollout), rollout)).To(Succeed())
+	g.Expect(rollout.GetAnnotations()).To(HaveKeyWithValue(constants.AnnotationAppliedSuffixKey, suffix))
+}
+
+func TestRolloutReconcileWithoutOidcAppsPods(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	rollout := getTargetRollout()
+	c := newFakeClient(g, rollout)
+
+	r := &RolloutReconciler{Client: c}
+	_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(rollout)})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	secrets := &corev1.SecretList{}
+	g.Expect(c.List(ctx, secrets)).To(Succeed())
+	g.Expect(secrets.Items).To(BeEmpty())
+}
+
+func TestReconcileDependenciesUnsupportedUnstructured(t *testing.T) {
+	g := NewWithT(t)
+
+	object := &unstructured.Unstructured{}
+	object.SetAPIVersion("example.com/v1")
+	object.SetKind("Workload")
+
+	g.Expect(reconcileDependencies(context.TODO(), newFakeClient(g), object)).To(MatchError(errUnsupportedKind))
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Shard selects the workloads reconciled by one of several controller instances, which share the workloads of the
+// cluster. The workloads are partitioned by the hash of their namespace and name, rather than of their UID, so that
+// the orphaned resources of a deleted workload are cleaned up by the same instance.
+type Shard struct {
+	// Index is the zero based index of the shard of this controller instance
+	Index int
+	// Count is the number of shards, a count of zero or one reconciles all workloads
+	Count int
+}
+
+// Validate verifies that the shard index is within the shard count
+func (s Shard) Validate() error {
+	if s.Count < 0 {
+		return fmt.Errorf("invalid shard count %d, expected a non-negative number", s.Count)
+	}
+
+	if s.Count > 1 && (s.Index < 0 || s.Index >= s.Count) {
+		return fmt.Errorf("invalid shard index %d, expected a number from 0 to %d", s.Index, s.Count-1)
+	}
+
+	return nil
+}
+
+// Contains returns true if the workload is reconciled by the controller instance of the shard
+func (s Shard) Contains(key client.ObjectKey) bool {
+	if s.Count <= 1 {
+		return true
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key.String()))
+
+	return int(h.Sum32()%uint32(s.Count)) == s.Index // #nosec G115 -- The count is validated to be positive
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestShardPartitionsWorkloads(t *testing.T) {
+	g := NewWithT(t)
+
+	shards := []Shard{{Index: 0, Count: 2}, {Index: 1, Count: 2}}
+	counts := make([]int, len(shards))
+
+	for i := range 100 {
+		key := client.ObjectKey{Namespace: fmt.Sprintf("namespace-%d", i%7), Name: fmt.Sprintf("workload-%d", i)}
+
+		owners := 0
+
+		for j, s := range shards {
+			if s.Contains(key) {
+				owners++
+				counts[j]++
+			}
+		}
+
+		// Each workload is reconciled by exactly one shard
+		g.Expect(owners).To(Equal(1), key.String())
+	}
+
+	g.Expect(counts[0]).To(BeNumerically(">", 25))
+	g.Expect(counts[1]).To(BeNumerically(">", 25))
+
+	// Without shards all the workloads are reconciled
+	g.Expect(Shard{}.Contains(client.ObjectKey{Namespace: "default", Name: "nginx"})).To(BeTrue())
+	g.Expect(Shard{Count: 1}.Contains(client.ObjectKey{Namespace: "default", Name: "nginx"})).To(BeTrue())
+}
+
+func TestShardValidate(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(Shard{}.Validate()).To(Succeed())
+	g.Expect(Shard{Index: 1, Count: 2}.Validate()).To(Succeed())
+	g.Expect(Shard{Index: 2, Count: 2}.Validate()).To(MatchError(ContainSubstring("invalid shard index 2")))
+	g.Expect(Shard{Index: -1, Count: 2}.Validate()).To(MatchError(ContainSubstring("invalid shard index -1")))
+	g.Expect(Shard{Count: -1}.Validate()).To(MatchError(ContainSubstring("invalid shard count -1")))
+}
+
+func TestReconcileSkipsWorkloadsOfOtherShards(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	statefulSet := getTargetStatefulSet()
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(statefulSet)}
+
+	t.Cleanup(func() { forgetOutcome(statefulSet) })
+
+	own := Shard{Count: 2}
+	if !own.Contains(request.NamespacedName) {
+		own.Index = 1
+	}
+
+	other := Shard{Index: 1 - own.Index, Count: 2}
+
+	for _, tc := range []struct {
+		shard   Shard
+		secrets int
+	}{
+		{shard: other, secrets: 0},
+		{shard: own, secrets: 2},
+	} {
+		c := newFakeClient(g, statefulSet, getStatefulSetPod(0))
+		r := &StatefulSetReconciler{Client: c, Shard: tc.shard}
+
+		_, err := r.Reconcile(ctx, request)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		secrets := &corev1.SecretList{}
+		g.Expect(c.List(ctx, secrets, client.InNamespace("default"))).To(Succeed())
+		g.Expect(secrets.Items).To(HaveLen(tc.secrets))
+	}
+}
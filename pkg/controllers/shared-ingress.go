@@ -0,0 +1,213 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+// errSharedIngressPathConflict is returned when the base path is already registered on the shared ingress by another
+// workload
+var errSharedIngressPathConflict = errors.New("shared ingress path is already in use")
+
+// sharedIngressName returns the name of the shared ingress of a host
+func sharedIngressName(host string) string {
+	return constants.ResourceName(constants.SharedIngressName, rand.GenerateSha256(host))
+}
+
+// reconcileSharedIngressPath registers the path rule of a target deployment on the shared ingress of its host, or
+// de-registers it when the target no longer uses a shared ingress
+func reconcileSharedIngressPath(ctx context.Context, c client.Client, object client.Object) error {
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	owner := ownerLabelValue(workloadKind(object), client.ObjectKeyFromObject(object))
+
+	if !cfg.GetIngressShared(object) || cfg.GetSkipIngress(object) {
+		return deregisterSharedIngressPaths(ctx, c, object.GetNamespace(), owner)
+	}
+
+	desired, err := createIngressForDeployment(object)
+	if err != nil {
+		return fmt.Errorf("failed to create oauth2 ingress: %w", err)
+	}
+
+	// The path is moved, when the shared ingress host of the target changes
+	name := sharedIngressName(desired.Spec.Rules[0].Host)
+	if err := deregisterSharedIngressPaths(ctx, c, object.GetNamespace(), owner, name); err != nil {
+		return err
+	}
+
+	return registerSharedIngressPath(ctx, c, owner, name, desired)
+}
+
+// registerSharedIngressPath adds the path rule of the desired ingress of a workload to the shared ingress, which is
+// created when missing. The base path registered by the workload is recorded in an owner annotation, so that it is
+// removed once the workload is gone.
+func registerSharedIngressPath(ctx context.Context, c client.Client, owner, name string,
+	desired networkingv1.Ingress) error {
+	path := desired.Spec.Rules[0].HTTP.Paths[0]
+
+	return retry.RetryOnConflict(configuration.GetOIDCAppsControllerConfig().GetRetryBackoff(), func() error {
+		ingress := &networkingv1.Ingress{}
+
+		err := c.Get(ctx, client.ObjectKey{Namespace: desired.GetNamespace(), Name: name}, ingress)
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed to get shared ingress %s: %w", name, err)
+		}
+
+		if apierrors.IsNotFound(err) {
+			ingress = &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: desired.GetNamespace(),
+					Labels:    map[string]string{constants.LabelKey: constants.LabelValue},
+				},
+				Spec: *desired.Spec.DeepCopy(),
+			}
+			ingress.Spec.Rules[0].HTTP.Paths = nil
+		}
+
+		// The ingress settings follow the last registered workload, the targets sharing a host share their settings
+		existing := ingress.DeepCopy()
+		ingress.Annotations = withSharedIngressAnnotations(ingress.Annotations, desired.Annotations)
+		setIngressClass(ingress, desired.Spec.IngressClassName, desired.Annotations)
+		ingress.Spec.TLS = desired.Spec.TLS
+
+		for o, p := range sharedIngressOwners(ingress) {
+			if o != owner && p == path.Path {
+				return fmt.Errorf("%w: path %s on host %s", errSharedIngressPathConflict, p,
+					desired.Spec.Rules[0].Host)
+			}
+		}
+
+		removeSharedIngressPath(ingress, owner)
+		ingress.Annotations[constants.AnnotationSharedIngressOwnerPrefix+owner] = path.Path
+
+		paths := append(ingress.Spec.Rules[0].HTTP.Paths, path)
+		slices.SortFunc(paths, func(a, b networkingv1.HTTPIngressPath) int { return strings.Compare(a.Path, b.Path) })
+		ingress.Spec.Rules[0].HTTP.Paths = paths
+
+		if ingress.GetResourceVersion() == "" {
+			return c.Create(ctx, ingress)
+		}
+
+		if maps.Equal(existing.GetAnnotations(), ingress.GetAnnotations()) &&
+			equality.Semantic.DeepEqual(existing.Spec, ingress.Spec) {
+			return nil
+		}
+
+		return c.Update(ctx, ingress)
+	})
+}
+
+// deregisterSharedIngressPaths removes the path rules registered by a workload from the shared ingresses in the
+// namespace, except for the kept ingress. The shared ingresses without any path rules left are deleted.
+func deregisterSharedIngressPaths(ctx context.Context, c client.Client, namespace, owner string,
+	keep ...string) error {
+	ingresses := &networkingv1.IngressList{}
+	if err := c.List(ctx, ingresses,
+		client.InNamespace(namespace),
+		client.MatchingLabels{constants.LabelKey: constants.LabelValue},
+	); err != nil {
+		return fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	for _, item := range ingresses.Items {
+		if _, registered := sharedIngressOwners(&item)[owner]; !registered || slices.Contains(keep, item.GetName()) {
+			continue
+		}
+
+		if err := retry.RetryOnConflict(configuration.GetOIDCAppsControllerConfig().GetRetryBackoff(), func() error {
+			ingress := &networkingv1.Ingress{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(&item), ingress); err != nil {
+				return client.IgnoreNotFound(err)
+			}
+
+			removeSharedIngressPath(ingress, owner)
+
+			if len(sharedIngressOwners(ingress)) == 0 {
+				return client.IgnoreNotFound(c.Delete(ctx, ingress, deletionPropagation()))
+			}
+
+			return c.Update(ctx, ingress)
+		}); err != nil {
+			return fmt.Errorf("failed to de-register from shared ingress %s: %w", item.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// sharedIngressOwners returns the base paths of a shared ingress by the owner label values of the registered workloads
+func sharedIngressOwners(ingress client.Object) map[string]string {
+	owners := make(map[string]string)
+
+	for k, v := range ingress.GetAnnotations() {
+		if owner, found := strings.CutPrefix(k, constants.AnnotationSharedIngressOwnerPrefix); found {
+			owners[owner] = v
+		}
+	}
+
+	return owners
+}
+
+// removeSharedIngressPath removes the path rule and the owner annotation of a workload from a shared ingress
+func removeSharedIngressPath(ingress *networkingv1.Ingress, owner string) {
+	p, found := sharedIngressOwners(ingress)[owner]
+	if !found {
+		return
+	}
+
+	delete(ingress.Annotations, constants.AnnotationSharedIngressOwnerPrefix+owner)
+
+	for i := range ingress.Spec.Rules {
+		if ingress.Spec.Rules[i].HTTP == nil {
+			continue
+		}
+
+		ingress.Spec.Rules[i].HTTP.Paths = slices.DeleteFunc(ingress.Spec.Rules[i].HTTP.Paths,
+			func(path networkingv1.HTTPIngressPath) bool { return path.Path == p })
+	}
+}
+
+// withSharedIngressAnnotations returns the owner annotations of a shared ingress merged with the desired annotations
+func withSharedIngressAnnotations(existing, desired map[string]string) map[string]string {
+	annotations := make(map[string]string, len(existing)+len(desired))
+
+	for k, v := range existing {
+		if strings.HasPrefix(k, constants.AnnotationSharedIngressOwnerPrefix) {
+			annotations[k] = v
+		}
+	}
+
+	maps.Copy(annotations, desired)
+
+	return annotations
+}
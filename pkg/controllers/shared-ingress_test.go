@@ -0,0 +1,146 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func TestSharedIngressPathRules(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.Shared, ingressConf.Host = true, "apps.domain.org"
+
+	nginx := getTargetDeployment()
+	nginx.SetUID("shared-nginx")
+
+	other := getTargetDeployment()
+	other.Name, other.UID = "other", "shared-other"
+
+	t.Cleanup(func() {
+		ingressConf.Shared, ingressConf.Host = false, ""
+
+		forgetOutcome(nginx)
+		forgetOutcome(other)
+	})
+
+	c := newFakeClient(g, nginx, other)
+
+	g.Expect(reconcileDependencies(ctx, c, nginx)).To(Succeed())
+	g.Expect(reconcileDependencies(ctx, c, other)).To(Succeed())
+
+	// The oauth2-proxy endpoints are nested under the base path of the workload
+	g.Expect(configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyPrefix(nginx)).To(Equal("/nginx/oauth2"))
+
+	// Both workloads are registered on the shared ingress instead of dedicated ones
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(c.List(ctx, ingresses)).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+
+	shared := ingresses.Items[0]
+	g.Expect(shared.Name).To(Equal(constants.SharedIngressName + "-" + rand.GenerateSha256("apps.domain.org")))
+	g.Expect(shared.OwnerReferences).To(BeEmpty())
+	g.Expect(shared.Labels).NotTo(HaveKey(constants.LabelOwnerKey))
+	g.Expect(shared.Spec.Rules).To(HaveLen(1))
+	g.Expect(shared.Spec.Rules[0].Host).To(Equal("apps.domain.org"))
+	g.Expect(sharedIngressPaths(shared)).To(Equal(map[string]string{
+		"/nginx": constants.ServiceNameOauth2Service + "-" + rand.GenerateSha256("nginx-default"),
+		"/other": constants.ServiceNameOauth2Service + "-" + rand.GenerateSha256("other-default"),
+	}))
+
+	// The path rule of a removed workload is de-registered
+	g.Expect(deleteOwnedResources(ctx, c, other, deletionPropagation())).To(Succeed())
+
+	key := client.ObjectKeyFromObject(&shared)
+	g.Expect(c.Get(ctx, key, &shared)).To(Succeed())
+	g.Expect(sharedIngressPaths(shared)).To(HaveLen(1))
+	g.Expect(sharedIngressPaths(shared)).To(HaveKey("/nginx"))
+	g.Expect(shared.Annotations).To(HaveLen(2))
+	g.Expect(shared.Annotations).To(HaveKeyWithValue(constants.AnnotationIngressClassKey, "nginx"))
+
+	// The shared ingress is deleted with its last path rule, also after the workload is gone
+	g.Expect(deleteOrphanedResources(ctx, c, "default", ownerLabelValue("Deployment", client.ObjectKeyFromObject(nginx)),
+		deletionPropagation())).To(Succeed())
+	g.Expect(c.List(ctx, ingresses)).To(Succeed())
+	g.Expect(ingresses.Items).To(BeEmpty())
+}
+
+func TestSharedIngressDisabled(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	ingressConf := configuration.GetOIDCAppsControllerConfig().Targets[0].Ingress
+	ingressConf.Shared, ingressConf.Host = true, "apps.domain.org"
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("shared-disabled")
+
+	t.Cleanup(func() {
+		ingressConf.Shared, ingressConf.Host = false, ""
+
+		forgetOutcome(deployment)
+	})
+
+	c := newFakeClient(g, deployment)
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	// Once the ingress is no longer shared, the path rule is replaced by a dedicated ingress
+	ingressConf.Shared = false
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(c.List(ctx, ingresses)).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+	g.Expect(ingresses.Items[0].Name).To(Equal(constants.IngressName + "-" + rand.GenerateSha256("nginx-default")))
+	g.Expect(ingresses.Items[0].Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/"))
+}
+
+func TestSharedIngressPathConflict(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	desired, err := createIngressForDeployment(getTargetDeployment())
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c := newFakeClient(g)
+	g.Expect(registerSharedIngressPath(ctx, c, "first", "shared", desired)).To(Succeed())
+
+	// Re-registering the same workload is idempotent
+	g.Expect(registerSharedIngressPath(ctx, c, "first", "shared", desired)).To(Succeed())
+
+	g.Expect(registerSharedIngressPath(ctx, c, "second", "shared", desired)).
+		To(MatchError(errSharedIngressPathConflict))
+}
+
+// sharedIngressPaths returns the backend services of the path rules of a shared ingress by their path
+func sharedIngressPaths(ingress networkingv1.Ingress) map[string]string {
+	paths := make(map[string]string)
+	for _, p := range ingress.Spec.Rules[0].HTTP.Paths {
+		paths[p.Path] = p.Backend.Service.Name
+	}
+
+	return paths
+}
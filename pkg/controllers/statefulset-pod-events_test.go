@@ -0,0 +1,74 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestEnqueueStatefulSetForPodCoalescesEvents(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	t.Cleanup(q.ShutDown)
+
+	h := EnqueueStatefulSetForPod(50 * time.Millisecond)
+
+	pod := func(name, kind string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: kind, Name: "nginx", UID: "target-statefulset"},
+			},
+		}}
+	}
+
+	// A burst of events of all the statefulset pods, e.g. during a rolling update
+	for i := range 2 {
+		p := pod(fmt.Sprintf("nginx-%d", i), "StatefulSet")
+		h.Create(ctx, event.CreateEvent{Object: p}, q)
+
+		for range 5 {
+			h.Update(ctx, event.UpdateEvent{ObjectOld: p, ObjectNew: p}, q)
+		}
+
+		h.Delete(ctx, event.DeleteEvent{Object: p}, q)
+	}
+
+	// Pods of other workloads are ignored
+	h.Update(ctx, event.UpdateEvent{ObjectOld: pod("nginx-rs", "ReplicaSet"), ObjectNew: pod("nginx-rs", "ReplicaSet")}, q)
+
+	g.Expect(q.Len()).To(BeZero())
+	g.Eventually(q.Len).Should(Equal(1))
+
+	item, _ := q.Get()
+	g.Expect(item).To(Equal(reconcile.Request{NamespacedName: types.NamespacedName{Name: "nginx", Namespace: "default"}}))
+	q.Done(item)
+
+	g.Consistently(q.Len, 200*time.Millisecond).Should(BeZero())
+}
@@ -16,8 +16,11 @@ package controllers
 
 import (
 	"context"
+	"errors"
 
 	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -28,14 +31,37 @@ import (
 // StatefulSetReconciler holds configuration for the reconciler
 type StatefulSetReconciler struct {
 	Client client.Client
+	// Recorder emits the events of the reconciled targets
+	Recorder record.EventRecorder
+	// RetryBudget is the number of consecutive permanent failures, after which a target is no longer requeued until its
+	// spec changes. Zero requeues forever.
+	RetryBudget int
+	// Shard selects the workloads reconciled by this controller instance
+	Shard Shard
+	// VerifyIngressDNS enables the warning events for the ingress hosts, which do not resolve to the ingress load
+	// balancer. It is disabled by default, as it performs DNS lookups.
+	VerifyIngressDNS bool
 }
 
 // Reconcile creates the auth & zutz secrets mounted to the target statefulset
 func (s *StatefulSetReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// The workloads of the other shards are reconciled by the other controller instances
+	if !s.Shard.Contains(request.NamespacedName) {
+		return reconcile.Result{}, nil
+	}
+
 	reconciledStatefulSet := &appsv1.StatefulSet{}
 
-	if err := s.Client.Get(ctx, request.NamespacedName, reconciledStatefulSet); client.IgnoreNotFound(err) != nil {
-		return reconcile.Result{}, err
+	if err := s.Client.Get(ctx, request.NamespacedName, reconciledStatefulSet); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+
+		forgetDeletedTarget(request.NamespacedName)
+
+		// Clean up the dependent resources, which are not garbage collected without owner references
+		return reconcile.Result{}, deleteOrphanedResources(ctx, s.Client, request.Namespace,
+			ownerLabelValue("StatefulSet", request.NamespacedName), deletionPropagation())
 	}
 
 	_log := log.FromContext(ctx).WithValues("resourceVersion", reconciledStatefulSet.GetResourceVersion())
@@ -51,7 +77,8 @@ func (s *StatefulSetReconciler) Reconcile(ctx context.Context, request reconcile
 	if !configuration.GetOIDCAppsControllerConfig().Match(reconciledStatefulSet) {
 		_log.V(9).Info("Reconciled statefulset is not an oidc-application-controller target, returning ...")
 
-		return reconcile.Result{}, nil
+		// Remove the proxies of a former target, e.g. after the oidc-apps label is removed
+		return reconcile.Result{}, removeAuthStack(ctx, s.Client, reconciledStatefulSet)
 	}
 
 	if !hasOidcAppsPods(ctx, s.Client, reconciledStatefulSet) {
@@ -62,18 +89,51 @@ func (s *StatefulSetReconciler) Reconcile(ctx context.Context, request reconcile
 	if !reconciledStatefulSet.GetDeletionTimestamp().IsZero() {
 		_log.V(9).Info("Remove owned resources")
 
-		if err := deleteOwnedResources(ctx, s.Client, reconciledStatefulSet); err != nil {
+		if err := deleteOwnedResources(ctx, s.Client, reconciledStatefulSet, deletionPropagation()); err != nil {
 			return reconcile.Result{}, err
 		}
 
+		forgetSuffix(reconciledStatefulSet)
+		forgetOutcome(reconciledStatefulSet)
+		forgetRetryBudget(reconciledStatefulSet)
+
 		return reconcile.Result{}, nil
 	}
 
-	if err := reconcileStatefulSetDependencies(ctx, s.Client, reconciledStatefulSet); err != nil {
+	warnOnUnexposedUpstreamPort(s.Recorder, reconciledStatefulSet)
+	warnOnInsecureSkipVerify(ctx, s.Recorder, reconciledStatefulSet)
+	warnOnExceededPerPodResourcesCap(s.Recorder, reconciledStatefulSet)
+
+	if err := reconcileDependencies(ctx, s.Client, reconciledStatefulSet); err != nil {
+		if !errors.Is(err, errClusterNotFound) {
+			logRedactedOauth2Config(ctx, reconciledStatefulSet)
+			warnOnSuffixConflict(s.Recorder, reconciledStatefulSet, err)
+
+			return reconcile.Result{}, spendRetryBudget(ctx, s.Recorder, s.RetryBudget, reconciledStatefulSet, err)
+		}
+
+		// Requeue with backoff until the Cluster resource of a freshly created shoot appears
+		_log.Info("Cluster resource not found, requeue", "namespace", reconciledStatefulSet.GetNamespace())
+
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	forgetRetryBudget(reconciledStatefulSet)
+
+	if s.VerifyIngressDNS {
+		warnOnUnresolvedIngressHosts(ctx, s.Client, s.Recorder, reconciledStatefulSet)
+	}
+
+	if err := migrateSuffix(ctx, s.Client, reconciledStatefulSet); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	requeueAfter, err := cookieSecretRotationDelay(ctx, s.Client, reconciledStatefulSet)
+	if err != nil {
 		return reconcile.Result{}, err
 	}
 
 	_log.Info("reconciled statefulset successfully")
 
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
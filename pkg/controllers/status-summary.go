@@ -0,0 +1,100 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// statusSummary is the auth-injection state of a target workload, recorded as JSON in its status annotation
+type statusSummary struct {
+	Ready           bool     `json:"ready"`
+	Reason          string   `json:"reason,omitempty"`
+	Secrets         int      `json:"secrets"`
+	Services        int      `json:"services"`
+	Ingresses       int      `json:"ingresses"`
+	NetworkPolicies int      `json:"networkPolicies"`
+	Hosts           []string `json:"hosts,omitempty"`
+}
+
+// recordStatusSummary records the summary of the resources generated for the target workload in its status
+// annotation. The workload is patched only when the summary changes, as the patch triggers another reconcile.
+func recordStatusSummary(ctx context.Context, c client.Client, object client.Object, desiredErr error) error {
+	owned, err := fetchOwnedResources(ctx, c, object)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(newStatusSummary(owned, desiredErr))
+	if err != nil {
+		return fmt.Errorf("failed to marshal the status summary: %w", err)
+	}
+
+	if object.GetAnnotations()[constants.AnnotationStatusKey] == string(b) {
+		return nil
+	}
+
+	// A copy is patched, leaving the reconciled object as it was rendered from
+	patched := object.DeepCopyObject().(client.Object)
+	patch := client.MergeFrom(object)
+	patched.SetAnnotations(withAnnotation(patched.GetAnnotations(), constants.AnnotationStatusKey, string(b)))
+
+	if err := c.Patch(ctx, patched, patch); err != nil {
+		return fmt.Errorf("failed to record the status summary: %w", err)
+	}
+
+	return nil
+}
+
+// newStatusSummary summarizes the generated resources of a target workload. The workload is not ready while its
+// resources are rendered without a Cluster resource, or their reconcile fails.
+func newStatusSummary(owned []client.Object, desiredErr error) statusSummary {
+	summary := statusSummary{Ready: desiredErr == nil}
+	if desiredErr != nil {
+		summary.Reason = desiredErr.Error()
+	}
+
+	for _, o := range owned {
+		switch r := o.(type) {
+		case *corev1.Secret:
+			summary.Secrets++
+		case *corev1.Service:
+			summary.Services++
+		case *networkingv1.NetworkPolicy:
+			summary.NetworkPolicies++
+		case *networkingv1.Ingress:
+			summary.Ingresses++
+
+			for _, rule := range r.Spec.Rules {
+				if rule.Host != "" && !slices.Contains(summary.Hosts, rule.Host) {
+					summary.Hosts = append(summary.Hosts, rule.Host)
+				}
+			}
+		}
+	}
+
+	slices.Sort(summary.Hosts)
+
+	return summary
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func TestStatusSummary(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("status-summary-deployment")
+
+	t.Cleanup(func() {
+		forgetSuffix(deployment)
+		forgetOutcome(deployment)
+	})
+
+	c := newFakeClient(g, deployment)
+	g.Expect(reconcileDependencies(ctx, c, deployment)).To(Succeed())
+
+	reconciled := &appsv1.Deployment{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), reconciled)).To(Succeed())
+	g.Expect(reconciled.GetAnnotations()).To(HaveKey(constants.AnnotationStatusKey))
+
+	summary := statusSummary{}
+	g.Expect(json.Unmarshal([]byte(reconciled.GetAnnotations()[constants.AnnotationStatusKey]), &summary)).
+		To(Succeed())
+
+	// The summary reflects the created resources
+	secrets, err := fetchOidcAppsSecrets(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	services, err := fetchOidcAppsServices(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	ingresses, err := fetchOidcAppsIngress(ctx, c, deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ingresses.Items).To(HaveLen(1))
+
+	g.Expect(summary.Ready).To(BeTrue())
+	g.Expect(summary.Reason).To(BeEmpty())
+	g.Expect(summary.Secrets).To(BeNumerically(">=", len(secrets.Items)))
+	g.Expect(summary.Secrets).To(BeNumerically(">", 0))
+	g.Expect(summary.Services).To(Equal(len(services.Items)))
+	g.Expect(summary.Ingresses).To(Equal(1))
+	g.Expect(summary.Hosts).To(ConsistOf(ingresses.Items[0].Spec.Rules[0].Host))
+
+	// An unchanged summary does not patch the workload
+	resourceVersion := reconciled.GetResourceVersion()
+	forgetOutcome(reconciled)
+	g.Expect(reconcileDependencies(ctx, c, reconciled)).To(Succeed())
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), reconciled)).To(Succeed())
+	g.Expect(reconciled.GetResourceVersion()).To(Equal(resourceVersion))
+}
+
+func TestStatusSummaryWithoutCluster(t *testing.T) {
+	g := NewWithT(t)
+
+	summary := newStatusSummary(nil, errClusterNotFound)
+	g.Expect(summary.Ready).To(BeFalse())
+	g.Expect(summary.Reason).To(Equal(errClusterNotFound.Error()))
+	g.Expect(summary.Ingresses).To(BeZero())
+}
+
+func TestStatusSummaryOfFailedReconcile(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	deployment.SetUID("status-summary-failed-deployment")
+
+	t.Cleanup(func() {
+		forgetSuffix(deployment)
+		forgetOutcome(deployment)
+	})
+
+	fakeClient := newFakeClient(g, deployment)
+	g.Expect(reconcileDependencies(ctx, fakeClient, deployment)).To(Succeed())
+
+	reconciled := &appsv1.Deployment{}
+	g.Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(deployment), reconciled)).To(Succeed())
+	g.Expect(reconciled.GetAnnotations()[constants.AnnotationStatusKey]).To(ContainSubstring(`"ready":true`))
+
+	// The reconcile fails upon a changed configuration
+	failing := interceptor.NewClient(fakeClient.(client.WithWatch), interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch,
+			opts ...client.PatchOption) error {
+			if _, ok := obj.(*networkingv1.Ingress); ok {
+				return errors.New("ingress patch failed")
+			}
+
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	})
+
+	reconciled.SetAnnotations(map[string]string{
+		constants.AnnotationStatusKey:        reconciled.GetAnnotations()[constants.AnnotationStatusKey],
+		constants.AnnotationProxyBodySizeKey: "10m",
+	})
+	g.Expect(reconcileDependencies(ctx, failing, reconciled)).To(MatchError(ContainSubstring("ingress patch failed")))
+
+	g.Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(deployment), reconciled)).To(Succeed())
+
+	summary := statusSummary{}
+	g.Expect(json.Unmarshal([]byte(reconciled.GetAnnotations()[constants.AnnotationStatusKey]), &summary)).
+		To(Succeed())
+	g.Expect(summary.Ready).To(BeFalse())
+	g.Expect(summary.Reason).To(ContainSubstring("ingress patch failed"))
+}
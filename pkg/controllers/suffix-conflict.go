@@ -0,0 +1,101 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// reasonSuffixConflict is the reason of the warning event emitted for a target, whose resource names collide with the
+// resources of another workload
+const reasonSuffixConflict = "SuffixConflict"
+
+// errSuffixConflict is returned when a desired resource is named after an oidc-apps resource of another workload
+var errSuffixConflict = errors.New("resource name suffix is already in use")
+
+// verifySuffixIsUnique returns an error if a desired resource has the name of an oidc-apps resource of another
+// workload in the namespace. Both workloads end up with the same suffix, e.g. through equal suffix annotations, and
+// the reconciles would otherwise take over each other's resources.
+func verifySuffixIsUnique(ctx context.Context, c client.Client, object client.Object, desired []client.Object) error {
+	for _, d := range desired {
+		// The names generated by the API server do not collide
+		if d.GetName() == "" {
+			continue
+		}
+
+		gvk, err := apiutil.GVKForObject(d, c.Scheme())
+		if err != nil {
+			return err
+		}
+
+		// The typed cached get is served by the informers the controller already runs
+		existing, ok := d.DeepCopyObject().(client.Object)
+		if !ok {
+			return fmt.Errorf("unexpected object %T", d)
+		}
+
+		if err := c.Get(ctx, client.ObjectKeyFromObject(d), existing); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return fmt.Errorf("failed to get %s %s: %w", gvk.Kind, d.GetName(), err)
+		}
+
+		if existing.GetLabels()[constants.LabelKey] != constants.LabelValue || isAnOwnedResource(object, existing) ||
+			hasDesiredOwner(existing, d) {
+			continue
+		}
+
+		return fmt.Errorf("%w: %s %s with suffix %s is managed for another workload", errSuffixConflict, gvk.Kind,
+			d.GetName(), getSuffix(object))
+	}
+
+	return nil
+}
+
+// hasDesiredOwner returns true if the existing resource is owned by an owner of the desired one, e.g. the per-pod
+// services and ingresses owned by a pod of the target, which carry no owner label when created by former releases
+func hasDesiredOwner(existing, desired client.Object) bool {
+	for _, ref := range existing.GetOwnerReferences() {
+		for _, d := range desired.GetOwnerReferences() {
+			if ref.UID == d.UID || isFormerOwnerReference(ref, d) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// warnOnSuffixConflict emits a warning event when the reconcile of the target failed upon a suffix conflict, as it is
+// resolved only by changing the suffix annotation of one of the workloads
+func warnOnSuffixConflict(recorder record.EventRecorder, object client.Object, err error) {
+	if recorder == nil || !errors.Is(err, errSuffixConflict) {
+		return
+	}
+
+	recorder.Event(object, corev1.EventTypeWarning, reasonSuffixConflict, err.Error())
+}
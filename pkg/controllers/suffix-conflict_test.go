@@ -0,0 +1,140 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func TestVerifySuffixIsUnique(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	first := getTargetDeployment()
+	first.SetUID("suffix-conflict-first")
+	first.SetAnnotations(map[string]string{constants.AnnotationSuffixKey: "shared"})
+
+	second := getTargetDeployment()
+	second.SetName("nginx-copy")
+	second.SetUID("suffix-conflict-second")
+	second.SetAnnotations(map[string]string{constants.AnnotationSuffixKey: "shared"})
+
+	t.Cleanup(func() {
+		for _, d := range []client.Object{first, second} {
+			forgetSuffix(d)
+			forgetOutcome(d)
+		}
+	})
+
+	c := newFakeClient(g, first, second)
+	g.Expect(reconcileDependencies(ctx, c, first)).To(Succeed())
+
+	// The reconciled workload does not conflict with its own resources
+	forgetOutcome(first)
+	g.Expect(reconcileDependencies(ctx, c, first)).To(Succeed())
+
+	// The second workload with the same suffix is refused, its resources would take over the ones of the first
+	err := reconcileDependencies(ctx, c, second)
+	g.Expect(err).To(MatchError(errSuffixConflict))
+	g.Expect(err).To(MatchError(ContainSubstring("suffix shared")))
+
+	secret := &corev1.Secret{}
+	g.Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: constants.SecretNameOauth2Proxy + "-shared"},
+		secret)).To(Succeed())
+	g.Expect(secret.OwnerReferences).To(HaveLen(1))
+	g.Expect(secret.OwnerReferences[0].UID).To(Equal(first.GetUID()))
+
+	// A distinct suffix resolves the conflict
+	second.SetAnnotations(map[string]string{constants.AnnotationSuffixKey: "copy"})
+	g.Expect(reconcileDependencies(ctx, c, second)).To(Succeed())
+}
+
+func TestVerifySuffixIsUniqueUpgradesPerPodResources(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	statefulSet := getTargetStatefulSet()
+	pods := []*corev1.Pod{getStatefulSetPod(0), getStatefulSetPod(1)}
+	pods[0].SetUID("suffix-upgrade-pod-0")
+	pods[1].SetUID("suffix-upgrade-pod-1")
+
+	t.Cleanup(func() {
+		forgetSuffix(statefulSet)
+		forgetOutcome(statefulSet)
+	})
+
+	c := newFakeClient(g, statefulSet, pods[0], pods[1])
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+
+	// The per-pod services and ingresses created by former releases are owned by the pods and carry no owner label
+	services := &corev1.ServiceList{}
+	g.Expect(c.List(ctx, services, client.InNamespace("default"))).To(Succeed())
+	g.Expect(services.Items).To(HaveLen(2))
+
+	ingresses := &networkingv1.IngressList{}
+	g.Expect(c.List(ctx, ingresses, client.InNamespace("default"))).To(Succeed())
+	g.Expect(ingresses.Items).To(HaveLen(2))
+
+	var existing []client.Object
+	for i := range services.Items {
+		existing = append(existing, &services.Items[i])
+	}
+
+	for i := range ingresses.Items {
+		existing = append(existing, &ingresses.Items[i])
+	}
+
+	for _, o := range existing {
+		labels := o.GetLabels()
+		delete(labels, constants.LabelOwnerKey)
+		o.SetLabels(labels)
+		g.Expect(c.Update(ctx, o)).To(Succeed())
+	}
+
+	forgetOutcome(statefulSet)
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+
+	// The resources of a recreated pod are adopted as well
+	g.Expect(c.Delete(ctx, pods[0])).To(Succeed())
+	recreated := getStatefulSetPod(0)
+	recreated.SetUID("suffix-upgrade-pod-0-recreated")
+	g.Expect(c.Create(ctx, recreated)).To(Succeed())
+
+	forgetOutcome(statefulSet)
+	g.Expect(reconcileDependencies(ctx, c, statefulSet)).To(Succeed())
+}
+
+func TestWarnOnSuffixConflict(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := record.NewFakeRecorder(10)
+	deployment := getTargetDeployment()
+
+	warnOnSuffixConflict(recorder, deployment, errors.New("another failure"))
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	warnOnSuffixConflict(recorder, deployment, errSuffixConflict)
+	g.Expect(recorder.Events).To(Receive(HavePrefix(corev1.EventTypeWarning + " " + reasonSuffixConflict)))
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func TestMigrateSuffix(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.TODO()
+
+	deployment := getTargetDeployment()
+	deployment.SetAnnotations(map[string]string{constants.AnnotationAppliedSuffixKey: "old"})
+
+	ownerRef := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "nginx", UID: deployment.GetUID()}
+	oidcLabels := map[string]string{constants.LabelKey: constants.LabelValue}
+	meta := func(name string, owned bool) metav1.ObjectMeta {
+		m := metav1.ObjectMeta{Name: name, Namespace: "default", Labels: oidcLabels}
+		if owned {
+			m.OwnerReferences = []metav1.OwnerReference{ownerRef}
+		}
+
+		return m
+	}
+
+	c := newFakeClient(g,
+		deployment,
+		&corev1.Secret{ObjectMeta: meta(constants.SecretNameOauth2Proxy+"-old", true)},
+		&corev1.Service{ObjectMeta: meta(constants.ServiceNameOauth2Service+"-old", true)},
+		&networkingv1.Ingress{ObjectMeta: meta(constants.IngressName+"-old", true)},
+		// A resource with the old suffix, which is not owned by the target
+		&corev1.Secret{ObjectMeta: meta("foreign-old", false)},
+	)
+
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+	g.Expect(reconcileDeploymentDependencies(ctx, c, deployment)).To(Succeed())
+	g.Expect(migrateSuffix(ctx, c, deployment)).To(Succeed())
+
+	suffix := rand.GenerateSha256("nginx-default")
+	key := func(name string) client.ObjectKey { return client.ObjectKey{Namespace: "default", Name: name} }
+
+	g.Expect(c.Get(ctx, key(constants.SecretNameOauth2Proxy+"-"+suffix), &corev1.Secret{})).To(Succeed())
+	g.Expect(c.Get(ctx, key(constants.ServiceNameOauth2Service+"-"+suffix), &corev1.Service{})).To(Succeed())
+	g.Expect(c.Get(ctx, key(constants.IngressName+"-"+suffix), &networkingv1.Ingress{})).To(Succeed())
+
+	g.Expect(c.Get(ctx, key(constants.SecretNameOauth2Proxy+"-old"), &corev1.Secret{})).NotTo(Succeed())
+	g.Expect(c.Get(ctx, key(constants.ServiceNameOauth2Service+"-old"), &corev1.Service{})).NotTo(Succeed())
+	g.Expect(c.Get(ctx, key(constants.IngressName+"-old"), &networkingv1.Ingress{})).NotTo(Succeed())
+	g.Expect(c.Get(ctx, key("foreign-old"), &corev1.Secret{})).To(Succeed())
+
+	updated := &appsv1.Deployment{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), updated)).To(Succeed())
+	g.Expect(updated.GetAnnotations()).To(HaveKeyWithValue(constants.AnnotationAppliedSuffixKey, suffix))
+
+	// A repeated migration with an unchanged suffix is a no-op
+	g.Expect(migrateSuffix(ctx, c, updated)).To(Succeed())
+}
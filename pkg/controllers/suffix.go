@@ -0,0 +1,210 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+// suffixCacheEntry holds a computed suffix together with the inputs it was derived from
+type suffixCacheEntry struct {
+	key    types.NamespacedName
+	inputs string
+	suffix string
+}
+
+// suffixCache keeps the name suffixes of the oidc-apps resources keyed by the target object UID, so that the suffix
+// stays stable across reconciles within the controller process lifetime
+type suffixCache struct {
+	mu      sync.RWMutex
+	entries map[types.UID]suffixCacheEntry
+}
+
+var suffixes = &suffixCache{entries: make(map[types.UID]suffixCacheEntry)}
+
+// getSuffix returns the name suffix of the resources owned by the given object. The suffix is taken from the
+// suffix annotation if present, otherwise it is derived from the object name and namespace.
+func getSuffix(object client.Object) string {
+	return suffixes.get(object)
+}
+
+// resourceName returns the name of a managed resource of the given object, <base>-<suffix>, with the ordinal of a
+// statefulset pod in between
+func resourceName(base string, object client.Object) string {
+	return constants.PodResourceName(base, podOrdinal(object), getSuffix(object))
+}
+
+// podOrdinal returns the ordinal of a statefulset pod, empty for other objects
+func podOrdinal(object client.Object) string {
+	i, err := strconv.ParseInt(fetchStrIndexIfPresent(object), 10, 32)
+	if err != nil {
+		return ""
+	}
+
+	return strconv.FormatInt(i, 10)
+}
+
+// forgetSuffix drops the cached suffix of an object, typically upon its deletion
+func forgetSuffix(object client.Object) {
+	suffixes.delete(object.GetUID())
+}
+
+func (s *suffixCache) get(object client.Object) string {
+	annotation := object.GetAnnotations()[constants.AnnotationSuffixKey]
+	inputs := object.GetName() + "-" + object.GetNamespace() + "/" + annotation
+
+	// Objects without an identity, i.e. not yet persisted, are not cached
+	uid := object.GetUID()
+	if uid == "" {
+		return computeSuffix(object.GetName(), object.GetNamespace(), annotation)
+	}
+
+	s.mu.RLock()
+	entry, ok := s.entries[uid]
+	s.mu.RUnlock()
+
+	if ok && entry.inputs == inputs {
+		return entry.suffix
+	}
+
+	suffix := computeSuffix(object.GetName(), object.GetNamespace(), annotation)
+
+	s.mu.Lock()
+	s.entries[uid] = suffixCacheEntry{key: client.ObjectKeyFromObject(object), inputs: inputs, suffix: suffix}
+	s.mu.Unlock()
+
+	return suffix
+}
+
+func (s *suffixCache) delete(uid types.UID) {
+	s.mu.Lock()
+	delete(s.entries, uid)
+	s.mu.Unlock()
+}
+
+// deleteKey drops the cached suffixes of the objects with the given name
+func (s *suffixCache) deleteKey(key types.NamespacedName) {
+	s.mu.Lock()
+	maps.DeleteFunc(s.entries, func(_ types.UID, e suffixCacheEntry) bool { return e.key == key })
+	s.mu.Unlock()
+}
+
+func computeSuffix(name, namespace, annotation string) string {
+	if annotation != "" {
+		return annotation
+	}
+
+	return rand.GenerateSha256(name + "-" + namespace)
+}
+
+// migrateSuffix removes the resources created with a previously applied suffix once the resources with the current
+// suffix are reconciled, and records the current suffix on the target. It shall be invoked after a successful
+// reconciliation of the target dependencies.
+func migrateSuffix(ctx context.Context, c client.Client, object client.Object) error {
+	suffix := getSuffix(object)
+	applied := object.GetAnnotations()[constants.AnnotationAppliedSuffixKey]
+
+	if applied == suffix {
+		return nil
+	}
+
+	if applied != "" {
+		log.FromContext(ctx).Info("Suffix is changed, removing the resources with the previous suffix",
+			"previous", applied, "current", suffix)
+
+		if err := deleteSuffixedResources(ctx, c, object, applied, deletionPropagation()); err != nil {
+			return err
+		}
+	}
+
+	patch := client.MergeFrom(object.DeepCopyObject().(client.Object))
+	annotations := object.GetAnnotations()
+
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+
+	annotations[constants.AnnotationAppliedSuffixKey] = suffix
+	object.SetAnnotations(annotations)
+
+	if err := c.Patch(ctx, object, patch); err != nil {
+		return fmt.Errorf("failed to record the applied suffix: %w", err)
+	}
+
+	return nil
+}
+
+// deleteSuffixedResources deletes the oidc-apps ingresses, services and secrets owned by the object and named with
+// the given suffix
+func deleteSuffixedResources(ctx context.Context, c client.Client, object client.Object, suffix string,
+	policy client.PropagationPolicy) error {
+	for _, list := range cleanupOrder() {
+		if err := c.List(ctx, list,
+			client.InNamespace(object.GetNamespace()),
+			client.MatchingLabels{constants.LabelKey: constants.LabelValue},
+		); err != nil {
+			return fmt.Errorf("failed to list resources: %w", err)
+		}
+
+		var items []client.Object
+
+		switch l := list.(type) {
+		case *corev1.SecretList:
+			for i := range l.Items {
+				items = append(items, &l.Items[i])
+			}
+		case *corev1.ServiceList:
+			for i := range l.Items {
+				items = append(items, &l.Items[i])
+			}
+		case *networkingv1.IngressList:
+			for i := range l.Items {
+				items = append(items, &l.Items[i])
+			}
+		case *networkingv1.NetworkPolicyList:
+			for i := range l.Items {
+				items = append(items, &l.Items[i])
+			}
+		}
+
+		for _, item := range items {
+			if !isAnOwnedResource(object, item) || !strings.HasSuffix(item.GetName(), "-"+suffix) {
+				continue
+			}
+
+			if err := c.Delete(ctx, item, policy); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete %s/%s: %w", item.GetNamespace(), item.GetName(), err)
+			}
+
+			log.FromContext(ctx).V(9).Info("Deleted", "name", item.GetName(), "namespace", item.GetNamespace())
+		}
+	}
+
+	return nil
+}
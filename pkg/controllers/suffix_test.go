@@ -0,0 +1,140 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
+)
+
+func TestSuffixCacheHit(t *testing.T) {
+	g := NewWithT(t)
+
+	cache := &suffixCache{entries: make(map[types.UID]suffixCacheEntry)}
+	deployment := getTargetDeployment()
+
+	suffix := cache.get(deployment)
+	g.Expect(suffix).To(Equal(rand.GenerateSha256("nginx-default")))
+	g.Expect(cache.entries).To(HaveKey(deployment.GetUID()))
+
+	// A cached entry is returned as long as the inputs are unchanged
+	cache.entries[deployment.GetUID()] = suffixCacheEntry{inputs: cache.entries[deployment.GetUID()].inputs, suffix: "cached"}
+	g.Expect(cache.get(deployment)).To(Equal("cached"))
+}
+
+func TestSuffixCacheInvalidation(t *testing.T) {
+	g := NewWithT(t)
+
+	cache := &suffixCache{entries: make(map[types.UID]suffixCacheEntry)}
+	deployment := getTargetDeployment()
+
+	g.Expect(cache.get(deployment)).To(Equal(rand.GenerateSha256("nginx-default")))
+
+	// Setting the suffix annotation invalidates the cached entry
+	deployment.SetAnnotations(map[string]string{constants.AnnotationSuffixKey: "custom"})
+	g.Expect(cache.get(deployment)).To(Equal("custom"))
+
+	// Removing the annotation falls back to the derived suffix
+	deployment.SetAnnotations(nil)
+	g.Expect(cache.get(deployment)).To(Equal(rand.GenerateSha256("nginx-default")))
+
+	cache.delete(deployment.GetUID())
+	g.Expect(cache.entries).To(BeEmpty())
+}
+
+func TestSuffixWithoutUIDIsNotCached(t *testing.T) {
+	g := NewWithT(t)
+
+	cache := &suffixCache{entries: make(map[types.UID]suffixCacheEntry)}
+	deployment := getTargetDeployment()
+	deployment.SetUID("")
+
+	g.Expect(cache.get(deployment)).To(Equal(rand.GenerateSha256("nginx-default")))
+	g.Expect(cache.entries).To(BeEmpty())
+}
+
+func TestResourceNamesAreConsistent(t *testing.T) {
+	g := NewWithT(t)
+
+	cfg := configuration.GetOIDCAppsControllerConfig()
+	cfg.Configuration.Oauth2Proxy.MetricsPort = 9090
+	cfg.Configuration.Oauth2Proxy.MetricsScrapers = []networkingv1.NetworkPolicyPeer{
+		{NamespaceSelector: &metav1.LabelSelector{}},
+	}
+
+	t.Cleanup(func() {
+		cfg.Configuration.Oauth2Proxy.MetricsPort = 0
+		cfg.Configuration.Oauth2Proxy.MetricsScrapers = nil
+	})
+
+	deployment := getTargetDeployment()
+	deployment.SetAnnotations(map[string]string{constants.AnnotationSuffixKey: "custom"})
+	t.Cleanup(func() { forgetSuffix(deployment) })
+
+	desired, err := desiredDeploymentResources(context.TODO(), newFakeClient(g, deployment), deployment)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	bases := []string{
+		constants.SecretNameOauth2Proxy,
+		constants.SecretNameResourceAttributes,
+		constants.SecretNameKubeconfig,
+		constants.SecretNameOidcCa,
+		constants.ServiceNameOauth2Service,
+		constants.IngressName,
+		constants.NetworkPolicyNameOauth2Metrics,
+	}
+
+	// Every resource type is named <base>-<suffix>
+	for _, d := range desired {
+		base, found := strings.CutSuffix(d.GetName(), "-custom")
+		g.Expect(found).To(BeTrue(), d.GetName())
+		g.Expect(bases).To(ContainElement(base), d.GetName())
+
+		if ingress, ok := d.(*networkingv1.Ingress); ok {
+			backend := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name
+			g.Expect(backend).To(Equal(resourceName(constants.ServiceNameOauth2Service, deployment)))
+		}
+	}
+
+	g.Expect(desired).To(ContainElements(
+		HaveField("ObjectMeta.Name", constants.SecretNameOauth2Proxy+"-custom"),
+		HaveField("ObjectMeta.Name", constants.ServiceNameOauth2Service+"-custom"),
+		HaveField("ObjectMeta.Name", constants.IngressName+"-custom"),
+		HaveField("ObjectMeta.Name", constants.NetworkPolicyNameOauth2Metrics+"-custom"),
+	))
+
+	// The resources of a statefulset pod carry its ordinal in between
+	pod := getStatefulSetPod(1)
+	suffix := getSuffix(pod)
+	g.Expect(resourceName(constants.IngressName, pod)).To(Equal(constants.IngressName + "-1-" + suffix))
+	g.Expect(resourceName(constants.ServiceNameOauth2Service, pod)).
+		To(Equal(constants.ServiceNameOauth2Service + "-1-" + suffix))
+
+	ingress, err := createIngressForPod(pod, getTargetStatefulSet())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ingress.GetName()).To(Equal(resourceName(constants.IngressName, pod)))
+	g.Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name).
+		To(Equal(resourceName(constants.ServiceNameOauth2Service, pod)))
+}
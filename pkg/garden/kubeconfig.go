@@ -0,0 +1,186 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package garden
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// ErrNotConfigured is returned when there is no garden access configured for the controller
+var ErrNotConfigured = errors.New("garden access is not configured")
+
+// DefaultClientTimeout is the deadline of the Cluster resource lookups, when GARDEN_CLIENT_TIMEOUT is not set
+const DefaultClientTimeout = 10 * time.Second
+
+// authInfoName is the name of the user used by the gardener extensions to access the garden cluster
+const authInfoName = "extension"
+
+// Enabled returns true when the controller runs with access to a garden cluster. The access is either provided through
+// a mounted kubeconfig (GARDEN_KUBECONFIG), or through a projected service account token and the garden API server
+// coordinates (GARDEN_SERVER_URL, GARDEN_CA_FILE).
+func Enabled() bool {
+	return os.Getenv(constants.GardenKubeconfig) != "" || os.Getenv(constants.GardenServerURL) != ""
+}
+
+// ClusterNamespace returns the namespace of the Cluster resources, empty when they are looked up cluster-wide
+func ClusterNamespace() string {
+	return os.Getenv(constants.GardenClusterNamespace)
+}
+
+// IsGardenNamespace returns true if the namespace contains seed workloads. The namespaces are configured by the
+// GARDEN_NAMESPACES variable, where an entry ending with * matches by prefix, and default to the garden namespace.
+func IsGardenNamespace(namespace string) bool {
+	entries := os.Getenv(constants.GardenNamespaces)
+	if strings.TrimSpace(entries) == "" {
+		return namespace == constants.GardenNamespace
+	}
+
+	for _, entry := range strings.Split(entries, ",") {
+		entry = strings.TrimSpace(entry)
+		if prefix, found := strings.CutSuffix(entry, "*"); found {
+			if strings.HasPrefix(namespace, prefix) {
+				return true
+			}
+
+			continue
+		}
+
+		if entry != "" && entry == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientTimeout returns the deadline of the Cluster resource lookups, which would otherwise block the reconciliation
+// when the API server is unreachable. Unset or invalid values default to DefaultClientTimeout.
+func ClientTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(constants.GardenClientTimeout)); err == nil && d > 0 {
+		return d
+	}
+
+	return DefaultClientTimeout
+}
+
+// KubeconfigPath returns the path of the mounted garden kubeconfig
+func KubeconfigPath() string {
+	return filepath.Join(filepath.Dir(os.Getenv(constants.GardenKubeconfig)), "kubeconfig")
+}
+
+// TokenPath returns the path of the garden access token. It is fetched from the GARDEN_ACCESS_TOKEN directory
+// if present, or from the GARDEN_KUBECONFIG directory
+func TokenPath() string {
+	if p := os.Getenv(constants.GardenAccessToken); p != "" {
+		return filepath.Join(p, "token")
+	}
+
+	return filepath.Join(filepath.Dir(os.Getenv(constants.GardenKubeconfig)), "token")
+}
+
+// HasAccess verifies that the files needed to construct the garden kubeconfig are present
+func HasAccess() bool {
+	if !Enabled() {
+		return false
+	}
+
+	if _, err := os.Stat(TokenPath()); err != nil {
+		return false
+	}
+
+	if os.Getenv(constants.GardenServerURL) != "" {
+		return true
+	}
+
+	_, err := os.Stat(KubeconfigPath())
+
+	return err == nil
+}
+
+// NewKubeconfig returns the garden kubeconfig with the current access token embedded in the extension user
+func NewKubeconfig() (*clientcmdv1.Config, error) {
+	if !Enabled() {
+		return nil, ErrNotConfigured
+	}
+
+	token, err := os.ReadFile(filepath.Clean(TokenPath()))
+	if err != nil {
+		return nil, fmt.Errorf("error reading garden access token: %w", err)
+	}
+
+	token = bytes.TrimSpace(token)
+
+	if server := os.Getenv(constants.GardenServerURL); server != "" {
+		return newServiceAccountKubeconfig(server, string(token))
+	}
+
+	kcfg, err := os.ReadFile(filepath.Clean(KubeconfigPath()))
+	if err != nil {
+		return nil, fmt.Errorf("error reading garden kubeconfig: %w", err)
+	}
+
+	kubeConfig := &clientcmdv1.Config{}
+	if err = yaml.Unmarshal(bytes.TrimSpace(kcfg), kubeConfig); err != nil {
+		return nil, fmt.Errorf("error unmarshalling garden kubeconfig: %w", err)
+	}
+
+	for i, n := range kubeConfig.AuthInfos {
+		if n.Name != authInfoName {
+			continue
+		}
+
+		kubeConfig.AuthInfos[i].AuthInfo.TokenFile = ""
+		kubeConfig.AuthInfos[i].AuthInfo.Token = string(token)
+	}
+
+	return kubeConfig, nil
+}
+
+func newServiceAccountKubeconfig(server, token string) (*clientcmdv1.Config, error) {
+	cluster := clientcmdv1.Cluster{Server: server}
+
+	if caFile := os.Getenv(constants.GardenCAFile); caFile != "" {
+		ca, err := os.ReadFile(filepath.Clean(caFile))
+		if err != nil {
+			return nil, fmt.Errorf("error reading garden CA bundle: %w", err)
+		}
+
+		cluster.CertificateAuthorityData = ca
+	}
+
+	return &clientcmdv1.Config{
+		APIVersion:     "v1",
+		Kind:           "Config",
+		CurrentContext: "garden",
+		Clusters:       []clientcmdv1.NamedCluster{{Name: "garden", Cluster: cluster}},
+		AuthInfos: []clientcmdv1.NamedAuthInfo{
+			{Name: authInfoName, AuthInfo: clientcmdv1.AuthInfo{Token: token}},
+		},
+		Contexts: []clientcmdv1.NamedContext{
+			{Name: "garden", Context: clientcmdv1.Context{Cluster: "garden", AuthInfo: authInfoName}},
+		},
+	}, nil
+}
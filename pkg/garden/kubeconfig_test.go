@@ -0,0 +1,158 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package garden
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+const gardenKubeconfig = `apiVersion: v1
+kind: Config
+current-context: garden
+clusters:
+- name: garden
+  cluster:
+    server: https://api.garden.local
+contexts:
+- name: garden
+  context:
+    cluster: garden
+    user: extension
+users:
+- name: extension
+  user:
+    tokenFile: /var/run/secrets/gardener.cloud/shoot/generic-kubeconfig/token
+`
+
+func TestNewKubeconfigNotConfigured(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv(constants.GardenKubeconfig, "")
+	t.Setenv(constants.GardenServerURL, "")
+
+	g.Expect(Enabled()).To(BeFalse())
+	g.Expect(HasAccess()).To(BeFalse())
+
+	_, err := NewKubeconfig()
+	g.Expect(err).To(MatchError(ErrNotConfigured))
+}
+
+func TestNewKubeconfigFromKubeconfigFile(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	g.Expect(os.WriteFile(filepath.Join(dir, "kubeconfig"), []byte(gardenKubeconfig), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "token"), []byte("garden-token\n"), 0o600)).To(Succeed())
+
+	t.Setenv(constants.GardenKubeconfig, filepath.Join(dir, "kubeconfig"))
+	t.Setenv(constants.GardenServerURL, "")
+	t.Setenv(constants.GardenAccessToken, "")
+
+	g.Expect(Enabled()).To(BeTrue())
+	g.Expect(HasAccess()).To(BeTrue())
+
+	kubeConfig, err := NewKubeconfig()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kubeConfig.Clusters).To(HaveLen(1))
+	g.Expect(kubeConfig.Clusters[0].Cluster.Server).To(Equal("https://api.garden.local"))
+	g.Expect(kubeConfig.AuthInfos).To(HaveLen(1))
+	g.Expect(kubeConfig.AuthInfos[0].AuthInfo.TokenFile).To(BeEmpty())
+	g.Expect(kubeConfig.AuthInfos[0].AuthInfo.Token).To(Equal("garden-token"))
+}
+
+func TestNewKubeconfigFromServiceAccount(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	g.Expect(os.WriteFile(filepath.Join(dir, "token"), []byte("sa-token"), 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("ca-bundle"), 0o600)).To(Succeed())
+
+	t.Setenv(constants.GardenKubeconfig, "")
+	t.Setenv(constants.GardenServerURL, "https://api.garden.local")
+	t.Setenv(constants.GardenAccessToken, dir)
+	t.Setenv(constants.GardenCAFile, filepath.Join(dir, "ca.crt"))
+
+	g.Expect(Enabled()).To(BeTrue())
+	g.Expect(HasAccess()).To(BeTrue())
+
+	kubeConfig, err := NewKubeconfig()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(kubeConfig.CurrentContext).To(Equal("garden"))
+	g.Expect(kubeConfig.Clusters).To(HaveLen(1))
+	g.Expect(kubeConfig.Clusters[0].Cluster.Server).To(Equal("https://api.garden.local"))
+	g.Expect(kubeConfig.Clusters[0].Cluster.CertificateAuthorityData).To(Equal([]byte("ca-bundle")))
+	g.Expect(kubeConfig.AuthInfos).To(HaveLen(1))
+	g.Expect(kubeConfig.AuthInfos[0].AuthInfo.Token).To(Equal("sa-token"))
+	g.Expect(kubeConfig.Contexts[0].Context.AuthInfo).To(Equal(authInfoName))
+}
+
+func TestNewKubeconfigFromServiceAccountMissingToken(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv(constants.GardenKubeconfig, "")
+	t.Setenv(constants.GardenServerURL, "https://api.garden.local")
+	t.Setenv(constants.GardenAccessToken, t.TempDir())
+
+	g.Expect(HasAccess()).To(BeFalse())
+
+	_, err := NewKubeconfig()
+	g.Expect(err).To(MatchError(os.ErrNotExist))
+}
+
+func TestClientTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Setenv(constants.GardenClientTimeout, "")
+	g.Expect(ClientTimeout()).To(Equal(DefaultClientTimeout))
+
+	t.Setenv(constants.GardenClientTimeout, "invalid")
+	g.Expect(ClientTimeout()).To(Equal(DefaultClientTimeout))
+
+	t.Setenv(constants.GardenClientTimeout, "-1s")
+	g.Expect(ClientTimeout()).To(Equal(DefaultClientTimeout))
+
+	t.Setenv(constants.GardenClientTimeout, "3s")
+	g.Expect(ClientTimeout()).To(Equal(3 * time.Second))
+}
+
+func TestIsGardenNamespace(t *testing.T) {
+	g := NewWithT(t)
+
+	// Only the garden namespace by default
+	t.Setenv(constants.GardenNamespaces, "")
+	g.Expect(IsGardenNamespace("garden")).To(BeTrue())
+	g.Expect(IsGardenNamespace("garden-runtime")).To(BeFalse())
+	g.Expect(IsGardenNamespace("shoot--foo--bar")).To(BeFalse())
+
+	t.Setenv(constants.GardenNamespaces, "garden, garden-*,extension-*")
+	g.Expect(IsGardenNamespace("garden")).To(BeTrue())
+	g.Expect(IsGardenNamespace("garden-runtime")).To(BeTrue())
+	g.Expect(IsGardenNamespace("extension-oidc-apps")).To(BeTrue())
+	g.Expect(IsGardenNamespace("gardener")).To(BeFalse())
+	g.Expect(IsGardenNamespace("shoot--foo--bar")).To(BeFalse())
+	g.Expect(IsGardenNamespace("")).To(BeFalse())
+
+	// The default garden namespace is replaced by the configured ones
+	t.Setenv(constants.GardenNamespaces, "seed-system")
+	g.Expect(IsGardenNamespace("seed-system")).To(BeTrue())
+	g.Expect(IsGardenNamespace("garden")).To(BeFalse())
+}
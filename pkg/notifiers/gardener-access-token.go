@@ -17,18 +17,17 @@ package notifiers
 import (
 	"bytes"
 	"context"
-	"os"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/yaml"
 
 	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/garden"
 )
 
 var _ manager.Runnable = &gardenerAccessTokenNotifier{}
@@ -108,41 +107,18 @@ func (g *gardenerAccessTokenNotifier) startCalculateHashPath(ctx context.Context
 }
 
 func (g *gardenerAccessTokenNotifier) updateSecrets(ctx context.Context) {
-	tokenBytes, err := os.ReadFile(g.tokenPath)
+	kubeConfig, err := garden.NewKubeconfig()
 	if err != nil {
-		_log.Error(err, "error reading access token")
+		_log.Error(err, "error creating garden kubeconfig")
 
 		return
 	}
 
-	tokenBytes = bytes.TrimSpace(tokenBytes)
-	kubeConfigBytes, err := os.ReadFile(g.kubeconfigPath)
-
-	if err != nil {
-		_log.Error(err, "error reading kubeconfig")
-
-		return
-	}
-
-	kubeConfigBytes = bytes.TrimSpace(kubeConfigBytes)
-	kubeConfig := clientcmdv1.Config{}
-
-	if err = yaml.Unmarshal(kubeConfigBytes, &kubeConfig); err != nil {
-		_log.Error(err, "error unmarshaling kubeconfig")
-	}
-
-	for i, n := range kubeConfig.AuthInfos {
-		if n.Name != "extension" {
-			continue
-		}
-
-		kubeConfig.AuthInfos[i].AuthInfo.TokenFile = ""
-		kubeConfig.AuthInfos[i].AuthInfo.Token = string(tokenBytes)
-	}
-
 	kubeconfigBytes, err := yaml.Marshal(kubeConfig)
 	if err != nil {
 		_log.Error(err, "error marshaling kubeconfig")
+
+		return
 	}
 
 	kubeConfigList := &corev1.SecretList{}
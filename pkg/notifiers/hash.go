@@ -23,6 +23,10 @@ import (
 )
 
 func getFileSha256(filePath string) string {
+	if filePath == "" {
+		return ""
+	}
+
 	stat, err := os.Stat(filePath)
 	if err != nil {
 		_log.Error(err, "cannot stat file path", "path", filePath)
@@ -19,7 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -29,24 +29,29 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	autoscalerv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/scale/scheme/autoscalingv1"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/ptr"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -57,6 +62,7 @@ import (
 	"github.com/gardener/oidc-apps-controller/pkg/configuration"
 	"github.com/gardener/oidc-apps-controller/pkg/constants"
 	"github.com/gardener/oidc-apps-controller/pkg/controllers"
+	"github.com/gardener/oidc-apps-controller/pkg/garden"
 	"github.com/gardener/oidc-apps-controller/pkg/notifiers"
 	oidcappswebhook "github.com/gardener/oidc-apps-controller/pkg/webhook"
 )
@@ -113,13 +119,16 @@ func RunController(ctx context.Context, o *Options) error {
 			&networkingv1.Ingress{}: {
 				Label: labels.SelectorFromSet(labels.Set{constants.LabelKey: constants.LabelValue}),
 			},
+			&networkingv1.NetworkPolicy{}: {
+				Label: labels.SelectorFromSet(labels.Set{constants.LabelKey: constants.LabelValue}),
+			},
 			&autoscalerv1.VerticalPodAutoscaler{}: {
 				Label: oidcAppsSelector,
 			},
 		}}
 
 	// Add additional scheme in case of running in gardener cluster
-	if len(os.Getenv(constants.GardenKubeconfig)) > 0 {
+	if garden.Enabled() {
 		// Add gardener Cluster schemes
 		if err := gardenextensionsv1alpha1.AddToScheme(sch); err != nil {
 			return fmt.Errorf("could not initialize the runtime scheme: %w", err)
@@ -127,6 +136,11 @@ func RunController(ctx context.Context, o *Options) error {
 
 		cluster := &gardenextensionsv1alpha1.Cluster{}
 		cacheOptions.ByObject[cluster] = cache.ByObject{}
+
+		// Scope the Cluster cache, so that the controller needs access to the Cluster namespace only
+		if namespace := garden.ClusterNamespace(); namespace != "" {
+			cacheOptions.ByObject[cluster] = cache.ByObject{Namespaces: map[string]cache.Config{namespace: {}}}
+		}
 	}
 
 	// NAMESPACE is a required environment variable for the oidc-apps-controller certificate manager
@@ -138,6 +152,16 @@ func RunController(ctx context.Context, o *Options) error {
 		return errors.New("NAMESPACE environment variable is not set")
 	}
 
+	if err := o.shard().Validate(); err != nil {
+		return err
+	}
+
+	// Each shard elects its own leader, so that the instances of all the shards are active
+	leaderElectionID := "oidc-apps-controller"
+	if o.shardCount > 1 {
+		leaderElectionID = fmt.Sprintf("%s-shard-%d", leaderElectionID, o.shardIndex)
+	}
+
 	cfg := config.GetConfigOrDie()
 	cfg.QPS = float32(100)
 	cfg.Burst = 200
@@ -147,7 +171,7 @@ func RunController(ctx context.Context, o *Options) error {
 			Cache:                         cacheOptions,
 			Scheme:                        sch,
 			LeaderElection:                true,
-			LeaderElectionID:              "oidc-apps-controller",
+			LeaderElectionID:              leaderElectionID,
 			LeaderElectionNamespace:       os.Getenv(constants.NAMESPACE),
 			LeaseDuration:                 ptr.To(15 * time.Second),
 			RenewDeadline:                 ptr.To(10 * time.Second),
@@ -162,7 +186,7 @@ func RunController(ctx context.Context, o *Options) error {
 	}
 
 	// Set domain name if we are running in a gardener cluster
-	if len(os.Getenv(constants.GardenKubeconfig)) > 0 && os.Getenv(constants.GardenSeedDomainName) == "" {
+	if garden.Enabled() && os.Getenv(constants.GardenSeedDomainName) == "" {
 		if err := setGardenDomainNameEnvVar(ctx, mgr.GetConfig()); err != nil {
 			return fmt.Errorf("could not set the garden domain name: %w", err)
 		}
@@ -178,14 +202,18 @@ func RunController(ctx context.Context, o *Options) error {
 		return fmt.Errorf("could not initialize cache indices: %w", err)
 	}
 
-	if err := addDeploymentController(mgr); err != nil {
+	if err := addDeploymentController(mgr, o); err != nil {
 		return fmt.Errorf("could not initialize deployment controller: %w", err)
 	}
 
-	if err := addStatefulSetController(mgr); err != nil {
+	if err := addStatefulSetController(mgr, o); err != nil {
 		return fmt.Errorf("could not initialize statefulset controller: %w", err)
 	}
 
+	if err := addRolloutController(mgr, o); err != nil {
+		return fmt.Errorf("could not initialize rollout controller: %w", err)
+	}
+
 	if err := addWebhookCertificateManager(mgr, o); err != nil {
 		return fmt.Errorf("could not initialize webhook certificate manager: %w", err)
 	}
@@ -202,10 +230,27 @@ func RunController(ctx context.Context, o *Options) error {
 		return fmt.Errorf("could not initialize mutating webhooks: %w", err)
 	}
 
+	if o.enableDebugEndpoint {
+		if err := mgr.AddMetricsServerExtraHandler(constants.DebugOauth2ConfigPath,
+			&controllers.Oauth2ConfigHandler{Client: mgr.GetClient()}); err != nil {
+			return fmt.Errorf("could not initialize the debug endpoint: %w", err)
+		}
+	}
+
+	if err := metrics.Registry.Register(controllers.NewSecretAgeCollector(mgr.GetClient())); err != nil {
+		return fmt.Errorf("could not register the secret age metric: %w", err)
+	}
+
 	if err := mgr.AddReadyzCheck("informer-sync", gardenerhealthz.NewCacheSyncHealthz(mgr.GetCache())); err != nil {
 		return fmt.Errorf("could not initialize controller readycheck: %w", err)
 	}
 
+	if o.verifyOidcIssuer {
+		if err := mgr.AddReadyzCheck("oidc-issuer", (&controllers.OidcIssuerCheck{}).Check); err != nil {
+			return fmt.Errorf("could not initialize the oidc issuer readycheck: %w", err)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
 		return fmt.Errorf("could not initialize controller healthcheck: %w", err)
 	}
@@ -253,6 +298,8 @@ func fetchPredicates(extensionConfig *configuration.OIDCAppsControllerConfig) pr
 			predicates = predicate.GenerationChangedPredicate{
 
 				TypedFuncs: predicate.Funcs{
+					// The initial list of the informers emits the create events of the existing workloads, hence the
+					// targets and the labelled former targets are reconciled on startup and converge on upgrade
 					CreateFunc: func(e event.CreateEvent) bool {
 						if extensionConfig.Match(e.Object) {
 							_log.V(9).Info("create event", "name", e.Object.GetName(), "namespace", e.Object.GetNamespace())
@@ -304,6 +351,13 @@ func fetchPredicates(extensionConfig *configuration.OIDCAppsControllerConfig) pr
 	return predicates
 }
 
+// workloadPredicates passes the events of the targets, the label removal of the former targets, whose proxies are
+// then removed, and the changes of the user secrets referenced by the targets
+func workloadPredicates(extensionConfig *configuration.OIDCAppsControllerConfig) predicate.Predicate {
+	return predicate.Or[client.Object](fetchPredicates(extensionConfig), controllers.LabelRemovalPredicate(),
+		controllers.ReferencedSecretPredicate())
+}
+
 func initializeManagerIndices(mgr manager.Manager) error {
 	if err := mgr.GetFieldIndexer().IndexField(
 		context.Background(),
@@ -372,11 +426,12 @@ func initializeManagerIndices(mgr manager.Manager) error {
 	return nil
 }
 
-func addDeploymentController(mgr manager.Manager) error {
+func addDeploymentController(mgr manager.Manager, o *Options) error {
 	return controllerruntime.NewControllerManagedBy(mgr).
 		Named("oidc-apps-deployments").
 		For(&appsv1.Deployment{}).
-		WithEventFilter(fetchPredicates(extensionConfig)).
+		WithOptions(controller.Options{RateLimiter: workloadRateLimiter(o)}).
+		WithEventFilter(workloadPredicates(extensionConfig)).
 		Watches(
 			&corev1.Secret{},
 			handler.EnqueueRequestForOwner(
@@ -401,20 +456,51 @@ func addDeploymentController(mgr manager.Manager) error {
 				&appsv1.Deployment{},
 			),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(
+			&networkingv1.NetworkPolicy{},
+			handler.EnqueueRequestForOwner(
+				mgr.GetScheme(),
+				mgr.GetRESTMapper(),
+				&appsv1.Deployment{},
+			),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
 		Watches(
 			&corev1.Pod{},
 			handler.EnqueueRequestsFromMapFunc(PodMapFuncForDeployment(mgr)),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
-		Complete(&controllers.DeploymentReconciler{Client: mgr.GetClient()})
+		Watches(
+			&corev1.Service{},
+			handler.EnqueueRequestsFromMapFunc(PodOwnedMapFuncForDeployment(mgr)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(
+			&networkingv1.Ingress{},
+			handler.EnqueueRequestsFromMapFunc(PodOwnedMapFuncForDeployment(mgr)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(
+			&corev1.Secret{},
+			controllers.EnqueueWorkloadsReferencingSecret(mgr.GetClient(),
+				func() client.ObjectList { return &appsv1.DeploymentList{} })).
+		Complete(&controllers.DeploymentReconciler{
+			Client:           mgr.GetClient(),
+			Recorder:         mgr.GetEventRecorderFor("oidc-apps-deployments"),
+			RetryBudget:      o.retryBudget,
+			Shard:            o.shard(),
+			VerifyIngressDNS: o.verifyIngressDNS,
+		})
 }
 
-func addStatefulSetController(mgr manager.Manager) error {
+func addStatefulSetController(mgr manager.Manager, o *Options) error {
 	return controllerruntime.NewControllerManagedBy(mgr).
 		Named("oidc-apps-statefulsets").
 		For(&appsv1.StatefulSet{}).
-		WithEventFilter(fetchPredicates(extensionConfig)).
+		WithOptions(controller.Options{RateLimiter: workloadRateLimiter(o)}).
+		WithEventFilter(workloadPredicates(extensionConfig)).
 		Watches(
 			&corev1.Pod{},
+			controllers.EnqueueStatefulSetForPod(controllers.StatefulSetPodEventsDebounce),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(
+			&corev1.Secret{},
 			handler.EnqueueRequestForOwner(
 				mgr.GetScheme(),
 				mgr.GetRESTMapper(),
@@ -422,22 +508,105 @@ func addStatefulSetController(mgr manager.Manager) error {
 			),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
 		Watches(
-			&corev1.Secret{},
+			&corev1.Service{},
+			handler.EnqueueRequestsFromMapFunc(ServiceMapFuncForStatefulset(mgr)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(
+			&networkingv1.Ingress{},
+			handler.EnqueueRequestsFromMapFunc(IngressMapFuncForStatefulset(mgr)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(
+			&networkingv1.NetworkPolicy{},
 			handler.EnqueueRequestForOwner(
 				mgr.GetScheme(),
 				mgr.GetRESTMapper(),
 				&appsv1.StatefulSet{},
 			),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(
+			&corev1.Secret{},
+			controllers.EnqueueWorkloadsReferencingSecret(mgr.GetClient(),
+				func() client.ObjectList { return &appsv1.StatefulSetList{} })).
+		Complete(&controllers.StatefulSetReconciler{
+			Client:           mgr.GetClient(),
+			Recorder:         mgr.GetEventRecorderFor("oidc-apps-statefulsets"),
+			RetryBudget:      o.retryBudget,
+			Shard:            o.shard(),
+			VerifyIngressDNS: o.verifyIngressDNS,
+		})
+}
+
+// addRolloutController adds the controller of the Argo Rollouts targets. It is added only when the Rollout CRD is
+// installed in the cluster.
+func addRolloutController(mgr manager.Manager, o *Options) error {
+	gvk := controllers.RolloutGroupVersionKind
+	if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			_log.Info("Rollout CRD is not installed, skipping the rollout controller", "gvk", gvk.String())
+
+			return nil
+		}
+
+		return fmt.Errorf("could not detect the rollout CRD: %w", err)
+	}
+
+	return controllerruntime.NewControllerManagedBy(mgr).
+		Named("oidc-apps-rollouts").
+		For(controllers.NewRollout()).
+		WithOptions(controller.Options{RateLimiter: workloadRateLimiter(o)}).
+		WithEventFilter(workloadPredicates(extensionConfig)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestForOwner(
+				mgr.GetScheme(),
+				mgr.GetRESTMapper(),
+				controllers.NewRollout(),
+			),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
 		Watches(
 			&corev1.Service{},
-			handler.EnqueueRequestsFromMapFunc(ServiceMapFuncForStatefulset(mgr)),
+			handler.EnqueueRequestForOwner(
+				mgr.GetScheme(),
+				mgr.GetRESTMapper(),
+				controllers.NewRollout(),
+			),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
 		Watches(
 			&networkingv1.Ingress{},
-			handler.EnqueueRequestsFromMapFunc(IngressMapFuncForStatefulset(mgr)),
+			handler.EnqueueRequestForOwner(
+				mgr.GetScheme(),
+				mgr.GetRESTMapper(),
+				controllers.NewRollout(),
+			),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
-		Complete(&controllers.StatefulSetReconciler{Client: mgr.GetClient()})
+		Watches(
+			&networkingv1.NetworkPolicy{},
+			handler.EnqueueRequestForOwner(
+				mgr.GetScheme(),
+				mgr.GetRESTMapper(),
+				controllers.NewRollout(),
+			),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(
+			&corev1.Pod{},
+			handler.EnqueueRequestsFromMapFunc(PodMapFuncForRollout(mgr)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{})).
+		Watches(
+			&corev1.Secret{},
+			controllers.EnqueueWorkloadsReferencingSecret(mgr.GetClient(),
+				func() client.ObjectList { return controllers.NewRolloutList() })).
+		Complete(&controllers.RolloutReconciler{
+			Client:           mgr.GetClient(),
+			Recorder:         mgr.GetEventRecorderFor("oidc-apps-rollouts"),
+			RetryBudget:      o.retryBudget,
+			Shard:            o.shard(),
+			VerifyIngressDNS: o.verifyIngressDNS,
+		})
+}
+
+// workloadRateLimiter returns the exponential backoff of the requeued workload reconcile requests
+func workloadRateLimiter(o *Options) workqueue.TypedRateLimiter[reconcile.Request] {
+	return workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](5*time.Millisecond, o.requeueMaxDelay)
 }
 
 // Add certificate manager in case no external certificate manager is available
@@ -456,18 +625,17 @@ func addWebhookCertificateManager(mgr manager.Manager, o *Options) error {
 
 func addGardenAccessTokenNotifier(mgr manager.Manager) error {
 	// Add garden-secret-notifier if the GARDEN environment variables are present
-	if os.Getenv(constants.GardenKubeconfig) != "" || os.Getenv(constants.GardenAccessToken) != "" {
-		kubeconfigPath := filepath.Dir(os.Getenv(constants.GardenKubeconfig))
-
-		tokenPath := os.Getenv(constants.GardenAccessToken)
-		if tokenPath == "" {
-			tokenPath = filepath.Dir(os.Getenv(constants.GardenKubeconfig))
+	if garden.Enabled() {
+		// In the service account mode there is no mounted kubeconfig, the CA bundle is watched instead
+		kubeconfigPath := os.Getenv(constants.GardenCAFile)
+		if os.Getenv(constants.GardenServerURL) == "" {
+			kubeconfigPath = garden.KubeconfigPath()
 		}
 
 		accessTokenNotifier := notifiers.NewGardenerAccessTokenNotifier(
 			mgr.GetClient(),
-			filepath.Join(kubeconfigPath, "kubeconfig"),
-			filepath.Join(tokenPath, "token"),
+			kubeconfigPath,
+			garden.TokenPath(),
 		)
 
 		return mgr.Add(accessTokenNotifier)
@@ -521,6 +689,11 @@ func addPrivateRegistrySecretControllers(mgr manager.Manager, o *Options) error
 }
 
 func addWebhooks(mgr manager.Manager, o *Options) error {
+	nativeSidecarsSupported, err := detectNativeSidecarsSupport(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+
 	// Add Mutating Admission Webhook Server
 	webhookServer := webhook.NewServer(webhook.Options{
 		Port:    o.webhookPort,
@@ -530,9 +703,10 @@ func addWebhooks(mgr manager.Manager, o *Options) error {
 	webhookServer.Register(
 		constants.PodWebHookPath,
 		&webhook.Admission{Handler: &oidcappswebhook.PodMutator{
-			Client:          mgr.GetClient(),
-			Decoder:         admission.NewDecoder(scheme.Scheme),
-			ImagePullSecret: o.registrySecret,
+			Client:                  mgr.GetClient(),
+			Decoder:                 admission.NewDecoder(scheme.Scheme),
+			ImagePullSecret:         o.registrySecret,
+			NativeSidecarsSupported: nativeSidecarsSupported,
 		}},
 	)
 
@@ -549,13 +723,36 @@ func addWebhooks(mgr manager.Manager, o *Options) error {
 		}},
 	)
 
+	webhookServer.Register(
+		constants.WorkloadWebHookPath,
+		&webhook.Admission{Handler: &oidcappswebhook.WorkloadValidator{}},
+	)
+
 	// Add the server to the manager
 	return mgr.Add(webhookServer)
 }
 
+// detectNativeSidecarsSupport returns true if the kube-apiserver version supports native sidecar containers
+func detectNativeSidecarsSupport(cfg *rest.Config) (bool, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return false, fmt.Errorf("could not initialize the discovery client: %w", err)
+	}
+
+	info, err := dc.ServerVersion()
+	if err != nil {
+		return false, fmt.Errorf("could not fetch the kube-apiserver version: %w", err)
+	}
+
+	supported := oidcappswebhook.NativeSidecarsSupported(info)
+	_log.Info("Detected kube-apiserver version", "version", info.GitVersion, "nativeSidecars", supported)
+
+	return supported, nil
+}
+
 // IsOidcAppsPod returns true if the pod is an oidc-apps enabled pod
 func IsOidcAppsPod(pod *corev1.Pod) bool {
-	for _, c := range pod.Spec.Containers {
+	for _, c := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
 		if c.Name == constants.ContainerNameOauth2Proxy || c.Name == constants.ContainerNameKubeRbacProxy {
 			_log.V(9).Info("oidc-apps enabled pod", "pod", pod.Name)
 
@@ -613,6 +810,52 @@ func PodMapFuncForDeployment(mgr manager.Manager) func(ctx context.Context, obj
 	}
 }
 
+// PodMapFuncForRollout returns a map function that returns reconcile requests for a target rollout triggered on
+// changes of a pod owned by a replicaset of the rollout
+func PodMapFuncForRollout(mgr manager.Manager) func(ctx context.Context, obj client.Object) []reconcile.Request {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			_log.Error(fmt.Errorf("object is not a pod"), "object", obj)
+
+			return nil
+		}
+
+		if !IsOidcAppsPod(pod) {
+			return nil
+		}
+
+		c := mgr.GetClient()
+
+		for _, r := range pod.GetOwnerReferences() {
+			if r.Kind != "ReplicaSet" {
+				continue
+			}
+
+			rs := &appsv1.ReplicaSet{}
+			if err := c.Get(ctx, types.NamespacedName{Name: r.Name, Namespace: pod.Namespace}, rs); err != nil {
+				if client.IgnoreNotFound(err) != nil {
+					_log.Error(err, "could not get replicaset", "name", r.Name, "namespace", pod.Namespace)
+				}
+
+				return nil
+			}
+
+			for _, d := range rs.GetOwnerReferences() {
+				if d.Kind != constants.RolloutKind {
+					continue
+				}
+
+				_log.V(9).Info("enqueue rollout", "name", d.Name, "namespace", rs.Namespace)
+
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: d.Name, Namespace: rs.Namespace}}}
+			}
+		}
+
+		return nil
+	}
+}
+
 // IngressMapFuncForStatefulset returns a map function that returns reconcile requests for a target statefulset triggered
 // on changes of an ingress owned by a pod owned by the statefulset
 func IngressMapFuncForStatefulset(mgr manager.Manager) func(ctx context.Context, obj client.Object) []reconcile.Request {
@@ -661,6 +904,34 @@ func IngressMapFuncForStatefulset(mgr manager.Manager) func(ctx context.Context,
 	}
 }
 
+// PodOwnedMapFuncForDeployment maps the services and ingresses of the deployment pods exposed per pod to their
+// deployment
+func PodOwnedMapFuncForDeployment(mgr manager.Manager) func(ctx context.Context, obj client.Object) []reconcile.Request {
+	podMapFunc := PodMapFuncForDeployment(mgr)
+
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		for _, o := range obj.GetOwnerReferences() {
+			if o.Kind != "Pod" {
+				continue
+			}
+
+			pod := &corev1.Pod{}
+			if err := mgr.GetClient().Get(ctx, types.NamespacedName{Name: o.Name, Namespace: obj.GetNamespace()},
+				pod); err != nil {
+				if client.IgnoreNotFound(err) != nil {
+					_log.Error(err, "could not get pod", "name", o.Name, "namespace", obj.GetNamespace())
+				}
+
+				continue
+			}
+
+			return podMapFunc(ctx, pod)
+		}
+
+		return nil
+	}
+}
+
 // ServiceMapFuncForStatefulset returns a map function that returns reconcile requests for a target statefulset triggered
 // on changes of a service owned by a pod owned by the statefulset
 func ServiceMapFuncForStatefulset(mgr manager.Manager) func(ctx context.Context, obj client.Object) []reconcile.Request {
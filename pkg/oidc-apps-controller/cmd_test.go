@@ -0,0 +1,53 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidcappscontroller
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/gardener/oidc-apps-controller/pkg/configuration"
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+func TestFetchPredicatesCreate(t *testing.T) {
+	g := NewWithT(t)
+
+	extensionConfig := &configuration.OIDCAppsControllerConfig{
+		Targets: []configuration.Target{{
+			Name:          "nginx",
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}},
+		}},
+	}
+	p := fetchPredicates(extensionConfig)
+
+	newDeployment := func(labels map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default", Labels: labels}}
+	}
+
+	// The existing targets are reconciled on startup
+	g.Expect(p.Create(event.CreateEvent{Object: newDeployment(map[string]string{"app": "nginx"})})).To(BeTrue())
+
+	// The labelled former targets are reconciled on startup, removing their proxies
+	g.Expect(p.Create(event.CreateEvent{
+		Object: newDeployment(map[string]string{"app": "other", constants.LabelKey: "nginx"}),
+	})).To(BeTrue())
+
+	g.Expect(p.Create(event.CreateEvent{Object: newDeployment(map[string]string{"app": "other"})})).To(BeFalse())
+}
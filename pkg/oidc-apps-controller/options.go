@@ -14,7 +14,14 @@
 
 package oidcappscontroller
 
-import "github.com/spf13/pflag"
+import (
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/controllers"
+)
 
 // Options holds th controller starup parameters
 type Options struct {
@@ -26,6 +33,13 @@ type Options struct {
 	webhookCertsDir      string
 	webhookName          string
 	registrySecret       string
+	requeueMaxDelay      time.Duration
+	retryBudget          int
+	shardIndex           int
+	shardCount           int
+	verifyIngressDNS     bool
+	verifyOidcIssuer     bool
+	enableDebugEndpoint  bool
 }
 
 // AddFlags adds the controller parameters to the flag set
@@ -45,4 +59,26 @@ func (o *Options) AddFlags(flagSet *pflag.FlagSet) {
 	flagSet.IntVar(&o.metricsPort, "metrics-port", 8080,
 		"The port of the oidc-apps controller metrics endpoint ")
 	flagSet.StringVar(&o.cacheSelectorString, "cache-selector", "", "The selector string for controller-runtime cache.")
+	flagSet.DurationVar(&o.requeueMaxDelay, "requeue-max-delay", 5*time.Minute,
+		"The maximum backoff delay of requeued workloads, e.g. waiting for the Cluster resource of a new shoot.")
+	flagSet.IntVar(&o.retryBudget, "retry-budget", 10,
+		"The number of consecutive permanent reconcile failures of a workload, after which it is no longer requeued "+
+			"until its spec changes. Zero requeues forever.")
+	flagSet.IntVar(&o.shardIndex, "shard-index", 0,
+		"The zero based index of the shard of workloads reconciled by this controller instance.")
+	flagSet.IntVar(&o.shardCount, "shard-count", 1,
+		"The number of controller instances sharing the workloads, each reconciling the workloads of its shard-index.")
+	flagSet.BoolVar(&o.verifyIngressDNS, "verify-ingress-dns", false,
+		"Emits warning events for the ingress hosts of the workloads, which do not resolve to the ingress load balancer.")
+	flagSet.BoolVar(&o.verifyOidcIssuer, "verify-oidc-issuer", false,
+		"Reports the controller not ready while the discovery documents of the configured OIDC issuers are not "+
+			"reachable.")
+	flagSet.BoolVar(&o.enableDebugEndpoint, "enable-debug-endpoint", false,
+		"Serves the rendered oauth2-proxy configuration of a target, with the sensitive values redacted, on the "+
+			constants.DebugOauth2ConfigPath+" path of the metrics endpoint.")
+}
+
+// shard returns the shard of the workloads reconciled by this controller instance
+func (o *Options) shard() controllers.Shard {
+	return controllers.Shard{Index: o.shardIndex, Count: o.shardCount}
 }
@@ -16,24 +16,30 @@ package webhook
 
 import (
 	"maps"
-	"os"
-	"path/filepath"
+	"math"
 	"reflect"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/gardener/oidc-apps-controller/imagevector"
 	"github.com/gardener/oidc-apps-controller/pkg/configuration"
 	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/garden"
 	"github.com/gardener/oidc-apps-controller/pkg/rand"
 )
 
+// systemTrustStoreFile is the CA bundle of the system trust store in the proxy images
+const systemTrustStoreFile = "/etc/ssl/certs/ca-certificates.crt"
+
 // Add an annotation to target workload.
 func addAnnotations(object client.Object) {
 	annotations := object.GetAnnotations()
@@ -51,34 +57,8 @@ func addAnnotations(object client.Object) {
 }
 
 func get2ProxySecretChecksum(object client.Object) string {
-	var cfg string
-
 	extConfig := configuration.GetOIDCAppsControllerConfig()
-
-	switch extConfig.GetClientSecret(object) {
-	case "":
-		cfg = configuration.NewOAuth2Config(
-			configuration.WithClientID(extConfig.GetClientID(object)),
-			configuration.WithClientSecretFile("/dev/null"),
-			configuration.WithScope(extConfig.GetScope(object)),
-			configuration.WithRedirectURL(extConfig.GetRedirectURL(object)),
-			configuration.WithOidcIssuerURL(extConfig.GetOidcIssuerURL(object)),
-			configuration.EnableSslInsecureSkipVerify(extConfig.GetSslInsecureSkipVerify(object)),
-			configuration.EnableInsecureOidcSkipIssuerVerification(extConfig.GetInsecureOidcSkipIssuerVerification(object)),
-			configuration.EnableInsecureOidcSkipNonce(extConfig.GetInsecureOidcSkipNonce(object)),
-		).Parse()
-	default:
-		cfg = configuration.NewOAuth2Config(
-			configuration.WithClientID(extConfig.GetClientID(object)),
-			configuration.WithClientSecret(extConfig.GetClientSecret(object)),
-			configuration.WithScope(extConfig.GetScope(object)),
-			configuration.WithRedirectURL(extConfig.GetRedirectURL(object)),
-			configuration.WithOidcIssuerURL(extConfig.GetOidcIssuerURL(object)),
-			configuration.EnableSslInsecureSkipVerify(extConfig.GetSslInsecureSkipVerify(object)),
-			configuration.EnableInsecureOidcSkipIssuerVerification(extConfig.GetInsecureOidcSkipIssuerVerification(object)),
-			configuration.EnableInsecureOidcSkipNonce(extConfig.GetInsecureOidcSkipNonce(object)),
-		).Parse()
-	}
+	cfg := configuration.NewOAuth2Config(extConfig.GetOauth2ProxyOptions(object)...).Parse()
 
 	return rand.GenerateFullSha256(cfg)
 }
@@ -121,6 +101,32 @@ func addPodAnnotations(pod *corev1.Pod, ann map[string]string) {
 	pod.SetAnnotations(annotations)
 }
 
+// propagateContainerAnnotations copies the container scoped pod annotations, in the form <key>/<container>, of the
+// application container to the proxy containers, unless they are already annotated
+func propagateContainerAnnotations(pod *corev1.Pod, keys []string) {
+	if len(keys) == 0 || len(pod.Spec.Containers) == 0 {
+		return
+	}
+
+	annotations := pod.GetAnnotations()
+	application := pod.Spec.Containers[0].Name
+
+	for _, key := range keys {
+		value, found := annotations[key+"/"+application]
+		if !found {
+			continue
+		}
+
+		for _, proxy := range []string{constants.ContainerNameOauth2Proxy, constants.ContainerNameKubeRbacProxy} {
+			if _, found := annotations[key+"/"+proxy]; !found {
+				annotations[key+"/"+proxy] = value
+			}
+		}
+	}
+
+	pod.SetAnnotations(annotations)
+}
+
 func addImagePullSecret(secretName string, podSpec *corev1.PodSpec) {
 	if secretName == "" {
 		return
@@ -148,6 +154,30 @@ func addImagePullSecret(secretName string, podSpec *corev1.PodSpec) {
 	)
 }
 
+// addOptionalConfigMapVolume adds or replaces an optional config map volume, so that the pods start when the config
+// map is missing
+func addOptionalConfigMapVolume(volumeName, configMapName string, podSpec *corev1.PodSpec) {
+	volume := corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+				Optional:             ptr.To(true),
+			},
+		},
+	}
+
+	for i, v := range podSpec.Volumes {
+		if v.Name == volumeName {
+			podSpec.Volumes[i] = volume
+
+			return
+		}
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, volume)
+}
+
 func addProjectedSecretSourceVolume(volumeName, secretName string, podSpec *corev1.PodSpec) {
 	volume := corev1.Volume{Name: volumeName}
 	appendVolume := true // Assume that there is no such volume
@@ -221,22 +251,155 @@ func addProjectedSecretSourceVolume(volumeName, secretName string, podSpec *core
 	}
 }
 
-func addProxyContainer(name string, podSpec *corev1.PodSpec, container corev1.Container) {
-	containers := podSpec.Containers
-	for i, c := range containers {
-		if c.Name == name {
-			podSpec.Containers = slices.Delete(podSpec.Containers, i, i+1)
+// serviceAccountVolumeName returns the name of the projected service account token volume of the pod
+func serviceAccountVolumeName(volumes []corev1.Volume) string {
+	name := ""
 
-			break
+	for _, v := range volumes {
+		if v.Projected == nil || !slices.ContainsFunc(v.Projected.Sources, func(s corev1.VolumeProjection) bool {
+			return s.ServiceAccountToken != nil
+		}) {
+			continue
+		}
+
+		if v.Name == constants.KubeRbacProxyAPIAccessVolumeName {
+			return v.Name
+		}
+
+		if name == "" {
+			name = v.Name
 		}
 	}
 
+	return name
+}
+
+// addAPIAccessVolume adds a copy of the projected service account token volume of the pod, where the API server CA is
+// taken from the ca.crt key of the given secret instead of the kube-root-ca.crt config map
+func addAPIAccessVolume(secretName string, podSpec *corev1.PodSpec) {
+	podSpec.Volumes = slices.DeleteFunc(podSpec.Volumes, func(v corev1.Volume) bool {
+		return v.Name == constants.KubeRbacProxyAPIAccessVolumeName
+	})
+
+	name := serviceAccountVolumeName(podSpec.Volumes)
+	if name == "" {
+		// The service account token is not mounted in the pod
+		return
+	}
+
+	idx := slices.IndexFunc(podSpec.Volumes, func(v corev1.Volume) bool { return v.Name == name })
+	projected := podSpec.Volumes[idx].Projected.DeepCopy()
+
+	for i, s := range projected.Sources {
+		if s.ConfigMap == nil {
+			continue
+		}
+
+		projected.Sources[i] = corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Items:                []corev1.KeyToPath{{Key: "ca.crt", Path: "ca.crt"}},
+				Optional:             ptr.To(false),
+			},
+		}
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         constants.KubeRbacProxyAPIAccessVolumeName,
+		VolumeSource: corev1.VolumeSource{Projected: projected},
+	})
+}
+
+// addProxyContainer adds the proxy container to the pod spec, replacing a previously injected one. Native sidecars
+// are added as init containers with restartPolicy Always, otherwise the proxy is added as a regular container.
+func addProxyContainer(name string, podSpec *corev1.PodSpec, container corev1.Container, nativeSidecar bool) {
+	isProxy := func(c corev1.Container) bool { return c.Name == name }
+	podSpec.Containers = slices.DeleteFunc(podSpec.Containers, isProxy)
+	podSpec.InitContainers = slices.DeleteFunc(podSpec.InitContainers, isProxy)
+
+	if nativeSidecar {
+		container.RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
+		podSpec.InitContainers = append(podSpec.InitContainers, container)
+
+		return
+	}
+
 	podSpec.Containers = append(podSpec.Containers, container)
 }
 
+// findProxyContainer returns the injected proxy container, either a regular or a native sidecar container
+func findProxyContainer(name string, podSpec *corev1.PodSpec) *corev1.Container {
+	for i := range podSpec.InitContainers {
+		if podSpec.InitContainers[i].Name == name {
+			return &podSpec.InitContainers[i]
+		}
+	}
+
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == name {
+			return &podSpec.Containers[i]
+		}
+	}
+
+	return nil
+}
+
+// NativeSidecarsSupported returns true if the cluster version supports native sidecar containers, enabled by default
+// since Kubernetes 1.29
+func NativeSidecarsSupported(info *version.Info) bool {
+	if info == nil {
+		return false
+	}
+
+	v, err := utilversion.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return false
+	}
+
+	return v.AtLeast(utilversion.MajorMinor(1, 29))
+}
+
+// runsManagedProxy returns true if the target itself runs the managed oauth2-proxy, so that no proxy is injected into
+// a proxy. Such targets are identified by the well-known app.kubernetes.io/name label or by a container, other than
+// the injected sidecars, running the oauth2-proxy image of the controller.
+func runsManagedProxy(pod *corev1.Pod, owner client.Object) bool {
+	for _, o := range []client.Object{pod, owner} {
+		if o != nil && o.GetLabels()[constants.LabelAppNameKey] == constants.ContainerNameOauth2Proxy {
+			return true
+		}
+	}
+
+	image, err := imagevector.ImageVector().FindImage("oauth2-proxy")
+	if err != nil || image.Repository == nil {
+		return false
+	}
+
+	for _, c := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+		if c.Name == constants.ContainerNameOauth2Proxy || c.Name == constants.ContainerNameKubeRbacProxy {
+			continue
+		}
+
+		if imageRepository(c.Image) == *image.Repository {
+			return true
+		}
+	}
+
+	return false
+}
+
+// imageRepository returns the repository of an image reference, without its tag and digest
+func imageRepository(image string) string {
+	repository, _, _ := strings.Cut(image, "@")
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+
+	return repository
+}
+
 func fetchKubconfigSecretName(suffix string, object client.Object) string {
 	if configuration.GetOIDCAppsControllerConfig().GetKubeConfigStr(object) != "" {
-		return "kubeconfig-" + suffix
+		return constants.ResourceName(constants.SecretNameKubeconfig, suffix)
 	}
 
 	if configuration.GetOIDCAppsControllerConfig().GetKubeSecretName(object) != "" {
@@ -244,12 +407,13 @@ func fetchKubconfigSecretName(suffix string, object client.Object) string {
 	}
 
 	// In case of gardener mounted kubeconfig, the name of the secret is as below
-	return constants.SecretNameKubeconfig + "-" + suffix
+	return constants.ResourceName(constants.SecretNameKubeconfig, suffix)
 }
 
 func fetchOidcCASecretName(suffix string, object client.Object) string {
-	if configuration.GetOIDCAppsControllerConfig().GetOidcCABundle(object) != "" {
-		return constants.SecretNameOidcCa + "-" + suffix
+	if configuration.GetOIDCAppsControllerConfig().GetOidcCABundle(object) != "" ||
+		configuration.GetOIDCAppsControllerConfig().GetOidcCASecretKeyRef(object) != nil {
+		return constants.ResourceName(constants.SecretNameOidcCa, suffix)
 	}
 
 	return configuration.GetOIDCAppsControllerConfig().GetOidcCASecretName(object)
@@ -272,7 +436,9 @@ func fetchTargetSuffix(object client.Object) string {
 	return suffix
 }
 
-func buildUpstreamURL(target string, podSpec corev1.PodSpec) string {
+// buildUpstreamURL returns the URL of the upstream target. A named port is resolved from the ports of the given
+// container, or of any container when none is given.
+func buildUpstreamURL(target, containerName string, podSpec corev1.PodSpec) string {
 	before, after, _ := strings.Cut(target, ",")
 
 	protocol, f := strings.CutPrefix(before, "protocol=")
@@ -293,6 +459,10 @@ func buildUpstreamURL(target string, podSpec corev1.PodSpec) string {
 
 	// It is a named port shall iterate over the container ports
 	for _, container := range podSpec.Containers {
+		if containerName != "" && container.Name != containerName {
+			continue
+		}
+
 		for _, p := range container.Ports {
 			if p.Name == port {
 				return protocol + "://localhost" + ":" + strconv.Itoa(int(p.ContainerPort))
@@ -303,6 +473,23 @@ func buildUpstreamURL(target string, podSpec corev1.PodSpec) string {
 	return ""
 }
 
+// addLoopbackHostAlias resolves the hostname to the loopback address of the pod
+func addLoopbackHostAlias(podSpec *corev1.PodSpec, hostname string) {
+	for i, alias := range podSpec.HostAliases {
+		if alias.IP != "127.0.0.1" {
+			continue
+		}
+
+		if !slices.Contains(alias.Hostnames, hostname) {
+			podSpec.HostAliases[i].Hostnames = append(podSpec.HostAliases[i].Hostnames, hostname)
+		}
+
+		return
+	}
+
+	podSpec.HostAliases = append(podSpec.HostAliases, corev1.HostAlias{IP: "127.0.0.1", Hostnames: []string{hostname}})
+}
+
 func getKubeRbacProxyContainer(clientID, issuerURL, upstream string, pod *corev1.Pod, owner client.Object) corev1.Container {
 	image, _ := imagevector.ImageVector().FindImage("kube-rbac-proxy-watcher")
 
@@ -318,22 +505,13 @@ func getKubeRbacProxyContainer(clientID, issuerURL, upstream string, pod *corev1
 		},
 	}
 
-	// Add the service account token volume mount
-	for _, v := range pod.Spec.Volumes {
-		if v.Projected != nil && v.Projected.Sources != nil {
-			for _, s := range v.Projected.Sources {
-				if s.ServiceAccountToken != nil {
-					serviceAccountVolumeMount := corev1.VolumeMount{
-						Name:      v.Name,
-						ReadOnly:  true,
-						MountPath: "/var/run/secrets/kubernetes.io/serviceaccount",
-					}
-					volumeMounts = append(volumeMounts, serviceAccountVolumeMount)
-
-					break
-				}
-			}
-		}
+	// Add the service account token volume mount, preferring the one trusting a custom API server CA
+	if name := serviceAccountVolumeName(pod.Spec.Volumes); name != "" {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      name,
+			ReadOnly:  true,
+			MountPath: "/var/run/secrets/kubernetes.io/serviceaccount",
+		})
 	}
 
 	containerResourceRequirements := corev1.ResourceRequirements{
@@ -347,7 +525,7 @@ func getKubeRbacProxyContainer(clientID, issuerURL, upstream string, pod *corev1
 		},
 	}
 
-	for _, c := range pod.Spec.Containers {
+	for _, c := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
 		if c.Name != constants.ContainerNameKubeRbacProxy {
 			continue
 		}
@@ -384,6 +562,7 @@ func getKubeRbacProxyContainer(clientID, issuerURL, upstream string, pod *corev1
 		},
 		Resources:    containerResourceRequirements,
 		VolumeMounts: volumeMounts,
+		Lifecycle:    shutdownDrainLifecycle(owner),
 	}
 
 	if shallAddKubeConfigSecretName(owner) {
@@ -391,15 +570,52 @@ func getKubeRbacProxyContainer(clientID, issuerURL, upstream string, pod *corev1
 		container.Args = append(container.Args, "--kubeconfig=/etc/kube-rbac-proxy/kubeconfig")
 	}
 
+	// The upstream health path is served without authentication by the oauth2-proxy, hence it shall not be
+	// authorized either
+	if healthPath := configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyUpstreamHealthPath(owner); healthPath != "" {
+		container.Args = append(container.Args, "--ignore-paths="+healthPath)
+	}
+
 	// TODO: There is a bug https://github.com/brancz/kube-rbac-proxy/issues/259
 	if shallAddOidcCaSecretName(owner) {
 		// Add volume mount and start parameter if the secret name is provided
 		container.Args = append(container.Args, "--oidc-ca-file=/etc/kube-rbac-proxy/ca.crt")
+	} else if configuration.GetOIDCAppsControllerConfig().GetOidcPublicIssuer(owner) {
+		// The kube-rbac-proxy does not fall back to the system trust store without a CA file, hence the one of the
+		// image is passed for public issuers
+		container.Args = append(container.Args, "--oidc-ca-file="+systemTrustStoreFile)
+	}
+
+	return container
+}
+
+// getUpstreamKubeRbacProxyContainer returns the kube-rbac-proxy sidecar of the additional upstream with the given
+// index, which listens on its own port and forwards to the upstream
+func getUpstreamKubeRbacProxyContainer(index int, clientID, issuerURL, upstream string, pod *corev1.Pod,
+	owner client.Object) corev1.Container {
+	container := getKubeRbacProxyContainer(clientID, issuerURL, upstream, pod, owner)
+	if pod == nil {
+		return container
+	}
+
+	port := configuration.UpstreamProxyPort(index)
+	container.Name = upstreamKubeRbacProxyName(index)
+	container.Ports = []corev1.ContainerPort{{Name: "rbac-" + strconv.Itoa(index+1), ContainerPort: port}}
+
+	for i, arg := range container.Args {
+		if strings.HasPrefix(arg, "--insecure-listen-address=") {
+			container.Args[i] = "--insecure-listen-address=0.0.0.0:" + strconv.Itoa(int(port))
+		}
 	}
 
 	return container
 }
 
+// upstreamKubeRbacProxyName returns the container name of the kube-rbac-proxy sidecar of an additional upstream
+func upstreamKubeRbacProxyName(index int) string {
+	return constants.ResourceName(constants.ContainerNameKubeRbacProxy, strconv.Itoa(index+1))
+}
+
 func getOIDCProxyContainer(pod *corev1.PodSpec, owner client.Object) corev1.Container {
 	image, _ := imagevector.ImageVector().FindImage("oauth2-proxy")
 
@@ -426,7 +642,7 @@ func getOIDCProxyContainer(pod *corev1.PodSpec, owner client.Object) corev1.Cont
 		},
 	}
 
-	for _, c := range pod.Containers {
+	for _, c := range slices.Concat(pod.InitContainers, pod.Containers) {
 		if c.Name != constants.ContainerNameOauth2Proxy {
 			continue
 		}
@@ -448,17 +664,11 @@ func getOIDCProxyContainer(pod *corev1.PodSpec, owner client.Object) corev1.Cont
 		Name:            constants.ContainerNameOauth2Proxy,
 		Image:           image.String(),
 		ImagePullPolicy: "IfNotPresent",
-		Args: []string{"--provider=oidc",
-			"--config=/etc/oauth2-proxy/oauth2-proxy.cfg",
-			"--code-challenge-method=S256",
+		Args: []string{"--config=/etc/oauth2-proxy/oauth2-proxy.cfg",
 			"--pass-authorization-header=true",
-			"--cookie-secret=" + rand.GenerateRandomString(16),
 			"--cookie-refresh=3600s",
 			"--http-address=0.0.0.0:8000",
 			"--email-domain=*",
-			"--reverse-proxy=true",
-			"--skip-provider-button=true",
-			"--skip-jwt-bearer-tokens=true",
 			"--upstream=http://127.0.0.1:8100"},
 		SecurityContext: &corev1.SecurityContext{
 			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
@@ -470,6 +680,7 @@ func getOIDCProxyContainer(pod *corev1.PodSpec, owner client.Object) corev1.Cont
 		},
 		Resources:    containerResourceRequirements,
 		VolumeMounts: volumeMounts,
+		Lifecycle:    shutdownDrainLifecycle(owner),
 	}
 
 	if shallAddOidcCaSecretName(owner) {
@@ -477,9 +688,112 @@ func getOIDCProxyContainer(pod *corev1.PodSpec, owner client.Object) corev1.Cont
 		container.Args = append(container.Args, "--provider-ca-file=/etc/oauth2-proxy/ca.crt")
 	}
 
+	// The custom templates are mounted next to the configuration, the projected configuration volume is read-only
+	if configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyCustomTemplatesName(owner) != "" {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      constants.Oauth2TemplatesVolumeName,
+			ReadOnly:  true,
+			MountPath: constants.Oauth2TemplatesDir,
+		})
+	}
+
+	// The cookie secret is shared by the replicas and preserved by the controller across the secret recreations
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name: "OAUTH2_PROXY_COOKIE_SECRET",
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: constants.ResourceName(constants.SecretNameOauth2Proxy, fetchTargetSuffix(owner)),
+			},
+			Key: constants.Oauth2CookieSecretKey,
+		}},
+	})
+
+	// The shared banner and footer are passed as environment variables, which oauth2-proxy prefers over the
+	// configuration file. The optional references tolerate a missing config map or key.
+	if name := configuration.GetOIDCAppsControllerConfig().GetOauth2ProxySignInPageConfigMapName(owner); name != "" {
+		container.Env = append(container.Env,
+			optionalConfigMapEnvVar("OAUTH2_PROXY_BANNER", name, constants.SignInPageBannerKey),
+			optionalConfigMapEnvVar("OAUTH2_PROXY_FOOTER", name, constants.SignInPageFooterKey),
+		)
+	}
+
+	// The configured refresh period is rendered in the oauth2-proxy configuration, which the flag would override
+	if configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyCookieRefresh(owner) != "" {
+		container.Args = slices.DeleteFunc(container.Args, func(arg string) bool {
+			return strings.HasPrefix(arg, "--cookie-refresh=")
+		})
+	}
+
+	if metricsPort := configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyMetricsPort(owner); metricsPort > 0 {
+		// Expose the metrics on a dedicated port, so they can be scraped without authentication
+		container.Args = append(container.Args, "--metrics-address=0.0.0.0:"+strconv.Itoa(int(metricsPort)))
+		container.Ports = append(container.Ports, corev1.ContainerPort{Name: "metrics", ContainerPort: metricsPort})
+	}
+
+	// The basic Authorization header of the legacy upstreams would be replaced by the ID token
+	if configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyPassBasicAuth(owner) {
+		for i, arg := range container.Args {
+			if arg == "--pass-authorization-header=true" {
+				container.Args[i] = "--pass-authorization-header=false"
+			}
+		}
+	}
+
+	// The upstream paths are rewritten and the X-Auth-Request headers are set by the alpha configuration, which
+	// replaces the upstream, header and listen address flags. oauth2-proxy refuses to start with both.
+	if configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyAlphaConfig(owner) {
+		container.Args = slices.DeleteFunc(container.Args, func(arg string) bool {
+			return slices.ContainsFunc(alphaConfigFlags, func(flag string) bool { return strings.HasPrefix(arg, flag) })
+		})
+		container.Args = append(container.Args, "--alpha-config=/etc/oauth2-proxy/"+constants.Oauth2AlphaConfigKey)
+	}
+
+	container.StartupProbe = configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyStartupProbe(owner)
+	container.ReadinessProbe = configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyReadinessProbe(owner)
+
 	return container
 }
 
+// shutdownDrainLifecycle returns the lifecycle of a proxy, which keeps serving for the drain period of the target upon
+// the pod termination, nil without a drain period. The sleep action does not depend on a shell in the proxy images.
+func shutdownDrainLifecycle(owner client.Object) *corev1.Lifecycle {
+	drain := configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyShutdownDrain(owner)
+	if drain <= 0 {
+		return nil
+	}
+
+	return &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{
+		Sleep: &corev1.SleepAction{Seconds: int64(math.Ceil(drain.Seconds()))},
+	}}
+}
+
+// addTerminationGracePeriod raises the termination grace period of the pod to cover the drain period of the proxies,
+// followed by the default grace period for the shutdown of the containers
+func addTerminationGracePeriod(podSpec *corev1.PodSpec, drain time.Duration) {
+	if drain <= 0 {
+		return
+	}
+
+	minimum := int64(math.Ceil(drain.Seconds())) + corev1.DefaultTerminationGracePeriodSeconds
+	if ptr.Deref(podSpec.TerminationGracePeriodSeconds, corev1.DefaultTerminationGracePeriodSeconds) < minimum {
+		podSpec.TerminationGracePeriodSeconds = ptr.To(minimum)
+	}
+}
+
+// alphaConfigFlags are the oauth2-proxy flags, which are set by the alpha configuration instead
+var alphaConfigFlags = []string{"--upstream=", "--http-address=", "--metrics-address=", "--pass-authorization-header="}
+
+func optionalConfigMapEnvVar(name, configMapName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+			Key:                  key,
+			Optional:             ptr.To(true),
+		}},
+	}
+}
+
 func shallAddKubeConfigSecretName(object client.Object) bool {
 	// There are potentially two sources of the kubeconfig:
 	// 1. Configuration, meaning the kubeconfig secret reference is supplied with the oidc-apps-controller setup
@@ -494,21 +808,12 @@ func shallAddKubeConfigSecretName(object client.Object) bool {
 		return true
 	}
 
-	d := filepath.Dir(os.Getenv("GARDEN_KUBECONFIG"))
-
-	if _, err := os.Stat(filepath.Join(d, "kubeconfig")); err != nil {
-		return false
-	}
-
-	if _, err := os.Stat(filepath.Join(d, "token")); err != nil {
-		return false
-	}
-
-	return true
+	return garden.HasAccess()
 }
 
 func shallAddOidcCaSecretName(object client.Object) bool {
-	if configuration.GetOIDCAppsControllerConfig().GetOidcCABundle(object) != "" {
+	if configuration.GetOIDCAppsControllerConfig().GetOidcCABundle(object) != "" ||
+		configuration.GetOIDCAppsControllerConfig().GetOidcCASecretKeyRef(object) != nil {
 		return true
 	}
 
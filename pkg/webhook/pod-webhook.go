@@ -16,6 +16,7 @@ package webhook
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"slices"
@@ -23,6 +24,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/json"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -31,16 +33,23 @@ import (
 
 	"github.com/gardener/oidc-apps-controller/pkg/configuration"
 	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/rand"
 )
 
 // Register the webhook with the server
 var _ admission.Handler = &PodMutator{}
 
+// errTargetRunsProxy is logged for a target, which runs the oauth2-proxy itself, e.g. due to a too broad target
+// selector
+var errTargetRunsProxy = errors.New("the target runs the oauth2-proxy itself")
+
 // PodMutator is a handler modifying the resource definitions of the pod targets
 type PodMutator struct {
 	Client          client.Client
 	Decoder         webhook.AdmissionDecoder
 	ImagePullSecret string
+	// NativeSidecarsSupported is set when the cluster supports native sidecar containers
+	NativeSidecarsSupported bool
 }
 
 // Handle provides interface implementation for the PodMutator
@@ -69,14 +78,30 @@ func (p *PodMutator) Handle(ctx context.Context, req webhook.AdmissionRequest) w
 		return webhook.Allowed("not a target")
 	}
 
+	// Do not inject a proxy into a proxy
+	if runsManagedProxy(pod, owner) {
+		_log.Error(errTargetRunsProxy, "skipping the injection", "owner", client.ObjectKeyFromObject(owner))
+
+		return webhook.Allowed("target runs the oauth2-proxy")
+	}
+
 	_log.Info("handling pod admission request")
 
 	patch := pod.DeepCopy()
 	clientID := configuration.GetOIDCAppsControllerConfig().GetClientID(owner)
 	ussuerURL := configuration.GetOIDCAppsControllerConfig().GetOidcIssuerURL(owner)
 	upstream := configuration.GetOIDCAppsControllerConfig().GetUpstreamTarget(owner)
-	upstreamURL := buildUpstreamURL(upstream, patch.Spec)
+	upstreamURL := buildUpstreamURL(upstream, configuration.GetOIDCAppsControllerConfig().GetTargetContainer(owner),
+		patch.Spec)
+
+	// The upstream is addressed by its TLS server name, which resolves to the pod itself
+	if serverName := configuration.GetOIDCAppsControllerConfig().GetUpstreamServerName(owner); serverName != "" &&
+		upstreamURL != "" {
+		upstreamURL = strings.Replace(upstreamURL, "://localhost", "://"+serverName, 1)
+		addLoopbackHostAlias(&patch.Spec, serverName)
+	}
 	suffix := fetchTargetSuffix(owner)
+	nativeSidecar := p.NativeSidecarsSupported && configuration.GetOIDCAppsControllerConfig().GetNativeSidecars(owner)
 
 	// Add the OIDC annotation to the deployment template
 	addAnnotations(patch)
@@ -88,6 +113,9 @@ func (p *PodMutator) Handle(ctx context.Context, req webhook.AdmissionRequest) w
 		},
 	)
 
+	// Propagate the container scoped annotations of the application container to the proxies
+	propagateContainerAnnotations(patch, configuration.GetOIDCAppsControllerConfig().GetPropagatedAnnotations(owner))
+
 	// Add required labels to pod spec template
 	addPodLabels(patch,
 		map[string]string{
@@ -98,7 +126,7 @@ func (p *PodMutator) Handle(ctx context.Context, req webhook.AdmissionRequest) w
 	// Add the oauth2-proxy volume
 	addProjectedSecretSourceVolume(
 		constants.Oauth2VolumeName,
-		constants.SecretNameOauth2Proxy+"-"+suffix,
+		constants.ResourceName(constants.SecretNameOauth2Proxy, suffix),
 		&patch.Spec,
 	)
 
@@ -110,10 +138,15 @@ func (p *PodMutator) Handle(ctx context.Context, req webhook.AdmissionRequest) w
 		)
 	}
 
+	// Add an optional config map volume with the custom oauth2-proxy templates
+	if name := configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyCustomTemplatesName(owner); name != "" {
+		addOptionalConfigMapVolume(constants.Oauth2TemplatesVolumeName, name, &patch.Spec)
+	}
+
 	// Add the resource-attribute secret volume for the kube-rbac-proxy
 	addProjectedSecretSourceVolume(
 		constants.KubeRbacProxyVolumeName,
-		constants.SecretNameResourceAttributes+"-"+suffix,
+		constants.ResourceName(constants.SecretNameResourceAttributes, suffix),
 		&patch.Spec,
 	)
 
@@ -135,46 +168,58 @@ func (p *PodMutator) Handle(ctx context.Context, req webhook.AdmissionRequest) w
 		)
 	}
 
+	// Add an optional service account token volume trusting a custom API server CA for the kube-rbac-proxy
+	if name := configuration.GetOIDCAppsControllerConfig().GetKubeRbacProxyAPIServerCASecretName(owner); name != "" &&
+		!shallAddKubeConfigSecretName(owner) {
+		addAPIAccessVolume(name, &patch.Spec)
+	}
+
 	// Add the OAUTH2 proxy sidecar to the pod template
-	addProxyContainer(constants.ContainerNameOauth2Proxy, &patch.Spec, getOIDCProxyContainer(&patch.Spec, owner))
+	addProxyContainer(constants.ContainerNameOauth2Proxy, &patch.Spec, getOIDCProxyContainer(&patch.Spec, owner),
+		nativeSidecar)
 
 	// Add the kube-rbac-proxy sidecar to the pod template
 	addProxyContainer(constants.ContainerNameKubeRbacProxy, &patch.Spec, getKubeRbacProxyContainer(clientID,
-		ussuerURL, upstreamURL, patch, owner))
+		ussuerURL, upstreamURL, patch, owner), nativeSidecar)
+
+	// Add a kube-rbac-proxy sidecar per additional upstream, which the oauth2-proxy routes to by the request path
+	for i, u := range configuration.GetOIDCAppsControllerConfig().GetUpstreams(owner) {
+		addProxyContainer(upstreamKubeRbacProxyName(i), &patch.Spec, getUpstreamKubeRbacProxyContainer(i, clientID,
+			ussuerURL, buildUpstreamURL(u.Target(), u.TargetContainer, patch.Spec), patch, owner), nativeSidecar)
+	}
+
+	// The pods terminate only after the drain period of the proxies
+	addTerminationGracePeriod(&patch.Spec, configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyShutdownDrain(owner))
 
 	// Add image pull secret if the proxy container images are served from private registry
 	if len(p.ImagePullSecret) > 0 {
 		addImagePullSecret(p.ImagePullSecret, &patch.Spec)
 	}
 
-	podIndex, present := patch.GetObjectMeta().GetLabels()["statefulset.kubernetes.io/pod-name"]
-	if present {
+	// The pods of a statefulset or a deployment exposed through per-pod ingresses are redirected to their own hosts
+	podIndex, present := perPodHostIndex(patch, owner)
+	if present && configuration.GetOIDCAppsControllerConfig().GetPerPodIngress(owner) {
 		hostPrefix := configuration.GetOIDCAppsControllerConfig().GetHost(owner)
 
 		host, domain, found := strings.Cut(hostPrefix, ".")
 		if found {
-			l := strings.Split(podIndex, "-")
-			host = fmt.Sprintf("%s-%s.%s", host, l[len(l)-1], domain)
+			host = fmt.Sprintf("%s-%s.%s", host, podIndex, domain)
 		}
 
 		_log.Info(fmt.Sprintf("host: %s", host))
 
-		for idx, container := range patch.Spec.Containers {
-			if container.Name != "oauth2-proxy" {
-				continue
-			}
-			// Remove the argument if present
-			for i, arg := range container.Args {
-				if strings.HasPrefix(arg, "--redirect-url") {
-					patch.Spec.Containers[idx].Args = slices.Delete(patch.Spec.Containers[idx].Args, i, i+1)
-				}
-			}
-			// Add the correct argument
-			patch.Spec.Containers[idx].Args = append(patch.Spec.Containers[idx].Args,
-				fmt.Sprintf("--redirect-url=https://%s/oauth2/callback", host),
+		if container := findProxyContainer(constants.ContainerNameOauth2Proxy, &patch.Spec); container != nil {
+			// Remove the arguments if present
+			container.Args = slices.DeleteFunc(container.Args, func(arg string) bool {
+				return strings.HasPrefix(arg, "--redirect-url") || strings.HasPrefix(arg, "--cookie-name")
+			})
+			// Add the correct arguments, the per-pod cookie name keeps the sessions of the pods apart
+			container.Args = append(container.Args,
+				fmt.Sprintf("--redirect-url=https://%s%s/callback", host,
+					configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyPrefix(owner)),
+				fmt.Sprintf("--cookie-name=%s_%s",
+					configuration.GetOIDCAppsControllerConfig().GetOauth2ProxyCookieName(owner), podIndex),
 			)
-
-			break
 		}
 	}
 
@@ -191,6 +236,33 @@ func (p *PodMutator) Handle(ctx context.Context, req webhook.AdmissionRequest) w
 	return admission.PatchResponseFromRaw(original, patched)
 }
 
+// perPodHostIndex returns the index distinguishing the host of a pod exposed through a dedicated ingress, the ordinal
+// of a statefulset pod or the name hash of a deployment pod. The deployment pods are labeled with their name hash, for
+// the selector of their dedicated service.
+func perPodHostIndex(pod *corev1.Pod, owner client.Object) (string, bool) {
+	if podName, present := pod.GetLabels()["statefulset.kubernetes.io/pod-name"]; present {
+		l := strings.Split(podName, "-")
+
+		return l[len(l)-1], true
+	}
+
+	if _, isDeployment := owner.(*appsv1.Deployment); !isDeployment ||
+		!configuration.GetOIDCAppsControllerConfig().GetPerPodIngress(owner) {
+		return "", false
+	}
+
+	// The name of a replicaset pod is generated by the API server only after the admission, hence it is generated here
+	// the same way, so that the pod host is known upfront
+	if pod.GetName() == "" {
+		pod.SetName(pod.GetGenerateName() + rand.GenerateRandomString(5))
+	}
+
+	index := rand.GenerateSha256(pod.GetName())
+	addPodLabels(pod, map[string]string{constants.LabelPodNameHashKey: index})
+
+	return index, true
+}
+
 func isTarget(ctx context.Context, c client.Client, pod *corev1.Pod) (bool, client.Object) {
 	// Identify the workload
 	owners := pod.GetOwnerReferences()
@@ -219,9 +291,29 @@ func isTarget(ctx context.Context, c client.Client, pod *corev1.Pod) (bool, clie
 				return false, nil
 			}
 
+			// A bare replicaset is not a target workload
+			if len(replicaset.GetOwnerReferences()) == 0 {
+				return false, nil
+			}
+
+			owner := replicaset.GetOwnerReferences()[0]
+			if owner.Kind == constants.RolloutKind {
+				rollout := &unstructured.Unstructured{}
+				rollout.SetAPIVersion(owner.APIVersion)
+				rollout.SetKind(owner.Kind)
+
+				if err := c.Get(ctx, client.ObjectKey{Name: owner.Name, Namespace: pod.GetNamespace()},
+					rollout); err != nil {
+					log.FromContext(ctx).Error(err, "unable to get rollout for object", "object", pod)
+
+					return false, nil
+				}
+
+				return configuration.GetOIDCAppsControllerConfig().Match(rollout), rollout
+			}
+
 			deployment := &appsv1.Deployment{}
-			if err := c.Get(ctx, client.ObjectKey{Name: replicaset.GetOwnerReferences()[0].Name,
-				Namespace: pod.GetNamespace()},
+			if err := c.Get(ctx, client.ObjectKey{Name: owner.Name, Namespace: pod.GetNamespace()},
 				deployment); err != nil {
 				log.FromContext(ctx).Error(err, "unable to get deployment for object", "object", pod)
 
@@ -30,9 +30,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
@@ -468,6 +472,118 @@ var _ = Describe("Oidc Apps MutatingAdmission Framework Test", func() {
 				))
 			}) // It
 		}) // When
+		When("the target configuration designates a public OIDC issuer", func() {
+			It("shall verify the issuer against the system trust store", func() {
+				target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+				target.Configuration.OidcPublicIssuer = ptr.To(true)
+				DeferCleanup(func() { target.Configuration.OidcPublicIssuer = nil })
+
+				pp := patchPod(targetPod)
+
+				// The configured oidc CA bundle is not projected
+				for _, v := range pp.Spec.Volumes {
+					if v.Name == constants.KubeRbacProxyVolumeName {
+						Expect(v.Projected.Sources).NotTo(ContainElement(HaveField("Secret.Name",
+							"oidc-ca-"+rand.GenerateSha256(targetDeployment.Name+"-"+targetDeployment.Namespace))))
+					}
+				}
+
+				for _, c := range pp.Spec.Containers {
+					switch c.Name {
+					case constants.ContainerNameKubeRbacProxy:
+						Expect(c.Args).To(ContainElement("--oidc-ca-file=/etc/ssl/certs/ca-certificates.crt"))
+						Expect(c.Args).NotTo(ContainElement("--oidc-ca-file=/etc/kube-rbac-proxy/ca.crt"))
+					case constants.ContainerNameOauth2Proxy:
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--provider-ca-file=")))
+					}
+				}
+			})
+		}) // When the target configuration designates a public OIDC issuer
+		When("the target configuration has an API server CA secret for the kube-rbac-proxy", func() {
+			var podWithAPIAccess *corev1.Pod
+
+			BeforeEach(func() {
+				podWithAPIAccess = targetPod.DeepCopy()
+				podWithAPIAccess.Spec.Volumes = append(podWithAPIAccess.Spec.Volumes, corev1.Volume{
+					Name: "kube-api-access-abcde",
+					VolumeSource: corev1.VolumeSource{
+						Projected: &corev1.ProjectedVolumeSource{
+							Sources: []corev1.VolumeProjection{
+								{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"}},
+								{ConfigMap: &corev1.ConfigMapProjection{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "kube-root-ca.crt"},
+									Items:                []corev1.KeyToPath{{Key: "ca.crt", Path: "ca.crt"}},
+								}},
+								{DownwardAPI: &corev1.DownwardAPIProjection{
+									Items: []corev1.DownwardAPIVolumeFile{{
+										Path:     "namespace",
+										FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+									}},
+								}},
+							},
+						},
+					},
+				})
+			})
+
+			It("shall mount the service account token trusting the API server CA", func() {
+				target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+				target.Configuration.KubeRbacProxy = &configuration.KubeRbacProxyConfig{
+					APIServerCASecretRef: &corev1.SecretReference{Name: "apiserver-ca"},
+				}
+				DeferCleanup(func() { target.Configuration.KubeRbacProxy = nil })
+
+				pp := patchPod(podWithAPIAccess)
+
+				var apiAccess *corev1.Volume
+				for i, v := range pp.Spec.Volumes {
+					if v.Name == constants.KubeRbacProxyAPIAccessVolumeName {
+						apiAccess = &pp.Spec.Volumes[i]
+					}
+				}
+				Expect(apiAccess).NotTo(BeNil())
+				Expect(apiAccess.Projected.Sources).To(HaveLen(3))
+				Expect(apiAccess.Projected.Sources[0].ServiceAccountToken).NotTo(BeNil())
+				Expect(apiAccess.Projected.Sources[1].ConfigMap).To(BeNil())
+				Expect(apiAccess.Projected.Sources[1].Secret).To(Equal(&corev1.SecretProjection{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "apiserver-ca"},
+					Items:                []corev1.KeyToPath{{Key: "ca.crt", Path: "ca.crt"}},
+					Optional:             ptr.To(false),
+				}))
+				Expect(apiAccess.Projected.Sources[2].DownwardAPI).NotTo(BeNil())
+
+				// The pod service account volume is kept for the other containers
+				Expect(pp.Spec.Volumes).To(ContainElement(HaveField("Name", "kube-api-access-abcde")))
+
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameKubeRbacProxy {
+						Expect(c.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+							Name:      constants.KubeRbacProxyAPIAccessVolumeName,
+							ReadOnly:  true,
+							MountPath: "/var/run/secrets/kubernetes.io/serviceaccount",
+						}))
+						Expect(c.VolumeMounts).NotTo(ContainElement(HaveField("Name", "kube-api-access-abcde")))
+					}
+				}
+			})
+
+			It("shall mount the pod service account token by default", func() {
+				pp := patchPod(podWithAPIAccess)
+
+				Expect(pp.Spec.Volumes).NotTo(ContainElement(HaveField("Name",
+					constants.KubeRbacProxyAPIAccessVolumeName)))
+
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameKubeRbacProxy {
+						Expect(c.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+							Name:      "kube-api-access-abcde",
+							ReadOnly:  true,
+							MountPath: "/var/run/secrets/kubernetes.io/serviceaccount",
+						}))
+					}
+				}
+			})
+		}) // When the target configuration has an API server CA secret for the kube-rbac-proxy
 		When("there is a container resource defined in the incoming request which are less than default", func() {
 			It("shall modify the container resources", func() {
 				pp := patchPod(podWithLessResources)
@@ -525,7 +641,724 @@ var _ = Describe("Oidc Apps MutatingAdmission Framework Test", func() {
 				}
 			})
 		}) // When there isn't any container resource defined in the incoming request
+		When("the target configuration has an oauth2-proxy metrics port", func() {
+			It("shall expose the metrics port on the oauth2-proxy container", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.MetricsPort = 9090
+				DeferCleanup(func() { oauth2Proxy.MetricsPort = 0 })
+
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.Args).To(ContainElement("--metrics-address=0.0.0.0:9090"))
+						Expect(c.Ports).To(ContainElement(corev1.ContainerPort{Name: "metrics", ContainerPort: 9090}))
+					}
+				}
+			})
+		}) // When the target configuration has an oauth2-proxy metrics port
+		When("the target configuration has custom oauth2-proxy templates", func() {
+			It("shall mount the optional templates config map into the oauth2-proxy container", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.CustomTemplatesRef = &corev1.LocalObjectReference{Name: "branded-pages"}
+				DeferCleanup(func() { oauth2Proxy.CustomTemplatesRef = nil })
+
+				pp := patchPod(targetPod)
+				Expect(pp.Spec.Volumes).To(ContainElement(corev1.Volume{
+					Name: constants.Oauth2TemplatesVolumeName,
+					VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "branded-pages"},
+						Optional:             ptr.To(true),
+					}},
+				}))
+
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+							Name:      constants.Oauth2TemplatesVolumeName,
+							ReadOnly:  true,
+							MountPath: constants.Oauth2TemplatesDir,
+						}))
+					}
+				}
+			})
+			It("shall not mount the templates when they are not configured", func() {
+				pp := patchPod(targetPod)
+				for _, v := range pp.Spec.Volumes {
+					Expect(v.Name).NotTo(Equal(constants.Oauth2TemplatesVolumeName))
+				}
+
+				for _, c := range pp.Spec.Containers {
+					for _, m := range c.VolumeMounts {
+						Expect(m.Name).NotTo(Equal(constants.Oauth2TemplatesVolumeName))
+					}
+				}
+			})
+		}) // When the target configuration has custom oauth2-proxy templates
+		When("the target configuration has a sign-in page config map", func() {
+			It("shall pass the banner and the footer from the optional config map to the oauth2-proxy", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.SignInPage = &configuration.SignInPageConf{
+					ConfigMapRef: &corev1.LocalObjectReference{Name: "login-banner"},
+				}
+				DeferCleanup(func() { oauth2Proxy.SignInPage = nil })
+
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						// A missing config map or key does not block the pod start
+						Expect(c.Env).To(ContainElements(
+							corev1.EnvVar{Name: "OAUTH2_PROXY_BANNER", ValueFrom: &corev1.EnvVarSource{
+								ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "login-banner"},
+									Key:                  constants.SignInPageBannerKey,
+									Optional:             ptr.To(true),
+								},
+							}},
+							corev1.EnvVar{Name: "OAUTH2_PROXY_FOOTER", ValueFrom: &corev1.EnvVarSource{
+								ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "login-banner"},
+									Key:                  constants.SignInPageFooterKey,
+									Optional:             ptr.To(true),
+								},
+							}},
+						))
+					}
+				}
+			})
+			It("shall not reference a config map when it is not configured", func() {
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						for _, e := range c.Env {
+							Expect(e.ValueFrom.ConfigMapKeyRef).To(BeNil())
+						}
+					}
+				}
+			})
+		}) // When the target configuration has a sign-in page config map
+		When("the oauth2-proxy sidecar is injected", func() {
+			It("shall take the oauth2-proxy cookie secret from the oauth2 secret", func() {
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--cookie-secret")))
+						Expect(c.Env).To(ContainElement(corev1.EnvVar{
+							Name: "OAUTH2_PROXY_COOKIE_SECRET",
+							ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{
+									Name: constants.SecretNameOauth2Proxy + "-" + rand.GenerateSha256("nginx-nginx"),
+								},
+								Key: constants.Oauth2CookieSecretKey,
+							}},
+						}))
+					}
+				}
+			})
+		}) // When the oauth2-proxy sidecar is injected
+		When("the target configuration has an upstream health path", func() {
+			It("shall not authorize the health path in the kube-rbac-proxy", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.UpstreamHealthPath = "/healthz"
+				DeferCleanup(func() { oauth2Proxy.UpstreamHealthPath = "" })
+
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameKubeRbacProxy {
+						Expect(c.Args).To(ContainElement("--ignore-paths=/healthz"))
+					}
+				}
+			})
+		}) // When the target configuration has an upstream health path
+		When("the target configuration has an upstream TLS server name", func() {
+			It("shall address the upstream by the server name resolving to the pod", func() {
+				target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+				target.TargetProtocol = "https"
+				target.TargetPort = intstr.FromInt32(8443)
+				target.TargetServerName = "app.example.org"
+				DeferCleanup(func() {
+					target.TargetProtocol = ""
+					target.TargetPort = intstr.IntOrString{}
+					target.TargetServerName = ""
+				})
+
+				pp := patchPod(targetPod)
+				Expect(pp.Spec.HostAliases).To(ConsistOf(corev1.HostAlias{IP: "127.0.0.1", Hostnames: []string{"app.example.org"}}))
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameKubeRbacProxy {
+						Expect(c.Args).To(ContainElement("--upstream=https://app.example.org:8443"))
+					}
+				}
+
+				// A repeated admission does not duplicate the host alias
+				Expect(patchPod(pp).Spec.HostAliases).To(Equal(pp.Spec.HostAliases))
+			})
+		}) // When the target configuration has an upstream TLS server name
+		When("the target configuration rewrites the upstream paths", func() {
+			It("shall configure the oauth2-proxy through the alpha configuration", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.UpstreamPathRewrite = &configuration.UpstreamPathRewriteConf{}
+				oauth2Proxy.MetricsPort = 9090
+				DeferCleanup(func() {
+					oauth2Proxy.UpstreamPathRewrite = nil
+					oauth2Proxy.MetricsPort = 0
+				})
+
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.Args).To(ContainElement("--alpha-config=/etc/oauth2-proxy/" + constants.Oauth2AlphaConfigKey))
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--upstream=")))
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--http-address=")))
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--metrics-address=")))
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--pass-authorization-header=")))
+						Expect(c.Ports).To(ContainElement(HaveField("ContainerPort", int32(9090))))
+					}
+				}
+			})
+		}) // When the target configuration rewrites the upstream paths
+		When("the target configuration sets the X-Auth-Request headers", func() {
+			It("shall configure the oauth2-proxy through the alpha configuration", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.SetXAuthRequest = ptr.To(true)
+				DeferCleanup(func() { oauth2Proxy.SetXAuthRequest = nil })
+
+				for _, c := range patchPod(targetPod).Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.Args).To(ContainElement("--alpha-config=/etc/oauth2-proxy/" + constants.Oauth2AlphaConfigKey))
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--upstream=")))
+					}
+				}
+			})
+		}) // When the target configuration sets the X-Auth-Request headers
+		When("the target configuration passes the basic auth", func() {
+			It("shall not pass the ID token in the Authorization header", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.PassBasicAuth = ptr.To(true)
+				oauth2Proxy.BasicAuthPassword = "password"
+				DeferCleanup(func() {
+					oauth2Proxy.PassBasicAuth = nil
+					oauth2Proxy.BasicAuthPassword = ""
+				})
+
+				for _, c := range patchPod(targetPod).Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.Args).To(ContainElement("--pass-authorization-header=false"))
+						Expect(c.Args).NotTo(ContainElement("--pass-authorization-header=true"))
+					}
+				}
+			})
+		}) // When the target configuration passes the basic auth
+		When("the target configuration has additional upstreams", func() {
+			It("shall route to each upstream through a dedicated kube-rbac-proxy", func() {
+				target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+				target.TargetPort = intstr.FromString("http")
+				target.TargetContainer = "app"
+				target.Upstreams = []configuration.UpstreamConf{
+					{Path: "/api/", TargetPort: intstr.FromString("http"), TargetContainer: "api"},
+					{Path: "/admin/", TargetPort: intstr.FromInt32(9443), TargetProtocol: "https"},
+				}
+				DeferCleanup(func() {
+					target.TargetPort = intstr.IntOrString{}
+					target.TargetContainer = ""
+					target.Upstreams = nil
+				})
+
+				// Both application containers declare a port named http
+				pod := targetPod.DeepCopy()
+				pod.Spec.Containers = []corev1.Container{
+					{Name: "app", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}},
+					{Name: "api", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 9090}}},
+				}
+
+				pp := patchPod(pod)
+				args := make(map[string][]string)
+				for _, c := range pp.Spec.Containers {
+					args[c.Name] = c.Args
+				}
+
+				Expect(args).To(HaveKey(constants.ContainerNameKubeRbacProxy))
+				Expect(args[constants.ContainerNameKubeRbacProxy]).To(ContainElements(
+					"--insecure-listen-address=0.0.0.0:8100", "--upstream=http://localhost:8080"))
+				Expect(args).To(HaveKey(constants.ContainerNameKubeRbacProxy + "-1"))
+				Expect(args[constants.ContainerNameKubeRbacProxy+"-1"]).To(ContainElements(
+					"--insecure-listen-address=0.0.0.0:8101", "--upstream=http://localhost:9090"))
+				Expect(args).To(HaveKey(constants.ContainerNameKubeRbacProxy + "-2"))
+				Expect(args[constants.ContainerNameKubeRbacProxy+"-2"]).To(ContainElements(
+					"--insecure-listen-address=0.0.0.0:8102", "--upstream=https://localhost:9443"))
+				Expect(args[constants.ContainerNameOauth2Proxy]).To(
+					ContainElement("--alpha-config=/etc/oauth2-proxy/" + constants.Oauth2AlphaConfigKey))
+			})
+		}) // When the target configuration has additional upstreams
+		When("the target configuration has a shutdown drain period", func() {
+			It("shall keep the proxies serving for the drain period upon the pod termination", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.ShutdownDrain = "14500ms"
+				DeferCleanup(func() { oauth2Proxy.ShutdownDrain = "" })
+
+				pp := patchPod(targetPod)
+				drain := &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{Sleep: &corev1.SleepAction{Seconds: 15}}}
+				for _, name := range []string{constants.ContainerNameOauth2Proxy, constants.ContainerNameKubeRbacProxy} {
+					Expect(pp.Spec.Containers).To(ContainElement(And(HaveField("Name", name), HaveField("Lifecycle", drain))))
+				}
+				Expect(pp.Spec.TerminationGracePeriodSeconds).To(Equal(ptr.To(int64(45))))
+
+				// A longer termination grace period of the pod is kept
+				pod := targetPod.DeepCopy()
+				pod.Spec.TerminationGracePeriodSeconds = ptr.To(int64(120))
+				Expect(patchPod(pod).Spec.TerminationGracePeriodSeconds).To(Equal(ptr.To(int64(120))))
+			})
+		}) // When the target configuration has a shutdown drain period
+		When("the target configuration has no shutdown drain period", func() {
+			It("shall terminate the proxies right away", func() {
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					Expect(c.Lifecycle).To(BeNil(), c.Name)
+				}
+				Expect(pp.Spec.TerminationGracePeriodSeconds).To(BeNil())
+			})
+		}) // When the target configuration has no shutdown drain period
+		When("the target configuration sets the session cookie refresh", func() {
+			It("shall not override it with the cookie refresh flag", func() {
+				for _, c := range patchPod(targetPod).Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.Args).To(ContainElement("--cookie-refresh=3600s"))
+					}
+				}
+
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.CookieRefresh = "15m"
+				DeferCleanup(func() { oauth2Proxy.CookieRefresh = "" })
+
+				for _, c := range patchPod(targetPod).Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--cookie-refresh")))
+					}
+				}
+			})
+		}) // When the target configuration sets the session cookie refresh
+		When("the target configuration governs the sign-in page", func() {
+			It("shall not override it with the skip provider button flag", func() {
+				for _, c := range patchPod(targetPod).Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--skip-provider-button")))
+					}
+				}
+			})
+		}) // When the target configuration governs the sign-in page
+		When("the target configuration does not trust the forwarded headers", func() {
+			It("shall not override it with the reverse proxy flag", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.ReverseProxy = ptr.To(false)
+				DeferCleanup(func() { oauth2Proxy.ReverseProxy = nil })
+
+				for _, c := range patchPod(targetPod).Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.Args).NotTo(ContainElement(HavePrefix("--reverse-proxy")))
+					}
+				}
+			})
+		}) // When the target configuration does not trust the forwarded headers
+		When("the target configuration enables the oauth2-proxy startup probe", func() {
+			It("shall render a startup probe on the oauth2-proxy container", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.StartupProbe = &configuration.StartupProbeConf{Enabled: true}
+				DeferCleanup(func() { oauth2Proxy.StartupProbe = nil })
+
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.StartupProbe).NotTo(BeNil())
+						Expect(c.StartupProbe.HTTPGet.Path).To(Equal("/ping"))
+						Expect(c.StartupProbe.FailureThreshold).To(Equal(int32(30)))
+					}
+				}
+			})
+			It("shall not render a startup probe when it is not enabled", func() {
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.StartupProbe).To(BeNil())
+					}
+				}
+			})
+		}) // When the target configuration enables the oauth2-proxy startup probe
+		When("the target configuration enables the upstream readiness", func() {
+			It("shall probe the upstream health path through the oauth2-proxy", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.UpstreamHealthPath = "/healthz"
+				oauth2Proxy.UpstreamReadiness = ptr.To(true)
+				DeferCleanup(func() {
+					oauth2Proxy.UpstreamHealthPath = ""
+					oauth2Proxy.UpstreamReadiness = nil
+				})
+
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					switch c.Name {
+					case constants.ContainerNameOauth2Proxy:
+						Expect(c.ReadinessProbe).NotTo(BeNil())
+						Expect(c.ReadinessProbe.HTTPGet.Path).To(Equal("/healthz"))
+						Expect(c.ReadinessProbe.HTTPGet.Port.String()).To(Equal("oauth2"))
+					case constants.ContainerNameKubeRbacProxy:
+						// The probe passes the kube-rbac-proxy without authorization
+						Expect(c.Args).To(ContainElement("--ignore-paths=/healthz"))
+					}
+				}
+			})
+			It("shall not render a readiness probe without the upstream readiness", func() {
+				oauth2Proxy := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration.Oauth2Proxy
+				oauth2Proxy.UpstreamHealthPath = "/healthz"
+				DeferCleanup(func() { oauth2Proxy.UpstreamHealthPath = "" })
+
+				pp := patchPod(targetPod)
+				for _, c := range pp.Spec.Containers {
+					if c.Name == constants.ContainerNameOauth2Proxy {
+						Expect(c.ReadinessProbe).To(BeNil())
+					}
+				}
+			})
+		}) // When the target configuration enables the upstream readiness
+		When("the target configuration enables native sidecars", func() {
+			BeforeEach(func() {
+				configuration.GetOIDCAppsControllerConfig().Configuration.NativeSidecars = ptr.To(true)
+				DeferCleanup(func() { configuration.GetOIDCAppsControllerConfig().Configuration.NativeSidecars = nil })
+			})
+			It("shall inject the proxies as native sidecar init containers", func() {
+				podWebhook.NativeSidecarsSupported = true
+
+				pp := patchPod(targetPod)
+				Expect(pp.Spec.Containers).To(BeEmpty())
+				Expect(pp.Spec.InitContainers).To(HaveLen(2))
+
+				for _, c := range pp.Spec.InitContainers {
+					Expect(c.Name).To(BeElementOf(constants.ContainerNameOauth2Proxy, constants.ContainerNameKubeRbacProxy))
+					Expect(c.RestartPolicy).To(HaveValue(Equal(corev1.ContainerRestartPolicyAlways)))
+				}
+			})
+			It("shall fall back to regular containers when the cluster does not support native sidecars", func() {
+				podWebhook.NativeSidecarsSupported = false
+
+				pp := patchPod(targetPod)
+				Expect(pp.Spec.InitContainers).To(BeEmpty())
+				Expect(pp.Spec.Containers).To(HaveLen(2))
+
+				for _, c := range pp.Spec.Containers {
+					Expect(c.RestartPolicy).To(BeNil())
+				}
+			})
+		}) // When the target configuration enables native sidecars
+		When("the target configuration propagates container scoped annotations", func() {
+			const apparmor = "container.apparmor.security.beta.kubernetes.io"
+
+			var annotatedPod *corev1.Pod
+
+			BeforeEach(func() {
+				annotatedPod = targetPod.DeepCopy()
+				annotatedPod.Spec.Containers = []corev1.Container{{Name: "nginx", Image: "nginx"}}
+				annotatedPod.SetAnnotations(map[string]string{
+					apparmor + "/nginx":    "runtime/default",
+					"prometheus.io/scrape": "true",
+				})
+			})
+			It("shall preserve the pod annotations", func() {
+				pp := patchPod(annotatedPod)
+				Expect(pp.GetAnnotations()).To(HaveKeyWithValue(apparmor+"/nginx", "runtime/default"))
+				Expect(pp.GetAnnotations()).To(HaveKeyWithValue("prometheus.io/scrape", "true"))
+				Expect(pp.GetAnnotations()).NotTo(HaveKey(apparmor + "/" + constants.ContainerNameOauth2Proxy))
+			})
+			It("shall propagate the annotations of the application container to the proxies", func() {
+				cfg := configuration.GetOIDCAppsControllerConfig().Targets[0].Configuration
+				cfg.PropagatedAnnotations = []string{apparmor, "sidecar.example.com"}
+				DeferCleanup(func() { cfg.PropagatedAnnotations = nil })
+
+				annotatedPod.GetAnnotations()[apparmor+"/"+constants.ContainerNameKubeRbacProxy] = "unconfined"
+
+				pp := patchPod(annotatedPod)
+				Expect(pp.GetAnnotations()).To(HaveKeyWithValue(apparmor+"/nginx", "runtime/default"))
+				Expect(pp.GetAnnotations()).To(HaveKeyWithValue("prometheus.io/scrape", "true"))
+				Expect(pp.GetAnnotations()).To(HaveKeyWithValue(apparmor+"/"+constants.ContainerNameOauth2Proxy,
+					"runtime/default"))
+				// An explicit annotation of a proxy is not overridden
+				Expect(pp.GetAnnotations()).To(HaveKeyWithValue(apparmor+"/"+constants.ContainerNameKubeRbacProxy,
+					"unconfined"))
+				Expect(pp.GetAnnotations()).NotTo(HaveKey("sidecar.example.com/" + constants.ContainerNameOauth2Proxy))
+			})
+		}) // When the target configuration propagates container scoped annotations
+		When("the cluster version is detected", func() {
+			It("shall support native sidecars as of Kubernetes 1.29", func() {
+				Expect(webhook.NativeSidecarsSupported(nil)).To(BeFalse())
+				Expect(webhook.NativeSidecarsSupported(&version.Info{GitVersion: "v1.28.9"})).To(BeFalse())
+				Expect(webhook.NativeSidecarsSupported(&version.Info{GitVersion: "v1.29.0"})).To(BeTrue())
+				Expect(webhook.NativeSidecarsSupported(&version.Info{GitVersion: "v1.31.2-gke.1000"})).To(BeTrue())
+				Expect(webhook.NativeSidecarsSupported(&version.Info{GitVersion: "invalid"})).To(BeFalse())
+			})
+		}) // When the cluster version is detected
 	}) // Context
+	Context("when a pod belongs to a target rollout", func() {
+		var rolloutPod *corev1.Pod
+
+		BeforeEach(func() {
+			rollout := &unstructured.Unstructured{}
+			rollout.SetAPIVersion(constants.RolloutAPIVersion)
+			rollout.SetKind(constants.RolloutKind)
+			rollout.SetName("nginx-rollout")
+			rollout.SetNamespace("nginx")
+			rollout.SetUID("target-rollout")
+			rollout.SetLabels(map[string]string{"app": "nginx"})
+
+			replicaSet := &appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nginx-rollout-rs-0001",
+					Namespace: "nginx",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: constants.RolloutAPIVersion,
+						Kind:       constants.RolloutKind,
+						Name:       "nginx-rollout",
+						UID:        "target-rollout",
+					}},
+				},
+			}
+			rolloutPod = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nginx-rollout",
+					Namespace: "nginx",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "apps/v1",
+						Kind:       "ReplicaSet",
+						Name:       "nginx-rollout-rs-0001",
+					}},
+				},
+			}
+
+			s := runtime.NewScheme()
+			Expect(scheme.AddToScheme(s)).To(Succeed())
+			podWebhook.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(rollout, replicaSet).Build()
+		})
+
+		It("shall inject the auth & authz proxies", func() {
+			patchedPod := patchPod(rolloutPod)
+
+			Expect(patchedPod.Spec.Containers).To(ContainElements(
+				HaveField("Name", constants.ContainerNameOauth2Proxy),
+				HaveField("Name", constants.ContainerNameKubeRbacProxy),
+			))
+			Expect(patchedPod.GetAnnotations()).To(HaveKeyWithValue(constants.AnnotationSuffixKey,
+				rand.GenerateSha256("nginx-rollout-nginx")))
+		})
+	}) // Context when a pod belongs to a target rollout
+	Context("when a pod belongs to a target statefulset", func() {
+		statefulSetPod := func(name string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "nginx",
+					Labels:    map[string]string{"statefulset.kubernetes.io/pod-name": name},
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "apps/v1",
+						Kind:       "StatefulSet",
+						Name:       "nginx-sts",
+					}},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			statefulSet := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nginx-sts",
+					Namespace: "nginx",
+					Labels:    map[string]string{"app": "nginx"},
+				},
+			}
+
+			s := runtime.NewScheme()
+			Expect(scheme.AddToScheme(s)).To(Succeed())
+			podWebhook.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(statefulSet).Build()
+		})
+
+		It("shall set a unique cookie name per pod", func() {
+			cookieName := func(pod *corev1.Pod) string {
+				for _, c := range pod.Spec.Containers {
+					if c.Name != constants.ContainerNameOauth2Proxy {
+						continue
+					}
+
+					for _, arg := range c.Args {
+						if name, found := strings.CutPrefix(arg, "--cookie-name="); found {
+							return name
+						}
+					}
+				}
+
+				return ""
+			}
+
+			first := cookieName(patchPod(statefulSetPod("nginx-sts-0")))
+			second := cookieName(patchPod(statefulSetPod("nginx-sts-1")))
+
+			Expect(first).To(Equal("_oauth2_proxy_" + rand.GenerateSha256("nginx-sts-nginx") + "_0"))
+			Expect(second).To(Equal("_oauth2_proxy_" + rand.GenerateSha256("nginx-sts-nginx") + "_1"))
+		})
+		It("shall redirect to the callback on the per-pod host under the ingress base path", func() {
+			target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+			target.Ingress = &configuration.IngressConf{Host: "nginx.domain.org", Path: "/app"}
+			DeferCleanup(func() { target.Ingress = nil })
+
+			for _, c := range patchPod(statefulSetPod("nginx-sts-1")).Spec.Containers {
+				if c.Name == constants.ContainerNameOauth2Proxy {
+					Expect(c.Args).To(ContainElement("--redirect-url=https://nginx-1.domain.org/app/oauth2/callback"))
+				}
+			}
+		})
+		It("shall not set per-pod arguments when the statefulset is exposed through a single ingress", func() {
+			statefulSet := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "nginx-sts",
+					Namespace:   "nginx",
+					Labels:      map[string]string{"app": "nginx"},
+					Annotations: map[string]string{constants.AnnotationIngressModeKey: constants.IngressModeSingle},
+				},
+			}
+
+			s := runtime.NewScheme()
+			Expect(scheme.AddToScheme(s)).To(Succeed())
+			podWebhook.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(statefulSet).Build()
+
+			for _, c := range patchPod(statefulSetPod("nginx-sts-1")).Spec.Containers {
+				if c.Name == constants.ContainerNameOauth2Proxy {
+					Expect(c.Args).NotTo(ContainElement(HavePrefix("--redirect-url")))
+					Expect(c.Args).NotTo(ContainElement(HavePrefix("--cookie-name")))
+				}
+			}
+		})
+	}) // Context when a pod belongs to a target statefulset
+	Context("when a pod belongs to a target deployment exposed per pod", func() {
+		var deploymentPod *corev1.Pod
+
+		BeforeEach(func() {
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "nginx-pp",
+					Namespace:   "nginx",
+					UID:         "target-deployment-pp",
+					Labels:      map[string]string{"app": "nginx"},
+					Annotations: map[string]string{constants.AnnotationIngressModeKey: constants.IngressModePerPod},
+				},
+			}
+			replicaSet := &appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nginx-pp-rs-0001",
+					Namespace: "nginx",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "nginx-pp",
+						UID:        "target-deployment-pp",
+					}},
+				},
+			}
+			// The name of a replicaset pod is not yet generated at the admission
+			deploymentPod = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "nginx-pp-rs-0001-",
+					Namespace:    "nginx",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "apps/v1",
+						Kind:       "ReplicaSet",
+						Name:       "nginx-pp-rs-0001",
+					}},
+				},
+			}
+
+			s := runtime.NewScheme()
+			Expect(scheme.AddToScheme(s)).To(Succeed())
+			podWebhook.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(deployment, replicaSet).Build()
+
+			target := &configuration.GetOIDCAppsControllerConfig().Targets[0]
+			target.Ingress = &configuration.IngressConf{Host: "nginx.domain.org"}
+			DeferCleanup(func() { target.Ingress = nil })
+		})
+
+		It("shall name the pod and redirect to the host of its name hash", func() {
+			patchedPod := patchPod(deploymentPod)
+
+			Expect(patchedPod.GetName()).To(HavePrefix("nginx-pp-rs-0001-"))
+			Expect(patchedPod.GetName()).To(HaveLen(len("nginx-pp-rs-0001-") + 5))
+
+			hash := rand.GenerateSha256(patchedPod.GetName())
+			Expect(patchedPod.GetLabels()).To(HaveKeyWithValue(constants.LabelPodNameHashKey, hash))
+
+			for _, c := range patchedPod.Spec.Containers {
+				if c.Name == constants.ContainerNameOauth2Proxy {
+					Expect(c.Args).To(ContainElement("--redirect-url=https://nginx-" + hash + ".domain.org/oauth2/callback"))
+				}
+			}
+		})
+		It("shall not set per-pod arguments in the single ingress mode", func() {
+			deployment := &appsv1.Deployment{}
+			Expect(podWebhook.Client.Get(context.Background(),
+				client.ObjectKey{Namespace: "nginx", Name: "nginx-pp"}, deployment)).To(Succeed())
+			deployment.SetAnnotations(nil)
+			Expect(podWebhook.Client.Update(context.Background(), deployment)).To(Succeed())
+
+			patchedPod := patchPod(deploymentPod)
+
+			Expect(patchedPod.GetLabels()).NotTo(HaveKey(constants.LabelPodNameHashKey))
+
+			for _, c := range patchedPod.Spec.Containers {
+				if c.Name == constants.ContainerNameOauth2Proxy {
+					Expect(c.Args).NotTo(ContainElement(HavePrefix("--redirect-url")))
+				}
+			}
+		})
+	}) // Context when a pod belongs to a target deployment exposed per pod
+	Context("when the target runs the oauth2-proxy itself", func() {
+		admit := func(pod *corev1.Pod) admission.Response {
+			raw, err := json.Marshal(pod)
+			Expect(err).NotTo(HaveOccurred())
+
+			return podWebhook.Handle(context.Background(), admission.Request{
+				AdmissionRequest: adminssionv1.AdmissionRequest{
+					UID:       "uid-request",
+					Kind:      metav1.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"},
+					Resource:  metav1.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"},
+					Namespace: "nginx",
+					Operation: adminssionv1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			})
+		}
+
+		It("shall not inject the proxies into a pod running the oauth2-proxy image", func() {
+			pod := targetPod.DeepCopy()
+			pod.Spec.Containers = []corev1.Container{
+				{Name: "proxy", Image: "quay.io/oauth2-proxy/oauth2-proxy:v7.6.0"},
+			}
+
+			resp := admit(pod)
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Patches).To(BeNil())
+		})
+
+		It("shall not inject the proxies into a pod labelled as oauth2-proxy", func() {
+			pod := targetPod.DeepCopy()
+			pod.SetLabels(map[string]string{constants.LabelAppNameKey: "oauth2-proxy"})
+
+			resp := admit(pod)
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Patches).To(BeNil())
+		})
+
+		It("shall inject the proxies into a pod running another image", func() {
+			pod := targetPod.DeepCopy()
+			pod.Spec.Containers = []corev1.Container{
+				{Name: "nginx", Image: "registry.example.org/oauth2-proxy/nginx:v1"},
+			}
+
+			Expect(patchPod(pod).Spec.Containers).To(ContainElement(
+				HaveField("Name", constants.ContainerNameOauth2Proxy)))
+		})
+	}) // Context when the target runs the oauth2-proxy itself
 	Context("when a pod does not belong to a target", func() {
 		It("there shall be no auth & authz proxies in the pod templates spec", func() {
 			raw, err := json.Marshal(nonTargetPod)
@@ -547,6 +1380,43 @@ var _ = Describe("Oidc Apps MutatingAdmission Framework Test", func() {
 			Expect(resp.Allowed).To(BeTrue())
 			Expect(resp.Patches).To(BeNil())
 		}) // It
+
+		It("there shall be no auth & authz proxies in a pod of a bare replicaset", func() {
+			bareReplicaSet := &appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nginx-bare",
+					Namespace: "nginx",
+					Labels:    map[string]string{"app": "nginx"},
+					UID:       "bare-replicaset",
+				},
+			}
+			Expect(podWebhook.Client.Create(context.Background(), bareReplicaSet)).To(Succeed())
+
+			pod := targetPod.DeepCopy()
+			pod.SetOwnerReferences([]metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       bareReplicaSet.GetName(),
+				UID:        bareReplicaSet.GetUID(),
+			}})
+			raw, err := json.Marshal(pod)
+			Expect(err).NotTo(HaveOccurred())
+			req := admission.Request{
+				AdmissionRequest: adminssionv1.AdmissionRequest{
+					UID:       "uid-request",
+					Kind:      metav1.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"},
+					Resource:  metav1.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"},
+					Namespace: "nginx",
+					Operation: adminssionv1.Create,
+					Object: runtime.RawExtension{
+						Raw: raw,
+					},
+				},
+			}
+			resp := podWebhook.Handle(context.Background(), req)
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Patches).To(BeNil())
+		}) // It
 	}) // Context when a pod does not belong to a target
 }) // Describe
 
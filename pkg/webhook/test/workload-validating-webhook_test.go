@@ -0,0 +1,113 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package test
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	adminssionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+	"github.com/gardener/oidc-apps-controller/pkg/webhook"
+)
+
+var _ = Describe("Oidc Apps Workload Validating Webhook", func() {
+	var (
+		workloadWebhook *webhook.WorkloadValidator
+		protected       *appsv1.Deployment
+	)
+
+	BeforeEach(func() {
+		workloadWebhook = &webhook.WorkloadValidator{}
+		protected = &appsv1.Deployment{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "protected",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app":              "nginx",
+					constants.LabelKey: constants.LabelValue,
+				},
+			},
+		}
+	})
+
+	validate := func(operation adminssionv1.Operation, oldObject, newObject *appsv1.Deployment) admission.Response {
+		req := admission.Request{AdmissionRequest: adminssionv1.AdmissionRequest{
+			Operation: operation,
+			Name:      newObject.GetName(),
+			Namespace: newObject.GetNamespace(),
+		}}
+
+		raw, err := json.Marshal(newObject)
+		Expect(err).NotTo(HaveOccurred())
+		req.Object = runtime.RawExtension{Raw: raw}
+
+		if oldObject != nil {
+			raw, err = json.Marshal(oldObject)
+			Expect(err).NotTo(HaveOccurred())
+			req.OldObject = runtime.RawExtension{Raw: raw}
+		}
+
+		return workloadWebhook.Handle(context.TODO(), req)
+	}
+
+	It("should block the removal of the oidc-apps label", func() {
+		updated := protected.DeepCopy()
+		delete(updated.Labels, constants.LabelKey)
+
+		response := validate(adminssionv1.Update, protected, updated)
+		Expect(response.Allowed).To(BeFalse())
+		Expect(response.Result.Message).To(ContainSubstring(constants.AnnotationAllowLabelRemovalKey))
+	})
+
+	It("should allow the removal of the oidc-apps label with the override annotation", func() {
+		updated := protected.DeepCopy()
+		delete(updated.Labels, constants.LabelKey)
+		updated.SetAnnotations(map[string]string{constants.AnnotationAllowLabelRemovalKey: "true"})
+
+		response := validate(adminssionv1.Update, protected, updated)
+		Expect(response.Allowed).To(BeTrue())
+	})
+
+	It("should allow updates keeping the oidc-apps label", func() {
+		updated := protected.DeepCopy()
+		updated.Spec.Replicas = new(int32)
+		updated.Labels["tier"] = "frontend"
+
+		response := validate(adminssionv1.Update, protected, updated)
+		Expect(response.Allowed).To(BeTrue())
+	})
+
+	It("should allow updates of workloads without the oidc-apps label", func() {
+		unprotected := protected.DeepCopy()
+		delete(unprotected.Labels, constants.LabelKey)
+
+		response := validate(adminssionv1.Update, unprotected, unprotected.DeepCopy())
+		Expect(response.Allowed).To(BeTrue())
+	})
+
+	It("should allow create operations", func() {
+		response := validate(adminssionv1.Create, nil, protected)
+		Expect(response.Allowed).To(BeTrue())
+	})
+})
@@ -21,6 +21,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/json"
 	autoscalerv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/utils/ptr"
@@ -129,6 +130,19 @@ func isTargetRefMatched(ctx context.Context, c client.Client, namespace string,
 		}
 
 		return configuration.GetOIDCAppsControllerConfig().Match(statefulset)
+
+	case constants.RolloutKind:
+		rollout := &unstructured.Unstructured{}
+		rollout.SetAPIVersion(ref.APIVersion)
+		rollout.SetKind(ref.Kind)
+
+		if err := c.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: namespace}, rollout); err != nil {
+			log.FromContext(ctx).V(9).Info("unable to get rollout", "name", ref.Name)
+
+			return false
+		}
+
+		return configuration.GetOIDCAppsControllerConfig().Match(rollout)
 	}
 
 	return false
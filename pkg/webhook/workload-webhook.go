@@ -0,0 +1,72 @@
+// Copyright 2024 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gardener/oidc-apps-controller/pkg/constants"
+)
+
+// Register the webhook with the server
+var _ admission.Handler = &WorkloadValidator{}
+
+// WorkloadValidator is a handler rejecting the removal of the oidc-apps label from the protected workloads.
+// Removing the label disables the authentication and exposes the workload, hence it requires an explicit
+// override annotation.
+type WorkloadValidator struct{}
+
+// Handle provides interface implementation for the WorkloadValidator
+func (w *WorkloadValidator) Handle(ctx context.Context, req webhook.AdmissionRequest) webhook.AdmissionResponse {
+	if req.Operation != admissionv1.Update {
+		return webhook.Allowed("not an update")
+	}
+
+	oldObject, newObject := &metav1.PartialObjectMetadata{}, &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(req.OldObject.Raw, oldObject); err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	if err := json.Unmarshal(req.Object.Raw, newObject); err != nil {
+		return webhook.Errored(http.StatusBadRequest, err)
+	}
+
+	if _, protected := oldObject.GetLabels()[constants.LabelKey]; !protected {
+		return webhook.Allowed("not a protected workload")
+	}
+
+	if _, found := newObject.GetLabels()[constants.LabelKey]; found {
+		return webhook.Allowed("label is present")
+	}
+
+	if newObject.GetAnnotations()[constants.AnnotationAllowLabelRemovalKey] == "true" {
+		log.FromContext(ctx).Info("oidc-apps label is removed with an override",
+			"kind", req.Kind.Kind, "namespace", req.Namespace, "name", req.Name)
+
+		return webhook.Allowed("label removal is allowed by annotation")
+	}
+
+	return webhook.Denied(fmt.Sprintf("removing the %s label disables the authentication of the workload, "+
+		"set the %s=true annotation to allow it", constants.LabelKey, constants.AnnotationAllowLabelRemovalKey))
+}